@@ -1,6 +1,7 @@
 package block_parser
 
 import (
+	"das_register_server/event"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 )
@@ -18,5 +19,6 @@ func (b *BlockParser) ActionConfigCell(req FuncTransactionHandleReq) (resp FuncT
 		resp.Err = fmt.Errorf("AsyncDasConfigCell err: %s", err.Error())
 		return
 	}
+	event.PublishConfig(req.TxHash)
 	return
 }
@@ -87,6 +87,9 @@ func (b *BlockParser) ActionConfirmProposal(req FuncTransactionHandleReq) (resp
 		resp.Err = fmt.Errorf("UpdateOrdersRegisterStatus err: %s", err.Error())
 		return
 	}
+	if err := b.enqueueRegisterWebhook(orderIds, accountIds, req.TxHash); err != nil {
+		log.Error("enqueueRegisterWebhook err:", err.Error(), req.TxHash)
+	}
 	// notify
 	notify.SendLarkRegisterNotify(&notify.SendLarkRegisterNotifyParam{
 		Action:  common.DasActionConfirmProposal,
@@ -137,7 +140,7 @@ func doDiscordNotify(inviters []tables.TableAccountInfo, builderPreMap map[strin
 		defer http_api.RecoverPanic()
 		log.Info("doDiscordNotify:", len(contentList))
 		for _, v := range contentList {
-			if err := notify.SendNotifyDiscord(config.Cfg.Notify.DiscordWebhook, v); err != nil {
+			if err := notify.SendNotifyDiscord(config.Cfg().Notify.DiscordWebhook, v); err != nil {
 				log.Error("SendNotifyDiscord err:", err.Error())
 			}
 		}
@@ -146,7 +149,7 @@ func doDiscordNotify(inviters []tables.TableAccountInfo, builderPreMap map[strin
 	//	for _, v := range contentList {
 	//		tmp := strings.Replace(v, "** ", "", -1)
 	//		tmp = strings.Replace(tmp, " **", "", -1)
-	//		notify.SendLarkTextNotify(config.Cfg.Notify.LarkRegisterOkKey, "", tmp)
+	//		notify.SendLarkTextNotify(config.Cfg().Notify.LarkRegisterOkKey, "", tmp)
 	//	}
 	//}()
 }
@@ -190,7 +193,7 @@ func (b *BlockParser) doLarkNotify(inviters []tables.TableAccountInfo, builderPr
 	go func() {
 		defer http_api.RecoverPanic()
 		for _, v := range contentList {
-			notify.SendLarkTextNotify(config.Cfg.Notify.LarkRegisterOkKey, "", v)
+			notify.SendLarkTextNotify(config.Cfg().Notify.LarkRegisterOkKey, "", v)
 		}
 	}()
 }
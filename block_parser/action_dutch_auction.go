@@ -50,6 +50,6 @@ func (b *BlockParser) ActionBidExpiredAccountAuction(req FuncTransactionHandleRe
 	}
 
 	larkText := fmt.Sprintf("Auction: %s, %s, %s", account, owner, price)
-	notify.SendLarkTextNotify(config.Cfg.Notify.LarkRegisterOkKey, "", larkText)
+	notify.SendLarkTextNotify(config.Cfg().Notify.LarkRegisterOkKey, "", larkText)
 	return
 }
@@ -4,6 +4,7 @@ import (
 	"das_register_server/config"
 	"das_register_server/notify"
 	"das_register_server/tables"
+	"das_register_server/webhook"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/witness"
@@ -69,6 +70,11 @@ func (b *BlockParser) ActionRenewAccount(req FuncTransactionHandleReq) (resp Fun
 			resp.Err = fmt.Errorf("DoActionRenewAccount err: %s", err.Error())
 			return
 		}
+		if renewOrder, err := b.DbDao.GetOrderByOrderId(orderTx.OrderId); err != nil {
+			log.Error("GetOrderByOrderId err:", err.Error(), orderTx.OrderId)
+		} else {
+			webhook.DoEnqueue(b.DbDao, renewOrder, webhook.EventRenewed, req.TxHash)
+		}
 		// notify
 		notify.SendLarkRegisterNotify(&notify.SendLarkRegisterNotifyParam{
 			Action:  common.DasActionRenewAccount,
@@ -134,7 +140,7 @@ func (b *BlockParser) ActionRenewAccount(req FuncTransactionHandleReq) (resp Fun
 		renewYears = 1
 	}
 	larkText := fmt.Sprintf("Renew: %s, %d, %s", builder.Account, renewYears, owner)
-	notify.SendLarkTextNotify(config.Cfg.Notify.LarkRegisterOkKey, "", larkText)
+	notify.SendLarkTextNotify(config.Cfg().Notify.LarkRegisterOkKey, "", larkText)
 
 	return
 }
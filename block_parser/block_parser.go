@@ -2,9 +2,11 @@ package block_parser
 
 import (
 	"context"
+	"das_register_server/cache"
 	"das_register_server/config"
 	"das_register_server/dao"
 	"das_register_server/notify"
+	"das_register_server/prometheus"
 	"das_register_server/tables"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
@@ -32,6 +34,7 @@ type BlockParser struct {
 	Ctx                  context.Context
 	Cancel               context.CancelFunc
 	Wg                   *sync.WaitGroup
+	RC                   *cache.RedisCache
 }
 
 func (b *BlockParser) Run() error {
@@ -51,10 +54,15 @@ func (b *BlockParser) Run() error {
 		for {
 			select {
 			default:
+				start := time.Now()
 				latestBlockNumber, err := b.DasCore.Client().GetTipBlockNumber(b.Ctx)
+				prometheus.ObserveLatency(prometheus.ComponentCkbRpc, "get_tip_block_number", start)
 				if err != nil {
 					log.Error("GetTipBlockNumber err:", err.Error())
 				} else {
+					if prometheus.Tools != nil && latestBlockNumber >= b.CurrentBlockNumber {
+						prometheus.Tools.Metrics.ParserLag().Set(float64(latestBlockNumber - b.CurrentBlockNumber))
+					}
 					if b.ConcurrencyNum > 1 && b.CurrentBlockNumber < (latestBlockNumber-b.ConfirmNum-b.ConcurrencyNum) {
 						nowTime := time.Now()
 						if err = b.parserConcurrencyMode(); err != nil {
@@ -95,7 +103,9 @@ func (b *BlockParser) initCurrentBlockNumber(currentBlockNumber uint64) error {
 
 func (b *BlockParser) parserSubMode() error {
 	log.Debug("parserSubMode:", b.CurrentBlockNumber)
+	start := time.Now()
 	block, err := b.DasCore.Client().GetBlockByNumber(b.Ctx, b.CurrentBlockNumber)
+	prometheus.ObserveLatency(prometheus.ComponentCkbRpc, "get_block_by_number", start)
 	if err != nil {
 		return fmt.Errorf("GetBlockByNumber err: %s", err.Error())
 	} else {
@@ -140,7 +150,9 @@ func (b *BlockParser) checkFork(parentHash string) (bool, error) {
 func (b *BlockParser) parserConcurrencyMode() error {
 	log.Debug("parserConcurrencyMode:", b.CurrentBlockNumber, b.ConcurrencyNum)
 	for i := uint64(0); i < b.ConcurrencyNum; i++ {
+		start := time.Now()
 		block, err := b.DasCore.Client().GetBlockByNumber(b.Ctx, b.CurrentBlockNumber)
+		prometheus.ObserveLatency(prometheus.ComponentCkbRpc, "get_block_by_number", start)
 		if err != nil {
 			return fmt.Errorf("GetBlockByNumber err: %s [%d]", err.Error(), b.CurrentBlockNumber)
 		}
@@ -199,9 +211,15 @@ func (b *BlockParser) parsingBlockData(block *types.Block) error {
 			resp := handle(req)
 			if resp.Err != nil {
 				log.Error("action handle resp:", req.Action, blockNumber, txHash, resp.Err.Error())
-				notify.SendLarkErrNotify("Block Parse", notify.GetLarkTextNotifyStr("TransactionHandle", txHash, resp.Err.Error()))
+				notify.SendAlert(notify.SeverityCritical, "Block Parse", notify.GetLarkTextNotifyStr("TransactionHandle", txHash, resp.Err.Error()))
 				return resp.Err
 			}
+			if err := b.publishStatusEvent(req); err != nil {
+				log.Error("publishStatusEvent err:", req.Action, txHash, err.Error())
+			}
+			if err := b.invalidateCache(req); err != nil {
+				log.Error("invalidateCache err:", req.Action, txHash, err.Error())
+			}
 		}
 	}
 	return nil
@@ -234,7 +252,7 @@ func (b *BlockParser) checkContractVersion() error {
 			if err == core.ErrContractMajorVersionDiff {
 				log.Errorf("contract[%s] version diff, chain[%s], service[%s].", v, chainVersion, defaultVersion)
 				log.Error("Please update the service. [https://github.com/dotbitHQ/das-register]")
-				if b.Cancel != nil && !config.Cfg.Server.NotExit {
+				if b.Cancel != nil && !config.Cfg().Server.NotExit {
 					b.Cancel()
 				}
 				return err
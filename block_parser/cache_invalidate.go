@@ -0,0 +1,139 @@
+package block_parser
+
+import (
+	"das_register_server/cache"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"time"
+)
+
+// the account info served by the api comes from the das-database parser, which may index
+// the same block a little later than this parser, so the tags are bumped a second time
+const cacheInvalidateDelay = time.Second * 15
+
+// invalidateCache purges the cached responses of the accounts and das-lock addresses touched by a handled tx
+func (b *BlockParser) invalidateCache(req FuncTransactionHandleReq) error {
+	if b.RC == nil || b.RC.GetRedisClient() == nil || req.Action == common.DasActionConfig {
+		return nil
+	}
+	var tags []string
+	var mapTags = make(map[string]struct{})
+	addTag := func(tag string) {
+		if _, ok := mapTags[tag]; !ok {
+			mapTags[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	}
+
+	// accounts, the old ones too for the txs that remove an account cell
+	for _, dataType := range []common.DataType{common.DataTypeNew, common.DataTypeOld} {
+		if builderMap, err := witness.AccountCellDataBuilderMapFromTx(req.Tx, dataType); err == nil {
+			for _, v := range builderMap {
+				addTag(cache.TagAccount(v.Account))
+			}
+		}
+	}
+
+	// das-lock owners and managers of the outputs, e.g. balance receivers and new account owners
+	dasLock, err := core.GetDasContractInfo(common.DasContractNameDispatchCellType)
+	if err != nil {
+		return fmt.Errorf("GetDasContractInfo err: %s", err.Error())
+	}
+	addLockTags := func(lock *types.Script) {
+		if lock == nil || !dasLock.IsSameTypeId(lock.CodeHash) {
+			return
+		}
+		ownerHex, managerHex, err := b.DasCore.Daf().ArgsToHex(lock.Args)
+		if err != nil {
+			log.Warn("ArgsToHex err:", err.Error(), req.TxHash)
+			return
+		}
+		addTag(cache.TagAddress(ownerHex.ChainType, ownerHex.AddressHex))
+		addTag(cache.TagAddress(managerHex.ChainType, managerHex.AddressHex))
+	}
+	for _, v := range req.Tx.Outputs {
+		addLockTags(v.Lock)
+	}
+
+	// das-lock owners and managers of the inputs, e.g. the old owner of an account transferred or sold
+	// through another service, the previous txs are fetched in one batch and the ones failing are skipped
+	var batch []types.BatchTransactionItem
+	batchIndex := make(map[types.Hash]int)
+	for _, v := range req.Tx.Inputs {
+		if v.PreviousOutput == nil {
+			continue
+		}
+		if _, ok := batchIndex[v.PreviousOutput.TxHash]; !ok {
+			batchIndex[v.PreviousOutput.TxHash] = len(batch)
+			batch = append(batch, types.BatchTransactionItem{Hash: v.PreviousOutput.TxHash})
+		}
+	}
+	if len(batch) > 0 {
+		if err := b.DasCore.Client().BatchTransactions(b.Ctx, batch); err != nil {
+			log.Warn("BatchTransactions err:", err.Error(), req.TxHash)
+			batch = nil
+		}
+	}
+	for _, v := range req.Tx.Inputs {
+		if v.PreviousOutput == nil || batch == nil {
+			continue
+		}
+		item := batch[batchIndex[v.PreviousOutput.TxHash]]
+		if item.Error != nil {
+			log.Warn("GetTransaction err:", item.Error.Error(), v.PreviousOutput.TxHash)
+			continue
+		} else if item.Result == nil || item.Result.Transaction == nil {
+			continue
+		}
+		if tx := item.Result.Transaction; uint(len(tx.Outputs)) > v.PreviousOutput.Index {
+			addLockTags(tx.Outputs[v.PreviousOutput.Index].Lock)
+		}
+	}
+
+	// the sender of a tx built by this server, its account when the tx has no account cell
+	pending, err := b.DbDao.GetPendingByOutpoint(req.Action, common.OutPoint2String(req.TxHash, 0))
+	if err != nil {
+		log.Warn("GetPendingByOutpoint err:", err.Error(), req.TxHash)
+	} else if pending.Id > 0 {
+		addTag(cache.TagAddress(pending.ChainType, pending.Address))
+		if pending.Account != "" {
+			addTag(cache.TagAccount(pending.Account))
+		}
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+	log.Info("invalidateCache:", req.Action, req.TxHash, tags)
+	if err := b.RC.InvalidateCacheTags(tags...); err != nil {
+		return fmt.Errorf("InvalidateCacheTags err: %s", err.Error())
+	}
+	b.delayInvalidateCache(req.TxHash, tags)
+	return nil
+}
+
+// delayInvalidateCache bumps the tags again after cacheInvalidateDelay, unless the parser is stopped first
+func (b *BlockParser) delayInvalidateCache(txHash string, tags []string) {
+	if b.Wg != nil {
+		b.Wg.Add(1)
+	}
+	go func() {
+		defer http_api.RecoverPanic()
+		if b.Wg != nil {
+			defer b.Wg.Done()
+		}
+		timer := time.NewTimer(cacheInvalidateDelay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			if err := b.RC.InvalidateCacheTags(tags...); err != nil {
+				log.Error("InvalidateCacheTags err:", err.Error(), txHash)
+			}
+		case <-b.Ctx.Done():
+		}
+	}()
+}
@@ -0,0 +1,40 @@
+package block_parser
+
+import (
+	"das_register_server/event"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+)
+
+// publishStatusEvent pushes the order and pending tx changes made by a handled tx to the stream subscribers
+func (b *BlockParser) publishStatusEvent(req FuncTransactionHandleReq) error {
+	if !event.Enabled() {
+		return nil
+	}
+	txList, err := b.DbDao.GetOrderTxListByHash(req.TxHash)
+	if err != nil {
+		return fmt.Errorf("GetOrderTxListByHash err: %s", err.Error())
+	}
+	if len(txList) > 0 {
+		var orderIds []string
+		var mapAction = make(map[string]string)
+		for _, v := range txList {
+			orderIds = append(orderIds, v.OrderId)
+			mapAction[v.OrderId] = string(v.Action)
+		}
+		orders, err := b.DbDao.GetOrderListByOrderIds(orderIds)
+		if err != nil {
+			return fmt.Errorf("GetOrderListByOrderIds err: %s", err.Error())
+		}
+		for _, v := range orders {
+			event.PublishOrder(v, mapAction[v.OrderId], req.TxHash)
+		}
+	}
+
+	pending, err := b.DbDao.GetPendingByOutpoint(req.Action, common.OutPoint2String(req.TxHash, 0))
+	if err != nil {
+		return fmt.Errorf("GetPendingByOutpoint err: %s", err.Error())
+	}
+	event.PublishTx(pending)
+	return nil
+}
@@ -0,0 +1,33 @@
+package block_parser
+
+import (
+	"das_register_server/tables"
+	"das_register_server/webhook"
+	"fmt"
+)
+
+// enqueueRegisterWebhook notifies partners of the orders registered by a confirm proposal tx
+// and of their own orders for the same accounts that lost and got closed
+func (b *BlockParser) enqueueRegisterWebhook(orderIds, accountIds []string, hash string) error {
+	if len(orderIds) > 0 {
+		orders, err := b.DbDao.GetOrderListByOrderIds(orderIds)
+		if err != nil {
+			return fmt.Errorf("GetOrderListByOrderIds err: %s", err.Error())
+		}
+		for _, v := range orders {
+			if v.RegisterStatus == tables.RegisterStatusRegistered {
+				webhook.DoEnqueue(b.DbDao, v, webhook.EventRegistered, hash)
+			}
+		}
+	}
+	if len(accountIds) > 0 {
+		failedOrders, err := b.DbDao.GetClosedUnRegisteredOrders(accountIds)
+		if err != nil {
+			return fmt.Errorf("GetClosedUnRegisteredOrders err: %s", err.Error())
+		}
+		for _, v := range failedOrders {
+			webhook.DoEnqueue(b.DbDao, v, webhook.EventFailed, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/go-redis/redis"
+	"strings"
+	"time"
+)
+
+// Cached responses are keyed with the versions of their tags (account or owner address),
+// bumping a tag version makes every response keyed with the old version unreachable.
+// The version key outlives the longest response data time, so it never resets to a
+// version that still has cached data.
+const cacheTagVersionExpiration = time.Hour * 24
+
+func TagAccount(account string) string {
+	return strings.ToLower(fmt.Sprintf("account:%s", account))
+}
+
+func TagAddress(chainType common.ChainType, address string) string {
+	return strings.ToLower(fmt.Sprintf("address:%d:%s", chainType, address))
+}
+
+func (r *RedisCache) getCacheTagKey(tag string) string {
+	return fmt.Sprintf("cache:tag:%s", tag)
+}
+
+func (r *RedisCache) GetCacheTagVersions(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	if r.red == nil {
+		return nil, fmt.Errorf("redis is nil")
+	}
+	var keys []string
+	for _, v := range tags {
+		keys = append(keys, r.getCacheTagKey(v))
+	}
+	res, err := r.red.MGet(keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, v := range res {
+		if s, ok := v.(string); ok {
+			versions = append(versions, s)
+		} else {
+			versions = append(versions, "0")
+		}
+	}
+	return versions, nil
+}
+
+func (r *RedisCache) InvalidateCacheTags(tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	if r.red == nil {
+		return fmt.Errorf("redis is nil")
+	}
+	_, err := r.red.Pipelined(func(pipe redis.Pipeliner) error {
+		for _, v := range tags {
+			key := r.getCacheTagKey(v)
+			pipe.Incr(key)
+			pipe.Expire(key, cacheTagVersionExpiration)
+		}
+		return nil
+	})
+	return err
+}
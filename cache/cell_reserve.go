@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/go-redis/redis"
+	"strconv"
+	"time"
+)
+
+const (
+	cellReserveKey     = "register:cell_reserve"
+	cellReserveLockKey = "register:cell_reserve:lock"
+)
+
+// LockCellReserve makes the processes spending the pay address select their cells one at a time,
+// the server and the admin commands share it
+func (r *RedisCache) LockCellReserve(expiration time.Duration) error {
+	if r == nil || r.red == nil {
+		return nil
+	}
+	ret := r.red.SetNX(cellReserveLockKey, "", expiration)
+	if err := ret.Err(); err != nil {
+		return fmt.Errorf("redis set nx-->%s", err.Error())
+	}
+	if !ret.Val() {
+		return ErrDistributedLockPreemption
+	}
+	return nil
+}
+
+func (r *RedisCache) UnlockCellReserve() error {
+	if r == nil || r.red == nil {
+		return nil
+	}
+	return r.red.Del(cellReserveLockKey).Err()
+}
+
+// AddReservedOutPoints records out points reserved by this process until expiration
+func (r *RedisCache) AddReservedOutPoints(outPoints []string, expiration time.Duration) error {
+	if r == nil || r.red == nil || len(outPoints) == 0 {
+		return nil
+	}
+	score := float64(time.Now().Add(expiration).Unix())
+	members := make([]redis.Z, 0, len(outPoints))
+	for _, v := range outPoints {
+		members = append(members, redis.Z{Score: score, Member: v})
+	}
+	return r.red.ZAdd(cellReserveKey, members...).Err()
+}
+
+// GetReservedOutPoints are the out points reserved by any process and not yet expired
+func (r *RedisCache) GetReservedOutPoints() ([]string, error) {
+	if r == nil || r.red == nil {
+		return nil, nil
+	}
+	if err := r.red.ZRemRangeByScore(cellReserveKey, "-inf", strconv.FormatInt(time.Now().Unix(), 10)).Err(); err != nil {
+		return nil, fmt.Errorf("redis zremrangebyscore-->%s", err.Error())
+	}
+	return r.red.ZRange(cellReserveKey, 0, -1).Result()
+}
+
+func (r *RedisCache) DelReservedOutPoints(outPoints []string) error {
+	if r == nil || r.red == nil || len(outPoints) == 0 {
+		return nil
+	}
+	members := make([]interface{}, 0, len(outPoints))
+	for _, v := range outPoints {
+		members = append(members, v)
+	}
+	return r.red.ZRem(cellReserveKey, members...).Err()
+}
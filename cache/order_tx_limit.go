@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// LockOrderTx makes one process send or change the tx of an order at a time, the tx timer and the admin commands share it
+func (r *RedisCache) LockOrderTx(orderId string, expiration time.Duration) error {
+	if r == nil || r.red == nil {
+		return nil
+	}
+	ret := r.red.SetNX(fmt.Sprintf("register:order_tx:%s", orderId), "", expiration)
+	if err := ret.Err(); err != nil {
+		return fmt.Errorf("redis set nx-->%s", err.Error())
+	}
+	if !ret.Val() {
+		return ErrDistributedLockPreemption
+	}
+	return nil
+}
+
+func (r *RedisCache) UnlockOrderTx(orderId string) error {
+	if r == nil || r.red == nil {
+		return nil
+	}
+	return r.red.Del(fmt.Sprintf("register:order_tx:%s", orderId)).Err()
+}
@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// LockWebhookDelivery makes one instance send a delivery, without redis every instance may send it
+func (r *RedisCache) LockWebhookDelivery(deliveryId string, expiration time.Duration) error {
+	if r == nil || r.red == nil {
+		return nil
+	}
+	ret := r.red.SetNX(fmt.Sprintf("register:webhook:%s", deliveryId), "", expiration)
+	if err := ret.Err(); err != nil {
+		return fmt.Errorf("redis set nx-->%s", err.Error())
+	}
+	if !ret.Val() {
+		return ErrDistributedLockPreemption
+	}
+	return nil
+}
+
+func (r *RedisCache) UnlockWebhookDelivery(deliveryId string) error {
+	if r == nil || r.red == nil {
+		return nil
+	}
+	return r.red.Del(fmt.Sprintf("register:webhook:%s", deliveryId)).Err()
+}
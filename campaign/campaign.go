@@ -0,0 +1,188 @@
+package campaign
+
+import (
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api/logger"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+var log = logger.NewLogger("campaign", logger.LevelDebug)
+
+// minAmountUSD is left to pay after the discounts, free orders go through gift cards
+var minAmountUSD = decimal.NewFromFloat(0.01)
+
+// Counter counts the orders of a campaign that are not closed, in total and of the address
+type Counter interface {
+	CountCouponCampaignUsage(campaignId string, chainType common.ChainType, address string) (total, byAddress int64, err error)
+}
+
+// Order is what the rules are evaluated against
+type Order struct {
+	Action     tables.CampaignAction
+	AccountLen int
+	CharSets   []common.AccountCharSet // without .bit
+	Years      int
+	ChainType  common.ChainType
+	Address    string
+	HasInviter bool
+	AmountUSD  decimal.Decimal // after the inviter discount, premium and das discount
+}
+
+type Result struct {
+	Discounts   []tables.CampaignDiscount
+	DiscountUSD decimal.Decimal
+	AmountUSD   decimal.Decimal
+}
+
+// Usages are the usage rows to create with the order
+func (r *Result) Usages(o Order) []tables.TableCouponCampaignUsage {
+	var list []tables.TableCouponCampaignUsage
+	for _, v := range r.Discounts {
+		list = append(list, tables.TableCouponCampaignUsage{
+			CampaignId:  v.CampaignId,
+			ChainType:   o.ChainType,
+			Address:     o.Address,
+			Action:      o.Action,
+			DiscountUSD: v.DiscountUSD,
+		})
+	}
+	return list
+}
+
+// CharSetsWithoutSuffix drops the .bit chars of an account char list
+func CharSetsWithoutSuffix(list []common.AccountCharSet) []common.AccountCharSet {
+	if tables.EndWithDotBitChar(list) {
+		return list[:len(list)-4]
+	}
+	return list
+}
+
+type candidate struct {
+	campaignId string
+	rules      tables.CampaignRules
+}
+
+// Evaluate applies the best discount of the active campaigns to the order: either the best single campaign
+// or all the eligible campaigns that stack together, whichever takes more off. A campaign with broken rules is skipped.
+func Evaluate(campaigns []tables.TableCouponCampaign, counter Counter, o Order, now time.Time) (*Result, error) {
+	res := Result{DiscountUSD: decimal.Zero, AmountUSD: o.AmountUSD}
+	var eligible []candidate
+	for _, v := range campaigns {
+		if !v.IsActive(now) {
+			continue
+		}
+		rules, err := v.GetRules()
+		if err == nil {
+			err = rules.Check()
+		}
+		if err != nil {
+			log.Warn("Evaluate skip campaign:", v.CampaignId, err.Error())
+			continue
+		}
+		if !match(&rules, o) {
+			continue
+		}
+		if rules.MaxTotal > 0 || rules.MaxPerAddress > 0 {
+			total, byAddress, err := counter.CountCouponCampaignUsage(v.CampaignId, o.ChainType, o.Address)
+			if err != nil {
+				return nil, fmt.Errorf("CountCouponCampaignUsage err: %s", err.Error())
+			}
+			if rules.CapReached(total, byAddress) {
+				continue
+			}
+		}
+		eligible = append(eligible, candidate{campaignId: v.CampaignId, rules: rules})
+	}
+
+	var best []tables.CampaignDiscount
+	bestUSD := decimal.Zero
+	var stacking []candidate
+	for _, v := range eligible {
+		if v.rules.StackWithCampaigns {
+			stacking = append(stacking, v)
+		}
+		if list, off := apply([]candidate{v}, o.AmountUSD); off.GreaterThan(bestUSD) {
+			best, bestUSD = list, off
+		}
+	}
+	if len(stacking) > 1 {
+		if list, off := apply(stacking, o.AmountUSD); off.GreaterThan(bestUSD) {
+			best, bestUSD = list, off
+		}
+	}
+	if bestUSD.IsPositive() {
+		res.Discounts, res.DiscountUSD, res.AmountUSD = best, bestUSD, o.AmountUSD.Sub(bestUSD)
+	}
+	return &res, nil
+}
+
+func match(r *tables.CampaignRules, o Order) bool {
+	if o.HasInviter && !r.StackWithInviter {
+		return false
+	}
+	if r.MinYears > 0 && o.Years < r.MinYears {
+		return false
+	}
+	if len(r.Actions) > 0 {
+		ok := false
+		for _, v := range r.Actions {
+			ok = ok || v == o.Action
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(r.AccountLengths) > 0 {
+		ok := false
+		for _, v := range r.AccountLengths {
+			ok = ok || v == o.AccountLen
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(r.CharSets) > 0 {
+		allowed := make(map[common.AccountCharType]bool)
+		for _, v := range r.CharSets {
+			allowed[v] = true
+		}
+		for _, v := range o.CharSets {
+			if !allowed[v.CharSetName] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// apply takes the percentages off the running amount first and the fixed amounts after,
+// the amount never goes under minAmountUSD
+func apply(list []candidate, amountUSD decimal.Decimal) ([]tables.CampaignDiscount, decimal.Decimal) {
+	var discounts []tables.CampaignDiscount
+	left := amountUSD
+	add := func(id string, off decimal.Decimal) {
+		off = off.RoundDown(2)
+		if max := left.Sub(minAmountUSD); off.GreaterThan(max) {
+			off = max
+		}
+		if !off.IsPositive() {
+			return
+		}
+		left = left.Sub(off)
+		discounts = append(discounts, tables.CampaignDiscount{CampaignId: id, DiscountUSD: off})
+	}
+	for _, v := range list {
+		if v.rules.DiscountType == tables.CampaignDiscountPercent {
+			add(v.campaignId, left.Mul(v.rules.DiscountValue).Div(decimal.NewFromInt(100)))
+		}
+	}
+	for _, v := range list {
+		if v.rules.DiscountType == tables.CampaignDiscountFixedUSD {
+			add(v.campaignId, v.rules.DiscountValue)
+		}
+	}
+	return discounts, amountUSD.Sub(left)
+}
@@ -0,0 +1,82 @@
+package campaign
+
+import (
+	"das_register_server/tables"
+	"encoding/json"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+)
+
+type testCounter map[string]int64
+
+func (c testCounter) CountCouponCampaignUsage(campaignId string, chainType common.ChainType, address string) (int64, int64, error) {
+	return c[campaignId], c[campaignId+address], nil
+}
+
+func testCampaign(t *testing.T, id string, rules tables.CampaignRules) tables.TableCouponCampaign {
+	bys, err := json.Marshal(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	return tables.TableCouponCampaign{CampaignId: id, Rules: string(bys), StartAt: now.Add(-time.Hour), ExpiredAt: now.Add(time.Hour)}
+}
+
+func TestEvaluate(t *testing.T) {
+	order := Order{
+		Action:     tables.CampaignActionRegister,
+		AccountLen: 5,
+		CharSets:   []common.AccountCharSet{{CharSetName: common.AccountCharTypeEn}},
+		Years:      2,
+		Address:    "0x01",
+		AmountUSD:  decimal.NewFromInt(100),
+	}
+	percent := tables.CampaignRules{DiscountType: tables.CampaignDiscountPercent, DiscountValue: decimal.NewFromInt(20), StackWithCampaigns: true}
+	fixed := tables.CampaignRules{DiscountType: tables.CampaignDiscountFixedUSD, DiscountValue: decimal.NewFromInt(10), StackWithCampaigns: true}
+	digitOnly := tables.CampaignRules{DiscountType: tables.CampaignDiscountPercent, DiscountValue: decimal.NewFromInt(90),
+		CharSets: []common.AccountCharType{common.AccountCharTypeDigit}}
+	renewOnly := tables.CampaignRules{DiscountType: tables.CampaignDiscountPercent, DiscountValue: decimal.NewFromInt(90),
+		Actions: []tables.CampaignAction{tables.CampaignActionRenew}}
+	exclusive := tables.CampaignRules{DiscountType: tables.CampaignDiscountPercent, DiscountValue: decimal.NewFromInt(25), MaxPerAddress: 1}
+	list := []tables.TableCouponCampaign{
+		testCampaign(t, "percent", percent), testCampaign(t, "fixed", fixed),
+		testCampaign(t, "digit", digitOnly), testCampaign(t, "renew", renewOnly), testCampaign(t, "exclusive", exclusive),
+	}
+
+	// 20% then 10 USD stacked beats 25% alone
+	res, err := Evaluate(list, testCounter{}, order, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.DiscountUSD.Equal(decimal.NewFromInt(30)) || !res.AmountUSD.Equal(decimal.NewFromInt(70)) || len(res.Discounts) != 2 {
+		t.Fatal(res.DiscountUSD, res.AmountUSD, res.Discounts)
+	}
+
+	// the stacking campaigns do not apply with the inviter discount
+	order.HasInviter = true
+	if res, _ = Evaluate(list, testCounter{}, order, time.Now()); len(res.Discounts) != 0 {
+		t.Fatal(res.Discounts)
+	}
+	order.HasInviter = false
+
+	// the exclusive one wins when the stacking ones are capped away
+	fixed.MaxTotal, percent.MaxTotal = 1, 1
+	list[0], list[1] = testCampaign(t, "percent", percent), testCampaign(t, "fixed", fixed)
+	res, _ = Evaluate(list, testCounter{"percent": 1, "fixed": 1}, order, time.Now())
+	if len(res.Discounts) != 1 || res.Discounts[0].CampaignId != "exclusive" || !res.DiscountUSD.Equal(decimal.NewFromInt(25)) {
+		t.Fatal(res.Discounts)
+	}
+	res, _ = Evaluate(list, testCounter{"percent": 1, "fixed": 1, "exclusive0x01": 1}, order, time.Now())
+	if len(res.Discounts) != 0 {
+		t.Fatal(res.Discounts)
+	}
+
+	// a fixed discount never makes the order free
+	big := tables.CampaignRules{DiscountType: tables.CampaignDiscountFixedUSD, DiscountValue: decimal.NewFromInt(1000)}
+	res, _ = Evaluate([]tables.TableCouponCampaign{testCampaign(t, "big", big)}, testCounter{}, order, time.Now())
+	if !res.AmountUSD.Equal(minAmountUSD) {
+		t.Fatal(res.AmountUSD)
+	}
+}
@@ -0,0 +1,305 @@
+package cellpool
+
+import (
+	"das_register_server/cache"
+	"das_register_server/config"
+	"das_register_server/prometheus"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/dotbitHQ/das-lib/dascache"
+	"github.com/dotbitHQ/das-lib/http_api/logger"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var log = logger.NewLogger("cellpool", logger.LevelDebug)
+
+const (
+	defaultSplitCkb        = 2000
+	defaultMinFreeCells    = 10
+	defaultDemandWindow    = time.Hour
+	splitSizeRoundCkb      = 100
+	splitOutputLimit       = 200
+	demandHeadroomPercent  = 120 // the split size covers the 90th percentile demand plus fee and change headroom
+	targetPeakMultiplier   = 2   // free cells kept per reservation in the busiest minute
+	maxFreeCellsSearchPage = 2000
+
+	sharedLockTime    = time.Second * 30 // the shared lock is held while the cells are selected
+	sharedLockWait    = time.Second * 10
+	sharedReserveTime = time.Minute * 10 // a reservation not sent or released by then is dropped, e.g. its process died
+	sharedSentTime    = time.Minute * 30 // as long as the das cache of the server keeps the cells of a sent tx
+)
+
+// Pool hands out the free balance cells of the server pay lock to the txs, every reservation is held
+// in the das cache so concurrent txtool goroutines never select the same cell. With redis the reservations
+// are shared too, so the server and the admin commands never select the same cell either.
+type Pool struct {
+	dasCore  *core.DasCore
+	dasCache *dascache.DasCache
+	lock     *types.Script
+	rc       *cache.RedisCache
+	mu       sync.Mutex
+	demand   []demand
+}
+
+type demand struct {
+	at       time.Time
+	capacity uint64
+}
+
+func New(dasCore *core.DasCore, dasCache *dascache.DasCache, lock *types.Script, rc *cache.RedisCache) *Pool {
+	return &Pool{dasCore: dasCore, dasCache: dasCache, lock: lock, rc: rc}
+}
+
+// lockShared takes the shared lock and adds the cells reserved by the other processes to the das cache
+func (p *Pool) lockShared() (func(), error) {
+	if p.rc == nil || p.rc.GetRedisClient() == nil {
+		return func() {}, nil
+	}
+	deadline := time.Now().Add(sharedLockWait)
+	for {
+		err := p.rc.LockCellReserve(sharedLockTime)
+		if err == nil {
+			break
+		} else if err != cache.ErrDistributedLockPreemption || time.Now().After(deadline) {
+			return nil, fmt.Errorf("LockCellReserve err: %s", err.Error())
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+	unlock := func() {
+		if err := p.rc.UnlockCellReserve(); err != nil {
+			log.Error("UnlockCellReserve err:", err.Error())
+		}
+	}
+	outPoints, err := p.rc.GetReservedOutPoints()
+	if err != nil {
+		unlock()
+		return nil, fmt.Errorf("GetReservedOutPoints err: %s", err.Error())
+	}
+	var others []string
+	for _, v := range outPoints {
+		if !p.dasCache.ExistOutPoint(v) {
+			others = append(others, v)
+		}
+	}
+	p.dasCache.AddOutPoint(others)
+	return unlock, nil
+}
+
+func (p *Pool) share(outPoints []string, expiration time.Duration) {
+	if err := p.rc.AddReservedOutPoints(outPoints, expiration); err != nil {
+		log.Error("AddReservedOutPoints err:", err.Error())
+	}
+}
+
+func (p *Pool) unshare(outPoints []string) {
+	if err := p.rc.DelReservedOutPoints(outPoints); err != nil {
+		log.Error("DelReservedOutPoints err:", err.Error())
+	}
+}
+
+func (p *Pool) Lock() *types.Script {
+	return p.lock
+}
+
+func demandWindow() time.Duration {
+	if m := config.Cfg().CellPool.DemandWindowMinutes; m > 0 {
+		return time.Minute * time.Duration(m)
+	}
+	return defaultDemandWindow
+}
+
+func (p *Pool) addDemand(capacity uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	p.demand = append(p.demand, demand{at: now, capacity: capacity})
+	for len(p.demand) > 0 && now.Sub(p.demand[0].at) > demandWindow() {
+		p.demand = p.demand[1:]
+	}
+}
+
+// SplitSize is the capacity of the change cells, sized so that one free cell pays one typical tx of the recent demand
+func (p *Pool) SplitSize() uint64 {
+	min := uint64(defaultSplitCkb)
+	if config.Cfg().Server.SplitCkb > 0 {
+		min = config.Cfg().Server.SplitCkb
+	}
+	min *= common.OneCkb
+
+	p.mu.Lock()
+	list := make([]uint64, 0, len(p.demand))
+	for _, v := range p.demand {
+		list = append(list, v.capacity)
+	}
+	p.mu.Unlock()
+	return splitSize(list, min)
+}
+
+func splitSize(list []uint64, min uint64) uint64 {
+	if len(list) == 0 {
+		return min
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	size := list[len(list)*9/10] * demandHeadroomPercent / 100
+	round := splitSizeRoundCkb * common.OneCkb
+	size = (size + round - 1) / round * round
+	if size < min {
+		return min
+	}
+	return size
+}
+
+// TargetFreeCells is the number of free cells to keep, twice the reservations of the busiest minute of the demand window
+func (p *Pool) TargetFreeCells() int {
+	min := defaultMinFreeCells
+	if config.Cfg().CellPool.MinFreeCells > 0 {
+		min = config.Cfg().CellPool.MinFreeCells
+	}
+
+	p.mu.Lock()
+	perMinute := make(map[int64]int)
+	peak := 0
+	for _, v := range p.demand {
+		m := v.at.Unix() / 60
+		perMinute[m]++
+		if perMinute[m] > peak {
+			peak = perMinute[m]
+		}
+	}
+	p.mu.Unlock()
+	if peak*targetPeakMultiplier > min {
+		return peak * targetPeakMultiplier
+	}
+	return min
+}
+
+// Reservation is the cells selected for one tx
+type Reservation struct {
+	pool      *Pool
+	Key       string
+	Cells     []*indexer.LiveCell
+	Change    uint64
+	outPoints []string
+	done      bool
+}
+
+// Reserve selects free cells worth capacityNeed plus change, key names the tx in the logs (e.g. the order id).
+// The caller must end the reservation with Sent or Release.
+func (p *Pool) Reserve(key string, capacityNeed, capacityForChange uint64) (*Reservation, error) {
+	p.addDemand(capacityNeed)
+	unlock, err := p.lockShared()
+	if err != nil {
+		prometheus.ObserveCellPool("reserve_fail")
+		return nil, err
+	}
+	defer unlock()
+	change, cells, err := p.dasCore.GetBalanceCellWithLock(&core.ParamGetBalanceCells{
+		LockScript:        p.lock,
+		CapacityNeed:      capacityNeed,
+		DasCache:          p.dasCache,
+		CapacityForChange: capacityForChange,
+		SearchOrder:       indexer.SearchOrderDesc,
+	})
+	if err != nil {
+		prometheus.ObserveCellPool("reserve_fail")
+		return nil, fmt.Errorf("GetBalanceCellWithLock err %s", err.Error())
+	}
+	r := Reservation{pool: p, Key: key, Cells: cells, Change: change}
+	for _, v := range cells {
+		r.outPoints = append(r.outPoints, common.OutPointStruct2String(v.OutPoint))
+	}
+	p.share(r.outPoints, sharedReserveTime)
+	prometheus.ObserveCellPool("reserve")
+	log.Info("Reserve:", key, capacityNeed, len(cells), change)
+	return &r, nil
+}
+
+// Inputs are the reserved cells as tx inputs
+func (r *Reservation) Inputs() []*types.CellInput {
+	var list []*types.CellInput
+	for _, v := range r.Cells {
+		list = append(list, &types.CellInput{PreviousOutput: v.OutPoint})
+	}
+	return list
+}
+
+// ChangeOutputs splits the change into cells of the pool split size, the last output takes the remainder
+func (r *Reservation) ChangeOutputs() ([]*types.CellOutput, error) {
+	if r.Change == 0 {
+		return nil, nil
+	}
+	list, err := core.SplitOutputCell2(r.Change, r.pool.SplitSize(), splitOutputLimit, r.pool.lock, nil, indexer.SearchOrderDesc)
+	if err != nil {
+		return nil, fmt.Errorf("SplitOutputCell2 err: %s", err.Error())
+	}
+	return list, nil
+}
+
+// Sent keeps the cells reserved until the das cache expires them, by then the tx is committed or rejected
+func (r *Reservation) Sent() {
+	if r == nil || r.done {
+		return
+	}
+	r.done = true
+	r.pool.dasCache.AddOutPoint(r.outPoints)
+	r.pool.share(r.outPoints, sharedSentTime)
+	prometheus.ObserveCellPool("sent")
+}
+
+// Release returns the cells to the pool after the tx was not sent. When err shows a cell is already
+// spent or unknown to the node, the cells stay reserved until they expire instead of failing the next tx too.
+// So do they when the send failed without a rejection of the node, e.g. a timeout, as the tx may be in the pool.
+// Calling it after Sent does nothing, so it can be deferred.
+func (r *Reservation) Release(err error) {
+	if r == nil || r.done {
+		return
+	}
+	r.done = true
+	if err != nil && IsOutPointConflict(err) {
+		prometheus.ObserveCellPool("conflict")
+		log.Warn("Release keep conflicting cells reserved:", r.Key, err.Error())
+		r.pool.share(r.outPoints, sharedSentTime)
+		return
+	}
+	if err != nil && IsMaybeSent(err) {
+		prometheus.ObserveCellPool("maybe_sent")
+		log.Warn("Release keep the cells of a tx maybe sent reserved:", r.Key, err.Error())
+		r.pool.dasCache.AddOutPoint(r.outPoints)
+		r.pool.share(r.outPoints, sharedSentTime)
+		return
+	}
+	r.pool.dasCache.ClearOutPoint(r.outPoints)
+	r.pool.unshare(r.outPoints)
+	prometheus.ObserveCellPool("release")
+}
+
+// IsOutPointConflict reports the node rejected a tx because an input is spent or not yet known
+func IsOutPointConflict(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "Unknown(OutPoint") || strings.Contains(msg, "Dead(OutPoint") ||
+		strings.Contains(msg, "RBFRejected") || strings.Contains(msg, "Duplicated")
+}
+
+// sendRejections are the send_transaction errors of a node that did not take the tx into its pool
+var sendRejections = []string{"PoolRejected", "PoolIsFull", "TransactionFailedToVerify", "TransactionFailedToResolve", "Malformed"}
+
+// IsMaybeSent reports err came from the send_transaction rpc without a rejection of the node,
+// e.g. a timeout or a transport error, so the tx may be in the pool. The checks and signing before the rpc don't count.
+func IsMaybeSent(err error) bool {
+	msg := err.Error()
+	if !strings.Contains(msg, "SendTransaction err") || strings.Contains(msg, "checkTxBeforeSend") || strings.Contains(msg, "remoteSignTx") {
+		return false
+	}
+	for _, v := range sendRejections {
+		if strings.Contains(msg, v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package cellpool
+
+import (
+	"errors"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"testing"
+	"time"
+)
+
+func TestSplitSize(t *testing.T) {
+	min := 2000 * common.OneCkb
+	if size := splitSize(nil, min); size != min {
+		t.Fatal(size)
+	}
+	var list []uint64
+	for i := 1; i <= 10; i++ {
+		list = append(list, uint64(i)*1000*common.OneCkb)
+	}
+	// the 90th percentile is 10000 CKB, plus the headroom
+	if size := splitSize(list, min); size != 12000*common.OneCkb {
+		t.Fatal(size / common.OneCkb)
+	}
+}
+
+func TestTargetFreeCells(t *testing.T) {
+	p := Pool{}
+	if n := p.TargetFreeCells(); n != defaultMinFreeCells {
+		t.Fatal(n)
+	}
+	now := time.Now().Truncate(time.Minute)
+	for i := 0; i < 8; i++ {
+		p.demand = append(p.demand, demand{at: now, capacity: common.OneCkb})
+	}
+	if n := p.TargetFreeCells(); n != 16 {
+		t.Fatal(n)
+	}
+}
+
+func testCells(ckb ...uint64) []*indexer.LiveCell {
+	var list []*indexer.LiveCell
+	for _, v := range ckb {
+		list = append(list, &indexer.LiveCell{Output: &types.CellOutput{Capacity: v * common.OneCkb}})
+	}
+	return list
+}
+
+func TestPlanSplit(t *testing.T) {
+	size := 100 * common.OneCkb
+	if inputs, count := planSplit(testCells(100, 100, 100), size, 3); inputs != nil || count != 0 {
+		t.Fatal("split a full pool")
+	}
+	if inputs, count := planSplit(testCells(150, 100), size, 5); inputs != nil || count != 0 {
+		t.Fatal("split a cell smaller than two sizes")
+	}
+	// 3 more cells are needed, the remainder replaces the input
+	inputs, count := planSplit(testCells(1000, 100), size, 5)
+	if len(inputs) != 1 || count != 3 {
+		t.Fatal(len(inputs), count)
+	}
+	inputs, count = planSplit(testCells(300, 250, 100), size, 10)
+	if len(inputs) != 2 || count != 4 {
+		t.Fatal(len(inputs), count)
+	}
+}
+
+func TestIsOutPointConflict(t *testing.T) {
+	if !IsOutPointConflict(errors.New("SendTransaction err: TransactionFailedToResolve: Resolve failed Unknown(OutPoint(0x12))")) {
+		t.Fatal("unknown out point")
+	}
+	if IsOutPointConflict(errors.New("ValidationFailure: see the error code 35")) {
+		t.Fatal("validation failure")
+	}
+}
+
+func TestIsMaybeSent(t *testing.T) {
+	for msg, maybe := range map[string]bool{
+		"SendTransaction err: SendTransaction err: Post \"http://127.0.0.1:8114\": context deadline exceeded":                     true,
+		"SendTransaction err: SendTransaction err: EOF":                                                                           true,
+		"SendTransaction err: SendTransaction err: PoolRejectedTransactionByMinFeeRate: the min fee rate is 1000":                 false,
+		"SendTransaction err: SendTransaction err: TransactionFailedToVerify: Verification failed Script(TransactionScriptError)": false,
+		"SendTransaction err: checkTxBeforeSend err: checkTxBeforeSend failed, txFee: 1":                                          false,
+		"SendTransaction err: remoteSignTx err: no pay signer":                                                                    false,
+		"BuildTransaction err: capacity not enough":                                                                               false,
+	} {
+		if IsMaybeSent(errors.New(msg)) != maybe {
+			t.Fatal(msg)
+		}
+	}
+}
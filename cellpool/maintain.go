@@ -0,0 +1,121 @@
+package cellpool
+
+import (
+	"context"
+	"das_register_server/prometheus"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"sort"
+)
+
+const maxSplitInputs = 50
+
+// FreeCells returns the spendable cells of the lock that are not reserved, largest first
+func (p *Pool) FreeCells(ctx context.Context) ([]*indexer.LiveCell, error) {
+	searchKey := indexer.SearchKey{
+		Script:     p.lock,
+		ScriptType: indexer.ScriptTypeLock,
+		Filter:     &indexer.CellsFilter{OutputDataLenRange: &[2]uint64{0, 1}},
+	}
+	var list []*indexer.LiveCell
+	cursor := ""
+	for len(list) < maxFreeCellsSearchPage {
+		liveCells, err := p.dasCore.Client().GetCells(ctx, &searchKey, indexer.SearchOrderDesc, indexer.SearchLimit, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("GetCells err: %s", err.Error())
+		}
+		for _, v := range liveCells.Objects {
+			if v.Output.Type == nil && !p.dasCache.ExistOutPoint(common.OutPointStruct2String(v.OutPoint)) {
+				list = append(list, v)
+			}
+		}
+		if len(liveCells.Objects) < int(indexer.SearchLimit) || cursor == liveCells.LastCursor {
+			break
+		}
+		cursor = liveCells.LastCursor
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Output.Capacity > list[j].Output.Capacity })
+	return list, nil
+}
+
+// Usable counts the free cells big enough to pay a split size tx
+func Usable(free []*indexer.LiveCell, size uint64) int {
+	n := 0
+	for _, v := range free {
+		if v.Output.Capacity >= size {
+			n++
+		}
+	}
+	return n
+}
+
+// planSplit picks the largest free cells to split into count cells of size so that the usable cells reach target,
+// free is sorted largest first. Nothing is planned when the pool is full or no cell is worth splitting.
+func planSplit(free []*indexer.LiveCell, size uint64, target int) (inputs []*indexer.LiveCell, count int) {
+	need := target - Usable(free, size)
+	if need <= 0 {
+		return nil, 0
+	}
+	total := uint64(0)
+	for _, v := range free {
+		if v.Output.Capacity < size*2 || len(inputs) >= maxSplitInputs {
+			break
+		}
+		inputs = append(inputs, v)
+		total += v.Output.Capacity
+		// every input was usable itself and the remainder cell replaces one of them
+		if total >= uint64(need+len(inputs))*size {
+			break
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, 0
+	}
+	count = int(total/size) - 1 // the remainder cell keeps at least one size, the tx fee is paid from it
+	if count > need+len(inputs)-1 {
+		count = need + len(inputs) - 1
+	}
+	if count < 1 {
+		return nil, 0
+	}
+	return inputs, count
+}
+
+// PlanSplit returns a reservation of the cells to split and the outputs to create, nil when the pool is full.
+// The last output is the remainder, the caller pays the tx fee from it.
+func (p *Pool) PlanSplit(ctx context.Context) (*Reservation, []*types.CellOutput, error) {
+	unlock, err := p.lockShared()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+	free, err := p.FreeCells(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	size, target := p.SplitSize(), p.TargetFreeCells()
+	prometheus.ObserveCellPoolFree(Usable(free, size), target, size/common.OneCkb)
+	inputs, count := planSplit(free, size, target)
+	if count == 0 {
+		return nil, nil, nil
+	}
+
+	r := Reservation{pool: p, Key: "split", Cells: inputs}
+	total := uint64(0)
+	for _, v := range inputs {
+		r.outPoints = append(r.outPoints, common.OutPointStruct2String(v.OutPoint))
+		total += v.Output.Capacity
+	}
+	p.dasCache.AddOutPoint(r.outPoints)
+	p.share(r.outPoints, sharedReserveTime)
+
+	var outputs []*types.CellOutput
+	for i := 0; i < count; i++ {
+		outputs = append(outputs, &types.CellOutput{Capacity: size, Lock: p.lock})
+	}
+	outputs = append(outputs, &types.CellOutput{Capacity: total - size*uint64(count), Lock: p.lock})
+	log.Info("PlanSplit:", len(free), target, size, len(inputs), count)
+	return &r, outputs, nil
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"das_register_server/cache"
+	"das_register_server/cellpool"
+	"das_register_server/config"
+	"das_register_server/dao"
+	"das_register_server/tables"
+	"das_register_server/txtool"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/scorpiotzh/toolib"
+	"github.com/urfave/cli/v2"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// admin commands replace editing the order tables by hand, they only go through the dao and txtool methods the server uses,
+// e.g. ./das_register_server -c config.yaml admin info -o <order_id>
+// The db is opened without migrating, the commands changing an order take the order tx lock of the tx timer
+// and are recorded in t_admin_audit
+
+type adminTool struct {
+	operator string
+	dbDao    *dao.DbDao
+	rc       *cache.RedisCache
+	txTool   *txtool.TxTool
+	audit    *tables.TableAdminAudit
+}
+
+const adminLockTime = time.Minute
+
+func adminCommand() *cli.Command {
+	orderIdFlag := &cli.StringFlag{
+		Name:     "order-id",
+		Aliases:  []string{"o"},
+		Usage:    "order id",
+		Required: true,
+	}
+	return &cli.Command{
+		Name:  "admin",
+		Usage: "inspect and repair orders",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "operator",
+				Usage:   "who runs the command, logged with every action",
+				EnvVars: []string{"USER"},
+			},
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:   "info",
+				Usage:  "print the order with its txs and payments",
+				Flags:  []cli.Flag{orderIdFlag},
+				Action: runAdmin(false, (*adminTool).orderInfo),
+			},
+			{
+				Name:   "stuck",
+				Usage:  "list paid open orders not updated for a while",
+				Action: runAdmin(false, (*adminTool).stuckOrders),
+				Flags: []cli.Flag{
+					&cli.DurationFlag{Name: "older-than", Usage: "minimum time since the last update", Value: time.Minute * 30},
+					&cli.StringFlag{Name: "stage", Usage: "only orders in this stage, e.g. tx_send, pre_register_send"},
+					&cli.IntFlag{Name: "limit", Value: 100},
+				},
+			},
+			{
+				Name:   "retry-apply",
+				Usage:  "send the apply_register tx again, an order stuck in pre_register is reset with UpdateOrderRedoApply first",
+				Flags:  []cli.Flag{orderIdFlag},
+				Action: runAdmin(true, (*adminTool).retryApply),
+			},
+			{
+				Name:   "retry-pre-register",
+				Usage:  "send the pre_register tx again",
+				Flags:  []cli.Flag{orderIdFlag},
+				Action: runAdmin(true, (*adminTool).retryPreRegister),
+			},
+			{
+				Name:   "retry-renew",
+				Usage:  "send the renew_account tx again",
+				Flags:  []cli.Flag{orderIdFlag},
+				Action: runAdmin(true, (*adminTool).retryRenew),
+			},
+			{
+				Name:   "close",
+				Usage:  "close an open order without refunding it",
+				Flags:  []cli.Flag{orderIdFlag},
+				Action: runAdmin(false, (*adminTool).closeOrder),
+			},
+			{
+				Name:   "refund",
+				Usage:  "close the order and mark its confirmed payments for refund",
+				Flags:  []cli.Flag{orderIdFlag},
+				Action: runAdmin(false, (*adminTool).refundOrder),
+			},
+			{
+				Name:   "rehedge",
+				Usage:  "queue an order whose hedge failed for hedging again",
+				Flags:  []cli.Flag{orderIdFlag},
+				Action: runAdmin(false, (*adminTool).rehedgeOrder),
+			},
+			{
+				Name:   "referral-backfill",
+				Usage:  "fill t_referral_order from the register orders with an inviter created before it existed",
+				Action: runAdmin(false, (*adminTool).referralBackfill),
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "batch", Usage: "orders read per query", Value: 1000},
+				},
+			},
+			campaignCommand(),
+		},
+	}
+}
+
+// runAdmin loads the config, the db and redis, withTx also builds the das core and the tx tool for sending txs
+func runAdmin(withTx bool, action func(*adminTool, *cli.Context) error) cli.ActionFunc {
+	return func(ctx *cli.Context) (err error) {
+		if err := config.InitCfg(ctx.String("config"), config.ModeAdmin); err != nil {
+			return err
+		}
+		dbDao, err := dao.OpenGormDB(config.Cfg().DB.Mysql, config.Cfg().DB.ParserMysql)
+		if err != nil {
+			return fmt.Errorf("dao.OpenGormDB err: %s", err.Error())
+		}
+		red, err := toolib.NewRedisClient(config.Cfg().Cache.Redis.Addr, config.Cfg().Cache.Redis.Password, config.Cfg().Cache.Redis.DbNum)
+		if err != nil {
+			log.Warn("NewRedisClient err:", err.Error())
+		}
+		a := adminTool{operator: ctx.String("operator"), dbDao: dbDao, rc: cache.Initialize(red)}
+		defer func() {
+			a.finishAudit(err)
+			cancel()
+			wgServer.Wait()
+		}()
+
+		if withTx {
+			if red == nil {
+				return fmt.Errorf("redis is required to lock the order tx")
+			}
+			dasCore, dasCache, err := initDasCore(red)
+			if err != nil {
+				return fmt.Errorf("initDasCore err: %s", err.Error())
+			}
+			txBuilderBase, serverScript, err := initTxBuilder(dasCore)
+			if err != nil {
+				return fmt.Errorf("initTxBuilder err: %s", err.Error())
+			}
+			a.txTool = &txtool.TxTool{
+				Ctx:           ctxServer,
+				Wg:            &wgServer,
+				DbDao:         dbDao,
+				DasCore:       dasCore,
+				DasCache:      dasCache,
+				TxBuilderBase: txBuilderBase,
+				ServerScript:  serverScript,
+				RebootTime:    time.Now(),
+				RC:            a.rc,
+				CellPool:      cellpool.New(dasCore, dasCache, serverScript, a.rc),
+			}
+		}
+		return action(&a, ctx)
+	}
+}
+
+// getOpenOrder returns the order, an error if it does not exist or is closed
+func (a *adminTool) getOpenOrder(orderId string) (*tables.TableDasOrderInfo, error) {
+	order, err := a.dbDao.GetOrderByOrderId(orderId)
+	if err != nil {
+		return nil, fmt.Errorf("GetOrderByOrderId err: %s", err.Error())
+	} else if order.Id == 0 {
+		return nil, fmt.Errorf("order [%s] not exist", orderId)
+	} else if order.OrderStatus != tables.OrderStatusDefault {
+		return nil, fmt.Errorf("order [%s] is closed", orderId)
+	}
+	return &order, nil
+}
+
+// lockOrder takes the order tx lock of the tx timer for the commands changing the order without sending a tx
+func (a *adminTool) lockOrder(orderId string) (func(), error) {
+	if a.rc.GetRedisClient() == nil {
+		return nil, fmt.Errorf("redis is required to lock the order tx")
+	}
+	if err := a.rc.LockOrderTx(orderId, adminLockTime); err == cache.ErrDistributedLockPreemption {
+		return nil, txtool.ErrOrderTxLocked
+	} else if err != nil {
+		return nil, fmt.Errorf("LockOrderTx err: %s", err.Error())
+	}
+	return func() {
+		if err := a.rc.UnlockOrderTx(orderId); err != nil {
+			log.Error("UnlockOrderTx err:", err.Error(), orderId)
+		}
+	}, nil
+}
+
+// logAction records the action in t_admin_audit before it runs, runAdmin sets the result when it returns
+func (a *adminTool) logAction(action string, order *tables.TableDasOrderInfo) error {
+	before := fmt.Sprintf("action:%s account:%s order_status:%d pay_status:%d pre_register_status:%d register_status:%d hedge_status:%d",
+		order.Action, order.Account, order.OrderStatus, order.PayStatus, order.PreRegisterStatus, order.RegisterStatus, order.HedgeStatus)
+	return a.record(action, order.OrderId, before)
+}
+
+func (a *adminTool) record(action, orderId, before string) error {
+	log.Warn("admin:", a.operator, action, orderId, before)
+	audit := tables.TableAdminAudit{
+		Operator: a.operator,
+		Action:   action,
+		OrderId:  orderId,
+		Before:   before,
+	}
+	if err := a.dbDao.CreateAdminAudit(&audit); err != nil {
+		return fmt.Errorf("CreateAdminAudit err: %s", err.Error())
+	}
+	a.audit = &audit
+	return nil
+}
+
+func (a *adminTool) finishAudit(err error) {
+	if a.audit == nil {
+		return
+	}
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+		if len(result) > 1024 {
+			result = result[:1024]
+		}
+	}
+	if errUpdate := a.dbDao.UpdateAdminAuditResult(a.audit.Id, result); errUpdate != nil {
+		log.Error("UpdateAdminAuditResult err:", errUpdate.Error(), a.audit.Id, result)
+	}
+}
+
+func (a *adminTool) orderInfo(ctx *cli.Context) error {
+	orderId := ctx.String("order-id")
+	order, err := a.dbDao.GetOrderByOrderId(orderId)
+	if err != nil {
+		return fmt.Errorf("GetOrderByOrderId err: %s", err.Error())
+	} else if order.Id == 0 {
+		return fmt.Errorf("order [%s] not exist", orderId)
+	}
+	txs, err := a.dbDao.GetOrderTxListByOrderId(orderId)
+	if err != nil {
+		return fmt.Errorf("GetOrderTxListByOrderId err: %s", err.Error())
+	}
+	payments, err := a.dbDao.GetPayInfoListByOrderId(orderId)
+	if err != nil {
+		return fmt.Errorf("GetPayInfoListByOrderId err: %s", err.Error())
+	}
+	out, _ := json.MarshalIndent(struct {
+		Stage    tables.OrderStage             `json:"stage"`
+		Order    tables.TableDasOrderInfo      `json:"order"`
+		Txs      []tables.TableDasOrderTxInfo  `json:"txs"`
+		Payments []tables.TableDasOrderPayInfo `json:"payments"`
+	}{order.Stage(), order, txs, payments}, "", "  ")
+	fmt.Println(string(out))
+	return nil
+}
+
+func (a *adminTool) stuckOrders(ctx *cli.Context) error {
+	stage := tables.OrderStage(ctx.String("stage"))
+	list, err := a.dbDao.GetStuckOrders(time.Now().Add(-ctx.Duration("older-than")), ctx.Int("limit"))
+	if err != nil {
+		return fmt.Errorf("GetStuckOrders err: %s", err.Error())
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ORDER ID\tACTION\tACCOUNT\tSTAGE\tAGE\tLAST TX")
+	for _, v := range list {
+		if stage != "" && v.Stage() != stage {
+			continue
+		}
+		lastTx := ""
+		if txs, err := a.dbDao.GetOrderTxListByOrderId(v.OrderId); err != nil {
+			return fmt.Errorf("GetOrderTxListByOrderId err: %s", err.Error())
+		} else if len(txs) > 0 {
+			lastTx = fmt.Sprintf("%s %s", txs[0].Action, txs[0].Hash)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", v.OrderId, v.Action, v.Account, v.Stage(),
+			time.Since(v.UpdatedAt).Truncate(time.Second), lastTx)
+	}
+	return w.Flush()
+}
+
+func (a *adminTool) retryApply(ctx *cli.Context) error {
+	order, err := a.getOpenOrder(ctx.String("order-id"))
+	if err != nil {
+		return err
+	} else if order.Action != common.DasActionApplyRegister {
+		return fmt.Errorf("order action is %s", order.Action)
+	}
+	if err := a.logAction("retry-apply", order); err != nil {
+		return err
+	}
+	if order.PayStatus == tables.TxStatusOk && order.PreRegisterStatus == tables.TxStatusSending {
+		if err := a.redoApply(order.OrderId); err != nil {
+			return err
+		}
+		if order, err = a.getOpenOrder(order.OrderId); err != nil {
+			return err
+		}
+	}
+	if order.PayStatus != tables.TxStatusSending {
+		return fmt.Errorf("order is not waiting for the apply_register tx, stage: %s", order.Stage())
+	}
+	if err := a.txTool.DoOrderApplyTx(order); err != nil {
+		return fmt.Errorf("DoOrderApplyTx err: %s", err.Error())
+	}
+	log.Info("admin: retry-apply ok", order.OrderId)
+	return nil
+}
+
+// redoApply resets the order under the order tx lock, so the tx timer is not sending its pre_register tx meanwhile
+func (a *adminTool) redoApply(orderId string) error {
+	unlock, err := a.lockOrder(orderId)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := a.dbDao.UpdateOrderRedoApply(orderId); err != nil {
+		return fmt.Errorf("UpdateOrderRedoApply err: %s", err.Error())
+	}
+	return nil
+}
+
+func (a *adminTool) retryPreRegister(ctx *cli.Context) error {
+	order, err := a.getOpenOrder(ctx.String("order-id"))
+	if err != nil {
+		return err
+	} else if order.Action != common.DasActionApplyRegister {
+		return fmt.Errorf("order action is %s", order.Action)
+	} else if order.PreRegisterStatus != tables.TxStatusSending {
+		return fmt.Errorf("order is not waiting for the pre_register tx, stage: %s", order.Stage())
+	}
+	if err := a.logAction("retry-pre-register", order); err != nil {
+		return err
+	}
+	if err := a.txTool.DoOrderPreRegisterTx(order); err != nil {
+		return fmt.Errorf("DoOrderPreRegisterTx err: %s", err.Error())
+	}
+	log.Info("admin: retry-pre-register ok", order.OrderId)
+	return nil
+}
+
+func (a *adminTool) retryRenew(ctx *cli.Context) error {
+	order, err := a.getOpenOrder(ctx.String("order-id"))
+	if err != nil {
+		return err
+	} else if order.Action != common.DasActionRenewAccount || order.IsDidCell != tables.IsDidCellNo {
+		return fmt.Errorf("order action is %s, is did cell: %d", order.Action, order.IsDidCell)
+	} else if order.PayStatus != tables.TxStatusSending {
+		return fmt.Errorf("order is not waiting for the renew_account tx, stage: %s", order.Stage())
+	}
+	if err := a.logAction("retry-renew", order); err != nil {
+		return err
+	}
+	if err := a.txTool.DoOrderRenewTx(order); err != nil {
+		return fmt.Errorf("DoOrderRenewTx err: %s", err.Error())
+	}
+	log.Info("admin: retry-renew ok", order.OrderId)
+	return nil
+}
+
+func (a *adminTool) closeOrder(ctx *cli.Context) error {
+	unlock, err := a.lockOrder(ctx.String("order-id"))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	order, err := a.getOpenOrder(ctx.String("order-id"))
+	if err != nil {
+		return err
+	}
+	if err := a.logAction("close", order); err != nil {
+		return err
+	}
+	if err := a.dbDao.UpdateOrderStatusClosed(order.OrderId); err != nil {
+		return fmt.Errorf("UpdateOrderStatusClosed err: %s", err.Error())
+	}
+	log.Info("admin: close ok", order.OrderId)
+	return nil
+}
+
+func (a *adminTool) refundOrder(ctx *cli.Context) error {
+	orderId := ctx.String("order-id")
+	unlock, err := a.lockOrder(orderId)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	order, err := a.dbDao.GetOrderByOrderId(orderId)
+	if err != nil {
+		return fmt.Errorf("GetOrderByOrderId err: %s", err.Error())
+	} else if order.Id == 0 {
+		return fmt.Errorf("order [%s] not exist", orderId)
+	} else if order.OrderType != tables.OrderTypeSelf {
+		return fmt.Errorf("order type is %d, can not be refunded", order.OrderType)
+	} else if order.PayStatus == tables.TxStatusDefault {
+		return fmt.Errorf("order is not paid")
+	} else if order.PreRegisterStatus == tables.TxStatusOk || order.RegisterStatus >= tables.RegisterStatusProposal {
+		return fmt.Errorf("the pre_register tx is sent, register status %d, can not be refunded", order.RegisterStatus)
+	} else if order.Action != common.DasActionApplyRegister && order.PayStatus == tables.TxStatusOk {
+		return fmt.Errorf("the %s tx is sent, can not be refunded", order.Action)
+	}
+	payments, err := a.dbDao.GetPayInfoListByOrderId(orderId)
+	if err != nil {
+		return fmt.Errorf("GetPayInfoListByOrderId err: %s", err.Error())
+	}
+	refundable := false
+	for _, v := range payments {
+		if v.Status == tables.OrderTxStatusConfirm && v.RefundStatus == tables.TxStatusDefault && v.UniPayRefundStatus == tables.UniPayRefundStatusDefault {
+			refundable = true
+			break
+		}
+	}
+	if !refundable {
+		return fmt.Errorf("order has no confirmed payment left to refund")
+	}
+	if err := a.logAction("refund", &order); err != nil {
+		return err
+	}
+	if order.IsDidCell == tables.IsDidCellYes {
+		if err := a.dbDao.UpdateDidCellOrderToRefund(orderId); err != nil {
+			return fmt.Errorf("UpdateDidCellOrderToRefund err: %s", err.Error())
+		}
+	} else {
+		if err := a.dbDao.UpdateOrderToRefund(orderId); err != nil {
+			return fmt.Errorf("UpdateOrderToRefund err: %s", err.Error())
+		}
+		if err := a.dbDao.UpdatePayToRefund(orderId); err != nil {
+			return fmt.Errorf("UpdatePayToRefund err: %s", err.Error())
+		}
+	}
+	log.Info("admin: refund ok", orderId)
+	return nil
+}
+
+func (a *adminTool) rehedgeOrder(ctx *cli.Context) error {
+	orderId := ctx.String("order-id")
+	order, err := a.dbDao.GetOrderByOrderId(orderId)
+	if err != nil {
+		return fmt.Errorf("GetOrderByOrderId err: %s", err.Error())
+	} else if order.Id == 0 {
+		return fmt.Errorf("order [%s] not exist", orderId)
+	} else if order.HedgeStatus != tables.TxStatusFail {
+		return fmt.Errorf("order hedge status is %d, only orders whose hedge failed can be hedged again", order.HedgeStatus)
+	}
+	if err := a.logAction("rehedge", &order); err != nil {
+		return err
+	}
+	if err := a.dbDao.UpdateHedgeStatus(orderId, tables.TxStatusFail, tables.TxStatusSending); err != nil {
+		return fmt.Errorf("UpdateHedgeStatus err: %s", err.Error())
+	}
+	log.Info("admin: rehedge ok, the hedge timer picks it up in 3 minutes", orderId)
+	return nil
+}
+
+// referralBackfill adds the referral rows of the register orders in t_das_order_info, the rows keep the order creation time
+// so the dashboards see them in their period. Orders already in t_referral_order are left as they are, it can be run again.
+func (a *adminTool) referralBackfill(ctx *cli.Context) error {
+	batch := ctx.Int("batch")
+	if batch <= 0 {
+		return fmt.Errorf("batch must be positive")
+	}
+	if err := a.record("referral-backfill", "", fmt.Sprintf("batch:%d", batch)); err != nil {
+		return err
+	}
+	var lastId uint64
+	var scanned, inserted int64
+	for {
+		list, err := a.dbDao.GetRegisterOrdersAfterId(lastId, batch)
+		if err != nil {
+			return fmt.Errorf("GetRegisterOrdersAfterId err: %s", err.Error())
+		} else if len(list) == 0 {
+			break
+		}
+		var referrals []tables.TableReferralOrder
+		for i := range list {
+			if referral := tables.NewReferralOrder(&list[i]); referral != nil {
+				referral.CreatedAt = list[i].CreatedAt
+				referrals = append(referrals, *referral)
+			}
+		}
+		num, err := a.dbDao.CreateReferralOrdersIgnoreExisting(referrals)
+		if err != nil {
+			return fmt.Errorf("CreateReferralOrdersIgnoreExisting err: %s", err.Error())
+		}
+		lastId = list[len(list)-1].Id
+		scanned += int64(len(list))
+		inserted += num
+		log.Info("admin: referral-backfill", "last id:", lastId, "scanned:", scanned, "inserted:", inserted)
+	}
+	fmt.Printf("scanned %d register orders, inserted %d referral orders\n", scanned, inserted)
+	return nil
+}
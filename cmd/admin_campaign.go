@@ -0,0 +1,186 @@
+package main
+
+import (
+	"das_register_server/tables"
+	"encoding/json"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// campaign commands manage t_coupon_campaign, the rules json is checked before it is saved,
+// e.g. ./das_register_server -c config.yaml admin campaign create --id spring --rules '{"discount_type":"percent","discount_value":"10"}' --end 2026-06-01T00:00:00Z
+
+const campaignTimeLayout = time.RFC3339
+
+func campaignCommand() *cli.Command {
+	idFlag := &cli.StringFlag{Name: "id", Usage: "campaign id", Required: true}
+	editFlags := []cli.Flag{
+		&cli.StringFlag{Name: "name"},
+		&cli.StringFlag{Name: "rules", Usage: "rules json, see CampaignRules"},
+		&cli.StringFlag{Name: "start", Usage: "start time, " + campaignTimeLayout},
+		&cli.StringFlag{Name: "end", Usage: "end time, " + campaignTimeLayout},
+	}
+	return &cli.Command{
+		Name:  "campaign",
+		Usage: "create, update and close coupon campaigns",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "print the campaigns with their held usages",
+				Action: runAdmin(false, (*adminTool).campaignList),
+			},
+			{
+				Name:   "create",
+				Usage:  "create an enabled campaign, it starts now unless --start is set",
+				Flags:  append([]cli.Flag{idFlag}, editFlags...),
+				Action: runAdmin(false, (*adminTool).campaignCreate),
+			},
+			{
+				Name:   "update",
+				Usage:  "change the given fields of a campaign",
+				Flags:  append([]cli.Flag{idFlag}, editFlags...),
+				Action: runAdmin(false, (*adminTool).campaignUpdate),
+			},
+			{
+				Name:   "close",
+				Usage:  "disable a campaign, the orders already discounted keep their discount",
+				Flags:  []cli.Flag{idFlag},
+				Action: runAdmin(false, (*adminTool).campaignClose),
+			},
+		},
+	}
+}
+
+func (a *adminTool) campaignList(ctx *cli.Context) error {
+	list, err := a.dbDao.GetCouponCampaignList()
+	if err != nil {
+		return fmt.Errorf("GetCouponCampaignList err: %s", err.Error())
+	}
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tNAME\tACTIVE\tSTART\tEND\tHELD\tRULES")
+	for _, v := range list {
+		held, err := a.dbDao.CountHeldCouponCampaignUsage(v.CampaignId)
+		if err != nil {
+			return fmt.Errorf("CountHeldCouponCampaignUsage err: %s", err.Error())
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%d\t%s\n", v.CampaignId, v.Name, v.IsActive(now),
+			v.StartAt.Format(campaignTimeLayout), v.ExpiredAt.Format(campaignTimeLayout), held, v.Rules)
+	}
+	return w.Flush()
+}
+
+// setCampaignFields applies the flags that are set to the campaign and checks the result
+func setCampaignFields(ctx *cli.Context, campaign *tables.TableCouponCampaign) error {
+	if ctx.IsSet("name") {
+		campaign.Name = ctx.String("name")
+	}
+	if ctx.IsSet("rules") {
+		var rules tables.CampaignRules
+		if err := json.Unmarshal([]byte(ctx.String("rules")), &rules); err != nil {
+			return fmt.Errorf("rules json.Unmarshal err: %s", err.Error())
+		} else if err := rules.Check(); err != nil {
+			return fmt.Errorf("rules: %s", err.Error())
+		}
+		campaign.Rules = ctx.String("rules")
+	}
+	for flag, t := range map[string]*time.Time{"start": &campaign.StartAt, "end": &campaign.ExpiredAt} {
+		if !ctx.IsSet(flag) {
+			continue
+		}
+		v, err := time.Parse(campaignTimeLayout, ctx.String(flag))
+		if err != nil {
+			return fmt.Errorf("%s: %s", flag, err.Error())
+		}
+		*t = v
+	}
+	if campaign.Rules == "" {
+		return fmt.Errorf("rules is required")
+	} else if !campaign.ExpiredAt.After(campaign.StartAt) {
+		return fmt.Errorf("end must be after start")
+	}
+	return nil
+}
+
+func (a *adminTool) getCampaign(campaignId string) (*tables.TableCouponCampaign, error) {
+	campaign, err := a.dbDao.GetCouponCampaign(campaignId)
+	if err != nil {
+		return nil, fmt.Errorf("GetCouponCampaign err: %s", err.Error())
+	} else if campaign.Id == 0 {
+		return nil, fmt.Errorf("campaign [%s] not exist", campaignId)
+	}
+	return &campaign, nil
+}
+
+func (a *adminTool) logCampaign(action string, campaign *tables.TableCouponCampaign) error {
+	before := fmt.Sprintf("campaign_id:%s name:%s status:%d start_at:%s expired_at:%s rules:%s", campaign.CampaignId, campaign.Name,
+		campaign.Status, campaign.StartAt.Format(campaignTimeLayout), campaign.ExpiredAt.Format(campaignTimeLayout), campaign.Rules)
+	if len(before) > 1024 {
+		before = before[:1024]
+	}
+	return a.record(action, "", before)
+}
+
+func (a *adminTool) campaignCreate(ctx *cli.Context) error {
+	campaignId := ctx.String("id")
+	if old, err := a.dbDao.GetCouponCampaign(campaignId); err != nil {
+		return fmt.Errorf("GetCouponCampaign err: %s", err.Error())
+	} else if old.Id > 0 {
+		return fmt.Errorf("campaign [%s] exists", campaignId)
+	}
+	campaign := tables.TableCouponCampaign{
+		CampaignId: campaignId,
+		Status:     tables.CampaignStatusEnable,
+		StartAt:    time.Now(),
+	}
+	if err := setCampaignFields(ctx, &campaign); err != nil {
+		return err
+	}
+	if err := a.logCampaign("campaign-create", &campaign); err != nil {
+		return err
+	}
+	if err := a.dbDao.CreateCouponCampaign(&campaign); err != nil {
+		return fmt.Errorf("CreateCouponCampaign err: %s", err.Error())
+	}
+	log.Info("admin: campaign-create ok", campaignId)
+	return nil
+}
+
+func (a *adminTool) campaignUpdate(ctx *cli.Context) error {
+	campaign, err := a.getCampaign(ctx.String("id"))
+	if err != nil {
+		return err
+	}
+	if err := a.logCampaign("campaign-update", campaign); err != nil {
+		return err
+	}
+	if err := setCampaignFields(ctx, campaign); err != nil {
+		return err
+	}
+	if err := a.dbDao.UpdateCouponCampaign(campaign); err != nil {
+		return fmt.Errorf("UpdateCouponCampaign err: %s", err.Error())
+	}
+	log.Info("admin: campaign-update ok", campaign.CampaignId)
+	return nil
+}
+
+func (a *adminTool) campaignClose(ctx *cli.Context) error {
+	campaign, err := a.getCampaign(ctx.String("id"))
+	if err != nil {
+		return err
+	} else if campaign.Status == tables.CampaignStatusDisable {
+		return fmt.Errorf("campaign [%s] is closed", campaign.CampaignId)
+	}
+	if err := a.logCampaign("campaign-close", campaign); err != nil {
+		return err
+	}
+	campaign.Status = tables.CampaignStatusDisable
+	if err := a.dbDao.UpdateCouponCampaign(campaign); err != nil {
+		return fmt.Errorf("UpdateCouponCampaign err: %s", err.Error())
+	}
+	log.Info("admin: campaign-close ok", campaign.CampaignId)
+	return nil
+}
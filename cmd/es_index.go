@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"das_register_server/config"
+	"das_register_server/dao"
+	"das_register_server/elastic"
+	"das_register_server/suggest"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/urfave/cli/v2"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// es-index keeps the recommend-acc index of account/recommend, a build fills a new versioned index and then
+// points the alias to it, e.g. ./das_register_server -c config.yaml es-index build -f words.txt
+
+const (
+	recommendAccBatch      = 500
+	recommendAccPruneEvery = time.Hour
+)
+
+type esIndexTool struct {
+	dbDao *dao.DbDao
+	es    *elastic.Es
+}
+
+func esIndexCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "es-index",
+		Usage: "build and prune the recommend-acc index",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "build",
+				Usage:  "index a word list into a new version and switch the alias to it",
+				Action: runEsIndex((*esIndexTool).build),
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Usage: "one word per line, optionally followed by ,acc_type", Required: true},
+					&cli.IntFlag{Name: "keep", Usage: "versions kept after the switch, the new one included", Value: 2},
+				},
+			},
+			{
+				Name:   "prune",
+				Usage:  "remove the words registered since the build, the server also does it every hour",
+				Action: runEsIndex((*esIndexTool).prune),
+			},
+			{
+				Name:   "list",
+				Usage:  "print the versions and the one the alias points to",
+				Action: runEsIndex((*esIndexTool).list),
+			},
+		},
+	}
+}
+
+func runEsIndex(action func(*esIndexTool, *cli.Context) error) cli.ActionFunc {
+	return func(ctx *cli.Context) error {
+		if err := config.InitCfg(ctx.String("config"), config.ModeAdmin); err != nil {
+			return err
+		}
+		dbDao, err := dao.OpenGormDB(config.Cfg().DB.Mysql, config.Cfg().DB.ParserMysql)
+		if err != nil {
+			return fmt.Errorf("dao.OpenGormDB err: %s", err.Error())
+		}
+		es, err := elastic.InitEs()
+		if err != nil {
+			return fmt.Errorf("elastic.InitEs err: %s", err.Error())
+		}
+		return action(&esIndexTool{dbDao: dbDao, es: es}, ctx)
+	}
+}
+
+// readRecommendAcc reads the word list, blank lines and lines starting with # are skipped
+func readRecommendAcc(path string) ([]elastic.RecommendAcc, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var list []elastic.RecommendAcc
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var doc elastic.RecommendAcc
+		word, accType, _ := strings.Cut(text, ",")
+		if accType != "" {
+			if doc.AccType, err = strconv.Atoi(strings.TrimSpace(accType)); err != nil {
+				return nil, fmt.Errorf("line %d: acc_type [%s] invalid", line, accType)
+			}
+		}
+		doc.Acc = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(word), common.DasAccountSuffix))
+		if doc.Acc == "" || strings.Contains(doc.Acc, ".") {
+			return nil, fmt.Errorf("line %d: word [%s] invalid", line, word)
+		}
+		if seen[doc.Acc] {
+			continue
+		}
+		seen[doc.Acc] = true
+		doc.AccLenth = len(suggest.Chars(doc.Acc))
+		list = append(list, doc)
+	}
+	return list, scanner.Err()
+}
+
+// registeredRecommendAcc are the accs of the list that have an account
+func registeredRecommendAcc(dbDao *dao.DbDao, list []elastic.RecommendAcc) (map[string]bool, error) {
+	var accounts []string
+	for _, v := range list {
+		accounts = append(accounts, v.Acc+common.DasAccountSuffix)
+	}
+	res := make(map[string]bool)
+	if len(accounts) == 0 {
+		return res, nil
+	}
+	registered, err := dbDao.GetAccounts(accounts)
+	if err != nil {
+		return nil, fmt.Errorf("GetAccounts err: %s", err.Error())
+	}
+	for _, v := range registered {
+		res[strings.TrimSuffix(v.Account, common.DasAccountSuffix)] = true
+	}
+	return res, nil
+}
+
+func (t *esIndexTool) build(ctx *cli.Context) error {
+	list, err := readRecommendAcc(ctx.String("file"))
+	if err != nil {
+		return fmt.Errorf("readRecommendAcc err: %s", err.Error())
+	} else if len(list) == 0 {
+		return fmt.Errorf("no words in %s", ctx.String("file"))
+	}
+	index := elastic.RecommendAccIndexName(time.Now())
+	if err := t.es.CreateRecommendAccIndex(ctx.Context, index); err != nil {
+		return err
+	}
+
+	indexed := 0
+	for i := 0; i < len(list); i += recommendAccBatch {
+		end := i + recommendAccBatch
+		if end > len(list) {
+			end = len(list)
+		}
+		registered, err := registeredRecommendAcc(t.dbDao, list[i:end])
+		if err != nil {
+			return err
+		}
+		var docs []elastic.RecommendAcc
+		for _, v := range list[i:end] {
+			if !registered[v.Acc] {
+				docs = append(docs, v)
+			}
+		}
+		if err := t.es.IndexRecommendAcc(ctx.Context, index, docs); err != nil {
+			return fmt.Errorf("IndexRecommendAcc err: %s, the alias is unchanged, delete [%s] by hand", err.Error(), index)
+		}
+		indexed += len(docs)
+	}
+	if _, err := t.es.EsCli.Refresh(index).Do(ctx.Context); err != nil {
+		return fmt.Errorf("Refresh err: %s", err.Error())
+	}
+	if err := t.es.SwitchRecommendAccAlias(ctx.Context, index); err != nil {
+		return err
+	}
+	log.Info("es-index: build ok", index, "words:", len(list), "indexed:", indexed)
+
+	indices, _, err := t.es.RecommendAccIndices(ctx.Context)
+	if err != nil {
+		return err
+	}
+	if keep := ctx.Int("keep"); keep > 0 && len(indices) > keep {
+		if err := t.es.DeleteIndices(ctx.Context, indices[keep:]); err != nil {
+			return err
+		}
+		log.Info("es-index: deleted", indices[keep:])
+	}
+	return nil
+}
+
+func (t *esIndexTool) prune(ctx *cli.Context) error {
+	removed, err := pruneRecommendAcc(ctx.Context, t.es, t.dbDao)
+	if err != nil {
+		return err
+	}
+	log.Info("es-index: prune ok, removed:", removed)
+	return nil
+}
+
+func (t *esIndexTool) list(ctx *cli.Context) error {
+	indices, aliased, err := t.es.RecommendAccIndices(ctx.Context)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]bool)
+	for _, v := range aliased {
+		current[v] = true
+	}
+	for _, v := range indices {
+		if current[v] {
+			fmt.Println(v, "<-", elastic.RecommendAccAlias)
+		} else {
+			fmt.Println(v)
+		}
+	}
+	return nil
+}
+
+// pruneRecommendAcc removes the words that were registered from the index behind the alias
+func pruneRecommendAcc(ctx context.Context, es *elastic.Es, dbDao *dao.DbDao) (int, error) {
+	var removed []string
+	err := es.ScrollRecommendAcc(ctx, recommendAccBatch, func(list []elastic.RecommendAcc) error {
+		registered, err := registeredRecommendAcc(dbDao, list)
+		if err != nil {
+			return err
+		}
+		for acc := range registered {
+			removed = append(removed, acc)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ScrollRecommendAcc err: %s", err.Error())
+	}
+	// deleted after the scroll, the scroll does not see its own deletes anyway
+	for i := 0; i < len(removed); i += recommendAccBatch {
+		end := i + recommendAccBatch
+		if end > len(removed) {
+			end = len(removed)
+		}
+		if err := es.DeleteRecommendAcc(ctx, removed[i:end]); err != nil {
+			return 0, fmt.Errorf("DeleteRecommendAcc err: %s", err.Error())
+		}
+	}
+	return len(removed), nil
+}
+
+// runRecommendAccPrune prunes the index every hour while the server runs
+func runRecommendAccPrune(es *elastic.Es, dbDao *dao.DbDao) {
+	ticker := time.NewTicker(recommendAccPruneEvery)
+	wgServer.Add(1)
+	go func() {
+		defer http_api.RecoverPanic()
+		defer wgServer.Done()
+		for {
+			select {
+			case <-ticker.C:
+				if removed, err := pruneRecommendAcc(ctxServer, es, dbDao); err != nil {
+					log.Error("pruneRecommendAcc err:", err.Error())
+				} else if removed > 0 {
+					log.Info("pruneRecommendAcc removed:", removed)
+				}
+			case <-ctxServer.Done():
+				ticker.Stop()
+				log.Info("recommend acc prune done")
+				return
+			}
+		}
+	}()
+}
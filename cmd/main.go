@@ -4,22 +4,29 @@ import (
 	"context"
 	"das_register_server/block_parser"
 	"das_register_server/cache"
+	"das_register_server/cellpool"
 	"das_register_server/config"
 	"das_register_server/dao"
 	"das_register_server/elastic"
+	"das_register_server/event"
 	"das_register_server/http_server"
+	"das_register_server/notify"
 	"das_register_server/prometheus"
+	"das_register_server/report"
+	"das_register_server/reserved"
+	"das_register_server/signer"
 	"das_register_server/timer"
+	"das_register_server/tracing"
 	"das_register_server/txtool"
 	"das_register_server/unipay"
-	"encoding/json"
+	"das_register_server/wallet"
+	"das_register_server/webhook"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/dascache"
 	"github.com/dotbitHQ/das-lib/http_api"
 	"github.com/dotbitHQ/das-lib/http_api/logger"
-	"github.com/dotbitHQ/das-lib/remote_sign"
 	"github.com/dotbitHQ/das-lib/sign"
 	"github.com/dotbitHQ/das-lib/txbuilder"
 	"github.com/go-redis/redis"
@@ -40,6 +47,8 @@ var (
 	wgServer          = sync.WaitGroup{}
 )
 
+const reservedRefreshEvery = time.Minute * 10
+
 func main() {
 	log.Debugf("start：")
 	app := &cli.App{
@@ -55,7 +64,8 @@ func main() {
 				Usage:   "Server Type, ``(default): api and timer server, `api`: api server, `timer`: timer server",
 			},
 		},
-		Action: runServer,
+		Action:   runServer,
+		Commands: []*cli.Command{adminCommand(), esIndexCommand()},
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -67,45 +77,77 @@ func runServer(ctx *cli.Context) error {
 
 	// config file
 	configFilePath := ctx.String("config")
-	if err := config.InitCfg(configFilePath); err != nil {
+	if err := config.InitCfg(configFilePath, config.Mode(ctx.String("mode"))); err != nil {
 		return err
 	}
 
 	// config file watcher
-	watcher, err := config.AddCfgFileWatcher(configFilePath)
+	watcher, err := config.AddCfgFileWatcher(configFilePath, func(changed []string, err error) {
+		if err != nil {
+			notify.SendAlert(notify.SeverityError, "config reload", err.Error())
+			return
+		} else if len(changed) > 0 {
+			notify.SendAlert(notify.SeverityWarning, "config reload", fmt.Sprintf("restart to apply: %v", changed))
+		}
+		if err := signer.Load(); err != nil {
+			notify.SendAlert(notify.SeverityError, "config reload", fmt.Sprintf("signer.Load err: %s", err.Error()))
+		}
+	})
 	if err != nil {
 		return err
 	}
 	// ============= service start =============
 
 	//sentry
-	if err := http_api.SentryInit(config.Cfg.Notify.SentryDsn); err != nil {
+	if err := http_api.SentryInit(config.Cfg().Notify.SentryDsn); err != nil {
 		return fmt.Errorf("SentryInit err: %s", err.Error())
 	}
 	defer http_api.RecoverPanic()
 
+	// alert routing
+	if err := notify.InitAlert(ctxServer, &wgServer); err != nil {
+		return fmt.Errorf("notify.InitAlert err: %s", err.Error())
+	}
+
+	// prometheus and tracing, before the clients so their calls are recorded
+	prometheus.Init()
+	traceShutdown, err := tracing.Init(ctxServer)
+	if err != nil {
+		return fmt.Errorf("tracing.Init err: %s", err.Error())
+	}
+
+	// mock signer, before the tx builder signs with it
+	if err := signer.RunMockServer(ctxServer, &wgServer); err != nil {
+		return fmt.Errorf("signer.RunMockServer err: %s", err.Error())
+	}
+
 	// db
-	dbDao, err := dao.NewGormDB(config.Cfg.DB.Mysql, config.Cfg.DB.ParserMysql)
+	dbDao, err := dao.NewGormDB(config.Cfg().DB.Mysql, config.Cfg().DB.ParserMysql)
 	if err != nil {
 		return fmt.Errorf("dao.NewGormDB err: %s", err.Error())
 	}
 	log.Info("db ok")
 
 	// redis
-	red, err := toolib.NewRedisClient(config.Cfg.Cache.Redis.Addr, config.Cfg.Cache.Redis.Password, config.Cfg.Cache.Redis.DbNum)
+	red, err := toolib.NewRedisClient(config.Cfg().Cache.Redis.Addr, config.Cfg().Cache.Redis.Password, config.Cfg().Cache.Redis.DbNum)
 	if err != nil {
 		log.Info("NewRedisClient err: %s", err.Error())
 		//return fmt.Errorf("NewRedisClient err:%s", err.Error())
 	} else {
 		log.Info("redis ok")
+		prometheus.WrapRedis(red)
+		event.Init(red)
 	}
 	rc := cache.Initialize(red)
 
-	es, err := elastic.InitEs()
-	if err != nil {
-		log.Warnf("es2.InitEs err: %s", err.Error())
-	} else {
-		log.Info("es ok")
+	var es *elastic.Es
+	if config.Cfg().SuggestEs() {
+		if es, err = elastic.InitEs(); err != nil {
+			log.Warnf("es2.InitEs err: %s", err.Error())
+		} else {
+			log.Info("es ok")
+			runRecommendAccPrune(es, dbDao)
+		}
 	}
 	// das core
 	dasCore, dasCache, err := initDasCore(red)
@@ -120,7 +162,6 @@ func runServer(ctx *cli.Context) error {
 	}
 
 	// prometheus
-	prometheus.Init()
 	prometheus.Tools.Run()
 
 	//service mode
@@ -158,7 +199,7 @@ func runServer(ctx *cli.Context) error {
 	//txTimer.DoRecyclePreEarly()
 	//log.Info("timer ok")
 	//
-	//if config.Cfg.Server.UniPayUrl != "" {
+	//if config.Cfg().Server.UniPayUrl != "" {
 	//	toolUniPay := unipay.ToolUniPay{
 	//		Ctx:   ctxServer,
 	//		Wg:    &wgServer,
@@ -189,10 +230,10 @@ func runServer(ctx *cli.Context) error {
 	//bp := block_parser.BlockParser{
 	//	DasCore:            dasCore,
 	//	DasCache:           dasCache,
-	//	CurrentBlockNumber: config.Cfg.Chain.CurrentBlockNumber,
+	//	CurrentBlockNumber: config.Cfg().Chain.CurrentBlockNumber,
 	//	DbDao:              dbDao,
-	//	ConcurrencyNum:     config.Cfg.Chain.ConcurrencyNum,
-	//	ConfirmNum:         config.Cfg.Chain.ConfirmNum,
+	//	ConcurrencyNum:     config.Cfg().Chain.ConcurrencyNum,
+	//	ConfirmNum:         config.Cfg().Chain.ConfirmNum,
 	//	Ctx:                ctxServer,
 	//	Cancel:             cancel,
 	//	Wg:                 &wgServer,
@@ -232,8 +273,8 @@ func runServer(ctx *cli.Context) error {
 	//
 	//// http service
 	//hs, err := http_server.Initialize(http_server.HttpServerParams{
-	//	Address:                config.Cfg.Server.HttpServerAddr,
-	//	InternalAddress:        config.Cfg.Server.HttpServerInternalAddr,
+	//	Address:                config.Cfg().Server.HttpServerAddr,
+	//	InternalAddress:        config.Cfg().Server.HttpServerInternalAddr,
 	//	DbDao:                  dbDao,
 	//	Rc:                     rc,
 	//	Es:                     es,
@@ -262,6 +303,9 @@ func runServer(ctx *cli.Context) error {
 			_ = watcher.Close()
 		}
 		cancel()
+		if err := traceShutdown(context.Background()); err != nil {
+			log.Error("traceShutdown err:", err.Error())
+		}
 		//hs.Shutdown()
 		//nameDaoTimer.CloseCron()
 		//txTimer.CloseCron()
@@ -279,23 +323,23 @@ func runServer(ctx *cli.Context) error {
 
 func initDasCore(red *redis.Client) (*core.DasCore, *dascache.DasCache, error) {
 	// ckb node
-	ckbClient, err := rpc.DialWithIndexer(config.Cfg.Chain.CkbUrl, config.Cfg.Chain.IndexUrl)
+	ckbClient, err := rpc.DialWithIndexer(config.Cfg().Chain.CkbUrl, config.Cfg().Chain.IndexUrl)
 	if err != nil {
 		return nil, nil, fmt.Errorf("rpc.DialWithIndexer err: %s", err.Error())
 	}
 	log.Info("ckb node ok")
 
 	// das init
-	env := core.InitEnvOpt(config.Cfg.Server.Net, common.DasContractNameConfigCellType, common.DasContractNameAccountCellType,
+	env := core.InitEnvOpt(config.Cfg().Server.Net, common.DasContractNameConfigCellType, common.DasContractNameAccountCellType,
 		common.DasContractNameBalanceCellType, common.DasContractNameDispatchCellType, common.DasContractNameApplyRegisterCellType,
 		common.DasContractNamePreAccountCellType, common.DasContractNameProposalCellType, common.DasContractNameReverseRecordCellType,
 		common.DasContractNameIncomeCellType, common.DasContractNameAlwaysSuccess, common.DASContractNameEip712LibCellType,
 		common.DASContractNameSubAccountCellType, common.DasKeyListCellType, common.DasContractNameDpCellType, common.DasContractNameDidCellType)
 	ops := []core.DasCoreOption{
-		core.WithClient(ckbClient),
+		core.WithClient(tracing.WrapCkbClient(ckbClient)),
 		core.WithDasContractArgs(env.ContractArgs),
 		core.WithDasContractCodeHash(env.ContractCodeHash),
-		core.WithDasNetType(config.Cfg.Server.Net),
+		core.WithDasNetType(config.Cfg().Server.Net),
 		core.WithTHQCodeHash(env.THQCodeHash),
 		core.WithDasRedis(red),
 	}
@@ -327,10 +371,15 @@ func initDasCore(red *redis.Client) (*core.DasCore, *dascache.DasCache, error) {
 }
 
 func initTxBuilder(dasCore *core.DasCore) (*txbuilder.DasTxBuilderBase, *types.Script, error) {
+	if err := signer.Load(); err != nil {
+		return nil, nil, fmt.Errorf("signer.Load err: %s", err.Error())
+	}
+	// the pay address is fixed for the process, its key is looked up in the signer registry on every sign
+	payServerAddress := signer.PayAddress()
 	payServerAddressArgs := ""
 	var serverScript *types.Script
-	if config.Cfg.Server.PayServerAddress != "" {
-		parseAddress, err := address.Parse(config.Cfg.Server.PayServerAddress)
+	if payServerAddress != "" {
+		parseAddress, err := address.Parse(payServerAddress)
 		if err != nil {
 			log.Error("pay server address.Parse err: ", err.Error())
 		} else {
@@ -339,15 +388,8 @@ func initTxBuilder(dasCore *core.DasCore) (*txbuilder.DasTxBuilderBase, *types.S
 		}
 	}
 	var handleSign sign.HandleSignCkbMessage
-	if config.Cfg.Server.RemoteSignApiUrl != "" && payServerAddressArgs != "" {
-		//remoteSignClient, err := sign.NewClient(ctxServer, config.Cfg.Server.RemoteSignApiUrl)
-		//if err != nil {
-		//	return nil, nil, fmt.Errorf("sign.NewClient err: %s", err.Error())
-		//}
-		//handleSign = sign.RemoteSign(remoteSignClient, config.Cfg.Server.Net, payServerAddressArgs)
-		handleSign = remote_sign.SignTxForCKBHandle(config.Cfg.Server.RemoteSignApiUrl, config.Cfg.Server.PayServerAddress)
-	} else if config.Cfg.Server.PayPrivate != "" {
-		handleSign = sign.LocalSign(config.Cfg.Server.PayPrivate)
+	if payServerAddressArgs != "" {
+		handleSign = signer.Handle(signer.RolePay, payServerAddress)
 	}
 	txBuilderBase := txbuilder.NewDasTxBuilderBase(ctxServer, dasCore, handleSign, payServerAddressArgs)
 	log.Info("tx builder ok")
@@ -356,6 +398,9 @@ func initTxBuilder(dasCore *core.DasCore) (*txbuilder.DasTxBuilderBase, *types.S
 }
 
 func initTimer(txBuilderBase *txbuilder.DasTxBuilderBase, serverScript *types.Script, dasCore *core.DasCore, dasCache *dascache.DasCache, dbDao *dao.DbDao, rc *cache.RedisCache) error {
+	// pay cells shared by the timer and the tx tool
+	cellPool := cellpool.New(dasCore, dasCache, serverScript, rc)
+
 	// service timer
 	txTimer := timer.NewTxTimer(timer.TxTimerParam{
 		Ctx:           ctxServer,
@@ -364,6 +409,7 @@ func initTimer(txBuilderBase *txbuilder.DasTxBuilderBase, serverScript *types.Sc
 		DasCore:       dasCore,
 		DasCache:      dasCache,
 		TxBuilderBase: txBuilderBase,
+		CellPool:      cellPool,
 	})
 	if err := txTimer.Run(); err != nil {
 		return fmt.Errorf("txTimer.Run() err: %s", err.Error())
@@ -371,7 +417,7 @@ func initTimer(txBuilderBase *txbuilder.DasTxBuilderBase, serverScript *types.Sc
 	txTimer.DoRecyclePreEarly()
 	log.Info("timer ok")
 
-	if config.Cfg.Server.UniPayUrl != "" {
+	if config.Cfg().Server.UniPayUrl != "" {
 		toolUniPay := unipay.ToolUniPay{
 			Ctx:   ctxServer,
 			Wg:    &wgServer,
@@ -380,7 +426,25 @@ func initTimer(txBuilderBase *txbuilder.DasTxBuilderBase, serverScript *types.Sc
 		toolUniPay.RunConfirmStatus()
 		toolUniPay.RunOrderRefund()
 		toolUniPay.RunDoOrderHedge()
-		toolUniPay.RunRegisterInfo()
+	}
+
+	// hot wallet monitor
+	walletMonitor := wallet.Monitor{
+		Ctx:     ctxServer,
+		Wg:      &wgServer,
+		DasCore: dasCore,
+		Rc:      rc,
+	}
+	walletMonitor.RunMonitor()
+
+	// operations report
+	toolReport := report.ToolReport{
+		Ctx:   ctxServer,
+		Wg:    &wgServer,
+		DbDao: dbDao,
+	}
+	if err := toolReport.RunReport(); err != nil {
+		return fmt.Errorf("RunReport err: %s", err.Error())
 	}
 
 	// tx timer
@@ -394,21 +458,32 @@ func initTimer(txBuilderBase *txbuilder.DasTxBuilderBase, serverScript *types.Sc
 		ServerScript:  serverScript,
 		RebootTime:    time.Now(),
 		RC:            rc,
+		CellPool:      cellPool,
 	}
 	txTool.Run()
 	txTool.RunDidCellTx()
 
+	// partner webhook
+	toolWebhook := webhook.ToolWebhook{
+		Ctx:   ctxServer,
+		Wg:    &wgServer,
+		DbDao: dbDao,
+		RC:    rc,
+	}
+	toolWebhook.RunDelivery()
+
 	// block parser
 	bp := block_parser.BlockParser{
 		DasCore:            dasCore,
 		DasCache:           dasCache,
-		CurrentBlockNumber: config.Cfg.Chain.CurrentBlockNumber,
+		CurrentBlockNumber: config.Cfg().Chain.CurrentBlockNumber,
 		DbDao:              dbDao,
-		ConcurrencyNum:     config.Cfg.Chain.ConcurrencyNum,
-		ConfirmNum:         config.Cfg.Chain.ConfirmNum,
+		ConcurrencyNum:     config.Cfg().Chain.ConcurrencyNum,
+		ConfirmNum:         config.Cfg().Chain.ConfirmNum,
 		Ctx:                ctxServer,
 		Cancel:             cancel,
 		Wg:                 &wgServer,
+		RC:                 rc,
 	}
 	if err := bp.Run(); err != nil {
 		return fmt.Errorf("block parser err: %s", err.Error())
@@ -418,65 +493,26 @@ func initTimer(txBuilderBase *txbuilder.DasTxBuilderBase, serverScript *types.Sc
 }
 
 func initApiServer(txBuilderBase *txbuilder.DasTxBuilderBase, serverScript *types.Script, dasCore *core.DasCore, dasCache *dascache.DasCache, dbDao *dao.DbDao, rc *cache.RedisCache, es *elastic.Es) error {
-	//
-	builderConfigCell, err := dasCore.ConfigCellDataBuilderByTypeArgsList(
-		common.ConfigCellTypeArgsPreservedAccount00,
-		common.ConfigCellTypeArgsPreservedAccount01,
-		common.ConfigCellTypeArgsPreservedAccount02,
-		common.ConfigCellTypeArgsPreservedAccount03,
-		common.ConfigCellTypeArgsPreservedAccount04,
-		common.ConfigCellTypeArgsPreservedAccount05,
-		common.ConfigCellTypeArgsPreservedAccount06,
-		common.ConfigCellTypeArgsPreservedAccount07,
-		common.ConfigCellTypeArgsPreservedAccount08,
-		common.ConfigCellTypeArgsPreservedAccount09,
-		common.ConfigCellTypeArgsPreservedAccount10,
-		common.ConfigCellTypeArgsPreservedAccount11,
-		common.ConfigCellTypeArgsPreservedAccount12,
-		common.ConfigCellTypeArgsPreservedAccount13,
-		common.ConfigCellTypeArgsPreservedAccount14,
-		common.ConfigCellTypeArgsPreservedAccount15,
-		common.ConfigCellTypeArgsPreservedAccount16,
-		common.ConfigCellTypeArgsPreservedAccount17,
-		common.ConfigCellTypeArgsPreservedAccount18,
-		common.ConfigCellTypeArgsPreservedAccount19,
-		common.ConfigCellTypeArgsUnavailable,
-	)
-	var mapReservedAccounts = make(map[string]struct{})
-	var mapUnAvailableAccounts = make(map[string]struct{})
-	if err != nil {
-		var cacheBuilder core.CacheConfigCellReservedAccounts
-		strCache, errCache := dasCore.GetConfigCellByCache(core.CacheConfigCellKeyReservedAccounts)
-		if errCache != nil {
-			log.Error("GetConfigCellByCache err: %s", errCache.Error())
-			return fmt.Errorf("unavailable account and preserved account init1 err: %s", err.Error())
-		} else if strCache == "" {
-			return fmt.Errorf("unavailable account and preserved account init2 err: %s", err.Error())
-		} else if errCache = json.Unmarshal([]byte(strCache), &cacheBuilder); errCache != nil {
-			log.Error("json.Unmarshal err: %s", errCache.Error())
-			return fmt.Errorf("unavailable account and preserved account init3 err: %s", err.Error())
-		}
-		mapReservedAccounts = cacheBuilder.MapReservedAccounts
-		mapUnAvailableAccounts = cacheBuilder.MapUnAvailableAccounts
-	} else {
-		mapReservedAccounts = builderConfigCell.ConfigCellPreservedAccountMap
-		mapUnAvailableAccounts = builderConfigCell.ConfigCellUnavailableAccountMap
+	// reserved and unavailable accounts, refreshed on config cell updates and every few minutes
+	reservedList := reserved.NewList(dasCore)
+	if err := reservedList.Refresh(); err != nil {
+		return fmt.Errorf("unavailable account and preserved account init err: %s", err.Error())
 	}
+	reservedList.Run(ctxServer, &wgServer, reservedRefreshEvery, event.WatchConfig(ctxServer))
 
 	// http service
 	hs, err := http_server.Initialize(http_server.HttpServerParams{
-		Address:                config.Cfg.Server.HttpServerAddr,
-		InternalAddress:        config.Cfg.Server.HttpServerInternalAddr,
-		DbDao:                  dbDao,
-		Rc:                     rc,
-		Es:                     es,
-		Ctx:                    ctxServer,
-		DasCore:                dasCore,
-		DasCache:               dasCache,
-		TxBuilderBase:          txBuilderBase,
-		ServerScript:           serverScript,
-		MapReservedAccounts:    mapReservedAccounts,
-		MapUnAvailableAccounts: mapUnAvailableAccounts,
+		Address:         config.Cfg().Server.HttpServerAddr,
+		InternalAddress: config.Cfg().Server.HttpServerInternalAddr,
+		DbDao:           dbDao,
+		Rc:              rc,
+		Es:              es,
+		Ctx:             ctxServer,
+		DasCore:         dasCore,
+		DasCache:        dasCache,
+		TxBuilderBase:   txBuilderBase,
+		ServerScript:    serverScript,
+		ReservedList:    reservedList,
 	})
 	if err != nil {
 		return fmt.Errorf("http server Initialize err:%s", err.Error())
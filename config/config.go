@@ -8,45 +8,94 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/scorpiotzh/toolib"
 	"github.com/shopspring/decimal"
+	"sync/atomic"
 	"time"
 )
 
 var (
-	Cfg CfgServer
-	log = logger.NewLogger("config", logger.LevelDebug)
+	log     = logger.NewLogger("config", logger.LevelDebug)
+	cfg     atomic.Value // *CfgServer
+	cfgMode Mode
 )
 
-func InitCfg(configFilePath string) error {
+func init() {
+	cfg.Store(&CfgServer{})
+}
+
+// Cfg is the current config snapshot, it is replaced as a whole on reload and must not be modified
+func Cfg() *CfgServer {
+	return cfg.Load().(*CfgServer)
+}
+
+func loadCfg(configFilePath string) (*CfgServer, error) {
+	var c CfgServer
+	if err := toolib.UnmarshalYamlFile(configFilePath, &c); err != nil {
+		return nil, fmt.Errorf("UnmarshalYamlFile err:%s", err.Error())
+	}
+	if err := c.resolveSecrets(); err != nil {
+		return nil, fmt.Errorf("resolveSecrets err: %s", err.Error())
+	}
+	return &c, nil
+}
+
+func logCfg(msg string, c *CfgServer) {
+	log.Info(msg, toolib.JsonString(c.Redacted()))
+}
+
+// InitCfg loads and validates the config for the mode the process runs in
+func InitCfg(configFilePath string, mode Mode) error {
 	if configFilePath == "" {
 		configFilePath = "../config/config.yaml"
 	}
 	log.Info("config file：", configFilePath)
-	if err := toolib.UnmarshalYamlFile(configFilePath, &Cfg); err != nil {
-		return fmt.Errorf("UnmarshalYamlFile err:%s", err.Error())
+	c, err := loadCfg(configFilePath)
+	if err != nil {
+		return err
+	}
+	if err := c.Validate(mode); err != nil {
+		return fmt.Errorf("config invalid: %s", err.Error())
 	}
-	private := Cfg.Server.PayPrivate
-	Cfg.Server.PayPrivate = ""
-	log.Info("config file：", toolib.JsonString(Cfg))
-	Cfg.Server.PayPrivate = private
+	logCfg("config file：", c)
+	cfgMode = mode
+	cfg.Store(c)
 	return nil
 }
 
-func AddCfgFileWatcher(configFilePath string) (*fsnotify.Watcher, error) {
+// AddCfgFileWatcher reloads the config on change, an invalid file is rejected and the running config kept,
+// restart-only keys keep their running value. onReload is called with the reload result.
+func AddCfgFileWatcher(configFilePath string, onReload func(changed []string, err error)) (*fsnotify.Watcher, error) {
 	if configFilePath == "" {
 		configFilePath = "../config/config.yaml"
 	}
 	return toolib.AddFileWatcher(configFilePath, func() {
 		log.Info("update config file：", configFilePath)
-		if err := toolib.UnmarshalYamlFile(configFilePath, &Cfg); err != nil {
-			log.Error("UnmarshalYamlFile err:", err.Error())
+		changed, err := reloadCfg(configFilePath)
+		if err != nil {
+			log.Error("reload config err:", err.Error())
+		} else if len(changed) > 0 {
+			log.Warn("restart-only config changed, restart to apply:", changed)
+		}
+		if onReload != nil {
+			onReload(changed, err)
 		}
-		private := Cfg.Server.PayPrivate
-		Cfg.Server.PayPrivate = ""
-		log.Info("update config file：", toolib.JsonString(Cfg))
-		Cfg.Server.PayPrivate = private
 	})
 }
 
+// reloadCfg returns the restart-only keys that changed in the file but were not applied
+func reloadCfg(configFilePath string) ([]string, error) {
+	c, err := loadCfg(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Validate(cfgMode); err != nil {
+		return nil, fmt.Errorf("config invalid: %s", err.Error())
+	}
+	changed := keepRestartOnly(Cfg(), c)
+	logCfg("update config file：", c)
+	cfg.Store(c)
+	return changed, nil
+}
+
 type CfgServer struct {
 	Server struct {
 		Name                    string            `json:"name" yaml:"name"`
@@ -55,7 +104,7 @@ type CfgServer struct {
 		HttpServerAddr          string            `json:"http_server_addr" yaml:"http_server_addr"`
 		HttpServerInternalAddr  string            `json:"http_server_internal_addr" yaml:"http_server_internal_addr"`
 		PayServerAddress        string            `json:"pay_server_address" yaml:"pay_server_address"`
-		PayPrivate              string            `json:"pay_private" yaml:"pay_private"`
+		PayPrivate              string            `json:"pay_private" yaml:"pay_private" secret:"private_key"`
 		RemoteSignApiUrl        string            `json:"remote_sign_api_url" yaml:"remote_sign_api_url"`
 		PushLogUrl              string            `json:"push_log_url" yaml:"push_log_url"`
 		PushLogIndex            string            `json:"push_log_index" yaml:"push_log_index"`
@@ -68,8 +117,7 @@ type CfgServer struct {
 		RecyclePreEarly         bool              `json:"recycle_pre_early" yaml:"recycle_pre_early"`
 		RecyclePreEarlyCronSpec string            `json:"recycle_pre_early_cron_spec" yaml:"recycle_pre_early_cron_spec"`
 		NotExit                 bool              `json:"not_exit" yaml:"not_exit"`
-		CouponFilePath          string            `json:"coupon_file_path" yaml:"coupon_file_path"`
-		CouponEncrySalt         string            `json:"coupon_encry_salt" yaml:"coupon_encry_salt"`
+		CouponEncrySalt         string            `json:"coupon_encry_salt" yaml:"coupon_encry_salt" secret:"true"`
 		CouponQrcodePrefix      string            `json:"coupon_qrcode_prefix" yaml:"coupon_qrcode_prefix"`
 		CouponCodeLength        uint8             `json:"coupon_code_length" yaml:"coupon_code_length"`
 		UniPayUrl               string            `json:"uni_pay_url" yaml:"uni_pay_url"`
@@ -78,10 +126,10 @@ type CfgServer struct {
 		PrometheusPushGateway   string            `json:"prometheus_push_gateway" yaml:"prometheus_push_gateway"`
 		// ConfigCellDPoint.transfer_whitelist
 		TransferWhitelist        string `json:"transfer_whitelist" yaml:"transfer_whitelist"`
-		TransferWhitelistPrivate string `json:"transfer_whitelist_private" yaml:"transfer_whitelist_private"`
+		TransferWhitelistPrivate string `json:"transfer_whitelist_private" yaml:"transfer_whitelist_private" secret:"private_key"`
 		//ConfigCellDPoint.capacity_recycle_whitelist
 		CapacityWhitelist        string `json:"capacity_whitelist" yaml:"capacity_whitelist"`
-		CapacityWhitelistPrivate string `json:"capacity_whitelist_private" yaml:"capacity_whitelist_private"`
+		CapacityWhitelistPrivate string `json:"capacity_whitelist_private" yaml:"capacity_whitelist_private" secret:"private_key"`
 		SplitCount               int    `json:"split_count" yaml:"split_count"`
 		SplitAmount              uint64 `json:"split_amount" yaml:"split_amount"`
 		TxTeeRate                uint64 `json:"tx_fee_rate" yaml:"tx_fee_rate"`
@@ -94,8 +142,45 @@ type CfgServer struct {
 		LarkRegisterOkKey string `json:"lark_register_ok_key" yaml:"lark_register_ok_key"`
 		LarkDasInfoKey    string `json:"lark_das_info_key" yaml:"lark_das_info_key"`
 		DiscordWebhook    string `json:"discord_webhook" yaml:"discord_webhook"`
-		SentryDsn         string `json:"sentry_dsn" yaml:"sentry_dsn"`
+		SentryDsn         string `json:"sentry_dsn" yaml:"sentry_dsn" secret:"true"`
 	} `json:"notify" yaml:"notify"`
+	Alert struct {
+		DedupSeconds       int          `json:"dedup_seconds" yaml:"dedup_seconds"`                 // identical alerts within the window are sent once, defaults to 600
+		RateLimitPerMinute int          `json:"rate_limit_per_minute" yaml:"rate_limit_per_minute"` // per sink, critical alerts are never limited, defaults to 20
+		Sinks              []AlertSink  `json:"sinks" yaml:"sinks"`
+		Routes             []AlertRoute `json:"routes" yaml:"routes"`
+	} `json:"alert" yaml:"alert"`
+	Report struct {
+		DailyCronSpec  string `json:"daily_cron_spec" yaml:"daily_cron_spec"`   // reports of yesterday, defaults to 01:00 every day
+		WeeklyCronSpec string `json:"weekly_cron_spec" yaml:"weekly_cron_spec"` // reports of last week, defaults to 01:10 on monday
+	} `json:"report" yaml:"report"`
+	StuckOrder struct {
+		SlaMinutes map[string]int64 `json:"sla_minutes" yaml:"sla_minutes"` // by order stage, overrides the defaults in timer/stuck_order.go
+	} `json:"stuck_order" yaml:"stuck_order"`
+	Signer struct {
+		MockAddr          string     `json:"mock_addr" yaml:"mock_addr"` // listen address of the mock remote sign service for the mock keys
+		Pay               SignerRole `json:"pay" yaml:"pay"`
+		TransferWhitelist SignerRole `json:"transfer_whitelist" yaml:"transfer_whitelist"`
+		CapacityWhitelist SignerRole `json:"capacity_whitelist" yaml:"capacity_whitelist"`
+	} `json:"signer" yaml:"signer"`
+	WalletMonitor struct {
+		SpendingWindowHours int             `json:"spending_window_hours" yaml:"spending_window_hours"` // spending is measured over this window, defaults to 6
+		Pay                 WalletThreshold `json:"pay" yaml:"pay"`
+		TransferWhitelist   WalletThreshold `json:"transfer_whitelist" yaml:"transfer_whitelist"`
+		CapacityWhitelist   WalletThreshold `json:"capacity_whitelist" yaml:"capacity_whitelist"`
+	} `json:"wallet_monitor" yaml:"wallet_monitor"`
+	CellPool struct {
+		MinFreeCells        int `json:"min_free_cells" yaml:"min_free_cells"`               // free cells of the pay address kept split, defaults to 10
+		DemandWindowMinutes int `json:"demand_window_minutes" yaml:"demand_window_minutes"` // split size and target follow the demand of this window, defaults to 60
+	} `json:"cell_pool" yaml:"cell_pool"`
+	Referral struct {
+		Tiers []ReferralTier `json:"tiers" yaml:"tiers"` // off-chain bonus on top of the on-chain rebates, the best tier reached applies
+	} `json:"referral" yaml:"referral"`
+	Suggest struct {
+		Es       *bool    `json:"es" yaml:"es"`             // also suggest the names of the recommend-acc index, defaults to on when es.addr is set
+		Prefixes []string `json:"prefixes" yaml:"prefixes"` // empty uses the built-in list
+		Suffixes []string `json:"suffixes" yaml:"suffixes"`
+	} `json:"suggest" yaml:"suggest"`
 	PayAddressMap map[string]string `json:"pay_address_map" yaml:"pay_address_map"`
 	Chain         struct {
 		CkbUrl             string `json:"ckb_url" yaml:"ckb_url"`
@@ -111,14 +196,20 @@ type CfgServer struct {
 	Cache struct {
 		Redis struct {
 			Addr     string `json:"addr" yaml:"addr"`
-			Password string `json:"password" yaml:"password"`
+			Password string `json:"password" yaml:"password" secret:"true"`
 			DbNum    int    `json:"db_num" yaml:"db_num"`
 		} `json:"redis" yaml:"redis"`
 	} `json:"cache" yaml:"cache"`
+	Trace struct {
+		Exporter    string  `json:"exporter" yaml:"exporter"` // empty disables tracing, stdout or otlp
+		Endpoint    string  `json:"endpoint" yaml:"endpoint"` // otlp http collector host:port
+		Insecure    bool    `json:"insecure" yaml:"insecure"`
+		SampleRatio float64 `json:"sample_ratio" yaml:"sample_ratio"` // 0 means sample all
+	} `json:"trace" yaml:"trace"`
 	ES struct {
 		Addr     string `json:"addr" yaml:"addr"`
 		User     string `json:"user" yaml:"user"`
-		Password string `json:"password" yaml:"password"`
+		Password string `json:"password" yaml:"password" secret:"true"`
 	} `json:"es" yaml:"es"`
 	Das struct {
 		AccountMinLength     uint8           `json:"account_min_length" yaml:"account_min_length"`
@@ -133,12 +224,105 @@ type CfgServer struct {
 		PremiumPercentage decimal.Decimal `json:"premium_percentage" yaml:"premium_percentage"`
 		PremiumBase       decimal.Decimal `json:"premium_base" yaml:"premium_base"`
 	} `json:"stripe" yaml:"stripe"`
+
+	inlinePrivateKeys []string // secret:"private_key" fields given in the yaml instead of a reference
+}
+
+type AlertSink struct {
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"` // lark, discord, slack, smtp, log
+	Url  string `json:"url" yaml:"url"`   // lark key, discord or slack-compatible webhook url
+	Smtp struct {
+		Addr     string   `json:"addr" yaml:"addr"` // host:port
+		User     string   `json:"user" yaml:"user"`
+		Password string   `json:"password" yaml:"password" secret:"true"`
+		From     string   `json:"from" yaml:"from"`
+		To       []string `json:"to" yaml:"to"`
+	} `json:"smtp" yaml:"smtp"`
+}
+
+// AlertRoute sends the alerts whose title matches and whose severity is at least MinSeverity to Sinks,
+// every matching route applies
+type AlertRoute struct {
+	Title       string   `json:"title" yaml:"title"` // regexp, empty matches all
+	MinSeverity string   `json:"min_severity" yaml:"min_severity"`
+	Sinks       []string `json:"sinks" yaml:"sinks"`
+}
+
+// SignerRole is the key of a signer role, during a rotation Previous still signs for its address until PreviousUntil
+type SignerRole struct {
+	Current       SignerKey `json:"current" yaml:"current"`
+	Previous      SignerKey `json:"previous" yaml:"previous"`
+	PreviousUntil string    `json:"previous_until" yaml:"previous_until"` // 2006-01-02 15:04:05, local time
+}
+
+type SignerKey struct {
+	Type      string `json:"type" yaml:"type"` // local, remote or mock
+	Address   string `json:"address" yaml:"address"`
+	Private   string `json:"private" yaml:"private" secret:"private_key"` // local and mock
+	RemoteUrl string `json:"remote_url" yaml:"remote_url"`                // remote
+}
+
+const (
+	SignerTypeLocal  = "local"
+	SignerTypeRemote = "remote"
+	SignerTypeMock   = "mock"
+
+	SignerRolePay               = "pay"
+	SignerRoleTransferWhitelist = "transfer_whitelist"
+	SignerRoleCapacityWhitelist = "capacity_whitelist"
+)
+
+// SuggestEs reports whether account/recommend also takes the names of the es index, unset follows es.addr
+func (c *CfgServer) SuggestEs() bool {
+	if c.Suggest.Es != nil {
+		return *c.Suggest.Es
+	}
+	return c.ES.Addr != ""
+}
+
+// SignerRoles returns the signer of every role, a role without signer config falls back to the server keys
+func (c *CfgServer) SignerRoles() map[string]SignerRole {
+	roles := map[string]SignerRole{
+		SignerRolePay:               c.Signer.Pay,
+		SignerRoleTransferWhitelist: c.Signer.TransferWhitelist,
+		SignerRoleCapacityWhitelist: c.Signer.CapacityWhitelist,
+	}
+	legacy := map[string]SignerKey{
+		SignerRolePay:               {Type: SignerTypeLocal, Address: c.Server.PayServerAddress, Private: c.Server.PayPrivate},
+		SignerRoleTransferWhitelist: {Type: SignerTypeLocal, Address: c.Server.TransferWhitelist, Private: c.Server.TransferWhitelistPrivate},
+		SignerRoleCapacityWhitelist: {Type: SignerTypeLocal, Address: c.Server.CapacityWhitelist, Private: c.Server.CapacityWhitelistPrivate},
+	}
+	if c.Server.RemoteSignApiUrl != "" {
+		legacy[SignerRolePay] = SignerKey{Type: SignerTypeRemote, Address: c.Server.PayServerAddress, RemoteUrl: c.Server.RemoteSignApiUrl}
+	}
+	for role, v := range roles {
+		if v.Current.Address == "" && legacy[role].Address != "" {
+			roles[role] = SignerRole{Current: legacy[role]}
+		}
+	}
+	return roles
+}
+
+// WalletThreshold alerts when the spendable capacity of a signer address gets low, zero disables a check
+type WalletThreshold struct {
+	MinCkb         uint64  `json:"min_ckb" yaml:"min_ckb"`
+	MinCells       int     `json:"min_cells" yaml:"min_cells"`
+	MinRunwayHours float64 `json:"min_runway_hours" yaml:"min_runway_hours"`
+}
+
+// ReferralTier is reached by an inviter with at least MinInvitees invitees and MinVolumeUSD registered in the payout period
+type ReferralTier struct {
+	Name         string          `json:"name" yaml:"name"`
+	MinInvitees  int64           `json:"min_invitees" yaml:"min_invitees"`
+	MinVolumeUSD decimal.Decimal `json:"min_volume_usd" yaml:"min_volume_usd"`
+	BonusRate    decimal.Decimal `json:"bonus_rate" yaml:"bonus_rate"` // share of the period's on-chain rebates paid as bonus
 }
 
 type DbMysql struct {
 	Addr        string `json:"addr" yaml:"addr"`
 	User        string `json:"user" yaml:"user"`
-	Password    string `json:"password" yaml:"password"`
+	Password    string `json:"password" yaml:"password" secret:"true"`
 	DbName      string `json:"db_name" yaml:"db_name"`
 	MaxOpenConn int    `json:"max_open_conn" yaml:"max_open_conn"`
 	MaxIdleConn int    `json:"max_idle_conn" yaml:"max_idle_conn"`
@@ -147,23 +331,23 @@ type DbMysql struct {
 func GetUnipayAddress(tokenId tables.PayTokenId) string {
 	switch tokenId {
 	case tables.TokenIdEth, tables.TokenIdErc20USDT:
-		return Cfg.PayAddressMap["eth"]
+		return Cfg().PayAddressMap["eth"]
 	case tables.TokenIdBnb, tables.TokenIdBep20USDT:
-		return Cfg.PayAddressMap["bsc"]
+		return Cfg().PayAddressMap["bsc"]
 	//case tables.TokenIdMatic:
-	//	return Cfg.PayAddressMap["polygon"]
+	//	return Cfg().PayAddressMap["polygon"]
 	case tables.TokenIdPol:
-		return Cfg.PayAddressMap["polygon"]
+		return Cfg().PayAddressMap["polygon"]
 	case tables.TokenIdTrx, tables.TokenIdTrc20USDT:
-		return Cfg.PayAddressMap["tron"]
+		return Cfg().PayAddressMap["tron"]
 	case tables.TokenIdCkb, tables.TokenIdDas, tables.TokenIdCkbCCC:
-		return Cfg.PayAddressMap["ckb"]
+		return Cfg().PayAddressMap["ckb"]
 	case tables.TokenIdDoge:
-		return Cfg.PayAddressMap["doge"]
+		return Cfg().PayAddressMap["doge"]
 	case tables.TokenIdStripeUSD:
 		return "stripe"
 	case tables.ToKenIdDidPoint:
-		return Cfg.PayAddressMap["did_point"]
+		return Cfg().PayAddressMap["did_point"]
 	}
 	log.Error("GetUnipayAddress not supported:", tokenId)
 	return ""
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Fields tagged `secret:"true"` or `secret:"private_key"` may be given as a reference instead of the value:
+//
+//	${ENV:NAME}  the environment variable NAME
+//	file:PATH    the content of the file PATH, trailing newlines trimmed
+//
+// Secret fields are redacted whenever the config is logged. A private key given inline is refused on mainnet.
+const (
+	secretTag        = "secret"
+	secretPrivateKey = "private_key"
+	secretEnvPrefix  = "${ENV:"
+	secretEnvSuffix  = "}"
+	secretFilePrefix = "file:"
+	secretRedacted   = "******"
+)
+
+// resolveSecret returns the value of a secret reference, inline values are returned as is
+func resolveSecret(value string) (v string, inline bool, err error) {
+	switch {
+	case strings.HasPrefix(value, secretEnvPrefix) && strings.HasSuffix(value, secretEnvSuffix):
+		name := strings.TrimSuffix(strings.TrimPrefix(value, secretEnvPrefix), secretEnvSuffix)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", false, fmt.Errorf("env %s not set", name)
+		}
+		return v, false, nil
+	case strings.HasPrefix(value, secretFilePrefix):
+		path := strings.TrimPrefix(value, secretFilePrefix)
+		bys, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("read %s err: %s", path, err.Error())
+		}
+		return strings.TrimRight(string(bys), "\r\n"), false, nil
+	}
+	return value, value != "", nil
+}
+
+// walkSecrets calls fn with the yaml key and the tag of every secret string field of v
+func walkSecrets(v reflect.Value, key string, fn func(key, tag string, field reflect.Value) error) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			if key != "" {
+				name = key + "." + name
+			}
+			if tag := f.Tag.Get(secretTag); tag != "" && f.Type.Kind() == reflect.String {
+				if err := fn(name, tag, v.Field(i)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := walkSecrets(v.Field(i), name, fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkSecrets(v.Index(i), fmt.Sprintf("%s[%d]", key, i), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveSecrets replaces the secret references of c by their value and records the private keys given inline
+func (c *CfgServer) resolveSecrets() error {
+	c.inlinePrivateKeys = nil
+	return walkSecrets(reflect.ValueOf(c).Elem(), "", func(key, tag string, field reflect.Value) error {
+		v, inline, err := resolveSecret(field.String())
+		if err != nil {
+			return fmt.Errorf("%s: %s", key, err.Error())
+		}
+		if inline && tag == secretPrivateKey {
+			c.inlinePrivateKeys = append(c.inlinePrivateKeys, key)
+		}
+		field.SetString(v)
+		return nil
+	})
+}
+
+// Redacted returns a copy of c with every secret field masked, for logging
+func (c *CfgServer) Redacted() *CfgServer {
+	var redacted CfgServer
+	bys, _ := json.Marshal(c)
+	_ = json.Unmarshal(bys, &redacted)
+	_ = walkSecrets(reflect.ValueOf(&redacted).Elem(), "", func(key, tag string, field reflect.Value) error {
+		if field.String() != "" {
+			field.SetString(secretRedacted)
+		}
+		return nil
+	})
+	return &redacted
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "pay_private")
+	if err := os.WriteFile(keyFile, []byte("0x02\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_DB_PASSWORD", "db-pwd")
+
+	c := testCfg(t)
+	c.Server.PayPrivate = "file:" + keyFile
+	c.DB.Mysql.Password = "${ENV:TEST_DB_PASSWORD}"
+	c.Alert.Sinks = []AlertSink{{Name: "mail"}}
+	c.Alert.Sinks[0].Smtp.Password = "smtp-pwd"
+	if err := c.resolveSecrets(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.PayPrivate != "0x02" || c.DB.Mysql.Password != "db-pwd" || c.Alert.Sinks[0].Smtp.Password != "smtp-pwd" {
+		t.Fatalf("resolved: %s %s %s", c.Server.PayPrivate, c.DB.Mysql.Password, c.Alert.Sinks[0].Smtp.Password)
+	}
+	if len(c.inlinePrivateKeys) != 0 {
+		t.Fatalf("inline: %v", c.inlinePrivateKeys)
+	}
+
+	redacted := c.Redacted()
+	if redacted.Server.PayPrivate != secretRedacted || redacted.DB.Mysql.Password != secretRedacted ||
+		redacted.Alert.Sinks[0].Smtp.Password != secretRedacted || redacted.DB.Mysql.Addr != c.DB.Mysql.Addr {
+		t.Fatalf("redacted: %+v", redacted.DB.Mysql)
+	}
+	if c.Alert.Sinks[0].Smtp.Password != "smtp-pwd" {
+		t.Fatal("Redacted modified the config")
+	}
+
+	c.DB.Mysql.Password = "${ENV:TEST_NOT_SET}"
+	if err := c.resolveSecrets(); err == nil || !strings.Contains(err.Error(), "db.mysql.password") {
+		t.Fatalf("unset env: %v", err)
+	}
+}
+
+func TestInlinePrivateKeyMainnet(t *testing.T) {
+	c := testCfg(t) // pay_private is inlined
+	if err := c.Validate(ModeAll); err != nil {
+		t.Fatal(err)
+	}
+	c.Server.Net = 1
+	c.Server.PayServerAddress = ""
+	c.Server.RemoteSignApiUrl = "http://127.0.0.1:8080"
+	err := c.Validate(ModeApi)
+	if err == nil || !strings.Contains(err.Error(), "server.pay_private: private key inlined on mainnet") {
+		t.Fatalf("mainnet inline key: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/shopspring/decimal"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Mode is the service mode given by the -m flag
+type Mode string
+
+const (
+	ModeAll   Mode = "" // api and timer
+	ModeApi   Mode = "api"
+	ModeTimer Mode = "timer"
+	ModeAdmin Mode = "admin"
+)
+
+const maxTxFeeRate = 1000 // shannons per byte
+
+// Validate returns every problem of the config for the mode at once
+func (c *CfgServer) Validate(mode Mode) error {
+	var errs []string
+	check := func(ok bool, format string, a ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, a...))
+		}
+	}
+	checkAddress := func(key, addr string) {
+		if addr == "" {
+			return
+		}
+		if _, err := address.Parse(addr); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: invalid ckb address %s: %s", key, addr, err.Error()))
+		}
+	}
+	checkRange := func(key string, v, min, max decimal.Decimal) {
+		check(v.GreaterThanOrEqual(min) && v.LessThanOrEqual(max), "%s: %s not in [%s, %s]", key, v, min, max)
+	}
+	one := decimal.NewFromInt(1)
+	net := c.Server.Net
+
+	// secrets
+	if net == common.DasNetTypeMainNet {
+		for _, key := range c.inlinePrivateKeys {
+			errs = append(errs, fmt.Sprintf("%s: private key inlined on mainnet, use ${ENV:NAME} or file:PATH", key))
+		}
+	}
+
+	// required
+	check(net == common.DasNetTypeMainNet || net == common.DasNetTypeTestnet2 || net == common.DasNetTypeTestnet3,
+		"server.net: unknown net %d", net)
+	check(c.DB.Mysql.Addr != "" && c.DB.Mysql.DbName != "", "db.mysql: addr and db_name are required")
+	check(c.DB.ParserMysql.Addr != "" && c.DB.ParserMysql.DbName != "", "db.parser_mysql: addr and db_name are required")
+	check(c.Chain.CkbUrl != "", "chain.ckb_url: required")
+	if mode == ModeAll || mode == ModeApi {
+		check(c.Server.HttpServerAddr != "", "server.http_server_addr: required in %s mode", modeName(mode))
+	}
+	signerRoles := c.SignerRoles()
+	if mode == ModeAll || mode == ModeTimer {
+		check(signerRoles[SignerRolePay].Current.Address != "",
+			"signer.pay or server.pay_server_address: required in %s mode", modeName(mode))
+	}
+
+	// the pay address is read once at start from signer.pay, the legacy key must name the same address
+	check(c.Signer.Pay.Current.Address == "" || c.Signer.Pay.Current.Address == c.Server.PayServerAddress,
+		"signer.pay.current.address: differs from server.pay_server_address")
+
+	// addresses
+	checkAddress("server.pay_server_address", c.Server.PayServerAddress)
+	checkAddress("server.transfer_whitelist", c.Server.TransferWhitelist)
+	checkAddress("server.capacity_whitelist", c.Server.CapacityWhitelist)
+	checkAddress("pay_address_map.ckb", c.PayAddressMap["ckb"])
+
+	// signers, only the timer signs so the api needs just the addresses
+	signs := mode == ModeAll || mode == ModeTimer
+	checkSignerKey := func(key string, k SignerKey) {
+		if k.Address == "" {
+			return
+		}
+		checkAddress(key+".address", k.Address)
+		switch k.Type {
+		case SignerTypeLocal, SignerTypeMock:
+			check(k.Private != "" || !signs, "%s.private: required by a %s signer in %s mode", key, k.Type, modeName(mode))
+			check(k.Type != SignerTypeMock || net != common.DasNetTypeMainNet, "%s: mock signer on mainnet", key)
+			check(k.Type != SignerTypeMock || c.Signer.MockAddr != "", "signer.mock_addr: required by the mock signer %s", key)
+		case SignerTypeRemote:
+			check(k.RemoteUrl != "" || !signs, "%s.remote_url: required by a remote signer in %s mode", key, modeName(mode))
+		default:
+			errs = append(errs, fmt.Sprintf("%s.type: unknown signer type %s", key, k.Type))
+		}
+	}
+	for role, v := range signerRoles {
+		checkSignerKey("signer."+role+".current", v.Current)
+		checkSignerKey("signer."+role+".previous", v.Previous)
+		if v.PreviousUntil != "" {
+			_, err := time.ParseInLocation("2006-01-02 15:04:05", v.PreviousUntil, time.Local)
+			check(err == nil, "signer.%s.previous_until: invalid time %s", role, v.PreviousUntil)
+		}
+	}
+
+	// ranges
+	check(c.Server.TxTeeRate <= maxTxFeeRate, "server.tx_fee_rate: %d greater than %d", c.Server.TxTeeRate, maxTxFeeRate)
+	check(c.Das.AccountMinLength <= c.Das.AccountMaxLength, "das.account_min_length: %d greater than das.account_max_length %d",
+		c.Das.AccountMinLength, c.Das.AccountMaxLength)
+	check(c.Das.OpenAccountMinLength <= c.Das.OpenAccountMaxLength, "das.open_account_min_length: %d greater than das.open_account_max_length %d",
+		c.Das.OpenAccountMinLength, c.Das.OpenAccountMaxLength)
+	check(c.Das.MaxRegisterYears > 0, "das.max_register_years: %d not positive", c.Das.MaxRegisterYears)
+	checkRange("das.premium", c.Das.Premium, decimal.Zero, one)
+	checkRange("das.discount", c.Das.Discount, decimal.Zero, one) // 0 means no discount
+	check(c.Stripe.PremiumPercentage.GreaterThanOrEqual(decimal.Zero) && c.Stripe.PremiumPercentage.LessThan(one),
+		"stripe.premium_percentage: %s not in [0, 1)", c.Stripe.PremiumPercentage)
+	check(!c.Stripe.PremiumBase.IsNegative(), "stripe.premium_base: %s negative", c.Stripe.PremiumBase)
+	check(c.Trace.SampleRatio >= 0 && c.Trace.SampleRatio <= 1, "trace.sample_ratio: %v not in [0, 1]", c.Trace.SampleRatio)
+	check(c.Trace.Exporter == "" || c.Trace.Exporter == "stdout" || c.Trace.Exporter == "otlp",
+		"trace.exporter: unknown exporter %s", c.Trace.Exporter)
+	for stage, v := range c.StuckOrder.SlaMinutes {
+		check(v >= 0, "stuck_order.sla_minutes.%s: %d negative", stage, v)
+	}
+	check(c.WalletMonitor.SpendingWindowHours >= 0, "wallet_monitor.spending_window_hours: %d negative", c.WalletMonitor.SpendingWindowHours)
+	for role, v := range map[string]WalletThreshold{SignerRolePay: c.WalletMonitor.Pay,
+		SignerRoleTransferWhitelist: c.WalletMonitor.TransferWhitelist, SignerRoleCapacityWhitelist: c.WalletMonitor.CapacityWhitelist} {
+		check(v.MinCells >= 0 && v.MinRunwayHours >= 0, "wallet_monitor.%s: negative threshold", role)
+	}
+	check(c.CellPool.MinFreeCells >= 0, "cell_pool.min_free_cells: %d negative", c.CellPool.MinFreeCells)
+	check(c.CellPool.DemandWindowMinutes >= 0, "cell_pool.demand_window_minutes: %d negative", c.CellPool.DemandWindowMinutes)
+	tierNames := make(map[string]bool)
+	for i, v := range c.Referral.Tiers {
+		check(v.Name != "" && !tierNames[v.Name], "referral.tiers[%d]: name empty or repeated", i)
+		check(v.MinInvitees >= 0 && !v.MinVolumeUSD.IsNegative(), "referral.tiers[%d]: negative minimum", i)
+		checkRange(fmt.Sprintf("referral.tiers[%d].bonus_rate", i), v.BonusRate, decimal.Zero, one)
+		tierNames[v.Name] = true
+	}
+	check(!c.SuggestEs() || c.ES.Addr != "", "suggest.es: es.addr empty")
+	for _, v := range append(append([]string{}, c.Suggest.Prefixes...), c.Suggest.Suffixes...) {
+		check(v != "" && !strings.Contains(v, "."), "suggest: affix [%s] empty or with a dot", v)
+	}
+	check(c.Alert.DedupSeconds >= 0, "alert.dedup_seconds: %d negative", c.Alert.DedupSeconds)
+	check(c.Alert.RateLimitPerMinute >= 0, "alert.rate_limit_per_minute: %d negative", c.Alert.RateLimitPerMinute)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func modeName(mode Mode) string {
+	if mode == ModeAll {
+		return "default"
+	}
+	return string(mode)
+}
+
+// restartOnly lists the keys that are read once at start, a reload keeps their running value.
+// Every other key is hot-reloadable.
+var restartOnly = []struct {
+	key   string
+	field func(c *CfgServer) interface{} // pointer to the field
+}{
+	{"server.net", func(c *CfgServer) interface{} { return &c.Server.Net }},
+	{"server.http_server_addr", func(c *CfgServer) interface{} { return &c.Server.HttpServerAddr }},
+	{"server.http_server_internal_addr", func(c *CfgServer) interface{} { return &c.Server.HttpServerInternalAddr }},
+	{"server.pay_server_address", func(c *CfgServer) interface{} { return &c.Server.PayServerAddress }},
+	{"server.pay_private", func(c *CfgServer) interface{} { return &c.Server.PayPrivate }},
+	{"server.remote_sign_api_url", func(c *CfgServer) interface{} { return &c.Server.RemoteSignApiUrl }},
+	{"server.transfer_whitelist", func(c *CfgServer) interface{} { return &c.Server.TransferWhitelist }},
+	{"server.transfer_whitelist_private", func(c *CfgServer) interface{} { return &c.Server.TransferWhitelistPrivate }},
+	{"server.capacity_whitelist", func(c *CfgServer) interface{} { return &c.Server.CapacityWhitelist }},
+	{"server.capacity_whitelist_private", func(c *CfgServer) interface{} { return &c.Server.CapacityWhitelistPrivate }},
+	{"signer.mock_addr", func(c *CfgServer) interface{} { return &c.Signer.MockAddr }},
+	{"server.prometheus_push_gateway", func(c *CfgServer) interface{} { return &c.Server.PrometheusPushGateway }},
+	{"notify.sentry_dsn", func(c *CfgServer) interface{} { return &c.Notify.SentryDsn }},
+	{"alert", func(c *CfgServer) interface{} { return &c.Alert }},
+	{"report", func(c *CfgServer) interface{} { return &c.Report }},
+	{"chain", func(c *CfgServer) interface{} { return &c.Chain }},
+	{"db", func(c *CfgServer) interface{} { return &c.DB }},
+	{"cache", func(c *CfgServer) interface{} { return &c.Cache }},
+	{"trace", func(c *CfgServer) interface{} { return &c.Trace }},
+	{"es", func(c *CfgServer) interface{} { return &c.ES }},
+}
+
+// keepRestartOnly copies the restart-only keys of old into c and returns the ones that differed
+func keepRestartOnly(old, c *CfgServer) []string {
+	var changed []string
+	for _, v := range restartOnly {
+		oldValue := reflect.ValueOf(v.field(old)).Elem()
+		newValue := reflect.ValueOf(v.field(c)).Elem()
+		if !reflect.DeepEqual(oldValue.Interface(), newValue.Interface()) {
+			changed = append(changed, v.key)
+			newValue.Set(oldValue)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/shopspring/decimal"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCfgYaml = `
+server:
+  net: 2
+  http_server_addr: ":8120"
+  pay_server_address: "ckt1qyqvsej8jggu4hmr45g4h8d9pfkpd0fayfksz44t9q"
+  pay_private: "0x01"
+  tx_fee_rate: 1
+chain:
+  ckb_url: "http://127.0.0.1:8114"
+db:
+  mysql:
+    addr: "127.0.0.1:3306"
+    db_name: "das_register_db"
+  parser_mysql:
+    addr: "127.0.0.1:3306"
+    db_name: "das_database"
+das:
+  account_min_length: 4
+  account_max_length: 42
+  max_register_years: 20
+  premium: "0"
+  discount: "0.9"
+`
+
+func testCfg(t *testing.T) *CfgServer {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testCfgYaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadCfg(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	c := testCfg(t)
+	if err := c.Validate(ModeAll); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Server.Net = 0
+	c.Server.PayServerAddress = "ckt1-bad"
+	c.Das.Discount = decimal.NewFromInt(2)
+	c.Trace.SampleRatio = 1.5
+	err := c.Validate(ModeAll)
+	if err == nil {
+		t.Fatal("invalid config passed")
+	}
+	for _, key := range []string{"server.net", "server.pay_server_address", "das.discount", "trace.sample_ratio"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("%s not reported: %s", key, err.Error())
+		}
+	}
+
+	c = testCfg(t)
+	c.Server.PayServerAddress, c.Server.PayPrivate = "", ""
+	if err := c.Validate(ModeApi); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(ModeTimer); err == nil {
+		t.Fatal("timer mode without pay server address passed")
+	}
+
+	// the signer registry and the legacy key must name one pay address
+	c = testCfg(t)
+	c.Signer.Pay.Current = SignerKey{Type: SignerTypeRemote, Address: "ckt1qyqrdsefa43s6m882pcj53m4gdnj4k440axqswmu83", RemoteUrl: "http://127.0.0.1:8080"}
+	if err := c.Validate(ModeAll); err == nil || !strings.Contains(err.Error(), "signer.pay.current.address") {
+		t.Fatalf("different pay addresses: %v", err)
+	}
+	c.Signer.Pay.Current.Address = c.Server.PayServerAddress
+	if err := c.Validate(ModeAll); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadCfg(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testCfgYaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := InitCfg(path, ModeAll); err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.Store(&CfgServer{})
+
+	// invalid files are rejected and the running snapshot kept
+	running := Cfg()
+	if err := os.WriteFile(path, []byte(strings.Replace(testCfgYaml, "max_register_years: 20", "max_register_years: 0", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloadCfg(path); err == nil {
+		t.Fatal("invalid reload accepted")
+	}
+	if Cfg() != running {
+		t.Fatal("snapshot replaced by an invalid reload")
+	}
+
+	// hot-reloadable keys apply, restart-only keys keep their running value
+	yaml := strings.Replace(testCfgYaml, "max_register_years: 20", "max_register_years: 10", 1)
+	yaml = strings.Replace(yaml, "net: 2", "net: 3", 1)
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := reloadCfg(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || changed[0] != "server.net" {
+		t.Fatalf("changed: %v", changed)
+	}
+	if Cfg().Das.MaxRegisterYears != 10 || Cfg().Server.Net != common.DasNetTypeTestnet2 {
+		t.Fatalf("reloaded: %d %d", Cfg().Das.MaxRegisterYears, Cfg().Server.Net)
+	}
+	if running.Das.MaxRegisterYears != 20 {
+		t.Fatal("old snapshot modified")
+	}
+}
@@ -1,8 +1,11 @@
 package dao
 
 import (
+	"context"
 	"das_register_server/config"
+	"das_register_server/prometheus"
 	"das_register_server/tables"
+	"das_register_server/tracing"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/http_api"
 	"gorm.io/gorm"
@@ -18,16 +21,25 @@ func (d *DbDao) InitDb(db, parserDb *gorm.DB) {
 	d.db = db
 }
 
+// WithContext returns a DbDao whose queries are traced as children of the span in ctx
+func (d *DbDao) WithContext(ctx context.Context) *DbDao {
+	if d == nil || ctx == nil {
+		return d
+	}
+	return &DbDao{db: d.db.WithContext(ctx), parserDb: d.parserDb.WithContext(ctx)}
+}
+
+// NewGormDB opens the dbs and migrates the tables of the server
 func NewGormDB(dbMysql, parserMysql config.DbMysql) (*DbDao, error) {
-	db, err := http_api.NewGormDB(dbMysql.Addr, dbMysql.User, dbMysql.Password, dbMysql.DbName, dbMysql.MaxOpenConn, dbMysql.MaxIdleConn)
+	d, err := OpenGormDB(dbMysql, parserMysql)
 	if err != nil {
-		return nil, fmt.Errorf("toolib.NewGormDB err: %s", err.Error())
+		return nil, err
 	}
 
 	// AutoMigrate will create tables, missing foreign keys, constraints, columns and indexes.
 	// It will change existing column’s type if its size, precision, nullable changed.
 	// It WON’T delete unused columns to protect your data.
-	if err = db.AutoMigrate(
+	if err = d.db.AutoMigrate(
 		&tables.TableBlockParserInfo{},
 		&tables.TableDasOrderInfo{},
 		&tables.TableDasOrderPayInfo{},
@@ -35,14 +47,42 @@ func NewGormDB(dbMysql, parserMysql config.DbMysql) (*DbDao, error) {
 		&tables.TableRegisterPendingInfo{},
 		&tables.TableCoupon{},
 		&tables.TableAuctionOrder{},
+		&tables.TablePartnerWebhook{},
+		&tables.TableWebhookDelivery{},
+		&tables.TableCouponCampaign{},
+		&tables.TableCouponCampaignUsage{},
+		&tables.TableReferralChannel{},
+		&tables.TableReferralOrder{},
+		&tables.TableAdminAudit{},
 	); err != nil {
 		return nil, err
 	}
+	return d, nil
+}
+
+// OpenGormDB opens the dbs without migrating, for the commands that must not change the schema
+func OpenGormDB(dbMysql, parserMysql config.DbMysql) (*DbDao, error) {
+	db, err := http_api.NewGormDB(dbMysql.Addr, dbMysql.User, dbMysql.Password, dbMysql.DbName, dbMysql.MaxOpenConn, dbMysql.MaxIdleConn)
+	if err != nil {
+		return nil, fmt.Errorf("toolib.NewGormDB err: %s", err.Error())
+	}
+	if err = prometheus.RegisterGormCallbacks(db, "register"); err != nil {
+		return nil, fmt.Errorf("RegisterGormCallbacks err: %s", err.Error())
+	}
+	if err = tracing.RegisterGormCallbacks(db, "register"); err != nil {
+		return nil, fmt.Errorf("tracing.RegisterGormCallbacks err: %s", err.Error())
+	}
 
 	parserDb, err := http_api.NewGormDB(parserMysql.Addr, parserMysql.User, parserMysql.Password, parserMysql.DbName, parserMysql.MaxOpenConn, parserMysql.MaxIdleConn)
 	if err != nil {
 		return nil, fmt.Errorf("toolib.NewGormDB err: %s", err.Error())
 	}
+	if err = prometheus.RegisterGormCallbacks(parserDb, "parser"); err != nil {
+		return nil, fmt.Errorf("RegisterGormCallbacks err: %s", err.Error())
+	}
+	if err = tracing.RegisterGormCallbacks(parserDb, "parser"); err != nil {
+		return nil, fmt.Errorf("tracing.RegisterGormCallbacks err: %s", err.Error())
+	}
 	return &DbDao{db: db, parserDb: parserDb}, nil
 }
 
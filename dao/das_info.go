@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/shopspring/decimal"
+	"time"
 )
 
 type AccountNumRegisterNum struct {
@@ -31,7 +32,7 @@ func (d *DbDao) GetOrderTotalAmount() (list []OrderTotalAmount, err error) {
 		Select("pay_token_id,SUM(pay_amount) amount,count(*) num").
 		Where("order_type=? AND pay_status=?", tables.OrderTypeSelf, tables.TxStatusOk).
 		Group("pay_token_id").Find(&list).Error
-	return list, nil
+	return
 }
 
 func (d *DbDao) GetOrderRefundTotalAmount() (list []OrderTotalAmount, err error) {
@@ -40,7 +41,7 @@ LEFT JOIN %s o ON o.order_id=p.order_id
 WHERE p.refund_status=2
 GROUP BY o.pay_token_id`, tables.TableNameDasOrderPayInfo, tables.TableNameDasOrderInfo)
 	err = d.db.Raw(sql).Find(&list).Error
-	return list, nil
+	return
 }
 
 //
@@ -68,3 +69,55 @@ func (d *DbDao) GetRegisterStatusCount() (list []RegisterStatusCount, err error)
 		Group("register_status").Find(&list).Error
 	return
 }
+
+// report, all ranges are [start, end)
+
+type OrderLengthAmount struct {
+	Length     int               `json:"length" gorm:"column:length"`
+	PayTokenId tables.PayTokenId `json:"pay_token_id" gorm:"column:pay_token_id"`
+	Amount     decimal.Decimal   `json:"amount" gorm:"column:amount"`
+	Num        int               `json:"num" gorm:"column:num"`
+}
+
+func (d *DbDao) GetPaidOrderLengthAmount(action common.DasAction, start, end time.Time) (list []OrderLengthAmount, err error) {
+	err = d.db.Model(tables.TableDasOrderInfo{}).
+		Select("CHAR_LENGTH(account)-4 AS length,pay_token_id,SUM(pay_amount) amount,count(*) num").
+		Where("order_type=? AND action=? AND pay_status=? AND timestamp>=? AND timestamp<?",
+			tables.OrderTypeSelf, action, tables.TxStatusOk, start.UnixMilli(), end.UnixMilli()).
+		Group("length,pay_token_id").Order("length,pay_token_id").Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetOrderRefundAmountByTime(start, end time.Time) (list []OrderTotalAmount, err error) {
+	sql := fmt.Sprintf(`SELECT o.pay_token_id,SUM(o.pay_amount)amount,count(*)num FROM %s p 
+LEFT JOIN %s o ON o.order_id=p.order_id 
+WHERE (p.refund_status=? OR p.uni_pay_refund_status=?) AND p.updated_at>=? AND p.updated_at<?
+GROUP BY o.pay_token_id`, tables.TableNameDasOrderPayInfo, tables.TableNameDasOrderInfo)
+	err = d.db.Raw(sql, tables.TxStatusOk, tables.UniPayRefundStatusRefunded, start, end).Find(&list).Error
+	return
+}
+
+type CouponUsedCount struct {
+	CouponType tables.CouponType `json:"type" gorm:"column:type"`
+	Num        int               `json:"num" gorm:"column:num"`
+}
+
+func (d *DbDao) GetCouponUsedCount(start, end time.Time) (list []CouponUsedCount, err error) {
+	err = d.db.Model(tables.TableCoupon{}).Select("type,count(*) num").
+		Where("use_at>=? AND use_at<?", start.Unix(), end.Unix()).
+		Group("type").Order("type").Find(&list).Error
+	return
+}
+
+type AuctionBidCount struct {
+	Num      int             `json:"num" gorm:"column:num"`
+	Accounts int             `json:"accounts" gorm:"column:accounts"`
+	Amount   decimal.Decimal `json:"amount" gorm:"column:amount"`
+}
+
+func (d *DbDao) GetAuctionBidCount(start, end time.Time) (info AuctionBidCount, err error) {
+	err = d.db.Model(tables.TableAuctionOrder{}).
+		Select("count(*) num,COUNT(DISTINCT account_id) accounts,IFNULL(SUM(basic_price+premium_price),0) amount").
+		Where("created_at>=? AND created_at<?", start, end).Find(&info).Error
+	return
+}
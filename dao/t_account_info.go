@@ -42,7 +42,7 @@ func (d *DbDao) SearchAccountListWithPage(chainType common.ChainType, address, k
 	case tables.CategoryToBeRecycled:
 		expiredAt := time.Now().Unix()
 		recycledAt := time.Now().Add(-time.Hour * 24 * 90).Unix()
-		if config.Cfg.Server.Net != common.DasNetTypeMainNet {
+		if config.Cfg().Server.Net != common.DasNetTypeMainNet {
 			recycledAt = time.Now().Add(-time.Hour * 24 * 30).Unix()
 		}
 		db = db.Where("expired_at<=? AND expired_at>=?", expiredAt, recycledAt)
@@ -79,7 +79,7 @@ func (d *DbDao) GetAccountsCount(chainType common.ChainType, address, keyword st
 	case tables.CategoryToBeRecycled:
 		expiredAt := time.Now().Unix()
 		recycledAt := time.Now().Add(-time.Hour * 24 * 90).Unix()
-		if config.Cfg.Server.Net != common.DasNetTypeMainNet {
+		if config.Cfg().Server.Net != common.DasNetTypeMainNet {
 			recycledAt = time.Now().Add(-time.Hour * 24 * 30).Unix()
 		}
 		db = db.Where("expired_at<=? AND expired_at>=?", expiredAt, recycledAt)
@@ -0,0 +1,17 @@
+package dao
+
+import (
+	"das_register_server/tables"
+)
+
+func (d *DbDao) CreateAdminAudit(audit *tables.TableAdminAudit) error {
+	return d.db.Create(audit).Error
+}
+
+func (d *DbDao) UpdateAdminAuditResult(id uint64, result string) error {
+	return d.db.Model(tables.TableAdminAudit{}).
+		Where("id=?", id).
+		Updates(map[string]interface{}{
+			"result": result,
+		}).Error
+}
@@ -4,6 +4,8 @@ import (
 	"das_register_server/tables"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
+	"github.com/shopspring/decimal"
+	"time"
 )
 
 func (d *DbDao) GetPendingAuctionOrder(chainType common.ChainType, addr string) (list []tables.TableAuctionOrder, err error) {
@@ -29,3 +31,54 @@ func (d *DbDao) GetAuctionOrderStatus(chainType common.ChainType, addr, hash str
 func (d *DbDao) CreateAuctionOrder(auctionOrder tables.TableAuctionOrder) (err error) {
 	return d.db.Create(&auctionOrder).Error
 }
+
+// GetAuctionAccounts returns the top level accounts expired in (expiredFrom, expiredTo) that can still be bid
+func (d *DbDao) GetAuctionAccounts(expiredFrom, expiredTo uint64, limit int) (list []tables.TableAccountInfo, err error) {
+	err = d.parserDb.Select("account_id, account, status, expired_at").
+		Where("expired_at>? AND expired_at<? AND status IN(?) AND parent_account_id=''",
+			expiredFrom, expiredTo, []tables.AccountStatus{tables.AccountStatusNormal, tables.AccountStatusOnCross}).
+		Order("expired_at").Limit(limit).Find(&list).Error
+	return
+}
+
+type AuctionBidCountByAccount struct {
+	Account string `json:"account" gorm:"column:account"`
+	Num     int64  `json:"num" gorm:"column:num"`
+}
+
+// GetAuctionBidCounts counts the bids not rejected since createdAfter per account
+func (d *DbDao) GetAuctionBidCounts(accounts []string, createdAfter time.Time) (list []AuctionBidCountByAccount, err error) {
+	if len(accounts) == 0 {
+		return
+	}
+	err = d.db.Table(tables.TableNameAuctionOrder+" AS o").Select("o.account, COUNT(*) AS num").
+		Joins(fmt.Sprintf("JOIN %s AS p ON o.outpoint=p.outpoint", tables.TableNameRegisterPendingInfo)).
+		Where("o.account IN ? AND p.status!=? AND o.created_at>?", accounts, tables.StatusRejected, createdAfter).
+		Group("o.account").Scan(&list).Error
+	return
+}
+
+type AuctionBid struct {
+	Account        string           `json:"account" gorm:"column:account"`
+	ChainType      common.ChainType `json:"chain_type" gorm:"column:chain_type"`
+	Address        string           `json:"address" gorm:"column:address"`
+	BasicPrice     decimal.Decimal  `json:"basic_price" gorm:"column:basic_price"`
+	PremiumPrice   decimal.Decimal  `json:"premium_price" gorm:"column:premium_price"`
+	BidTime        int64            `json:"bid_time" gorm:"column:bid_time"`
+	Outpoint       string           `json:"outpoint" gorm:"column:outpoint"`
+	Status         int              `json:"status" gorm:"column:status"`                   // tables.StatusPending, StatusConfirm or StatusRejected
+	BlockTimestamp uint64           `json:"block_timestamp" gorm:"column:block_timestamp"` // set by the parser when the bid tx is committed
+}
+
+// GetAuctionBidHistory returns the bids on the account, the latest first
+func (d *DbDao) GetAuctionBidHistory(account string, limit, offset int) (list []AuctionBid, total int64, err error) {
+	db := d.db.Table(tables.TableNameAuctionOrder+" AS o").
+		Joins(fmt.Sprintf("LEFT JOIN %s AS p ON o.outpoint=p.outpoint", tables.TableNameRegisterPendingInfo)).
+		Where("o.account=?", account)
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Select("o.account, o.chain_type, o.address, o.basic_price, o.premium_price, o.bid_time, o.outpoint, IFNULL(p.status,0) AS status, IFNULL(p.block_timestamp,0) AS block_timestamp").
+		Order("o.bid_time DESC").Limit(limit).Offset(offset).Scan(&list).Error
+	return
+}
@@ -3,13 +3,33 @@ package dao
 import (
 	"das_register_server/tables"
 	"fmt"
+	"gorm.io/gorm"
+	"time"
 )
 
 func (d *DbDao) CreateCoupon(data []tables.TableCoupon) (err error) {
 	if len(data) == 0 {
 		return fmt.Errorf("coupon is empty")
 	}
-	err = d.db.Create(&data).Error
+	err = d.db.CreateInBatches(&data, 500).Error
+	return
+}
+
+const existingCouponCodesChunk = 1000
+
+// GetExistingCouponCodes returns the hashed codes of the list that are already taken, queried in chunks of existingCouponCodesChunk
+func (d *DbDao) GetExistingCouponCodes(codes []string) (list []string, err error) {
+	for start := 0; start < len(codes); start += existingCouponCodesChunk {
+		end := start + existingCouponCodesChunk
+		if end > len(codes) {
+			end = len(codes)
+		}
+		var chunk []string
+		if err = d.db.Model(tables.TableCoupon{}).Where("code IN ?", codes[start:end]).Pluck("code", &chunk).Error; err != nil {
+			return nil, err
+		}
+		list = append(list, chunk...)
+	}
 	return
 }
 
@@ -41,3 +61,100 @@ func (d *DbDao) MarkChecked(order_id []string) error {
 			"is_check": 1,
 		}).Error
 }
+
+// CouponFilter selects coupons by ids, hashed codes, batch ids or the desc they were created with
+type CouponFilter struct {
+	Ids      []uint64
+	Codes    []string
+	BatchIds []string
+	Desc     string
+}
+
+func (f *CouponFilter) where(db *gorm.DB) *gorm.DB {
+	if len(f.Ids) > 0 {
+		db = db.Where("id IN ?", f.Ids)
+	}
+	if len(f.Codes) > 0 {
+		db = db.Where("code IN ?", f.Codes)
+	}
+	if len(f.BatchIds) > 0 {
+		db = db.Where("batch_id IN ?", f.BatchIds)
+	}
+	if f.Desc != "" {
+		db = db.Where("`desc`=?", f.Desc)
+	}
+	return db
+}
+
+// couponStatusWhere matches the status of tables.TableCoupon GetStatus
+func couponStatusWhere(db *gorm.DB, status tables.CouponStatus, now time.Time) *gorm.DB {
+	switch status {
+	case tables.CouponStatusUsed:
+		return db.Where("order_id!=''")
+	case tables.CouponStatusRevoked:
+		return db.Where("order_id='' AND revoked_at>0")
+	case tables.CouponStatusExpired:
+		return db.Where("order_id='' AND revoked_at=0 AND (start_at>? OR expired_at<?)", now, now)
+	case tables.CouponStatusAvailable:
+		return db.Where("order_id='' AND revoked_at=0 AND start_at<=? AND expired_at>=?", now, now)
+	}
+	return db
+}
+
+func (d *DbDao) GetCouponList(filter CouponFilter, couponType tables.CouponType, status tables.CouponStatus, limit, offset int) (list []tables.TableCoupon, total int64, err error) {
+	db := filter.where(d.db.Model(tables.TableCoupon{}))
+	if couponType > 0 {
+		db = db.Where("type=?", couponType)
+	}
+	db = couponStatusWhere(db, status, time.Now())
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return
+}
+
+// RevokeCoupons revokes the unused codes of the filter
+func (d *DbDao) RevokeCoupons(filter CouponFilter) (int64, error) {
+	res := filter.where(d.db.Model(tables.TableCoupon{})).
+		Where("order_id='' AND revoked_at=0").
+		Updates(map[string]interface{}{"revoked_at": time.Now().Unix()})
+	return res.RowsAffected, res.Error
+}
+
+// ExtendCoupons moves the expiry of the unused codes of the filter, revoked codes stay revoked
+func (d *DbDao) ExtendCoupons(filter CouponFilter, expiredAt time.Time) (int64, error) {
+	res := filter.where(d.db.Model(tables.TableCoupon{})).
+		Where("order_id='' AND revoked_at=0").
+		Updates(map[string]interface{}{"expired_at": expiredAt})
+	return res.RowsAffected, res.Error
+}
+
+type CouponStats struct {
+	BatchId    string            `json:"batch_id" gorm:"column:batch_id"`
+	Desc       string            `json:"desc" gorm:"column:desc"`
+	CouponType tables.CouponType `json:"type" gorm:"column:type"`
+	Total      int64             `json:"total" gorm:"column:total"`
+	Used       int64             `json:"used" gorm:"column:used"`             // redeemed by an order that is open or registered
+	Registered int64             `json:"registered" gorm:"column:registered"` // the order registered the account
+	Revoked    int64             `json:"revoked" gorm:"column:revoked"`
+	Expired    int64             `json:"expired" gorm:"column:expired"` // unused codes outside their time window
+	Available  int64             `json:"available" gorm:"column:available"`
+	FirstUseAt int64             `json:"first_use_at" gorm:"column:first_use_at"`
+	LastUseAt  int64             `json:"last_use_at" gorm:"column:last_use_at"`
+}
+
+// GetCouponStats returns the redemption stats of every batch and type, the codes created before
+// batch ids are grouped by the desc they were created with
+func (d *DbDao) GetCouponStats(filter CouponFilter) (list []CouponStats, err error) {
+	now := time.Now()
+	db := filter.where(d.db.Model(tables.TableCoupon{})).Select("batch_id, `desc`, type, COUNT(*) AS total,"+
+		" SUM(order_id!='') AS used,"+
+		" SUM(order_id!='' AND is_check=1) AS registered,"+
+		" SUM(order_id='' AND revoked_at>0) AS revoked,"+
+		" SUM(order_id='' AND revoked_at=0 AND (start_at>? OR expired_at<?)) AS expired,"+
+		" SUM(order_id='' AND revoked_at=0 AND start_at<=? AND expired_at>=?) AS available,"+
+		" IFNULL(MIN(NULLIF(use_at,0)),0) AS first_use_at, IFNULL(MAX(use_at),0) AS last_use_at", now, now, now, now)
+	err = db.Group("batch_id, `desc`, type").Order("MIN(id) DESC").Scan(&list).Error
+	return
+}
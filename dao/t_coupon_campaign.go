@@ -0,0 +1,140 @@
+package dao
+
+import (
+	"das_register_server/tables"
+	"errors"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var ErrCampaignCapReached = errors.New("campaign cap reached")
+
+// GetActiveCouponCampaigns returns the enabled campaigns running at now
+func (d *DbDao) GetActiveCouponCampaigns(now time.Time) (list []tables.TableCouponCampaign, err error) {
+	err = d.db.Where("status=? AND start_at<=? AND expired_at>?", tables.CampaignStatusEnable, now, now).
+		Order("id").Find(&list).Error
+	return
+}
+
+// CountCouponCampaignUsage counts the held usages of a campaign, in total and of the address
+func (d *DbDao) CountCouponCampaignUsage(campaignId string, chainType common.ChainType, address string) (total, byAddress int64, err error) {
+	return countCouponCampaignUsage(d.db, campaignId, chainType, address)
+}
+
+func (d *DbDao) CountHeldCouponCampaignUsage(campaignId string) (total int64, err error) {
+	err = d.db.Model(tables.TableCouponCampaignUsage{}).
+		Where("campaign_id=? AND status=?", campaignId, tables.CampaignUsageStatusHeld).Count(&total).Error
+	return
+}
+
+func countCouponCampaignUsage(db *gorm.DB, campaignId string, chainType common.ChainType, address string) (total, byAddress int64, err error) {
+	query := func() *gorm.DB {
+		return db.Model(tables.TableCouponCampaignUsage{}).
+			Where("campaign_id=? AND status=?", campaignId, tables.CampaignUsageStatusHeld)
+	}
+	if err = query().Count(&total).Error; err != nil {
+		return
+	}
+	err = query().Where("chain_type=? AND address=?", chainType, address).Count(&byAddress).Error
+	return
+}
+
+// GetCampaignUsageOrdersToRelease returns the orders of held usages that were closed without registering
+// or a confirmed renew_account tx, i.e. expired, refunded or closed by hand
+func (d *DbDao) GetCampaignUsageOrdersToRelease(limit int) (list []string, err error) {
+	sql := fmt.Sprintf(`SELECT DISTINCT u.order_id FROM %s u JOIN %s o ON o.order_id=u.order_id
+WHERE u.status=? AND o.order_status=? AND o.register_status!=?
+AND NOT EXISTS(SELECT 1 FROM %s t WHERE t.order_id=o.order_id AND t.action=? AND t.status=?) LIMIT ?`,
+		tables.TableNameCouponCampaignUsage, tables.TableNameDasOrderInfo, tables.TableNameDasOrderTxInfo)
+	err = d.db.Raw(sql, tables.CampaignUsageStatusHeld, tables.OrderStatusClosed, tables.RegisterStatusRegistered,
+		tables.TxActionRenewAccount, tables.OrderTxStatusConfirm, limit).Scan(&list).Error
+	return
+}
+
+func (d *DbDao) ReleaseCampaignUsages(orderIds []string) error {
+	if len(orderIds) == 0 {
+		return nil
+	}
+	return d.db.Model(tables.TableCouponCampaignUsage{}).
+		Where("order_id IN(?) AND status=?", orderIds, tables.CampaignUsageStatusHeld).
+		Updates(map[string]interface{}{
+			"status": tables.CampaignUsageStatusReleased,
+		}).Error
+}
+
+func (d *DbDao) GetCouponCampaign(campaignId string) (campaign tables.TableCouponCampaign, err error) {
+	err = d.db.Where("campaign_id=?", campaignId).Limit(1).Find(&campaign).Error
+	return
+}
+
+func (d *DbDao) GetCouponCampaignList() (list []tables.TableCouponCampaign, err error) {
+	err = d.db.Order("id DESC").Find(&list).Error
+	return
+}
+
+func (d *DbDao) CreateCouponCampaign(campaign *tables.TableCouponCampaign) error {
+	return d.db.Create(campaign).Error
+}
+
+// UpdateCouponCampaign saves the name, rules, time range and status of the campaign
+func (d *DbDao) UpdateCouponCampaign(campaign *tables.TableCouponCampaign) error {
+	return d.db.Model(tables.TableCouponCampaign{}).
+		Where("campaign_id=?", campaign.CampaignId).
+		Updates(map[string]interface{}{
+			"name":       campaign.Name,
+			"rules":      campaign.Rules,
+			"start_at":   campaign.StartAt,
+			"expired_at": campaign.ExpiredAt,
+			"status":     campaign.Status,
+		}).Error
+}
+
+// CreateOrderWithCampaigns creates the order with its campaign usages and referral. The campaign rows are locked while
+// the caps are counted again, so concurrent orders can not exceed them; ErrCampaignCapReached is returned then.
+func (d *DbDao) CreateOrderWithCampaigns(order tables.TableDasOrderInfo, payment tables.TableDasOrderPayInfo, usages []tables.TableCouponCampaignUsage) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		for _, v := range usages {
+			var campaign tables.TableCouponCampaign
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("campaign_id=?", v.CampaignId).Limit(1).Find(&campaign).Error; err != nil {
+				return err
+			}
+			rules, err := campaign.GetRules()
+			if err != nil {
+				return err
+			}
+			total, byAddress, err := countCouponCampaignUsage(tx, v.CampaignId, v.ChainType, v.Address)
+			if err != nil {
+				return err
+			}
+			if !campaign.IsActive(time.Now()) || rules.CapReached(total, byAddress) {
+				return ErrCampaignCapReached
+			}
+		}
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+		if referral := tables.NewReferralOrder(&order); referral != nil {
+			if err := tx.Create(referral).Error; err != nil {
+				return err
+			}
+		}
+		if payment.Hash != "" {
+			if err := tx.Create(&payment).Error; err != nil {
+				return err
+			}
+		}
+		if len(usages) > 0 {
+			for i := range usages {
+				usages[i].OrderId = order.OrderId
+			}
+			if err := tx.Create(&usages).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -23,6 +23,22 @@ func (d *DbDao) GetLatestRegisterOrderByLatest(accountId string) (order tables.T
 	return
 }
 
+// GetRegisterOrdersByAddress are the apply register orders of the address on the accounts, the latest of each account first
+func (d *DbDao) GetRegisterOrdersByAddress(chainType common.ChainType, address string, accountIds []string) (list []tables.TableDasOrderInfo, err error) {
+	err = d.db.Where("chain_type=? AND address=? AND account_id IN(?) AND action=?",
+		chainType, address, accountIds, common.DasActionApplyRegister).
+		Order("order_status,register_status DESC,id DESC").Find(&list).Error
+	return
+}
+
+// GetRegisterOrdersByLatest are the open apply register orders on the accounts, the latest of each account first
+func (d *DbDao) GetRegisterOrdersByLatest(accountIds []string) (list []tables.TableDasOrderInfo, err error) {
+	err = d.db.Where("account_id IN(?) AND action=? AND order_status=?",
+		accountIds, common.DasActionApplyRegister, tables.OrderStatusDefault).
+		Order("order_status,register_status DESC,id DESC").Find(&list).Error
+	return
+}
+
 func (d *DbDao) GetRegisteringOrders(chainType common.ChainType, address string) (list []tables.TableDasOrderInfo, err error) {
 	// SELECT account,MAX(register_status)AS register_status FROM t_das_order_status_info WHERE chain_type=? AND address=? AND order_status=? GROUP BY account
 	//err = d.db.Select("account,MAX(register_status) AS register_status").
@@ -127,7 +143,16 @@ func (d *DbDao) CreateOrder(order *tables.TableDasOrderInfo) error {
 	if order == nil {
 		return fmt.Errorf("order is nil")
 	}
-	return d.db.Create(&order).Error
+	referral := tables.NewReferralOrder(order)
+	if referral == nil {
+		return d.db.Create(&order).Error
+	}
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+		return tx.Create(referral).Error
+	})
 }
 
 func (d *DbDao) CreateOrderWithPayment(order tables.TableDasOrderInfo, payment tables.TableDasOrderPayInfo) error {
@@ -150,13 +175,16 @@ func (d *DbDao) CreateCouponOrder(order *tables.TableDasOrderInfo, coupon string
 			return err
 		}
 
-		if err := tx.Model(tables.TableCoupon{}).
-			Where("code = ? and order_id= ? and use_at=? ", coupon, "", 0).
+		res := tx.Model(tables.TableCoupon{}).
+			Where("code = ? and order_id= ? and use_at=? and revoked_at=?", coupon, "", 0, 0).
 			Updates(map[string]interface{}{
 				"order_id": order.OrderId,
 				"use_at":   time.Now().Unix(),
-			}).Error; err != nil {
-			return err
+			})
+		if res.Error != nil {
+			return res.Error
+		} else if res.RowsAffected == 0 {
+			return fmt.Errorf("coupon is used or revoked")
 		}
 		return nil
 	})
@@ -464,3 +492,36 @@ func (d *DbDao) UpdateHedgeStatus(orderId string, oldStatus, newStatus tables.Tx
 			"hedge_status": newStatus,
 		}).Error
 }
+
+func (d *DbDao) GetClosedUnRegisteredOrders(accountIds []string) (list []tables.TableDasOrderInfo, err error) {
+	err = d.db.Where("account_id IN(?) AND action=? AND order_type=? AND order_status=? AND register_status<?",
+		accountIds, common.DasActionApplyRegister, tables.OrderTypeSelf, tables.OrderStatusClosed, tables.RegisterStatusRegistered).
+		Find(&list).Error
+	return
+}
+
+type OrderStatusStat struct {
+	Action         common.DasAction      `json:"action" gorm:"column:action"`
+	RegisterStatus tables.RegisterStatus `json:"register_status" gorm:"column:register_status"`
+	Num            int64                 `json:"num" gorm:"column:num"`
+	MinTimestamp   int64                 `json:"min_timestamp" gorm:"column:min_timestamp"`
+}
+
+// GetOpenOrderStatusStats counts the paid orders that are still in progress, grouped by register stage
+func (d *DbDao) GetOpenOrderStatusStats() (list []OrderStatusStat, err error) {
+	err = d.db.Model(tables.TableDasOrderInfo{}).
+		Select("action,register_status,COUNT(*) AS num,MIN(timestamp) AS min_timestamp").
+		Where("order_status=? AND register_status>=? AND register_status<?",
+			tables.OrderStatusDefault, tables.RegisterStatusConfirmPayment, tables.RegisterStatusRegistered).
+		Group("action,register_status").Find(&list).Error
+	return
+}
+
+// GetStuckOrders returns the paid open orders not updated since updatedBefore, oldest first
+func (d *DbDao) GetStuckOrders(updatedBefore time.Time, limit int) (list []tables.TableDasOrderInfo, err error) {
+	err = d.db.Where("order_type=? AND order_status=? AND pay_status IN(?) AND register_status<? AND updated_at<?",
+		tables.OrderTypeSelf, tables.OrderStatusDefault, []tables.TxStatus{tables.TxStatusSending, tables.TxStatusOk},
+		tables.RegisterStatusRegistered, updatedBefore).
+		Order("updated_at").Limit(limit).Find(&list).Error
+	return
+}
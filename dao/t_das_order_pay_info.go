@@ -12,6 +12,11 @@ func (d *DbDao) GetPayInfoByOrderId(orderId string) (pay tables.TableDasOrderPay
 	return
 }
 
+func (d *DbDao) GetPayInfoListByOrderId(orderId string) (list []tables.TableDasOrderPayInfo, err error) {
+	err = d.db.Where("order_id=?", orderId).Order("id").Find(&list).Error
+	return
+}
+
 func (d *DbDao) CreateOrderPayInfo(orderPay *tables.TableDasOrderPayInfo) error {
 	if orderPay == nil {
 		return fmt.Errorf("order pay info is nil")
@@ -133,3 +138,19 @@ func (d *DbDao) UpdateUniPayRefundStatusToDefaultForNotUniPayOrder(payHash, orde
 			"uni_pay_refund_status": tables.UniPayRefundStatusDefault,
 		}).Error
 }
+
+type RefundQueueStat struct {
+	UniPayRefundStatus tables.UniPayRefundStatus `json:"uni_pay_refund_status" gorm:"column:uni_pay_refund_status"`
+	Num                int64                     `json:"num" gorm:"column:num"`
+}
+
+func (d *DbDao) GetRefundQueueStats() (list []RefundQueueStat, err error) {
+	timestamp := tables.GetPaymentInfoTimestamp()
+	err = d.db.Model(tables.TableDasOrderPayInfo{}).
+		Select("uni_pay_refund_status,COUNT(*) AS num").
+		Where("timestamp>=? AND `status`=? AND uni_pay_refund_status IN(?)",
+			timestamp, tables.OrderTxStatusConfirm,
+			[]tables.UniPayRefundStatus{tables.UniPayRefundStatusUnRefund, tables.UniPayRefundStatusRefunding}).
+		Group("uni_pay_refund_status").Find(&list).Error
+	return
+}
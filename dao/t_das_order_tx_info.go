@@ -11,6 +11,14 @@ func (d *DbDao) GetOrderTxListByOrderId(orderId string) (list []tables.TableDasO
 	return
 }
 
+func (d *DbDao) GetOrderTxListByOrderIds(orderIds []string) (list []tables.TableDasOrderTxInfo, err error) {
+	if len(orderIds) == 0 {
+		return
+	}
+	err = d.db.Where("order_id IN(?)", orderIds).Order("id").Find(&list).Error
+	return
+}
+
 func (d *DbDao) CreateOrderTx(orderTx *tables.TableDasOrderTxInfo) error {
 	return d.db.Clauses(clause.OnConflict{
 		DoUpdates: clause.AssignmentColumns([]string{
@@ -73,3 +81,8 @@ func (d *DbDao) UpdateRejectedTx(action tables.OrderTxAction, orderId string) er
 		return nil
 	})
 }
+
+func (d *DbDao) GetOrderTxListByHash(hash string) (list []tables.TableDasOrderTxInfo, err error) {
+	err = d.db.Where("hash=?", hash).Find(&list).Error
+	return
+}
@@ -0,0 +1,19 @@
+package dao
+
+import (
+	"das_register_server/tables"
+	"gorm.io/gorm/clause"
+)
+
+func (d *DbDao) GetPartnerWebhook(partnerId string) (info tables.TablePartnerWebhook, err error) {
+	err = d.db.Where("partner_id=?", partnerId).Limit(1).Find(&info).Error
+	return
+}
+
+func (d *DbDao) CreateOrUpdatePartnerWebhook(info tables.TablePartnerWebhook) error {
+	return d.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{
+			"url", "secret", "events", "status",
+		}),
+	}).Create(&info).Error
+}
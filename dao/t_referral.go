@@ -0,0 +1,102 @@
+package dao
+
+import (
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+func (d *DbDao) GetReferralChannel(accountId string) (channel tables.TableReferralChannel, err error) {
+	err = d.db.Where("account_id=?", accountId).Limit(1).Find(&channel).Error
+	return
+}
+
+func (d *DbDao) GetReferralChannelList(limit, offset int) (list []tables.TableReferralChannel, total int64, err error) {
+	db := d.db.Model(tables.TableReferralChannel{})
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return
+}
+
+// SetReferralChannel adds the channel or updates its status and remark
+func (d *DbDao) SetReferralChannel(channel tables.TableReferralChannel) error {
+	return d.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"account", "status", "remark"}),
+	}).Create(&channel).Error
+}
+
+// GetReferralOrders returns the registered orders of the period the account invited or was the channel of
+func (d *DbDao) GetReferralOrders(account string, start, end time.Time) (list []tables.TableReferralOrder, err error) {
+	err = d.db.Table(tables.TableNameReferralOrder+" AS r").Select("r.*").
+		Joins(fmt.Sprintf("JOIN %s AS o ON o.order_id=r.order_id", tables.TableNameDasOrderInfo)).
+		Where("(r.inviter_account=? OR r.channel_account=?) AND r.created_at>=? AND r.created_at<? AND o.register_status=?",
+			account, account, start, end, tables.RegisterStatusRegistered).
+		Order("r.id").Find(&list).Error
+	return
+}
+
+// GetRegisterOrdersAfterId returns the self register orders with an id above afterId, for the t_referral_order backfill
+func (d *DbDao) GetRegisterOrdersAfterId(afterId uint64, limit int) (list []tables.TableDasOrderInfo, err error) {
+	err = d.db.Where("id>? AND action=? AND order_type=?", afterId, common.DasActionApplyRegister, tables.OrderTypeSelf).
+		Order("id").Limit(limit).Find(&list).Error
+	return
+}
+
+// CreateReferralOrdersIgnoreExisting inserts the rows whose order is not in t_referral_order yet, it returns how many were inserted
+func (d *DbDao) CreateReferralOrdersIgnoreExisting(list []tables.TableReferralOrder) (int64, error) {
+	if len(list) == 0 {
+		return 0, nil
+	}
+	res := d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&list)
+	return res.RowsAffected, res.Error
+}
+
+type ReferralVolume struct {
+	Account   string          `json:"account" gorm:"column:account"`
+	Orders    int64           `json:"orders" gorm:"column:orders"`
+	VolumeUSD decimal.Decimal `json:"volume_usd" gorm:"column:volume_usd"`
+}
+
+// GetReferralVolumes returns the registered orders of the period per inviter and channel account
+func (d *DbDao) GetReferralVolumes(start, end time.Time) (list []ReferralVolume, err error) {
+	sql := fmt.Sprintf(`SELECT account, COUNT(*) AS orders, SUM(amount_usd) AS volume_usd FROM (
+ SELECT r.inviter_account AS account, r.amount_usd FROM %[1]s AS r JOIN %[2]s AS o ON o.order_id=r.order_id
+ WHERE r.created_at>=? AND r.created_at<? AND o.register_status=?
+ UNION ALL
+ SELECT r.channel_account AS account, r.amount_usd FROM %[1]s AS r JOIN %[2]s AS o ON o.order_id=r.order_id
+ WHERE r.created_at>=? AND r.created_at<? AND o.register_status=? AND r.channel_account!='' AND r.channel_account!=r.inviter_account
+) AS t GROUP BY account`, tables.TableNameReferralOrder, tables.TableNameDasOrderInfo)
+	err = d.db.Raw(sql, start, end, tables.RegisterStatusRegistered, start, end, tables.RegisterStatusRegistered).Scan(&list).Error
+	return
+}
+
+// GetInviterRebates returns the register rebates of the period paid to the inviter or channel account
+func (d *DbDao) GetInviterRebates(inviterAccount string, start, end time.Time) (list []tables.TableRebateInfo, err error) {
+	err = d.parserDb.Where("inviter_account=? AND service_type=? AND reward_type IN(?) AND block_timestamp>=? AND block_timestamp<?",
+		inviterAccount, tables.ServiceTypeRegister, []int{tables.RewardTypeInviter, tables.RewardTypeChannel}, start.UnixMilli(), end.UnixMilli()).
+		Order("id").Find(&list).Error
+	return
+}
+
+type RebateSum struct {
+	InviterAccount   string           `json:"inviter_account" gorm:"column:inviter_account"`
+	InviterChainType common.ChainType `json:"inviter_chain_type" gorm:"column:inviter_chain_type"`
+	InviterAddress   string           `json:"inviter_address" gorm:"column:inviter_address"`
+	Invitees         int64            `json:"invitees" gorm:"column:invitees"`
+	Reward           decimal.Decimal  `json:"reward" gorm:"column:reward"` // shannon
+}
+
+// GetRebateSums returns the register rebates of the period per inviter and channel
+func (d *DbDao) GetRebateSums(start, end time.Time) (list []RebateSum, err error) {
+	err = d.parserDb.Model(tables.TableRebateInfo{}).
+		Select("inviter_account, inviter_chain_type, inviter_address, COUNT(DISTINCT invitee_account) AS invitees, SUM(reward) AS reward").
+		Where("service_type=? AND reward_type IN(?) AND block_timestamp>=? AND block_timestamp<?",
+			tables.ServiceTypeRegister, []int{tables.RewardTypeInviter, tables.RewardTypeChannel}, start.UnixMilli(), end.UnixMilli()).
+		Group("inviter_account, inviter_chain_type, inviter_address").Order("reward DESC").Scan(&list).Error
+	return
+}
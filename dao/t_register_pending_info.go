@@ -53,3 +53,8 @@ func (d *DbDao) UpdatePendingStatusToConfirm(action, outpoint string, blockNumbe
 			"status":          tables.StatusConfirm,
 		}).Error
 }
+
+func (d *DbDao) GetPendingByOutpoint(action, outpoint string) (tx tables.TableRegisterPendingInfo, err error) {
+	err = d.db.Where("action=? AND outpoint=?", action, outpoint).Limit(1).Find(&tx).Error
+	return
+}
@@ -0,0 +1,64 @@
+package dao
+
+import (
+	"das_register_server/tables"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+func (d *DbDao) CreateWebhookDelivery(info tables.TableWebhookDelivery) error {
+	return d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&info).Error
+}
+
+func (d *DbDao) GetNeedSendWebhookDeliveries(limit int) (list []tables.TableWebhookDelivery, err error) {
+	err = d.db.Where("status=? AND next_at<=?", tables.DeliveryStatusPending, time.Now().UnixMilli()).
+		Order("next_at").Limit(limit).Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetWebhookDeliveryById(id uint64) (info tables.TableWebhookDelivery, err error) {
+	err = d.db.Where("id=?", id).Limit(1).Find(&info).Error
+	return
+}
+
+func (d *DbDao) UpdateWebhookDelivery(id uint64, attempts int, status tables.DeliveryStatus, nextAt int64, responseCode int, lastErr string) error {
+	return d.db.Model(tables.TableWebhookDelivery{}).
+		Where("id=? AND status=?", id, tables.DeliveryStatusPending).
+		Updates(map[string]interface{}{
+			"attempts":      attempts,
+			"status":        status,
+			"next_at":       nextAt,
+			"response_code": responseCode,
+			"last_err":      lastErr,
+		}).Error
+}
+
+// RedoWebhookDelivery puts a delivery back in the queue, e.g. after the partner fixed its endpoint
+func (d *DbDao) RedoWebhookDelivery(partnerId, deliveryId string) (rowsAffected int64, err error) {
+	res := d.db.Model(tables.TableWebhookDelivery{}).
+		Where("partner_id=? AND delivery_id=?", partnerId, deliveryId).
+		Updates(map[string]interface{}{
+			"status":   tables.DeliveryStatusPending,
+			"attempts": 0,
+			"next_at":  time.Now().UnixMilli(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+func (d *DbDao) GetWebhookDeliveryList(partnerId, orderId string, limit, offset int) (list []tables.TableWebhookDelivery, err error) {
+	db := d.db.Where("partner_id=?", partnerId)
+	if orderId != "" {
+		db = db.Where("order_id=?", orderId)
+	}
+	err = db.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetWebhookDeliveryCount(partnerId, orderId string) (count int64, err error) {
+	db := d.db.Model(tables.TableWebhookDelivery{}).Where("partner_id=?", partnerId)
+	if orderId != "" {
+		db = db.Where("order_id=?", orderId)
+	}
+	err = db.Count(&count).Error
+	return
+}
@@ -11,9 +11,9 @@ type Es struct {
 }
 
 func InitEs() (es *Es, err error) {
-	addr := config.Cfg.ES.Addr
-	user := config.Cfg.ES.User
-	pwd := config.Cfg.ES.Password
+	addr := config.Cfg().ES.Addr
+	user := config.Cfg().ES.User
+	pwd := config.Cfg().ES.Password
 	if addr == "" || user == "" || pwd == "" {
 
 	}
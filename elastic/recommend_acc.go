@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/olivere/elastic/v7"
-	"strconv"
 )
 
 type RecommendAcc struct {
@@ -14,26 +13,6 @@ type RecommendAcc struct {
 	Acc      string `json:"acc"`
 }
 
-func (es *Es) InsertRecommendAcc(datas []RecommendAcc) {
-	bulkRequest := es.EsCli.Bulk()
-	index := "recommend-acc"
-	for i, data := range datas {
-		doc := elastic.NewBulkIndexRequest().Index(index).Id(strconv.Itoa(i)).Doc(data)
-		bulkRequest = bulkRequest.Add(elastic.NewBulkIndexRequest().Index(index).Id(strconv.Itoa(i)).Doc(data))
-
-		bulkRequest = bulkRequest.Add(doc)
-	}
-
-	response, err := bulkRequest.Do(context.TODO())
-	if err != nil {
-		fmt.Println("bulkRequest.Do err : ", err.Error())
-		return
-	}
-	failed := response.Failed()
-	iter := len(failed)
-	fmt.Printf("error: %v, %v\n", response.Errors, iter)
-}
-
 func (es *Es) FuzzyQueryAcc(acc string, acc_length int, acc_type int) (data []string, err error) {
 	query := elastic.NewBoolQuery()
 	fuzzyEnWordsQuery := elastic.NewFuzzyQuery("acc", acc)
@@ -52,7 +31,7 @@ func (es *Es) FuzzyQueryAcc(acc string, acc_length int, acc_type int) (data []st
 	}
 
 	res, err := es.EsCli.Search().
-		Index("recommend-acc").
+		Index(RecommendAccAlias).
 		Query(query).
 		From(0).
 		Size(10).
@@ -77,7 +56,7 @@ func (es *Es) FuzzyQueryAcc(acc string, acc_length int, acc_type int) (data []st
 func (es *Es) TermQueryAcc(word string) (acc RecommendAcc, err error) {
 	query := elastic.NewTermQuery("acc", word)
 	res, err := es.EsCli.Search().
-		Index("recommend-acc").
+		Index(RecommendAccAlias).
 		Query(query).
 		From(0).
 		Size(10).
@@ -0,0 +1,171 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/olivere/elastic/v7"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RecommendAccAlias is what the queries search, the docs are in the versioned indices it points to
+// so a rebuild is switched in at once
+const RecommendAccAlias = "recommend-acc"
+
+const recommendAccMapping = `{
+	"mappings": {
+		"properties": {
+			"acc": {"type": "keyword"},
+			"acc_length": {"type": "integer"},
+			"acc_type": {"type": "integer"}
+		}
+	}
+}`
+
+// RecommendAccIndexName is a new versioned index for the alias
+func RecommendAccIndexName(t time.Time) string {
+	return fmt.Sprintf("%s-%s", RecommendAccAlias, t.Format("20060102150405"))
+}
+
+func (es *Es) CreateRecommendAccIndex(ctx context.Context, index string) error {
+	res, err := es.EsCli.CreateIndex(index).BodyString(recommendAccMapping).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("CreateIndex err: %s", err.Error())
+	} else if !res.Acknowledged {
+		return fmt.Errorf("CreateIndex [%s] not acknowledged", index)
+	}
+	return nil
+}
+
+// IndexRecommendAcc adds the docs to the index with the acc as id, indexing a word again replaces its doc
+func (es *Es) IndexRecommendAcc(ctx context.Context, index string, list []RecommendAcc) error {
+	if len(list) == 0 {
+		return nil
+	}
+	bulk := es.EsCli.Bulk()
+	for _, v := range list {
+		bulk.Add(elastic.NewBulkIndexRequest().Index(index).Id(v.Acc).Doc(v))
+	}
+	res, err := bulk.Do(ctx)
+	if err != nil {
+		return fmt.Errorf("bulk.Do err: %s", err.Error())
+	}
+	if failed := res.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d of %d docs failed, first: %s %v", len(failed), len(list), failed[0].Id, failed[0].Error)
+	}
+	return nil
+}
+
+// DeleteRecommendAcc removes the docs of the accs from the index behind the alias
+func (es *Es) DeleteRecommendAcc(ctx context.Context, accs []string) error {
+	if len(accs) == 0 {
+		return nil
+	}
+	bulk := es.EsCli.Bulk()
+	for _, v := range accs {
+		bulk.Add(elastic.NewBulkDeleteRequest().Index(RecommendAccAlias).Id(v))
+	}
+	res, err := bulk.Do(ctx)
+	if err != nil {
+		return fmt.Errorf("bulk.Do err: %s", err.Error())
+	}
+	for _, v := range res.Failed() {
+		if v.Status != 404 {
+			return fmt.Errorf("delete [%s] failed: %v", v.Id, v.Error)
+		}
+	}
+	return nil
+}
+
+// RecommendAccIndices are the versioned indices, the latest first, and the ones the alias points to
+func (es *Es) RecommendAccIndices(ctx context.Context) (indices, aliased []string, err error) {
+	rows, err := es.EsCli.CatIndices().Index(RecommendAccAlias + "-*").Do(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("CatIndices err: %s", err.Error())
+	}
+	for _, v := range rows {
+		indices = append(indices, v.Index)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(indices)))
+
+	aliases, err := es.EsCli.Aliases().Index("_all").Do(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Aliases err: %s", err.Error())
+	}
+	aliased = aliases.IndicesByAlias(RecommendAccAlias)
+	return indices, aliased, nil
+}
+
+// SwitchRecommendAccAlias points the alias to index only, in one request. An index named as the alias,
+// left by the versions before the alias, is deleted in the same request.
+func (es *Es) SwitchRecommendAccAlias(ctx context.Context, index string) error {
+	_, aliased, err := es.RecommendAccIndices(ctx)
+	if err != nil {
+		return err
+	}
+	var actions []elastic.AliasAction
+	for _, v := range aliased {
+		if v != index {
+			actions = append(actions, elastic.NewAliasRemoveAction(RecommendAccAlias).Index(v))
+		}
+	}
+	legacy, err := es.EsCli.IndexExists(RecommendAccAlias).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("IndexExists err: %s", err.Error())
+	} else if legacy && len(aliased) == 0 {
+		actions = append(actions, elastic.NewAliasRemoveIndexAction(RecommendAccAlias))
+	}
+	actions = append(actions, elastic.NewAliasAddAction(RecommendAccAlias).Index(index))
+
+	res, err := es.EsCli.Alias().Action(actions...).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("Alias err: %s", err.Error())
+	} else if !res.Acknowledged {
+		return fmt.Errorf("alias switch to [%s] not acknowledged", index)
+	}
+	return nil
+}
+
+// DeleteIndices deletes versioned indices, never the alias
+func (es *Es) DeleteIndices(ctx context.Context, indices []string) error {
+	for _, v := range indices {
+		if !strings.HasPrefix(v, RecommendAccAlias+"-") {
+			return fmt.Errorf("[%s] is not a versioned index", v)
+		}
+	}
+	if len(indices) == 0 {
+		return nil
+	}
+	if _, err := es.EsCli.DeleteIndex(indices...).Do(ctx); err != nil {
+		return fmt.Errorf("DeleteIndex err: %s", err.Error())
+	}
+	return nil
+}
+
+// ScrollRecommendAcc calls fn with the docs behind the alias, size at a time
+func (es *Es) ScrollRecommendAcc(ctx context.Context, size int, fn func([]RecommendAcc) error) error {
+	scroll := es.EsCli.Scroll(RecommendAccAlias).Size(size)
+	defer func() { _ = scroll.Clear(context.Background()) }()
+	for {
+		res, err := scroll.Do(ctx)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("scroll.Do err: %s", err.Error())
+		}
+		var list []RecommendAcc
+		for _, v := range res.Hits.Hits {
+			var doc RecommendAcc
+			if err := json.Unmarshal(v.Source, &doc); err != nil {
+				return fmt.Errorf("json.Unmarshal [%s] err: %s", v.Id, err.Error())
+			}
+			list = append(list, doc)
+		}
+		if err := fn(list); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"time"
+)
+
+func PublishConfig(hash string) {
+	if red == nil {
+		return
+	}
+	if err := red.Publish(ChannelConfig, hash).Err(); err != nil {
+		log.Error("Publish err:", err.Error(), ChannelConfig, hash)
+	}
+}
+
+// WatchConfig gives the hash of each config cell update until ctx is done,
+// a nil channel without redis so a select on it never fires
+func WatchConfig(ctx context.Context) <-chan string {
+	if red == nil {
+		return nil
+	}
+	ch := make(chan string, 1)
+	go func() {
+		defer http_api.RecoverPanic()
+		for {
+			if err := receiveConfig(ctx, ch); err != nil {
+				log.Error("receiveConfig err:", err.Error())
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second * 3):
+			}
+		}
+	}()
+	return ch
+}
+
+func receiveConfig(ctx context.Context, out chan<- string) error {
+	ps := red.Subscribe(ChannelConfig)
+	defer ps.Close()
+	if _, err := ps.Receive(); err != nil {
+		return fmt.Errorf("Subscribe err: %s", err.Error())
+	}
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return fmt.Errorf("channel closed")
+			}
+			select {
+			case out <- msg.Payload:
+			default:
+				// a refresh is already pending, it reads the latest config cells anyway
+			}
+		}
+	}
+}
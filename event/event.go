@@ -0,0 +1,131 @@
+package event
+
+import (
+	"das_register_server/tables"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api/logger"
+	"github.com/go-redis/redis"
+	"strings"
+	"time"
+)
+
+var (
+	log = logger.NewLogger("event", logger.LevelDebug)
+	red *redis.Client
+)
+
+const (
+	ChannelStatus = "event:status"
+	ChannelConfig = "event:config" // the hash of a config cell tx, the api reloads what it keeps of the config cells
+)
+
+type Type string
+
+const (
+	TypeOrder Type = "order"
+	TypeTx    Type = "tx"
+)
+
+// StatusEvent is published whenever the timer side (block parser, unipay loops) observes
+// an order status transition or a pending tx confirmation.
+type StatusEvent struct {
+	Type           Type                  `json:"type"`
+	OrderId        string                `json:"order_id"`
+	Account        string                `json:"account"`
+	ChainType      common.ChainType      `json:"chain_type"`
+	Address        string                `json:"address"`
+	Action         string                `json:"action"`
+	Hash           string                `json:"hash"`
+	PayStatus      tables.TxStatus       `json:"pay_status"`
+	RegisterStatus tables.RegisterStatus `json:"register_status"`
+	OrderStatus    tables.OrderStatus    `json:"order_status"`
+	TxStatus       int                   `json:"tx_status"`
+	BlockNumber    uint64                `json:"block_number"`
+	Timestamp      int64                 `json:"timestamp"`
+}
+
+func Init(client *redis.Client) {
+	red = client
+}
+
+func Enabled() bool {
+	return red != nil
+}
+
+func Publish(e StatusEvent) {
+	if red == nil {
+		return
+	}
+	if e.Timestamp == 0 {
+		e.Timestamp = time.Now().UnixMilli()
+	}
+	bys, err := json.Marshal(&e)
+	if err != nil {
+		log.Error("json.Marshal err:", err.Error())
+		return
+	}
+	if err = red.Publish(ChannelStatus, string(bys)).Err(); err != nil {
+		log.Error("Publish err:", err.Error(), e.Type, e.OrderId, e.Hash)
+	}
+}
+
+func PublishOrder(order tables.TableDasOrderInfo, action, hash string) {
+	if order.OrderId == "" {
+		return
+	}
+	Publish(StatusEvent{
+		Type:           TypeOrder,
+		OrderId:        order.OrderId,
+		Account:        order.Account,
+		ChainType:      order.ChainType,
+		Address:        order.Address,
+		Action:         action,
+		Hash:           hash,
+		PayStatus:      order.PayStatus,
+		RegisterStatus: order.RegisterStatus,
+		OrderStatus:    order.OrderStatus,
+	})
+}
+
+func PublishTx(pending tables.TableRegisterPendingInfo) {
+	if pending.Id == 0 {
+		return
+	}
+	hash, _ := common.String2OutPoint(pending.Outpoint)
+	Publish(StatusEvent{
+		Type:        TypeTx,
+		Account:     pending.Account,
+		ChainType:   pending.ChainType,
+		Address:     pending.Address,
+		Action:      pending.Action,
+		Hash:        hash,
+		TxStatus:    pending.Status,
+		BlockNumber: pending.BlockNumber,
+	})
+}
+
+// Filter matches events by order id, or by owner chain type and address.
+type Filter struct {
+	OrderId   string
+	ChainType common.ChainType
+	Address   string
+}
+
+func (f *Filter) Check() error {
+	if f.OrderId == "" && f.Address == "" {
+		return fmt.Errorf("order_id or address is required")
+	}
+	return nil
+}
+
+func (f *Filter) Match(e *StatusEvent) bool {
+	if f.OrderId != "" && f.OrderId != e.OrderId {
+		return false
+	}
+	if f.Address != "" && (f.ChainType != e.ChainType || !strings.EqualFold(f.Address, e.Address)) {
+		return false
+	}
+	return true
+}
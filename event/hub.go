@@ -0,0 +1,114 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"sync"
+	"time"
+)
+
+const subscriberBufferSize = 16
+
+type Subscriber struct {
+	C      chan StatusEvent
+	filter Filter
+}
+
+// Hub shares a single redis subscription between all local subscribers.
+type Hub struct {
+	ctx  context.Context
+	lock sync.RWMutex
+	subs map[*Subscriber]struct{}
+	once sync.Once
+}
+
+func NewHub(ctx context.Context) *Hub {
+	return &Hub{
+		ctx:  ctx,
+		subs: make(map[*Subscriber]struct{}),
+	}
+}
+
+func (h *Hub) Subscribe(filter Filter) (*Subscriber, error) {
+	if red == nil {
+		return nil, fmt.Errorf("redis is nil")
+	}
+	if err := filter.Check(); err != nil {
+		return nil, err
+	}
+	h.once.Do(h.run)
+
+	s := &Subscriber{
+		C:      make(chan StatusEvent, subscriberBufferSize),
+		filter: filter,
+	}
+	h.lock.Lock()
+	h.subs[s] = struct{}{}
+	h.lock.Unlock()
+	return s, nil
+}
+
+func (h *Hub) Unsubscribe(s *Subscriber) {
+	h.lock.Lock()
+	delete(h.subs, s)
+	h.lock.Unlock()
+}
+
+func (h *Hub) dispatch(e StatusEvent) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	for s := range h.subs {
+		if !s.filter.Match(&e) {
+			continue
+		}
+		select {
+		case s.C <- e:
+		default:
+			// slow consumer, it will catch up from the next event or a re-query
+			log.Warn("dispatch drop event:", e.Type, e.OrderId, e.Hash)
+		}
+	}
+}
+
+func (h *Hub) run() {
+	go func() {
+		defer http_api.RecoverPanic()
+		for {
+			if err := h.receive(); err != nil {
+				log.Error("receive err:", err.Error())
+			}
+			select {
+			case <-h.ctx.Done():
+				return
+			case <-time.After(time.Second * 3):
+			}
+		}
+	}()
+}
+
+func (h *Hub) receive() error {
+	ps := red.Subscribe(ChannelStatus)
+	defer ps.Close()
+	if _, err := ps.Receive(); err != nil {
+		return fmt.Errorf("Subscribe err: %s", err.Error())
+	}
+	ch := ps.Channel()
+	for {
+		select {
+		case <-h.ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return fmt.Errorf("channel closed")
+			}
+			var e StatusEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+				log.Error("json.Unmarshal err:", err.Error())
+				continue
+			}
+			h.dispatch(e)
+		}
+	}
+}
@@ -67,6 +67,8 @@ const (
 	MethodRewardsMine         = "das_myRewards"
 	MethodWithdrawList        = "das_withdrawList"
 	MethodAccountSearch       = "das_accountSearch"
+	MethodAccountSearchBulk   = "das_accountSearchBulk"
+	MethodAccountReserved     = "das_accountReserved"
 	MethodRegisteringList     = "das_registeringAccounts"
 	MethodOrderDetail         = "das_orderDetail"
 	MethodAddressDeposit      = "das_addressDeposit"
@@ -75,6 +77,9 @@ const (
 	MethodAuctionPrice        = "das_auctionPrice"
 	MethodAuctionOrderStatus  = "das_auctionOrderStatus"
 	MethodAuctionPendingOrder = "das_auctionPendingOrder"
+	MethodAuctionList         = "das_auctionList"
+	MethodAuctionBids         = "das_auctionBids"
+	MethodAuctionProjection   = "das_auctionProjection"
 
 	MethodReverseDeclare   = "das_reverseDeclare"
 	MethodReverseRedeclare = "das_reverseRedeclare"
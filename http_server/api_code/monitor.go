@@ -76,7 +76,7 @@ func DoMonitorLog(method string) gin.HandlerFunc {
 
 func DoMonitorLogRpc(apiResp *api_code.ApiResp, method, clientIp string, startTime time.Time) {
 	pushLog := ReqPushLog{
-		Index:   config.Cfg.Server.PushLogIndex,
+		Index:   config.Cfg().Server.PushLogIndex,
 		Method:  method,
 		Ip:      clientIp,
 		Latency: time.Since(startTime),
@@ -86,7 +86,7 @@ func DoMonitorLogRpc(apiResp *api_code.ApiResp, method, clientIp string, startTi
 	if apiResp.ErrNo != api_code.ApiCodeSuccess {
 		log.Warn("DoMonitorLog:", method, apiResp.ErrNo, apiResp.ErrMsg)
 	}
-	PushLog(config.Cfg.Server.PushLogUrl, pushLog)
+	PushLog(config.Cfg().Server.PushLogUrl, pushLog)
 }
 
 func getClientIp(ctx *gin.Context) string {
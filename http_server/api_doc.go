@@ -0,0 +1,147 @@
+package http_server
+
+import (
+	"das_register_server/event"
+	"das_register_server/http_server/api_doc"
+	"das_register_server/http_server/handle"
+	"das_register_server/referral"
+	"das_register_server/report"
+	"das_register_server/tables"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+)
+
+const (
+	apiDocTitle   = "das-register"
+	apiDocVersion = "v1"
+	apiDocPath    = "/v1/openapi.json"
+)
+
+// apiDocRoutes must list every route registered on the public engine in initRouter,
+// TestApiDocCoversRoutes fails otherwise.
+var apiDocRoutes = []api_doc.Route{
+	// query
+	{Method: http.MethodGet, Path: "/v1/version", Tag: "query", Resp: handle.RespVersion{}},
+	{Method: http.MethodPost, Path: "/v1/token/list", Tag: "query", Resp: handle.RespTokenList{}},
+	{Method: http.MethodPost, Path: "/v1/config/info", Tag: "query", Resp: handle.RespConfigInfo{}},
+	{Method: http.MethodPost, Path: "/v1/account/list", Tag: "query", Req: handle.ReqAccountList{}, Resp: handle.RespAccountList{}},
+	{Method: http.MethodPost, Path: "/v1/account/mine", Tag: "query", Req: handle.ReqAccountMine{}, Resp: handle.RespAccountMine{}},
+	{Method: http.MethodPost, Path: "/v1/account/detail", Tag: "query", Req: handle.ReqAccountDetail{}, Resp: handle.RespAccountDetail{}},
+	{Method: http.MethodPost, Path: "/v1/account/records", Tag: "query", Req: handle.ReqAccountRecords{}, Resp: handle.RespAccountRecords{}},
+	{Method: http.MethodPost, Path: "/v1/transaction/status", Tag: "query", Req: handle.ReqTransactionStatus{}, Resp: handle.RespTransactionStatus{}},
+	{Method: http.MethodPost, Path: "/v1/balance/info", Tag: "query", Req: handle.ReqBalanceInfo{}, Resp: handle.RespBalanceInfo{}},
+	{Method: http.MethodPost, Path: "/v1/transaction/list", Tag: "query", Req: handle.ReqTransactionList{}, Resp: handle.RespTransactionList{}},
+	{Method: http.MethodPost, Path: "/v1/rewards/mine", Tag: "query", Req: handle.ReqRewardsMine{}, Resp: handle.RespRewardsMine{}},
+	{Method: http.MethodPost, Path: "/v1/withdraw/list", Tag: "query", Req: handle.ReqWithdrawList{}, Resp: handle.RespWithdrawList{}},
+	{Method: http.MethodPost, Path: "/v1/account/search", Tag: "query", Req: handle.ReqAccountSearch{}, Resp: handle.RespAccountSearch{}},
+	{Method: http.MethodPost, Path: "/v1/account/search/bulk", Tag: "query", Summary: "status and price of several accounts", Req: handle.ReqAccountSearchBulk{}, Resp: handle.RespAccountSearchBulk{}},
+	{Method: http.MethodPost, Path: "/v1/account/reserved", Tag: "query", Summary: "whether an account is reserved or unavailable and why", Req: handle.ReqAccountReserved{}, Resp: handle.RespAccountReserved{}},
+	{Method: http.MethodPost, Path: "/v1/account/registering/list", Tag: "query", Req: handle.ReqRegisteringList{}, Resp: handle.RespRegisteringList{}},
+	{Method: http.MethodPost, Path: "/v1/account/order/detail", Tag: "query", Req: handle.ReqOrderDetail{}, Resp: handle.RespOrderDetail{}},
+	{Method: http.MethodPost, Path: "/v1/address/deposit", Tag: "query", Req: handle.ReqAddressDeposit{}, Resp: handle.RespAddressDeposit{}},
+	{Method: http.MethodPost, Path: "/v1/character/set/list", Tag: "query", Req: handle.ReqCharacterSetList{}, Resp: handle.RespCharacterSetList{}},
+	{Method: http.MethodPost, Path: "/v1/account/auction/info", Tag: "auction", Req: handle.ReqAccountAuctionInfo{}, Resp: handle.RespAccountAuctionInfo{}},
+	{Method: http.MethodPost, Path: "/v1/account/auction/price", Tag: "auction", Req: handle.ReqAuctionPrice{}, Resp: handle.RespAuctionPrice{}},
+	{Method: http.MethodPost, Path: "/v1/account/auction/order-status", Tag: "auction", Req: handle.ReqAuctionOrderStatus{}, Resp: handle.RepReqGetAuctionOrder{}},
+	{Method: http.MethodPost, Path: "/v1/account/auction/pending-order", Tag: "auction", Req: handle.ReqGetPendingAuctionOrder{}, Resp: []handle.RepReqGetAuctionOrder{}},
+	{Method: http.MethodPost, Path: "/v1/account/auction/list", Tag: "auction", Summary: "accounts on dutch auction with their current price", Req: handle.ReqAuctionList{}, Resp: handle.RespAuctionList{}},
+	{Method: http.MethodPost, Path: "/v1/account/auction/bids", Tag: "auction", Summary: "bid history of an account", Req: handle.ReqAuctionBids{}, Resp: handle.RespAuctionBids{}},
+	{Method: http.MethodPost, Path: "/v1/account/auction/projection", Tag: "auction", Summary: "projected auction price at future times", Req: handle.ReqAuctionProjection{}, Resp: handle.RespAuctionProjection{}},
+	{Method: http.MethodPost, Path: "/v1/account/recommend", Tag: "query", Req: handle.ReqAccountRecommend{}, Resp: handle.RepAccountRecommend{}},
+	{Method: http.MethodPost, Path: "/v1/did/cell/list", Tag: "did_cell", Req: handle.ReqDidCellList{}, Resp: handle.RespDidCellList{}},
+	{Method: http.MethodPost, Path: "/v1/did/cell/upgradable/list", Tag: "did_cell", Req: handle.ReqDidCellUpgradableList{}, Resp: handle.RespDidCellUpgradableList{}},
+	{Method: http.MethodPost, Path: "/v1/did/cell/upgrade/price", Tag: "did_cell", Req: handle.ReqDidCellUpgradePrice{}, Resp: handle.RespDidCellUpgradePrice{}},
+	{Method: http.MethodPost, Path: "/v1/did/cell/recyclable/list", Tag: "did_cell", Req: handle.ReqDidCellRecyclableList{}, Resp: handle.RespDidCellRecyclableList{}},
+	{Method: http.MethodPost, Path: "/v1/did/cell/daslock/edit/owner", Tag: "did_cell", Req: handle.ReqDidCellDasLockEditOwner{}, Resp: handle.RespDidCellDasLockEditOwner{}},
+
+	// operate
+	{Method: http.MethodPost, Path: "/v1/transaction/send", Tag: "operate", Req: handle.ReqTransactionSend{}, Resp: handle.RespTransactionSend{}},
+	{Method: http.MethodPost, Path: "/v1/balance/pay", Tag: "operate", Req: handle.ReqBalancePay{}, Resp: handle.RespBalancePay{}},
+	{Method: http.MethodPost, Path: "/v1/balance/withdraw", Tag: "operate", Req: handle.ReqBalanceWithdraw{}, Resp: handle.RespBalanceWithdraw{}},
+	{Method: http.MethodPost, Path: "/v1/balance/transfer", Tag: "operate", Req: handle.ReqBalanceTransfer{}, Resp: handle.RespBalanceTransfer{}},
+	{Method: http.MethodPost, Path: "/v1/balance/deposit", Tag: "operate", Req: handle.ReqBalanceDeposit{}, Resp: handle.RespBalanceDeposit{}},
+	{Method: http.MethodPost, Path: "/v1/account/edit/manager", Tag: "operate", Req: handle.ReqEditManager{}, Resp: handle.RespEditManager{}},
+	{Method: http.MethodPost, Path: "/v1/account/edit/owner", Tag: "operate", Req: handle.ReqDidCellEditOwner{}, Resp: handle.RespDidCellEditOwner{}},
+	{Method: http.MethodPost, Path: "/v1/account/edit/records", Tag: "operate", Req: handle.ReqDidCellEditRecord{}, Resp: handle.RespDidCellEditRecord{}},
+	{Method: http.MethodPost, Path: "/v1/account/order/renew", Tag: "operate", Req: handle.ReqDidCellRenew{}, Resp: handle.RespDidCellRenew{}},
+	{Method: http.MethodPost, Path: "/v1/account/order/register", Tag: "operate", Req: handle.ReqOrderRegister{}, Resp: handle.RespOrderRegister{}},
+	{Method: http.MethodPost, Path: "/v1/account/order/change", Tag: "operate", Req: handle.ReqOrderChange{}, Resp: handle.RespOrderChange{}},
+	{Method: http.MethodPost, Path: "/v1/account/order/pay/hash", Tag: "operate", Req: handle.ReqOrderPayHash{}, Resp: handle.RespOrderPayHash{}},
+	{Method: http.MethodPost, Path: "/v1/account/coupon/check", Tag: "operate", Req: handle.ReqCheckCoupon{}, Resp: handle.RespCouponInfo{}},
+	{Method: http.MethodPost, Path: "/v1/account/auction/bid", Tag: "auction", Req: handle.ReqAuctionBid{}, Resp: handle.RespAuctionBid{}},
+	{Method: http.MethodPost, Path: "/v1/did/cell/recycle", Tag: "did_cell", Req: handle.ReqDidCellRecycle{}, Resp: handle.RespDidCellRecycle{}},
+
+	// node rpc
+	{Method: http.MethodPost, Path: "/v1/node/ckb/rpc", Tag: "node", Req: handle.ReqCkbRpc{}, Resp: handle.RespCkbRpc{}, RawResp: true},
+	{Method: http.MethodGet, Path: "/v1/stream/status", Tag: "stream", Summary: "server-sent events, event name status or ping", Query: handle.ReqStatusStream{}, Resp: event.StatusEvent{}, RawResp: true, ContentType: "text/event-stream"},
+	{Method: http.MethodGet, Path: "/v1/test/jenkins", Tag: "node", Resp: "", RawResp: true},
+	{Method: http.MethodGet, Path: apiDocPath, Tag: "doc", RawResp: true},
+}
+
+// apiDocInternalRoutes must list every route registered on the internal engine in initRouter.
+var apiDocInternalRoutes = []api_doc.Route{
+	{Method: http.MethodPost, Path: "/v1/refund/apply", Tag: "internal", Req: handle.ReqRefundApply{}, Resp: handle.RespRefundApply{}},
+	{Method: http.MethodPost, Path: "/v1/sign/tx", Tag: "internal", Req: handle.ReqSignTx{}, Resp: handle.RespSignTx{}},
+	{Method: http.MethodPost, Path: "/v1/order/info", Tag: "internal", Req: handle.ReqOrderInfo{}, Resp: handle.RespOrderInfo{}},
+	{Method: http.MethodPost, Path: "/v1/account/register", Tag: "internal", Req: handle.ReqAccountRegister{}, Resp: handle.RespAccountRegister{}},
+	{Method: http.MethodPost, Path: "/v1/account/renew", Tag: "internal", Req: handle.ReqAccountRenew{}, Resp: handle.RespAccountRenew{}},
+	{Method: http.MethodPost, Path: "/v1/order/detail", Tag: "internal", Req: handle.ReqDasOrderDetail{}, Resp: handle.RespDasOrderDetail{}},
+	{Method: http.MethodPost, Path: "/v1/order/stuck", Tag: "internal", Summary: "paid open orders over the time budget of their stage", Req: handle.ReqOrderStuck{}, Resp: handle.RespOrderStuck{}},
+	{Method: http.MethodPost, Path: "/v1/wallet/status", Tag: "internal", Summary: "spendable capacity, cells and runway of the signer addresses", Req: handle.ReqWalletStatus{}, Resp: handle.RespWalletStatus{}},
+	{Method: http.MethodPost, Path: "/v1/create/coupon", Tag: "internal", Summary: "creates a batch per group and returns the codes as a csv or json download", Req: handle.ReqCreateCoupon{}, Resp: []handle.CouponExportRow{}},
+	{Method: http.MethodPost, Path: "/v1/coupon/list", Tag: "internal", Summary: "coupons by desc, type and status", Req: handle.ReqCouponList{}, Resp: handle.RespCouponList{}},
+	{Method: http.MethodPost, Path: "/v1/coupon/revoke", Tag: "internal", Summary: "revoke unused codes", Req: handle.ReqCouponRevoke{}, Resp: handle.RespCouponRevoke{}},
+	{Method: http.MethodPost, Path: "/v1/coupon/extend", Tag: "internal", Summary: "move the expiry of unused codes", Req: handle.ReqCouponExtend{}, Resp: handle.RespCouponExtend{}},
+	{Method: http.MethodPost, Path: "/v1/coupon/lookup", Tag: "internal", Summary: "a code and the order that used it", Req: handle.ReqCouponLookup{}, Resp: handle.RespCouponLookup{}},
+	{Method: http.MethodPost, Path: "/v1/coupon/stats", Tag: "internal", Summary: "redemption stats per batch", Req: handle.ReqCouponStats{}, Resp: handle.RespCouponStats{}},
+	{Method: http.MethodPost, Path: "/v1/unipay/notice", Tag: "internal", Req: handle.ReqUniPayNotice{}, Resp: handle.RespUniPayNotice{}},
+	{Method: http.MethodPost, Path: "/v1/partner/webhook/set", Tag: "webhook", Req: handle.ReqPartnerWebhookSet{}, Resp: handle.RespPartnerWebhookSet{}},
+	{Method: http.MethodPost, Path: "/v1/partner/webhook/info", Tag: "webhook", Req: handle.ReqPartnerWebhookInfo{}, Resp: handle.RespPartnerWebhookInfo{}},
+	{Method: http.MethodPost, Path: "/v1/partner/webhook/deliveries", Tag: "webhook", Req: handle.ReqPartnerWebhookDeliveries{}, Resp: handle.RespPartnerWebhookDeliveries{}},
+	{Method: http.MethodPost, Path: "/v1/partner/webhook/redeliver", Tag: "webhook", Req: handle.ReqPartnerWebhookRedeliver{}, Resp: handle.RespPartnerWebhookRedeliver{}},
+	{Method: http.MethodGet, Path: "/v1/report", Tag: "report", Summary: "daily or weekly operations report, format=markdown and csv return the rendered file instead", Query: handle.ReqReport{}, Resp: report.Report{}},
+	{Method: http.MethodPost, Path: "/v1/referral/dashboard", Tag: "referral", Summary: "invitees, volume and rewards of an inviter or channel by day, week or month", Req: handle.ReqReferralDashboard{}, Resp: referral.Dashboard{}},
+	{Method: http.MethodGet, Path: "/v1/referral/payout", Tag: "referral", Summary: "bonus payouts of the period by reward tier, format=csv returns the file instead", Query: handle.ReqReferralPayout{}, Resp: handle.RespReferralPayout{}},
+	{Method: http.MethodPost, Path: "/v1/referral/channel/list", Tag: "referral", Req: handle.ReqReferralChannelList{}, Resp: handle.RespReferralChannelList{}},
+	{Method: http.MethodPost, Path: "/v1/referral/channel/set", Tag: "referral", Summary: "whitelist or disable an inviter channel", Req: handle.ReqReferralChannelSet{}, Resp: tables.TableReferralChannel{}},
+	{Method: http.MethodGet, Path: apiDocPath, Tag: "doc", RawResp: true},
+	{Method: http.MethodGet, Path: "/metrics", Tag: "metrics", Summary: "prometheus text exposition format", Resp: "", RawResp: true, ContentType: "text/plain"},
+}
+
+// registeredApiDocRoutes drops documented routes that are not registered on the engine (e.g. /sign/tx on mainnet)
+func registeredApiDocRoutes(engine *gin.Engine, routes []api_doc.Route) []api_doc.Route {
+	registered := make(map[string]struct{})
+	for _, v := range engine.Routes() {
+		registered[api_doc.RouteKey(v.Method, v.Path)] = struct{}{}
+	}
+	var list []api_doc.Route
+	for _, v := range routes {
+		if _, ok := registered[v.Key()]; ok || v.Path == apiDocPath {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// apiDocHandle builds the document on the first request, by then initRouter has registered every route,
+// including the ones added after this handler (e.g. /metrics)
+func apiDocHandle(engine *gin.Engine, routes []api_doc.Route) gin.HandlerFunc {
+	var (
+		once sync.Once
+		doc  *api_doc.Doc
+		err  error
+	)
+	return func(ctx *gin.Context) {
+		once.Do(func() {
+			doc, err = api_doc.Build(apiDocTitle, apiDocVersion, registeredApiDocRoutes(engine, routes))
+		})
+		if err != nil {
+			log.Error("api_doc.Build err:", err.Error())
+			ctx.JSON(http.StatusOK, api_code.ApiRespErr(api_code.ApiCodeError500, err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusOK, doc)
+	}
+}
@@ -0,0 +1,329 @@
+package api_doc
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const OpenApiVersion = "3.0.3"
+
+// Route describes one registered gin route and the request/response structs of its handler.
+// Resp is wrapped in the common {err_no, err_msg, data} envelope unless RawResp is set.
+// Query fields (form tags) are documented as query parameters, ContentType overrides the response media type.
+type Route struct {
+	Method      string
+	Path        string
+	Summary     string
+	Tag         string
+	Query       interface{}
+	Req         interface{}
+	Resp        interface{}
+	RawResp     bool
+	ContentType string
+}
+
+func (r *Route) Key() string {
+	return RouteKey(r.Method, r.Path)
+}
+
+func RouteKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+type Doc struct {
+	OpenApi    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type Operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	OperationId string              `json:"operationId"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Build generates an OpenAPI 3 document for the given routes.
+func Build(title, version string, routes []Route) (*Doc, error) {
+	g := generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+	doc := Doc{
+		OpenApi:    OpenApiVersion,
+		Info:       Info{Title: title, Version: version},
+		Paths:      make(map[string]map[string]Operation),
+		Components: Components{Schemas: g.schemas},
+	}
+	operationIds := make(map[string]string)
+	for _, r := range routes {
+		method := strings.ToLower(r.Method)
+		if _, ok := doc.Paths[r.Path][method]; ok {
+			return nil, fmt.Errorf("duplicate route: %s", r.Key())
+		}
+		op := Operation{
+			Summary:     r.Summary,
+			OperationId: operationId(r.Method, r.Path),
+			Responses:   make(map[string]Response),
+		}
+		if other, ok := operationIds[op.OperationId]; ok {
+			return nil, fmt.Errorf("operationId [%s] of %s conflicts with %s", op.OperationId, r.Key(), other)
+		}
+		operationIds[op.OperationId] = r.Key()
+		if r.Tag != "" {
+			op.Tags = []string{r.Tag}
+		}
+		if r.Query != nil {
+			op.Parameters = g.queryParameters(reflect.TypeOf(r.Query))
+		}
+		if r.Req != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: g.schemaOf(reflect.TypeOf(r.Req))}},
+			}
+		}
+		var data *Schema
+		if r.Resp != nil {
+			data = g.schemaOf(reflect.TypeOf(r.Resp))
+		}
+		if !r.RawResp {
+			data = envelope(data)
+		} else if data == nil {
+			data = &Schema{}
+		}
+		contentType := r.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		op.Responses["200"] = Response{
+			Description: "OK",
+			Content:     map[string]MediaType{contentType: {Schema: data}},
+		}
+		if doc.Paths[r.Path] == nil {
+			doc.Paths[r.Path] = make(map[string]Operation)
+		}
+		doc.Paths[r.Path][method] = op
+	}
+	return &doc, nil
+}
+
+func (d *Doc) Json() ([]byte, error) {
+	return json.Marshal(d)
+}
+
+func envelope(data *Schema) *Schema {
+	if data == nil {
+		data = &Schema{Nullable: true}
+	}
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"err_no":  {Type: "integer"},
+			"err_msg": {Type: "string"},
+			"data":    data,
+		},
+		Required: []string{"err_no", "err_msg", "data"},
+	}
+}
+
+func operationId(method, path string) string {
+	parts := strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '-' || r == '_' || r == '.'
+	})
+	id := strings.ToLower(method)
+	for _, p := range parts {
+		id += strings.ToUpper(p[:1]) + p[1:]
+	}
+	return id
+}
+
+var (
+	typeDecimal    = reflect.TypeOf(decimal.Decimal{})
+	typeTime       = reflect.TypeOf(time.Time{})
+	typeRawMessage = reflect.TypeOf(json.RawMessage{})
+)
+
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func (g *generator) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case typeDecimal:
+		return &Schema{Type: "string", Format: "decimal"}
+	case typeTime:
+		return &Schema{Type: "string", Format: "date-time"}
+	case typeRawMessage:
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.componentName(t)}
+	}
+	// interface{} and anything else json can hold
+	return &Schema{}
+}
+
+func (g *generator) componentName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	for other := range g.names {
+		if other.Name() == t.Name() {
+			name = pkgName(t) + "." + t.Name()
+			break
+		}
+	}
+	g.names[t] = name
+	// reserve the name before walking the fields so recursive types terminate
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchema(t)
+	return name
+}
+
+func pkgName(t reflect.Type) string {
+	p := t.PkgPath()
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		p = p[i+1:]
+	}
+	return strings.ReplaceAll(p, "-", "_")
+}
+
+func (g *generator) queryParameters(t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var list []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("form"), ",")[0]
+		if f.PkgPath != "" || name == "" || name == "-" {
+			continue
+		}
+		list = append(list, Parameter{
+			Name:     name,
+			In:       "query",
+			Required: strings.Contains(f.Tag.Get("binding"), "required"),
+			Schema:   g.schemaOf(f.Type),
+		})
+	}
+	return list
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(&s, t)
+	sort.Strings(s.Required)
+	return &s
+}
+
+func (g *generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct && ft != typeDecimal && ft != typeTime {
+			g.addFields(s, ft)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := g.schemaOf(f.Type)
+		if strings.Contains(opts, "string") && fs.Type != "" {
+			fs = &Schema{Type: "string"}
+		}
+		s.Properties[name] = fs
+		if strings.Contains(f.Tag.Get("binding"), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
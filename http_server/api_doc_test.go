@@ -0,0 +1,103 @@
+package http_server
+
+import (
+	"das_register_server/cache"
+	"das_register_server/http_server/api_doc"
+	"das_register_server/http_server/handle"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestApiDocCoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hs := HttpServer{
+		engine:         gin.New(),
+		internalEngine: gin.New(),
+		h:              &handle.HttpHandle{},
+		rc:             cache.Initialize(nil),
+	}
+	hs.initRouter()
+
+	check := func(name string, engine *gin.Engine, routes []api_doc.Route) {
+		documented := make(map[string]struct{})
+		for _, v := range routes {
+			documented[v.Key()] = struct{}{}
+		}
+		for _, v := range engine.Routes() {
+			if _, ok := documented[api_doc.RouteKey(v.Method, v.Path)]; !ok {
+				t.Errorf("%s route %s %s has no schema in api_doc.go", name, v.Method, v.Path)
+			}
+		}
+
+		doc, err := api_doc.Build(apiDocTitle, apiDocVersion, routes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bys, err := doc.Json()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var refs []string
+		collectRefs(t, bys, &refs)
+		for _, ref := range refs {
+			component := strings.TrimPrefix(ref, "#/components/schemas/")
+			if _, ok := doc.Components.Schemas[component]; !ok {
+				t.Errorf("%s doc has dangling ref: %s", name, ref)
+			}
+		}
+	}
+	check("public", hs.engine, apiDocRoutes)
+	check("internal", hs.internalEngine, apiDocInternalRoutes)
+}
+
+func collectRefs(t *testing.T, bys []byte, refs *[]string) {
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			for k, item := range vv {
+				if s, ok := item.(string); ok && k == "$ref" {
+					*refs = append(*refs, s)
+				}
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range vv {
+				walk(item)
+			}
+		}
+	}
+	var doc interface{}
+	if err := json.Unmarshal(bys, &doc); err != nil {
+		t.Fatal(err)
+	}
+	walk(doc)
+}
+
+func TestApiDocServesLaterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hs := HttpServer{
+		engine:         gin.New(),
+		internalEngine: gin.New(),
+		h:              &handle.HttpHandle{},
+		rc:             cache.Initialize(nil),
+	}
+	hs.initRouter()
+
+	// /metrics is registered after the internal openapi.json
+	w := httptest.NewRecorder()
+	hs.internalEngine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	var doc struct {
+		Paths map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc.Paths["/metrics"]; !ok {
+		t.Fatal("/metrics is not in the served doc")
+	}
+}
@@ -0,0 +1,125 @@
+package http_server
+
+import (
+	"bytes"
+	"crypto/md5"
+	"das_register_server/cache"
+	"das_register_server/config"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// cacheTagFunc returns the cache tags of a request body, see cache.TagAccount and cache.TagAddress
+type cacheTagFunc func(body []byte) []string
+
+type cacheBodyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w cacheBodyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// middlewareCacheByTag caches responses like toolib.MiddlewareCacheByRedis, but with the versions
+// of the request tags in the key, so the block parser can purge them by cache.InvalidateCacheTags
+func (h *HttpServer) middlewareCacheByTag(dataExpiration, lockExpiration, updateExpiration time.Duration, tagFunc cacheTagFunc) gin.HandlerFunc {
+	red := h.rc.GetRedisClient()
+	return func(c *gin.Context) {
+		if red == nil {
+			return
+		}
+		body, err := c.GetRawData()
+		if err != nil {
+			log.Error("GetRawData err:", err.Error())
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+		var tags []string
+		if tagFunc != nil {
+			tags = tagFunc(body)
+		}
+		versions, err := h.rc.GetCacheTagVersions(tags)
+		if err != nil {
+			log.Error("GetCacheTagVersions err:", err.Error())
+			return
+		}
+		key := fmt.Sprintf("cache:resp:%s:%x", c.Request.URL.Path, md5.Sum(append([]byte(c.Request.URL.RawQuery+":"), body...)))
+		if len(versions) > 0 {
+			key = fmt.Sprintf("%s:%s", key, strings.Join(versions, "."))
+		}
+
+		cacheHandle := func() (string, error) {
+			blw := &cacheBodyWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
+			c.Writer = blw
+			c.Next()
+			if statusCode := c.Writer.Status(); statusCode != http.StatusOK {
+				return "", fmt.Errorf("status code [%d]", statusCode)
+			}
+			if blw.body.String() == "" {
+				return "", fmt.Errorf("body is nil")
+			}
+			return blw.body.String(), nil
+		}
+		res, err := toolib.CacheByRedis(red, key, dataExpiration, lockExpiration, updateExpiration, cacheHandle)
+		respHandle(c, res, err)
+	}
+}
+
+type reqCacheTag struct {
+	core.ChainTypeAddress
+	ChainType common.ChainType `json:"chain_type"`
+	Address   string           `json:"address"`
+	Account   string           `json:"account"`
+	Accounts  []string         `json:"accounts"`
+}
+
+func cacheTagAccount(body []byte) []string {
+	var req reqCacheTag
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil
+	}
+	var tags []string
+	if req.Account != "" {
+		tags = append(tags, cache.TagAccount(req.Account))
+	}
+	for _, v := range req.Accounts {
+		v = strings.TrimSpace(v)
+		if !strings.HasSuffix(strings.ToLower(v), common.DasAccountSuffix) {
+			v += common.DasAccountSuffix
+		}
+		tags = append(tags, cache.TagAccount(v))
+	}
+	return tags
+}
+
+func cacheTagAddress(body []byte) []string {
+	var req reqCacheTag
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil
+	}
+	if req.KeyInfo.Key != "" {
+		addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
+		if err != nil {
+			return nil
+		}
+		return []string{cache.TagAddress(addrHex.ChainType, addrHex.AddressHex)}
+	} else if req.Address != "" {
+		return []string{cache.TagAddress(req.ChainType, req.Address)}
+	}
+	return nil
+}
+
+func cacheTagAccountAndAddress(body []byte) []string {
+	return append(cacheTagAccount(body), cacheTagAddress(body)...)
+}
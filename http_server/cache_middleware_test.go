@@ -0,0 +1,26 @@
+package http_server
+
+import (
+	"das_register_server/cache"
+	"github.com/dotbitHQ/das-lib/common"
+	"testing"
+)
+
+func TestCacheTag(t *testing.T) {
+	if tags := cacheTagAccount([]byte(`{"account":"Test.bit"}`)); len(tags) != 1 || tags[0] != cache.TagAccount("test.bit") {
+		t.Fatal(tags)
+	}
+	if tags := cacheTagAccount([]byte(`{}`)); len(tags) != 0 {
+		t.Fatal(tags)
+	}
+	if tags := cacheTagAccount([]byte(`{"accounts":["aaaa.bit","Bbbb"]}`)); len(tags) != 2 || tags[1] != cache.TagAccount("bbbb.bit") {
+		t.Fatal(tags)
+	}
+	tags := cacheTagAddress([]byte(`{"chain_type":1,"address":"0xABC"}`))
+	if len(tags) != 1 || tags[0] != cache.TagAddress(common.ChainTypeEth, "0xabc") {
+		t.Fatal(tags)
+	}
+	if tags = cacheTagAccountAndAddress([]byte(`{"chain_type":1,"address":"0xabc","account":"test.bit"}`)); len(tags) != 2 {
+		t.Fatal(tags)
+	}
+}
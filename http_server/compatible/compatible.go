@@ -48,7 +48,7 @@ func ChainTypeAndCoinType(req interface{}, dc *core.DasCore) (dasAddressHex core
 			Type:    coinType,
 			KeyInfo: coinKeyInfo,
 		}
-		res, err := chainTypeAddress.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+		res, err := chainTypeAddress.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 		if err != nil {
 			err = fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
 			return dasAddressHex, err
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"das_register_server/config"
+	"das_register_server/reserved"
 	"github.com/nervosnetwork/ckb-sdk-go/address"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
 
@@ -206,12 +207,12 @@ func (h *HttpHandle) doAccountDetail(ctx context.Context, req *ReqAccountDetail,
 	req.Account = strings.ToLower(req.Account)
 	resp.Account = req.Account
 	resp.Status = tables.SearchStatusRegisterAble
-	resp.PremiumPercentage = config.Cfg.Stripe.PremiumPercentage
-	resp.PremiumBase = config.Cfg.Stripe.PremiumBase
+	resp.PremiumPercentage = config.Cfg().Stripe.PremiumPercentage
+	resp.PremiumBase = config.Cfg().Stripe.PremiumBase
 
 	// acc
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
 		return fmt.Errorf("SearchAccount err: %s", err.Error())
@@ -317,13 +318,13 @@ func (h *HttpHandle) doAccountDetail(ctx context.Context, req *ReqAccountDetail,
 		}
 
 		if acc.Status == tables.AccountStatusOnUpgrade {
-			didAcc, err := h.dbDao.GetDidAccountByAccountIdWithoutArgs(accountId)
+			didAcc, err := h.dbDao.WithContext(ctx).GetDidAccountByAccountIdWithoutArgs(accountId)
 			if err != nil {
 				apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to get did cell info")
 				return fmt.Errorf("GetDidAccountByAccountId err: %s", err.Error())
 			}
 			mode := address.Mainnet
-			if config.Cfg.Server.Net != common.DasNetTypeMainNet {
+			if config.Cfg().Server.Net != common.DasNetTypeMainNet {
 				mode = address.Testnet
 			}
 			addrOwner, err := address.ConvertScriptToAddress(mode, &types.Script{
@@ -351,17 +352,15 @@ func (h *HttpHandle) doAccountDetail(ctx context.Context, req *ReqAccountDetail,
 
 	if count == 1 {
 		// reserve account
-		accountName := strings.ToLower(strings.TrimSuffix(req.Account, common.DasAccountSuffix))
-		accountName = common.Bytes2Hex(common.Blake2b([]byte(accountName))[:20])
-
-		if _, ok := h.mapReservedAccounts[accountName]; ok {
+		entry := h.reservedList.LookupReservedFirst(req.Account)
+		if entry.Status == reserved.StatusReserved {
 			resp.Status = tables.SearchStatusReservedAccount
 			apiResp.ApiRespOK(resp)
 			return nil
 		}
 
 		// unavailable account
-		if _, ok := h.mapUnAvailableAccounts[accountName]; ok {
+		if entry.Status == reserved.StatusUnavailable {
 			resp.Status = tables.SearchStatusUnAvailableAccount
 			apiResp.ApiRespOK(resp)
 			return nil
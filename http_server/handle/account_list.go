@@ -78,7 +78,7 @@ func (h *HttpHandle) AccountList(ctx *gin.Context) {
 
 func (h *HttpHandle) doAccountList(ctx context.Context, req *ReqAccountList, apiResp *api_code.ApiResp) error {
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -88,7 +88,7 @@ func (h *HttpHandle) doAccountList(ctx context.Context, req *ReqAccountList, api
 	var resp RespAccountList
 	resp.List = make([]AccountData, 0)
 
-	list, err := h.dbDao.SearchAccountList(req.ChainType, req.Address)
+	list, err := h.dbDao.WithContext(ctx).SearchAccountList(req.ChainType, req.Address)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account list err")
 		return fmt.Errorf("SearchAccountList err: %s", err.Error())
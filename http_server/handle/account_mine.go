@@ -80,7 +80,7 @@ func (h *HttpHandle) doAccountMine(ctx context.Context, req *ReqAccountMine, api
 	req.Keyword = strings.ToLower(req.Keyword)
 	action := "AccountMine"
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -96,7 +96,7 @@ func (h *HttpHandle) doAccountMine(ctx context.Context, req *ReqAccountMine, api
 		}
 	}
 
-	list, err := h.dbDao.SearchAccountListWithPage(req.ChainType, req.Address, req.Keyword, req.GetLimit(), req.GetOffset(), req.Category)
+	list, err := h.dbDao.WithContext(ctx).SearchAccountListWithPage(req.ChainType, req.Address, req.Keyword, req.GetLimit(), req.GetOffset(), req.Category)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account list err")
 		return fmt.Errorf("SearchAccountList err: %s", err.Error())
@@ -113,7 +113,7 @@ func (h *HttpHandle) doAccountMine(ctx context.Context, req *ReqAccountMine, api
 		})
 	}
 
-	count, err := h.dbDao.GetAccountsCount(req.ChainType, req.Address, req.Keyword, req.Category)
+	count, err := h.dbDao.WithContext(ctx).GetAccountsCount(req.ChainType, req.Address, req.Keyword, req.Category)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "get account count err")
 		return fmt.Errorf("GetAccountsCount err: %s", err.Error())
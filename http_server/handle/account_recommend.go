@@ -2,16 +2,26 @@ package handle
 
 import (
 	"context"
+	"das_register_server/config"
+	"das_register_server/suggest"
 	"das_register_server/tables"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/http_api"
 	"github.com/gin-gonic/gin"
 	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
 	"net/http"
+	"sort"
 	"strings"
 )
 
+const (
+	accountRecommendMaxCandidates = 2 * accountSearchBulkMax
+	accountRecommendMaxResults    = 20
+	accountRecommendTierLength    = 5 // names of at least 5 chars have the basic price
+)
+
 type ReqAccountRecommend struct {
 	Account string `json:"account" binding:"required"`
 	//Page    int    `json:"page" binding:"required,gte=1"`
@@ -20,7 +30,15 @@ type ReqAccountRecommend struct {
 type RepAccountRecommend struct {
 	//TotalPage int      `json:"total_page"`
 	//Page      int      `json:"page"`
-	AccList []string `json:"acc_list"`
+	AccList []string               `json:"acc_list"`
+	List    []AccountRecommendItem `json:"list"` // acc_list with the prices, cheapest first
+}
+
+type AccountRecommendItem struct {
+	Account      string          `json:"account"`
+	Source       suggest.Source  `json:"source"` // edit, affix, number, emoji, length or es
+	BaseAmount   decimal.Decimal `json:"base_amount"`
+	AccountPrice decimal.Decimal `json:"account_price"`
 }
 
 func (h *HttpHandle) AccountRecommend(ctx *gin.Context) {
@@ -49,34 +67,123 @@ func (h *HttpHandle) AccountRecommend(ctx *gin.Context) {
 func (h *HttpHandle) doAccountRecommend(ctx context.Context, req *ReqAccountRecommend, apiResp *http_api.ApiResp) error {
 	var resp RepAccountRecommend
 	//check top level acc
-	acc := req.Account
+	acc := strings.ToLower(req.Account)
 	count := strings.Count(acc, ".")
 	if count != 1 || !strings.HasSuffix(acc, common.DasAccountSuffix) {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
 		return nil
 	}
-	acc = strings.ToLower(strings.ReplaceAll(acc, common.DasAccountSuffix, ""))
+	acc = strings.TrimSuffix(acc, common.DasAccountSuffix)
+
+	// candidates, the es ones first when it is on
+	var candidates []suggest.Candidate
+	if config.Cfg().SuggestEs() {
+		names, err := h.esRecommend(ctx, acc)
+		if err != nil {
+			log.Warn(ctx, "esRecommend err:", err.Error())
+		}
+		for _, v := range names {
+			candidates = append(candidates, suggest.Candidate{Name: v, Source: suggest.SourceEs})
+		}
+	}
+	candidates = append(candidates, suggest.Variants(acc, suggest.Options{
+		Prefixes:   config.Cfg().Suggest.Prefixes,
+		Suffixes:   config.Cfg().Suggest.Suffixes,
+		MinLength:  int(config.Cfg().Das.AccountMinLength),
+		MaxLength:  int(config.Cfg().Das.AccountMaxLength),
+		TierLength: accountRecommendTierLength,
+		Max:        accountRecommendMaxCandidates,
+	})...)
+	var names []string
+	sources := make(map[string]suggest.Candidate)
+	for _, v := range candidates {
+		name := v.Name + common.DasAccountSuffix
+		if _, ok := sources[name]; ok || len(names) == accountRecommendMaxCandidates {
+			continue
+		}
+		sources[name] = v
+		names = append(names, name)
+	}
+
+	// keep the ones that can be registered, cheapest first
+	var items []AccountSearchItem
+	for i := 0; i < len(names); i += accountSearchBulkMax {
+		end := i + accountSearchBulkMax
+		if end > len(names) {
+			end = len(names)
+		}
+		list, err := h.searchAccounts(ctx, 0, "", "", names[i:end], apiResp)
+		if err != nil || apiResp.ErrNo != http_api.ApiCodeSuccess {
+			return err
+		}
+		items = append(items, list...)
+	}
+	list := recommendList(items, sources)
+
+	//paging
+	//data, totalPage := h.pagingAcc(req.Page, req.Size, recommendAcc)
+	//resp.Page = req.Page
+	//resp.TotalPage = totalPage
+	if len(list) == 0 {
+		apiResp.ApiRespErr(http_api.ApiCodeRecommendAccEmpty, fmt.Sprintf("recommend acc is empty"))
+		return nil
+	}
+	resp.List = list
+	for _, v := range list {
+		resp.AccList = append(resp.AccList, v.Account)
+	}
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// recommendList keeps the searched candidates that can be registered, cheapest first and the closest of one price first
+func recommendList(items []AccountSearchItem, sources map[string]suggest.Candidate) []AccountRecommendItem {
+	var list []AccountRecommendItem
+	for _, v := range items {
+		if v.ErrNo != http_api.ApiCodeSuccess || v.Status != tables.SearchStatusRegisterAble {
+			continue
+		}
+		list = append(list, AccountRecommendItem{
+			Account:      v.Account,
+			Source:       sources[v.Account].Source,
+			BaseAmount:   v.BaseAmount,
+			AccountPrice: v.AccountPrice,
+		})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		pi, pj := list[i].BaseAmount.Add(list[i].AccountPrice), list[j].BaseAmount.Add(list[j].AccountPrice)
+		if !pi.Equal(pj) {
+			return pi.LessThan(pj)
+		}
+		return sources[list[i].Account].Distance < sources[list[j].Account].Distance
+	})
+	if len(list) > accountRecommendMaxResults {
+		list = list[:accountRecommendMaxResults]
+	}
+	return list
+}
 
+// esRecommend are the names the recommend-acc index gives by replacing the words of acc
+func (h *HttpHandle) esRecommend(ctx context.Context, acc string) ([]string, error) {
+	if h.es == nil {
+		return nil, fmt.Errorf("es not initialized")
+	}
 	//separate token
 	tokens, separateType, err := h.separateToken(acc)
 	if err != nil {
-		err = fmt.Errorf("separateToken err: %s", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeError500, fmt.Sprintf("separateToken err"))
-		return err
+		return nil, fmt.Errorf("separateToken err: %s", err.Error())
 	}
 	log.Info(ctx, "tokens: ", tokens)
 	//token recommend
 	recommendTokens, err := h.tokenRecommend(tokens)
 	if err != nil {
-		err = fmt.Errorf("tokenRecommend err: %s", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeError500, fmt.Sprintf("tokenRecommend err"))
-		return err
+		return nil, fmt.Errorf("tokenRecommend err: %s", err.Error())
 	}
 	log.Info(ctx, "recommendTokens: ", recommendTokens)
 	//Combine recommend
+	var res []string
 	length := len(tokens)
-	recommendAcc := make([]string, 0)
-	for i := 0; i < length; i++ {
+	for i := 0; i < length && i < len(recommendTokens); i++ {
 		if i > 2 {
 			break
 		}
@@ -85,65 +192,20 @@ func (h *HttpHandle) doAccountRecommend(ctx context.Context, req *ReqAccountReco
 			copy(tempToken, tokens)
 			tempToken[i] = v
 			newWord := strings.ToLower(strings.Join(tempToken, separateType))
-			fmt.Println("newword: ", newWord)
-			available, err := h.checkRecommendAvailable(ctx, acc, newWord)
-			if err != nil {
-				log.Errorf("checkRecommendAvailable err: %s", err.Error(), ctx)
-				continue
-			}
-			if !available {
-				continue
+			if newWord != acc && !strings.Contains(newWord, ".") {
+				res = append(res, newWord)
 			}
-			recommendAcc = append(recommendAcc, newWord+common.DasAccountSuffix)
 		}
 	}
-
-	//paging
-	//data, totalPage := h.pagingAcc(req.Page, req.Size, recommendAcc)
-	//resp.Page = req.Page
-	//resp.TotalPage = totalPage
-	if len(recommendAcc) == 0 {
-		apiResp.ApiRespErr(http_api.ApiCodeRecommendAccEmpty, fmt.Sprintf("recommend acc is empty"))
-		return nil
-	}
-	resp.AccList = recommendAcc
-	apiResp.ApiRespOK(resp)
-	return nil
+	return res, nil
 }
 
-func (h *HttpHandle) checkRecommendAvailable(ctx context.Context, acc, recommendAcc string) (available bool, err error) {
-	charSet, err := h.dasCore.GetAccountCharSetList(recommendAcc + common.DasAccountSuffix)
-	if err != nil {
-		err = fmt.Errorf("GetAccountCharSetList err: %s", err.Error())
-		return false, err
-	}
-	//check available
-	if strings.Contains(recommendAcc, ".") {
-		return false, nil
-	}
-	if recommendAcc == acc {
-		return false, nil
-	}
-	tempReq := &ReqAccountSearch{
-		Account:        recommendAcc + common.DasAccountSuffix,
-		AccountCharStr: charSet,
-	}
-	var tempRep http_api.ApiResp
-	_, status, _, _ := h.checkAccountBase(ctx, tempReq, &tempRep)
-	if tempRep.ErrNo != http_api.ApiCodeSuccess {
-		return false, nil
-	}
-	if status != tables.SearchStatusRegisterAble {
-		return false, nil
-	}
-	return true, nil
-}
 func (h *HttpHandle) tokenRecommend(tokens []string) (recommendTokens [][]string, err error) {
 	//recommendTokens := make([][]string, 0)
 	for _, v := range tokens {
 		recommendToken, err := h.es.FuzzyQueryAcc(v, len(v), 0)
 		if err != nil {
-			err = fmt.Errorf("FuzzyQueryAcc err: ", err.Error())
+			err = fmt.Errorf("FuzzyQueryAcc err: %s", err.Error())
 			return recommendTokens, err
 		}
 		recommendToken = lowerAndUnique(recommendToken)
@@ -155,7 +217,7 @@ func (h *HttpHandle) tokenRecommend(tokens []string) (recommendTokens [][]string
 			recommendToken, err := h.es.FuzzyQueryAcc(v, 0, 0)
 			if err != nil {
 				//continue
-				err = fmt.Errorf("FuzzyQueryAcc err: ", err.Error())
+				err = fmt.Errorf("FuzzyQueryAcc err: %s", err.Error())
 				return recommendTokens, err
 			}
 			recommendToken = lowerAndUnique(recommendToken)
@@ -183,16 +245,15 @@ func (h *HttpHandle) separateToken(acc string) (tokens []string, separateTag str
 			for i := 1; i < len(acc); i++ {
 				prefix := acc[:i]
 				suffix := acc[i:]
-				fmt.Println(prefix, suffix)
 				prefixAcc, err := h.es.TermQueryAcc(prefix)
 				if err != nil {
-					err = fmt.Errorf("TermQuery err:", err.Error())
+					err = fmt.Errorf("TermQuery err: %s", err.Error())
 					return tokens, separateTag, err
 				}
 				if prefixAcc.Acc != "" {
 					suffixAcc, err := h.es.TermQueryAcc(suffix)
 					if err != nil {
-						err = fmt.Errorf("TermQuery err:", err.Error())
+						err = fmt.Errorf("TermQuery err: %s", err.Error())
 						return tokens, separateTag, err
 					}
 					if suffixAcc.Acc != "" {
@@ -203,7 +264,6 @@ func (h *HttpHandle) separateToken(acc string) (tokens []string, separateTag str
 			}
 		}
 	}
-	fmt.Println("tokens: ", tokens)
 	return
 
 }
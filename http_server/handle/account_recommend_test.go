@@ -0,0 +1,58 @@
+package handle
+
+import (
+	"das_register_server/suggest"
+	"das_register_server/tables"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func TestRecommendList(t *testing.T) {
+	sources := map[string]suggest.Candidate{
+		"abcde1.bit":  {Name: "abcde1", Source: suggest.SourceNumber, Distance: 1},
+		"myabcde.bit": {Name: "myabcde", Source: suggest.SourceAffix, Distance: 2},
+		"abcdx.bit":   {Name: "abcdx", Source: suggest.SourceEdit, Distance: 1},
+		"abcd.bit":    {Name: "abcd", Source: suggest.SourceEdit, Distance: 1},
+	}
+	item := func(account string, status tables.SearchStatus, errNo api_code.ApiCode, price int64) AccountSearchItem {
+		return AccountSearchItem{
+			Account:      account,
+			Status:       status,
+			ErrNo:        errNo,
+			BaseAmount:   decimal.NewFromInt(1),
+			AccountPrice: decimal.NewFromInt(price),
+		}
+	}
+	items := []AccountSearchItem{
+		item("registered.bit", tables.SearchStatusRegistered, api_code.ApiCodeSuccess, 5),
+		item("reserved.bit", tables.SearchStatusReservedAccount, api_code.ApiCodeSuccess, 5),
+		item("unavailable.bit", tables.SearchStatusUnAvailableAccount, api_code.ApiCodeSuccess, 5),
+		item("notopen.bit", tables.SearchStatusRegisterNotOpen, api_code.ApiCodeSuccess, 5),
+		item("ordered.bit", tables.SearchStatusPaymentConfirm, api_code.ApiCodeSuccess, 5),
+		item("invalid.bit", tables.SearchStatusRegisterAble, api_code.ApiCodeAccountContainsInvalidChar, 5),
+		item("abcd.bit", tables.SearchStatusRegisterAble, api_code.ApiCodeSuccess, 160),
+		item("myabcde.bit", tables.SearchStatusRegisterAble, api_code.ApiCodeSuccess, 5),
+		item("abcdx.bit", tables.SearchStatusRegisterAble, api_code.ApiCodeSuccess, 5),
+		item("abcde1.bit", tables.SearchStatusRegisterAble, api_code.ApiCodeSuccess, 5),
+	}
+
+	list := recommendList(items, sources)
+	want := []string{"abcdx.bit", "abcde1.bit", "myabcde.bit", "abcd.bit"}
+	if len(list) != len(want) {
+		t.Fatal(list)
+	}
+	for i, v := range list {
+		if v.Account != want[i] || v.Source != sources[v.Account].Source {
+			t.Fatal(i, v.Account, v.Source)
+		}
+	}
+
+	items = items[:0]
+	for i := 0; i < accountRecommendMaxResults+5; i++ {
+		items = append(items, item("abcde1.bit", tables.SearchStatusRegisterAble, api_code.ApiCodeSuccess, 5))
+	}
+	if list = recommendList(items, sources); len(list) != accountRecommendMaxResults {
+		t.Fatal(len(list))
+	}
+}
@@ -78,7 +78,7 @@ func (h *HttpHandle) doAccountRecords(ctx context.Context, req *ReqAccountRecord
 	// account
 	req.Account = strings.ToLower(req.Account)
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
 		return fmt.Errorf("SearchAccount err: %s", err.Error())
@@ -91,7 +91,7 @@ func (h *HttpHandle) doAccountRecords(ctx context.Context, req *ReqAccountRecord
 		return nil
 	}
 
-	list, err := h.dbDao.SearchRecordsByAccount(accountId)
+	list, err := h.dbDao.WithContext(ctx).SearchRecordsByAccount(accountId)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search records err")
 		return fmt.Errorf("SearchRecordsByAccount err: %s", err.Error())
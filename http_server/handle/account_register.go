@@ -21,8 +21,9 @@ import (
 type ReqAccountRegister struct {
 	ReqAccountSearch
 	ReqOrderRegisterBase
-	CoinType string   `json:"coin_type"`
-	MintFrom MintFrom `json:"mint_from"`
+	CoinType  string   `json:"coin_type"`
+	MintFrom  MintFrom `json:"mint_from"`
+	PartnerId string   `json:"partner_id"` // optional, order lifecycle events are posted to the partner webhook
 }
 
 type MintFrom string
@@ -97,7 +98,7 @@ func (h *HttpHandle) doAccountRegister(ctx context.Context, req *ReqAccountRegis
 		log.Info(ctx, "AccountToAccountChars:", toolib.JsonString(req.AccountCharStr))
 	}
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -113,13 +114,13 @@ func (h *HttpHandle) doAccountRegister(ctx context.Context, req *ReqAccountRegis
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
 
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
 
 	// order check
-	if err := h.checkOrderInfo("", "", &req.ReqOrderRegisterBase, apiResp); err != nil {
+	if err := h.checkOrderInfo(ctx, "", "", &req.ReqOrderRegisterBase, apiResp); err != nil {
 		return fmt.Errorf("checkOrderInfo err: %s", err.Error())
 	}
 	if apiResp.ErrNo != api_code.ApiCodeSuccess {
@@ -219,6 +220,7 @@ func (h *HttpHandle) doInternalRegisterOrder(ctx context.Context, req *ReqAccoun
 		RegisterYears:  req.RegisterYears,
 		AmountTotalUSD: amountTotalUSD,
 		AmountTotalCKB: amountTotalCKB,
+		PartnerId:      req.PartnerId,
 	}
 	contentDataStr, err := json.Marshal(&orderContent)
 	if err != nil {
@@ -250,7 +252,7 @@ func (h *HttpHandle) doInternalRegisterOrder(ctx context.Context, req *ReqAccoun
 	order.CreateOrderId()
 	resp.OrderId = order.OrderId
 
-	if err := h.dbDao.CreateOrder(&order); err != nil {
+	if err := h.dbDao.WithContext(ctx).CreateOrder(&order); err != nil {
 		log.Error(ctx, "CreateOrder err:", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "create order fail")
 		return
@@ -259,7 +261,7 @@ func (h *HttpHandle) doInternalRegisterOrder(ctx context.Context, req *ReqAccoun
 	go func() {
 		defer api_code.RecoverPanic()
 		notify.SendLarkOrderNotify(&notify.SendLarkOrderNotifyParam{
-			Key:        config.Cfg.Notify.LarkRegisterKey,
+			Key:        config.Cfg().Notify.LarkRegisterKey,
 			Action:     "internal register order",
 			Account:    order.Account,
 			OrderId:    order.OrderId,
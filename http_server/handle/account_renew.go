@@ -26,6 +26,7 @@ type ReqAccountRenew struct {
 	Address    string           `json:"address"`
 	Account    string           `json:"account"`
 	RenewYears int              `json:"renew_years"`
+	PartnerId  string           `json:"partner_id"` // optional, order lifecycle events are posted to the partner webhook
 }
 
 type RespAccountRenew struct {
@@ -86,7 +87,7 @@ func (h *HttpHandle) doAccountRenew(ctx context.Context, req *ReqAccountRenew, a
 		return nil
 	}
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -97,18 +98,18 @@ func (h *HttpHandle) doAccountRenew(ctx context.Context, req *ReqAccountRenew, a
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
 
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
 
 	// check renew
-	if req.RenewYears < 1 || req.RenewYears > config.Cfg.Das.MaxRegisterYears {
+	if req.RenewYears < 1 || req.RenewYears > config.Cfg().Das.MaxRegisterYears {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("renew years[%d] invalid", req.RenewYears))
 		return nil
 	}
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account fail")
 		return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
@@ -174,6 +175,7 @@ func (h *HttpHandle) doInternalRenewOrder(ctx context.Context, acc tables.TableA
 		AmountTotalUSD: amountTotalUSD,
 		AmountTotalCKB: amountTotalCKB,
 		RenewYears:     req.RenewYears,
+		PartnerId:      req.PartnerId,
 	}
 	contentDataStr, err := json.Marshal(&orderContent)
 	if err != nil {
@@ -205,7 +207,7 @@ func (h *HttpHandle) doInternalRenewOrder(ctx context.Context, acc tables.TableA
 	order.CreateOrderId()
 	resp.OrderId = order.OrderId
 
-	if err := h.dbDao.CreateOrder(&order); err != nil {
+	if err := h.dbDao.WithContext(ctx).CreateOrder(&order); err != nil {
 		log.Error(ctx, "CreateOrder err:", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "create order fail")
 		return
@@ -214,7 +216,7 @@ func (h *HttpHandle) doInternalRenewOrder(ctx context.Context, acc tables.TableA
 	go func() {
 		defer api_code.RecoverPanic()
 		notify.SendLarkOrderNotify(&notify.SendLarkOrderNotifyParam{
-			Key:        config.Cfg.Notify.LarkRegisterKey,
+			Key:        config.Cfg().Notify.LarkRegisterKey,
 			Action:     "internal renew order",
 			Account:    order.Account,
 			OrderId:    order.OrderId,
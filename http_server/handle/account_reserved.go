@@ -0,0 +1,87 @@
+package handle
+
+import (
+	"das_register_server/reserved"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+)
+
+// curl -X POST http://127.0.0.1:8120/v1/account/reserved -d'{"account":"google.bit"}'
+
+type ReqAccountReserved struct {
+	Account string `json:"account" binding:"required"`
+}
+
+type RespAccountReserved struct {
+	Account   string          `json:"account"`
+	Status    reserved.Status `json:"status"` // reserved, unavailable or empty if the account is in neither list
+	Cell      string          `json:"cell"`   // the config cell that lists the account, empty when the lists come from the cache
+	Reason    string          `json:"reason"`
+	Source    reserved.Source `json:"source"`
+	UpdatedAt int64           `json:"updated_at"` // when the lists were last loaded, in ms
+}
+
+func (h *HttpHandle) AccountReserved(ctx *gin.Context) {
+	var (
+		funcName = "AccountReserved"
+		clientIp = GetClientIp(ctx)
+		req      ReqAccountReserved
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doAccountReserved(&req, &apiResp); err != nil {
+		log.Error("doAccountReserved err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doAccountReserved(req *ReqAccountReserved, apiResp *api_code.ApiResp) error {
+	var resp RespAccountReserved
+
+	resp.Account = strings.ToLower(strings.TrimSpace(req.Account))
+	if !strings.HasSuffix(resp.Account, common.DasAccountSuffix) {
+		resp.Account += common.DasAccountSuffix
+	}
+	if strings.Count(resp.Account, ".") > 1 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "sub-accounts are never reserved")
+		return nil
+	}
+
+	entry := h.reservedList.Lookup(resp.Account)
+	resp.Status, resp.Cell = entry.Status, entry.Cell
+	resp.Reason = reservedReason(entry)
+	source, updatedAt := h.reservedList.Info()
+	resp.Source, resp.UpdatedAt = source, updatedAt.UnixMilli()
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// reservedReason is the answer for a user asking why the account can't be registered
+func reservedReason(entry reserved.Entry) string {
+	cell := "the config cells"
+	if entry.Cell != "" {
+		cell = fmt.Sprintf("the %s config cell", entry.Cell)
+	}
+	switch entry.Status {
+	case reserved.StatusUnavailable:
+		return fmt.Sprintf("the account is listed as unavailable in %s and can not be registered", cell)
+	case reserved.StatusReserved:
+		return fmt.Sprintf("the account is reserved by %s and can not be registered through the normal process", cell)
+	}
+	return ""
+}
@@ -3,6 +3,7 @@ package handle
 import (
 	"context"
 	"das_register_server/config"
+	"das_register_server/reserved"
 	"das_register_server/tables"
 	"encoding/json"
 	"fmt"
@@ -88,13 +89,13 @@ func (h *HttpHandle) doAccountSearch(ctx context.Context, req *ReqAccountSearch,
 	var resp RespAccountSearch
 	req.Account = strings.ToLower(req.Account)
 	resp.RegisterTxMap = make(map[tables.RegisterStatus]RegisterTx)
-	resp.PremiumPercentage = config.Cfg.Stripe.PremiumPercentage
-	resp.PremiumBase = config.Cfg.Stripe.PremiumBase
+	resp.PremiumPercentage = config.Cfg().Stripe.PremiumPercentage
+	resp.PremiumBase = config.Cfg().Stripe.PremiumBase
 
 	if req.Address == "" && req.KeyInfo.Key == "" {
 
 	} else {
-		addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+		addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 		if err != nil {
 			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 			return nil
@@ -292,7 +293,7 @@ var OpenCharTypeMap = map[common.AccountCharType]struct{}{
 
 func (h *HttpHandle) checkAccountBase(ctx context.Context, req *ReqAccountSearch, apiResp *api_code.ApiResp) (confirmProposalHash string, status tables.SearchStatus, isSelf bool, openTs int64) {
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		log.Error(ctx, "GetAccountInfoByAccountId err:", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account fail")
@@ -304,96 +305,124 @@ func (h *HttpHandle) checkAccountBase(ctx context.Context, req *ReqAccountSearch
 			isSelf = true
 		}
 		return
-	} else {
-		accountName := strings.ToLower(strings.TrimSuffix(req.Account, common.DasAccountSuffix))
-		accountName = common.Bytes2Hex(common.Blake2b([]byte(accountName))[:20])
-		// unavailable
-		if _, ok := h.mapUnAvailableAccounts[accountName]; ok {
-			status = tables.SearchStatusUnAvailableAccount
-			return
+	}
+	status, openTs = h.checkAccountUnregistered(ctx, req, apiResp, &accountOpenCache{})
+	return
+}
+
+// accountOpenCache keeps the time cell and the release lucky number for the accounts of one search
+type accountOpenCache struct {
+	timestamp   int64
+	luckyNumber *uint32
+}
+
+func (h *HttpHandle) openCacheTimestamp(c *accountOpenCache) (int64, error) {
+	if c.timestamp == 0 {
+		tc, err := h.dasCore.GetTimeCell()
+		if err != nil {
+			return 0, err
+		}
+		c.timestamp = tc.Timestamp()
+	}
+	return c.timestamp, nil
+}
+
+func (h *HttpHandle) openCacheLuckyNumber(ctx context.Context, c *accountOpenCache) (uint32, bool) {
+	if c.luckyNumber != nil {
+		return *c.luckyNumber, true
+	}
+	configRelease, err := h.dasCore.ConfigCellDataBuilderByTypeArgs(common.ConfigCellTypeArgsRelease)
+	var luckyNumber uint32
+	if err != nil {
+		log.Error(ctx, "GetDasConfigCellInfo err:", err.Error())
+
+		var builderCache core.CacheConfigCellBase
+		strCache, errCache := h.dasCore.GetConfigCellByCache(core.CacheConfigCellKeyBase)
+		if errCache != nil {
+			log.Error("GetConfigCellByCache err: ", err.Error())
+			return 0, false
+		} else if strCache == "" {
+			return 0, false
+		} else if errCache = json.Unmarshal([]byte(strCache), &builderCache); errCache != nil {
+			log.Error("json.Unmarshal err: ", err.Error())
+			return 0, false
 		}
-		// reserved
-		if _, ok := h.mapReservedAccounts[accountName]; ok {
-			status = tables.SearchStatusReservedAccount
+		luckyNumber = builderCache.LuckyNumber
+	} else {
+		luckyNumber, _ = configRelease.LuckyNumber()
+	}
+	c.luckyNumber = &luckyNumber
+	return luckyNumber, true
+}
+
+// checkAccountUnregistered is the status of an account without account cell: unavailable, reserved or not open yet
+func (h *HttpHandle) checkAccountUnregistered(ctx context.Context, req *ReqAccountSearch, apiResp *api_code.ApiResp, openCache *accountOpenCache) (status tables.SearchStatus, openTs int64) {
+	accountName := reserved.AccountHash(req.Account)
+	// unavailable or reserved
+	switch h.reservedList.LookupHash(accountName).Status {
+	case reserved.StatusUnavailable:
+		status = tables.SearchStatusUnAvailableAccount
+		return
+	case reserved.StatusReserved:
+		status = tables.SearchStatusReservedAccount
+		return
+	}
+	// accLen
+	//accLen := common.GetAccountLength(req.Account)
+	accLen := uint8(len(req.AccountCharStr))
+	if tables.EndWithDotBitChar(req.AccountCharStr) {
+		accLen -= 4
+	}
+	log.Info(ctx, "account len:", accLen, req.Account)
+	if accLen < config.Cfg().Das.AccountMinLength || accLen > config.Cfg().Das.AccountMaxLength {
+		apiResp.ApiRespErr(api_code.ApiCodeAccountLenInvalid, fmt.Sprintf("account len err:%d [%s]", accLen, accountName))
+		return
+	} else if accLen >= config.Cfg().Das.OpenAccountMinLength && accLen <= config.Cfg().Das.OpenAccountMaxLength {
+		// check time cell
+		tcTimestamp, err := h.openCacheTimestamp(openCache)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeError500, fmt.Sprintf("get time cell err: %s", err.Error()))
 			return
 		}
-		// accLen
-		//accLen := common.GetAccountLength(req.Account)
-		accLen := uint8(len(req.AccountCharStr))
-		if tables.EndWithDotBitChar(req.AccountCharStr) {
-			accLen -= 4
+		openTimestamp := int64(1666094400)
+		if config.Cfg().Server.Net != common.DasNetTypeMainNet {
+			//openTimestamp = 1666094400
+			openTimestamp = 1665712800
 		}
-		log.Info(ctx, "account len:", accLen, req.Account)
-		if accLen < config.Cfg.Das.AccountMinLength || accLen > config.Cfg.Das.AccountMaxLength {
-			apiResp.ApiRespErr(api_code.ApiCodeAccountLenInvalid, fmt.Sprintf("account len err:%d [%s]", accLen, accountName))
-			return
-		} else if accLen >= config.Cfg.Das.OpenAccountMinLength && accLen <= config.Cfg.Das.OpenAccountMaxLength {
-			// check time cell
-			tc, err := h.dasCore.GetTimeCell()
-			if err != nil {
-				apiResp.ApiRespErr(api_code.ApiCodeError500, fmt.Sprintf("get time cell err: %s", err.Error()))
-				return
-			}
-			tcTimestamp := tc.Timestamp()
-			openTimestamp := int64(1666094400)
-			if config.Cfg.Server.Net != common.DasNetTypeMainNet {
-				//openTimestamp = 1666094400
-				openTimestamp = 1665712800
+		// check dao char type
+		isSameDaoCharType := true
+		for i, v := range req.AccountCharStr {
+			if v.Char == "." {
+				break
 			}
-			// check dao char type
-			isSameDaoCharType := true
-			for i, v := range req.AccountCharStr {
-				if v.Char == "." {
-					break
-				}
-				if i == 0 {
-					continue
-				}
-				if _, ok := OpenCharTypeMap[req.AccountCharStr[i].CharSetName]; !ok {
-					isSameDaoCharType = false
-					break
-				}
-				if req.AccountCharStr[i].CharSetName != req.AccountCharStr[i-1].CharSetName {
-					isSameDaoCharType = false
-					break
-				}
+			if i == 0 {
+				continue
 			}
-			if tcTimestamp >= openTimestamp && isSameDaoCharType {
-				return
+			if _, ok := OpenCharTypeMap[req.AccountCharStr[i].CharSetName]; !ok {
+				isSameDaoCharType = false
+				break
 			}
-
-			configRelease, err := h.dasCore.ConfigCellDataBuilderByTypeArgs(common.ConfigCellTypeArgsRelease)
-			var luckyNumber uint32
-			if err != nil {
-				log.Error(ctx, "GetDasConfigCellInfo err:", err.Error())
-
-				var builderCache core.CacheConfigCellBase
-				strCache, errCache := h.dasCore.GetConfigCellByCache(core.CacheConfigCellKeyBase)
-				if errCache != nil {
-					log.Error("GetConfigCellByCache err: ", err.Error())
-					apiResp.ApiRespErr(api_code.ApiCodeError500, "search config release fail")
-					return
-				} else if strCache == "" {
-					apiResp.ApiRespErr(api_code.ApiCodeError500, "search config release fail")
-					return
-				} else if errCache = json.Unmarshal([]byte(strCache), &builderCache); errCache != nil {
-					log.Error("json.Unmarshal err: ", err.Error())
-					apiResp.ApiRespErr(api_code.ApiCodeError500, "search config release fail")
-					return
-				}
-				luckyNumber = builderCache.LuckyNumber
-			} else {
-				luckyNumber, _ = configRelease.LuckyNumber()
+			if req.AccountCharStr[i].CharSetName != req.AccountCharStr[i-1].CharSetName {
+				isSameDaoCharType = false
+				break
 			}
+		}
+		if tcTimestamp >= openTimestamp && isSameDaoCharType {
+			return
+		}
 
-			log.Info(ctx, "config release lucky number: ", luckyNumber)
-			if resNum, _ := Blake256AndFourBytesBigEndian([]byte(req.Account)); resNum > luckyNumber {
-				status = tables.SearchStatusRegisterNotOpen
-				if isSameDaoCharType {
-					openTs = openTimestamp
-				}
-				return
+		luckyNumber, ok := h.openCacheLuckyNumber(ctx, openCache)
+		if !ok {
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "search config release fail")
+			return
+		}
+		log.Info(ctx, "config release lucky number: ", luckyNumber)
+		if resNum, _ := Blake256AndFourBytesBigEndian([]byte(req.Account)); resNum > luckyNumber {
+			status = tables.SearchStatusRegisterNotOpen
+			if isSameDaoCharType {
+				openTs = openTimestamp
 			}
+			return
 		}
 	}
 	return
@@ -405,7 +434,7 @@ func (h *HttpHandle) checkAddressOrder(ctx context.Context, req *ReqAccountSearc
 
 	var txList []tables.TableDasOrderTxInfo
 	var order tables.TableDasOrderInfo
-	order, err := h.dbDao.GetLatestRegisterOrderByAddress(req.ChainType, req.Address, accountId)
+	order, err := h.dbDao.WithContext(ctx).GetLatestRegisterOrderByAddress(req.ChainType, req.Address, accountId)
 	if err != nil {
 		log.Error(ctx, "GetLatestRegisterOrderByAddress err:", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search order fail")
@@ -413,7 +442,7 @@ func (h *HttpHandle) checkAddressOrder(ctx context.Context, req *ReqAccountSearc
 	}
 	timeCheck := time.Now().Add(-time.Hour*24*365).UnixNano() / 1e6
 	log.Info(ctx, "checkAddressOrder:", timeCheck, order.Timestamp)
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		log.Error(ctx, "GetAccountInfoByAccountId err:", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account fail")
@@ -433,7 +462,7 @@ func (h *HttpHandle) checkAddressOrder(ctx context.Context, req *ReqAccountSearc
 			if order.RegisterStatus == tables.RegisterStatusRegistered && order.CrossCoinType != "" {
 				status = tables.SearchStatusOnCross
 			}
-			payInfo, err := h.dbDao.GetPayInfoByOrderId(order.OrderId)
+			payInfo, err := h.dbDao.WithContext(ctx).GetPayInfoByOrderId(order.OrderId)
 			if err != nil {
 				log.Error(ctx, "GetPayInfoByOrderId err:", err.Error())
 				apiResp.ApiRespErr(api_code.ApiCodeDbError, "search order pay fail")
@@ -458,7 +487,7 @@ func (h *HttpHandle) checkAddressOrder(ctx context.Context, req *ReqAccountSearc
 				}
 			}
 		}
-		txList, err = h.dbDao.GetOrderTxListByOrderId(order.OrderId)
+		txList, err = h.dbDao.WithContext(ctx).GetOrderTxListByOrderId(order.OrderId)
 		if err != nil {
 			log.Error(ctx, "GetOrderTxListByOrderId err:", err.Error())
 			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search order tx fail")
@@ -494,7 +523,7 @@ func (h *HttpHandle) checkAddressOrder(ctx context.Context, req *ReqAccountSearc
 
 func (h *HttpHandle) checkOtherAddressOrder(ctx context.Context, req *ReqAccountSearch, apiResp *api_code.ApiResp) (status tables.SearchStatus) {
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	order, err := h.dbDao.GetLatestRegisterOrderByLatest(accountId)
+	order, err := h.dbDao.WithContext(ctx).GetLatestRegisterOrderByLatest(accountId)
 	if err != nil {
 		log.Error(ctx, "GetLatestRegisterOrderByLatest err:", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search order fail")
@@ -510,7 +539,7 @@ func (h *HttpHandle) checkSubAccount(ctx context.Context, req *ReqAccountSearch,
 	if count > 1 {
 		isSubAccount = true
 		accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-		acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+		acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 		if err != nil {
 			log.Error(ctx, "GetAccountInfoByAccountId err:", err.Error())
 			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account fail")
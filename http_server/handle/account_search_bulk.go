@@ -0,0 +1,257 @@
+package handle
+
+import (
+	"context"
+	"das_register_server/config"
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// curl -X POST http://127.0.0.1:8120/v1/account/search/bulk -d'{"type":"blockchain","key_info":{"coin_type":"60","key":"0xc9f53b1d85356b60453f867610888d89a0b667ad"},"accounts":["aaaa.bit","bbbbb.bit"]}'
+
+const accountSearchBulkMax = 50
+
+type ReqAccountSearchBulk struct {
+	core.ChainTypeAddress
+	ChainType common.ChainType `json:"chain_type"`
+	Address   string           `json:"address"`
+	Accounts  []string         `json:"accounts" binding:"required"` // at most 50, the char sets are worked out by the server
+}
+
+type RespAccountSearchBulk struct {
+	List []AccountSearchItem `json:"list"` // in the order of the request
+}
+
+type AccountSearchItem struct {
+	Account       string              `json:"account"`
+	Status        tables.SearchStatus `json:"status"`
+	AccountPrice  decimal.Decimal     `json:"account_price"`
+	BaseAmount    decimal.Decimal     `json:"base_amount"`
+	IsSelf        bool                `json:"is_self"`
+	OpenTimestamp int64               `json:"open_timestamp"`
+	ErrNo         api_code.ApiCode    `json:"err_no"` // the err_no account/search gives this account, 0 if ok
+	ErrMsg        string              `json:"err_msg"`
+}
+
+func (h *HttpHandle) AccountSearchBulk(ctx *gin.Context) {
+	var (
+		funcName = "AccountSearchBulk"
+		clientIp = GetClientIp(ctx)
+		req      ReqAccountSearchBulk
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doAccountSearchBulk(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doAccountSearchBulk err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doAccountSearchBulk(ctx context.Context, req *ReqAccountSearchBulk, apiResp *api_code.ApiResp) error {
+	var (
+		resp RespAccountSearchBulk
+		err  error
+	)
+	if len(req.Accounts) == 0 || len(req.Accounts) > accountSearchBulkMax {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("accounts must be 1 to %d", accountSearchBulkMax))
+		return nil
+	}
+	argsStr := ""
+	if req.Address != "" || req.KeyInfo.Key != "" {
+		addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
+			return nil
+		}
+		req.ChainType, req.Address = addressHex.ChainType, addressHex.AddressHex
+		hexAddress := core.DasAddressHex{
+			DasAlgorithmId: req.ChainType.ToDasAlgorithmId(true),
+			AddressHex:     req.Address,
+			IsMulti:        false,
+			ChainType:      req.ChainType,
+		}
+		args, err := h.dasCore.Daf().HexToArgs(hexAddress, hexAddress)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "HexToArgs err")
+			return fmt.Errorf("HexToArgs err: %s", err.Error())
+		}
+		argsStr = common.Bytes2Hex(args)
+	}
+
+	if resp.List, err = h.searchAccounts(ctx, req.ChainType, req.Address, argsStr, req.Accounts, apiResp); err != nil || apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return err
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// searchAccounts gives each account the status and price of doAccountSearch,
+// the accounts and the orders are looked up once for all of them
+func (h *HttpHandle) searchAccounts(ctx context.Context, chainType common.ChainType, address, argsStr string, list []string, apiResp *api_code.ApiResp) ([]AccountSearchItem, error) {
+	res := make([]AccountSearchItem, len(list))
+	searches := make([]ReqAccountSearch, len(list))
+	accountIds := make([]string, len(list))
+	for i, v := range list {
+		account := strings.ToLower(strings.TrimSpace(v))
+		if !strings.HasSuffix(account, common.DasAccountSuffix) {
+			account += common.DasAccountSuffix
+		}
+		res[i].Account = account
+		searches[i] = ReqAccountSearch{ChainType: chainType, Address: address, Account: account}
+		accountIds[i] = common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	}
+	accounts, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountIds(accountIds)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account fail")
+		return nil, fmt.Errorf("GetAccountInfoByAccountIds err: %s", err.Error())
+	}
+	accMap := make(map[string]tables.TableAccountInfo)
+	for _, v := range accounts {
+		accMap[v.AccountId] = v
+	}
+
+	// account status, the same checks as doAccountSearch up to the price
+	openCache := &accountOpenCache{}
+	prices := make(map[string][2]decimal.Decimal)
+	var pending []int
+	for i := range searches {
+		item, search := &res[i], &searches[i]
+		acc, registered := accMap[accountIds[i]]
+		if registered {
+			item.Status = acc.FormatAccountStatus()
+			item.IsSelf = chainType == acc.OwnerChainType && strings.EqualFold(address, acc.Owner)
+		}
+		if strings.Count(search.Account, ".") > 1 {
+			if !registered {
+				item.Status = tables.SearchStatusSubAccountUnRegister
+			}
+			continue
+		}
+
+		var itemResp api_code.ApiResp
+		if search.AccountCharStr, err = h.dasCore.GetAccountCharSetList(search.Account); err != nil {
+			itemResp.ApiRespErr(api_code.ApiCodeAccountContainsInvalidChar, err.Error())
+		} else {
+			h.checkAccountCharSet(search, &itemResp)
+		}
+		if itemResp.ErrNo == api_code.ApiCodeSuccess && !registered {
+			item.Status, item.OpenTimestamp = h.checkAccountUnregistered(ctx, search, &itemResp, openCache)
+		}
+		if itemResp.ErrNo != api_code.ApiCodeSuccess {
+			item.Status, item.IsSelf, item.ErrNo, item.ErrMsg = 0, false, itemResp.ErrNo, itemResp.ErrMsg
+			continue
+		}
+		if item.Status != tables.SearchStatusRegisterAble && !item.IsSelf {
+			continue
+		}
+
+		accLen := uint8(len(search.AccountCharStr))
+		if tables.EndWithDotBitChar(search.AccountCharStr) {
+			accLen -= 4
+		}
+		// the price only depends on the length and the byte size of the account
+		key := fmt.Sprintf("%d-%d", accLen, len(search.Account))
+		price, ok := prices[key]
+		if !ok {
+			if price[0], price[1], err = h.getAccountPrice(ctx, accLen, argsStr, search.Account, false); err != nil {
+				apiResp.ApiRespErr(api_code.ApiCodeError500, "get account price err")
+				return nil, fmt.Errorf("getAccountPrice err: %s", err.Error())
+			}
+			prices[key] = price
+		}
+		item.BaseAmount, item.AccountPrice = price[0], price[1]
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		return res, nil
+	}
+
+	// address order, the same rules as checkAddressOrder
+	var others []int
+	if address != "" {
+		var ids []string
+		for _, i := range pending {
+			ids = append(ids, accountIds[i])
+		}
+		orders, err := h.dbDao.WithContext(ctx).GetRegisterOrdersByAddress(chainType, address, ids)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search order fail")
+			return nil, fmt.Errorf("GetRegisterOrdersByAddress err: %s", err.Error())
+		}
+		latest := latestOrderByAccountId(orders)
+		timeCheck := time.Now().Add(-time.Hour*24*365).UnixNano() / 1e6
+		for _, i := range pending {
+			order := latest[accountIds[i]]
+			_, registered := accMap[accountIds[i]]
+			status := tables.SearchStatusRegisterAble
+			if (registered || timeCheck <= order.Timestamp) && order.Id > 0 &&
+				(order.OrderStatus == tables.OrderStatusDefault || order.RegisterStatus == tables.RegisterStatusRegistered) {
+				status = tables.FormatRegisterStatusToSearchStatus(order.RegisterStatus)
+				if order.OrderType == tables.OrderTypeSelf && order.RegisterStatus == tables.RegisterStatusRegistered && order.CrossCoinType != "" {
+					status = tables.SearchStatusOnCross
+				}
+			}
+			if status == tables.SearchStatusRegisterAble {
+				others = append(others, i)
+				continue
+			}
+			if res[i].Status == tables.SearchStatusRegisterAble {
+				res[i].Status = status
+			}
+			res[i].IsSelf = true
+		}
+	} else {
+		others = pending
+	}
+
+	// other register
+	if len(others) > 0 {
+		var ids []string
+		for _, i := range others {
+			ids = append(ids, accountIds[i])
+		}
+		orders, err := h.dbDao.WithContext(ctx).GetRegisterOrdersByLatest(ids)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search order fail")
+			return nil, fmt.Errorf("GetRegisterOrdersByLatest err: %s", err.Error())
+		}
+		latest := latestOrderByAccountId(orders)
+		for _, i := range others {
+			if order, ok := latest[accountIds[i]]; ok && res[i].Status == tables.SearchStatusRegisterAble {
+				res[i].Status = tables.FormatRegisterStatusToSearchStatus(order.RegisterStatus)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// latestOrderByAccountId keeps the first order of each account of a list sorted latest first
+func latestOrderByAccountId(list []tables.TableDasOrderInfo) map[string]tables.TableDasOrderInfo {
+	res := make(map[string]tables.TableDasOrderInfo)
+	for _, v := range list {
+		if _, ok := res[v.AccountId]; !ok {
+			res[v.AccountId] = v
+		}
+	}
+	return res
+}
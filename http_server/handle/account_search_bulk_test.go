@@ -0,0 +1,424 @@
+package handle
+
+import (
+	"bufio"
+	"context"
+	"das_register_server/config"
+	"das_register_server/dao"
+	"das_register_server/reserved"
+	"das_register_server/tables"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/go-redis/redis"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	"io"
+	"net"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testSearchAddress      = "0xc9f53b1d85356b60453f867610888d89a0b667ad"
+	testSearchOtherAddress = "0x15a33588908cf8edb27d1abe3852bf287abd3891"
+)
+
+// testAccountCharStr gives the char sets of a digit and en account, as the web does for account/search
+func testAccountCharStr(account string) []common.AccountCharSet {
+	var list []common.AccountCharSet
+	for _, v := range account {
+		charSetName := common.AccountCharTypeEn
+		if v >= '0' && v <= '9' {
+			charSetName = common.AccountCharTypeDigit
+		}
+		list = append(list, common.AccountCharSet{CharSetName: charSetName, Char: string(v)})
+	}
+	return list
+}
+
+// testSearchDb serves the single table queries of the search from fixed rows,
+// the conditions are col=?, col!=? and col IN(?,..) joined by AND
+type testSearchDb struct {
+	columns map[string][]string
+	rows    map[string][]map[string]driver.Value
+}
+
+func (d *testSearchDb) add(t *testing.T, list ...interface{}) {
+	for _, v := range list {
+		s, err := schema.Parse(v, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.columns[s.Table] = s.DBNames
+		rv := reflect.Indirect(reflect.ValueOf(v))
+		if rv.Field(0).Interface() == reflect.Zero(rv.Field(0).Type()).Interface() {
+			continue // a zero id only declares the table
+		}
+		row := make(map[string]driver.Value)
+		for _, name := range s.DBNames {
+			value, _ := s.FieldsByDBName[name].ValueOf(context.Background(), rv)
+			if valuer, ok := value.(driver.Valuer); ok {
+				if value, err = valuer.Value(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if row[name], err = driver.DefaultParameterConverter.ConvertValue(value); err != nil {
+				t.Fatal(name, err)
+			}
+		}
+		d.rows[s.Table] = append(d.rows[s.Table], row)
+	}
+}
+
+var (
+	testSearchFrom  = regexp.MustCompile("FROM `(\\w+)`")
+	testSearchWhere = regexp.MustCompile(`WHERE (.*?)(?: ORDER BY (.*?))?(?: LIMIT (\d+))?$`)
+	testSearchCond  = regexp.MustCompile("^`?(\\w+)`?\\s*(=|!=|IN)\\s*\\(?([?,\\s]+)\\)?$")
+)
+
+func (d *testSearchDb) query(query string, args []driver.NamedValue) (driver.Rows, error) {
+	from, where := testSearchFrom.FindStringSubmatch(query), testSearchWhere.FindStringSubmatch(strings.TrimSpace(query))
+	if from == nil || where == nil {
+		return nil, fmt.Errorf("query not supported: %s", query)
+	}
+	table := from[1]
+	columns, ok := d.columns[table]
+	if !ok {
+		return nil, fmt.Errorf("table not declared: %s", table)
+	}
+	rows := d.rows[table]
+	for _, v := range regexp.MustCompile(`(?i)\s+AND\s+`).Split(strings.Trim(where[1], "() "), -1) {
+		cond := testSearchCond.FindStringSubmatch(strings.Trim(v, "() "))
+		if cond == nil {
+			return nil, fmt.Errorf("condition not supported: %s", v)
+		}
+		n := strings.Count(cond[3], "?")
+		if n > len(args) {
+			return nil, fmt.Errorf("args missing: %s", query)
+		}
+		values := make(map[string]struct{})
+		for _, arg := range args[:n] {
+			values[fmt.Sprint(arg.Value)] = struct{}{}
+		}
+		args = args[n:]
+		var list []map[string]driver.Value
+		for _, row := range rows {
+			if _, ok := values[fmt.Sprint(row[cond[1]])]; ok == (cond[2] != "!=") {
+				list = append(list, row)
+			}
+		}
+		rows = list
+	}
+	if where[2] != "" {
+		orders := strings.Split(where[2], ",")
+		sort.SliceStable(rows, func(i, j int) bool {
+			for _, v := range orders {
+				fields := strings.Fields(v)
+				a, _ := strconv.ParseInt(fmt.Sprint(rows[i][fields[0]]), 10, 64)
+				b, _ := strconv.ParseInt(fmt.Sprint(rows[j][fields[0]]), 10, 64)
+				if a == b {
+					continue
+				} else if len(fields) > 1 && strings.EqualFold(fields[1], "DESC") {
+					return a > b
+				}
+				return a < b
+			}
+			return false
+		})
+	}
+	if where[3] != "" {
+		if limit, _ := strconv.Atoi(where[3]); limit < len(rows) {
+			rows = rows[:limit]
+		}
+	}
+	return &testSearchRows{columns: columns, rows: rows}, nil
+}
+
+type testSearchRows struct {
+	columns []string
+	rows    []map[string]driver.Value
+}
+
+func (r *testSearchRows) Columns() []string { return r.columns }
+func (r *testSearchRows) Close() error      { return nil }
+func (r *testSearchRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	for i, name := range r.columns {
+		dest[i] = r.rows[0][name]
+	}
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type testSearchConn struct{ db *testSearchDb }
+
+func (c *testSearchConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare not supported")
+}
+func (c *testSearchConn) Close() error              { return nil }
+func (c *testSearchConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("tx not supported") }
+func (c *testSearchConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.db.query(query, args)
+}
+
+type testSearchConnector struct{ db *testSearchDb }
+
+func (c *testSearchConnector) Connect(context.Context) (driver.Conn, error) {
+	return &testSearchConn{db: c.db}, nil
+}
+func (c *testSearchConnector) Driver() driver.Driver { return c }
+func (c *testSearchConnector) Open(string) (driver.Conn, error) {
+	return &testSearchConn{db: c.db}, nil
+}
+
+// testSearchClient gives the quote cell, the other ckb calls are not expected by the search
+type testSearchClient struct {
+	rpc.Client
+	quote uint64
+}
+
+func (c *testSearchClient) GetCells(context.Context, *indexer.SearchKey, indexer.SearchOrder, uint64, string) (*indexer.LiveCells, error) {
+	data := make([]byte, 10)
+	binary.BigEndian.PutUint64(data[2:], c.quote)
+	return &indexer.LiveCells{Objects: []*indexer.LiveCell{{OutputData: data}}}, nil
+}
+
+// testSearchRedis answers GET from values, as the config cell cache of the das core
+func testSearchRedis(t *testing.T, values map[string]string) *redis.Client {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					var cmd []string
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+					for i := 0; i < n; i++ {
+						if _, err = r.ReadString('\n'); err != nil {
+							return
+						}
+						arg, err := r.ReadString('\n')
+						if err != nil {
+							return
+						}
+						cmd = append(cmd, strings.TrimSuffix(arg, "\r\n"))
+					}
+					reply := "+OK\r\n"
+					if len(cmd) == 2 && strings.EqualFold(cmd[0], "GET") {
+						reply = "$-1\r\n"
+						if v, ok := values[cmd[1]]; ok {
+							reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+						}
+					}
+					if _, err = conn.Write([]byte(reply)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	red := redis.NewClient(&redis.Options{Addr: ln.Addr().String()})
+	t.Cleanup(func() { _ = red.Close() })
+	return red
+}
+
+func TestAccountSearchBulkParity(t *testing.T) {
+	das, server := config.Cfg().Das, config.Cfg().Server
+	defer func() {
+		config.Cfg().Das, config.Cfg().Server = das, server
+	}()
+	config.Cfg().Server.Net = common.DasNetTypeTestnet2
+	config.Cfg().Das.AccountMinLength, config.Cfg().Das.AccountMaxLength = 4, 42
+	config.Cfg().Das.OpenAccountMinLength, config.Cfg().Das.OpenAccountMaxLength = 0, 0
+
+	// the char sets of the config cell, loaded by the das core on a server
+	common.InitEnMap(strings.Split("abcdefghijklmnopqrstuvwxyz", ""))
+	common.InitDigitMap(strings.Split("0123456789", ""))
+	configCache, _ := json.Marshal(core.CacheConfigCellBase{
+		AccountCellBasicCapacity:       206 * common.OneCkb,
+		AccountCellPreparedFeeCapacity: common.OneCkb,
+		PriceConfigMap: map[uint8]core.ConfigCellPrice{
+			4: {PriceNew: 160 * common.UsdRateBase, PriceRenew: 160 * common.UsdRateBase},
+			5: {PriceNew: 5 * common.UsdRateBase, PriceRenew: 5 * common.UsdRateBase},
+		},
+	})
+	var wg sync.WaitGroup
+	dasCore := core.NewDasCore(context.Background(), &wg,
+		core.WithClient(&testSearchClient{quote: 4000}),
+		core.WithDasNetType(common.DasNetTypeTestnet2),
+		core.WithDasRedis(testSearchRedis(t, map[string]string{core.CacheConfigCellKeyBase: string(configCache)})))
+
+	accountId := func(account string) string {
+		return common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	}
+	now := time.Now().UnixMilli()
+	order := func(id uint64, account, address string, registerStatus tables.RegisterStatus, orderStatus tables.OrderStatus, timestamp int64, crossCoinType string) *tables.TableDasOrderInfo {
+		return &tables.TableDasOrderInfo{
+			Id:             id,
+			OrderType:      tables.OrderTypeSelf,
+			OrderId:        fmt.Sprintf("order%d", id),
+			AccountId:      accountId(account),
+			Account:        account,
+			Action:         common.DasActionApplyRegister,
+			ChainType:      common.ChainTypeEth,
+			Address:        address,
+			Timestamp:      timestamp,
+			PayTokenId:     tables.TokenIdEth,
+			RegisterStatus: registerStatus,
+			OrderStatus:    orderStatus,
+			CrossCoinType:  crossCoinType,
+		}
+	}
+	db := &testSearchDb{columns: make(map[string][]string), rows: make(map[string][]map[string]driver.Value)}
+	db.add(t, &tables.TableDasOrderPayInfo{}, &tables.TableDasOrderTxInfo{},
+		&tables.TableAccountInfo{Id: 1, AccountId: accountId("owned1.bit"), Account: "owned1.bit", OwnerChainType: common.ChainTypeEth, Owner: testSearchAddress},
+		&tables.TableAccountInfo{Id: 2, AccountId: accountId("sub.owned1.bit"), Account: "sub.owned1.bit", OwnerChainType: common.ChainTypeEth, Owner: testSearchOtherAddress},
+		&tables.TableAccountInfo{Id: 3, AccountId: accountId("crossed.bit"), Account: "crossed.bit", OwnerChainType: common.ChainTypeEth, Owner: testSearchAddress, Status: tables.AccountStatusOnCross},
+		order(1, "ordered1.bit", testSearchAddress, tables.RegisterStatusConfirmPayment, tables.OrderStatusDefault, now, ""),
+		order(2, "oldorder.bit", testSearchAddress, tables.RegisterStatusConfirmPayment, tables.OrderStatusClosed, now-time.Hour.Milliseconds()*24*400, ""),
+		order(3, "otherorder.bit", testSearchOtherAddress, tables.RegisterStatusApplyRegister, tables.OrderStatusDefault, now, ""),
+		order(4, "crossed.bit", testSearchAddress, tables.RegisterStatusRegistered, tables.OrderStatusClosed, now, "60"),
+		order(5, "closed1.bit", testSearchAddress, tables.RegisterStatusConfirmPayment, tables.OrderStatusClosed, now, ""),
+		order(6, "closed1.bit", testSearchOtherAddress, tables.RegisterStatusPreRegister, tables.OrderStatusDefault, now, ""),
+		order(7, "stale1.bit", testSearchAddress, tables.RegisterStatusConfirmPayment, tables.OrderStatusDefault, now-time.Hour.Milliseconds()*24*400, ""),
+	)
+	gormDb, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(&testSearchConnector{db: db}),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dbDao dao.DbDao
+	dbDao.InitDb(gormDb, gormDb)
+
+	h := &HttpHandle{
+		ctx:     context.Background(),
+		dbDao:   &dbDao,
+		dasCore: dasCore,
+		reservedList: reserved.NewListFromMaps(
+			map[string]struct{}{reserved.AccountHash("reservedx.bit"): {}},
+			map[string]struct{}{reserved.AccountHash("unavail.bit"): {}}),
+	}
+
+	accounts := []string{
+		"owned1.bit",     // registered
+		"sub.owned1.bit", // sub account
+		"x.owned1.bit",   // sub account not registered
+		"reservedx.bit",  // reserved
+		"unavail.bit",    // unavailable
+		"ordered1.bit",   // own order or other order
+		"oldorder.bit",   // own order closed a year ago
+		"otherorder.bit", // other order
+		"crossed.bit",    // own order registered and crossed
+		"closed1.bit",    // own order closed, other order open
+		"stale1.bit",     // own order open for over a year
+		"freeacct.bit",   // register able
+		"abc.bit",        // too short
+		"ab_cd.bit",      // invalid char
+	}
+	// the bulk list of testSearchAddress, so the parity is not met by both failing the same way
+	want := []struct {
+		status tables.SearchStatus
+		isSelf bool
+		errNo  api_code.ApiCode
+	}{
+		{tables.SearchStatusRegistered, true, api_code.ApiCodeSuccess},
+		{tables.SearchStatusRegistered, false, api_code.ApiCodeSuccess},
+		{tables.SearchStatusSubAccountUnRegister, false, api_code.ApiCodeSuccess},
+		{tables.SearchStatusReservedAccount, false, api_code.ApiCodeSuccess},
+		{tables.SearchStatusUnAvailableAccount, false, api_code.ApiCodeSuccess},
+		{tables.SearchStatusPaymentConfirm, true, api_code.ApiCodeSuccess},
+		{tables.SearchStatusRegisterAble, false, api_code.ApiCodeSuccess},
+		{tables.SearchStatusLockedAccount, false, api_code.ApiCodeSuccess},
+		{tables.SearchStatusOnCross, true, api_code.ApiCodeSuccess},
+		{tables.SearchStatusRegistering, false, api_code.ApiCodeSuccess},
+		{tables.SearchStatusPaymentConfirm, false, api_code.ApiCodeSuccess},
+		{tables.SearchStatusRegisterAble, false, api_code.ApiCodeSuccess},
+		{0, false, api_code.ApiCodeAccountLenInvalid},
+		{0, false, api_code.ApiCodeAccountContainsInvalidChar},
+	}
+	for _, address := range []string{testSearchAddress, testSearchOtherAddress, ""} {
+		keyInfo := core.ChainTypeAddress{Type: "blockchain", KeyInfo: core.KeyInfo{CoinType: common.CoinTypeEth, Key: address}}
+		if address == "" {
+			keyInfo = core.ChainTypeAddress{}
+		}
+		var bulkResp api_code.ApiResp
+		bulkReq := ReqAccountSearchBulk{ChainTypeAddress: keyInfo, Accounts: accounts}
+		if err := h.doAccountSearchBulk(context.Background(), &bulkReq, &bulkResp); err != nil {
+			t.Fatal(err)
+		} else if bulkResp.ErrNo != api_code.ApiCodeSuccess {
+			t.Fatal(bulkResp.ErrNo, bulkResp.ErrMsg)
+		}
+		bulkData := bulkResp.Data.(RespAccountSearchBulk)
+		if len(bulkData.List) != len(accounts) {
+			t.Fatalf("bulk list len %d != %d", len(bulkData.List), len(accounts))
+		}
+		if address == testSearchAddress {
+			for i, v := range bulkData.List {
+				if v.Status != want[i].status || v.IsSelf != want[i].isSelf || v.ErrNo != want[i].errNo {
+					t.Errorf("%s: bulk %d %t %d, want %d %t %d", v.Account, v.Status, v.IsSelf, v.ErrNo, want[i].status, want[i].isSelf, want[i].errNo)
+				}
+			}
+		}
+
+		for i, account := range accounts {
+			var resp api_code.ApiResp
+			req := ReqAccountSearch{ChainTypeAddress: keyInfo, Account: account, AccountCharStr: testAccountCharStr(account)}
+			if err := h.doAccountSearch(context.Background(), &req, &resp); err != nil {
+				t.Fatal(address, account, err)
+			}
+
+			item := bulkData.List[i]
+			if item.Account != account {
+				t.Errorf("%s %s: bulk account %s", address, account, item.Account)
+			}
+			if item.ErrNo != resp.ErrNo {
+				t.Errorf("%s %s: bulk err_no %d, search err_no %d %s", address, account, item.ErrNo, resp.ErrNo, resp.ErrMsg)
+				continue
+			} else if resp.ErrNo != api_code.ApiCodeSuccess {
+				continue
+			}
+			data := resp.Data.(RespAccountSearch)
+			if item.Status != data.Status || item.IsSelf != data.IsSelf || item.OpenTimestamp != data.OpenTimestamp {
+				t.Errorf("%s %s: bulk %d %t %d, search %d %t %d", address, account,
+					item.Status, item.IsSelf, item.OpenTimestamp, data.Status, data.IsSelf, data.OpenTimestamp)
+			}
+			if !item.AccountPrice.Equal(data.AccountPrice) || !item.BaseAmount.Equal(data.BaseAmount) {
+				t.Errorf("%s %s: bulk price %s %s, search price %s %s", address, account,
+					item.AccountPrice, item.BaseAmount, data.AccountPrice, data.BaseAmount)
+			}
+		}
+	}
+}
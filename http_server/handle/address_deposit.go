@@ -67,7 +67,7 @@ func (h *HttpHandle) doAddressDeposit(ctx context.Context, req *ReqAddressDeposi
 	}
 	log.Info(ctx, "doAddressDeposit:", req.Address, common.Bytes2Hex(lockScript.Args))
 
-	if config.Cfg.Server.Net == common.DasNetTypeMainNet {
+	if config.Cfg().Server.Net == common.DasNetTypeMainNet {
 		addr, err := address.ConvertScriptToAddress(address.Mainnet, lockScript)
 		if err != nil {
 			apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
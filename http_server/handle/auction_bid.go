@@ -3,6 +3,7 @@ package handle
 import (
 	"context"
 	"das_register_server/config"
+	"das_register_server/signer"
 	"das_register_server/tables"
 	"encoding/json"
 	"fmt"
@@ -58,7 +59,7 @@ func (h *HttpHandle) AccountAuctionBid(ctx *gin.Context) {
 }
 func (h *HttpHandle) doAccountAuctionBid(ctx context.Context, req *ReqAuctionBid, apiResp *http_api.ApiResp) (err error) {
 	var resp RespAuctionBid
-	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -71,7 +72,7 @@ func (h *HttpHandle) doAccountAuctionBid(ctx context.Context, req *ReqAuctionBid
 	req.address, req.chainType = addrHex.AddressHex, addrHex.ChainType
 
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "search account err")
 		return fmt.Errorf("SearchAccount err: %s", err.Error())
@@ -117,7 +118,7 @@ func (h *HttpHandle) doAccountAuctionBid(ctx context.Context, req *ReqAuctionBid
 		err = fmt.Errorf("GetAuctionConfig err: %s", err.Error())
 		return
 	}
-	premiumPrice := decimal.NewFromFloat(common.Premium(int64(acc.ExpiredAt+uint64(auctionConfig.GracePeriodTime)), nowTime))
+	premiumPrice := auctionPremium(acc.ExpiredAt, auctionConfig.GracePeriodTime, nowTime)
 	amountDP := basicPrice.Add(premiumPrice).Mul(decimal.NewFromInt(common.UsdRateBase)).BigInt().Uint64()
 	log.Info(ctx, "baseAmount: ", baseAmount, " accountPrice: ", accountPrice, " basicPrice: ", basicPrice, " premiumPrice: ", premiumPrice, " amountDP: ", amountDP)
 
@@ -136,7 +137,7 @@ func (h *HttpHandle) doAccountAuctionBid(ctx context.Context, req *ReqAuctionBid
 			return
 		} else {
 			apiResp.ApiRespErr(http_api.ApiCodeError500, err.Error())
-			return fmt.Errorf("dasCore.GetDpCells err: ", err.Error())
+			return fmt.Errorf("dasCore.GetDpCells err: %s", err.Error())
 		}
 	}
 	var reqBuild reqBuildTx
@@ -150,20 +151,21 @@ func (h *HttpHandle) doAccountAuctionBid(ctx context.Context, req *ReqAuctionBid
 		PremiumPrice: premiumPrice,
 		BidTime:      nowTime,
 	}
-	reqBuild.EvmChainId = req.GetChainId(config.Cfg.Server.Net)
+	reqBuild.EvmChainId = req.GetChainId(config.Cfg().Server.Net)
 	log.Info("doAccountAuctionBid EvmChainId:", reqBuild.EvmChainId)
 
 	// to lock & normal cell lock
-	if config.Cfg.Server.TransferWhitelist == "" || config.Cfg.Server.CapacityWhitelist == "" {
+	transferWhitelist, capacityWhitelist := signer.Address(signer.RoleTransferWhitelist), signer.Address(signer.RoleCapacityWhitelist)
+	if transferWhitelist == "" || capacityWhitelist == "" {
 		return fmt.Errorf("TransferWhitelist or CapacityWhitelist is empty")
 	}
-	toLock, err := address.Parse(config.Cfg.Server.TransferWhitelist)
+	toLock, err := address.Parse(transferWhitelist)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeError500, err.Error())
 		return fmt.Errorf("address.Parse err: %s", err.Error())
 	}
 
-	normalCellLock, err := address.Parse(config.Cfg.Server.CapacityWhitelist)
+	normalCellLock, err := address.Parse(capacityWhitelist)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeError500, err.Error())
 		return fmt.Errorf("address.Parse err: %s", err.Error())
@@ -331,8 +333,8 @@ func (h *HttpHandle) buildAuctionBidTx(req *reqBuildTx, p *auctionBidParams) (*t
 		DPLiveCellCapacity: totalCapacity,
 		DPTotalAmount:      totalDP,
 		DPTransferAmount:   p.AmountDP,
-		DPSplitCount:       config.Cfg.Server.SplitCount,
-		DPSplitAmount:      config.Cfg.Server.SplitAmount,
+		DPSplitCount:       config.Cfg().Server.SplitCount,
+		DPSplitAmount:      config.Cfg().Server.SplitAmount,
 		NormalCellLock:     p.NormalCellLock,
 	})
 	if err != nil {
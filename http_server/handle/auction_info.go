@@ -62,7 +62,7 @@ func (h *HttpHandle) doGetAccountAuctionInfo(ctx context.Context, req *ReqAccoun
 	var resp RespAccountAuctionInfo
 	var addrHex *core.DasAddressHex
 	if req.KeyInfo.Key != "" {
-		addrHex, err = req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+		addrHex, err = req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 		if err != nil {
 			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 			return nil
@@ -71,7 +71,7 @@ func (h *HttpHandle) doGetAccountAuctionInfo(ctx context.Context, req *ReqAccoun
 	}
 
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "search account err")
 		return fmt.Errorf("SearchAccount err: %s", err.Error())
@@ -97,7 +97,7 @@ func (h *HttpHandle) doGetAccountAuctionInfo(ctx context.Context, req *ReqAccoun
 
 	//search bid status of a account
 	createTime := time.Now().Unix() - 365*86400
-	list, err := h.dbDao.GetAuctionOrderByAccount(req.Account, createTime)
+	list, err := h.dbDao.WithContext(ctx).GetAuctionOrderByAccount(req.Account, createTime)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "db error")
 		return
@@ -0,0 +1,314 @@
+package handle
+
+import (
+	"context"
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// curl -X POST http://127.0.0.1:8120/v1/account/auction/list -d'{"min_length":4,"max_length":6,"max_price":"500","sort":"price","page":1,"size":20}'
+// curl -X POST http://127.0.0.1:8120/v1/account/auction/bids -d'{"account":"michaeltest1.bit","page":1,"size":20}'
+
+const (
+	AuctionSortEnd   = "end"   // ending soonest first
+	AuctionSortPrice = "price" // cheapest first
+
+	auctionListMaxAccounts = 5000 // accounts ending soonest the filters are applied to, truncated is set beyond it
+)
+
+type ReqAuctionList struct {
+	Pagination
+	MinLength    int             `json:"min_length"` // without .bit, 0 means no limit
+	MaxLength    int             `json:"max_length"`
+	MinPrice     decimal.Decimal `json:"min_price"` // USD, basic price plus premium
+	MaxPrice     decimal.Decimal `json:"max_price"`
+	MinRemaining int64           `json:"min_remaining"` // seconds until the auction ends
+	MaxRemaining int64           `json:"max_remaining"`
+	Sort         string          `json:"sort"` // end (default) or price
+}
+
+type RespAuctionList struct {
+	Total     int64             `json:"total"`
+	Now       int64             `json:"now"`       // time cell timestamp the prices are computed at
+	Truncated bool              `json:"truncated"` // more than auctionListMaxAccounts on auction, only the ones ending soonest were filtered
+	List      []AuctionListItem `json:"list"`
+}
+
+type AuctionListItem struct {
+	Account          string           `json:"account"`
+	AccountId        string           `json:"account_id"`
+	Length           int              `json:"length"`
+	ExpiredAt        uint64           `json:"expired_at"`
+	StartAuctionTime uint64           `json:"start_auction_time"`
+	EndAuctionTime   uint64           `json:"end_auction_time"`
+	Remaining        int64            `json:"remaining"`
+	BaseAmount       decimal.Decimal  `json:"base_amount"`
+	AccountPrice     decimal.Decimal  `json:"account_price"`
+	PremiumPrice     decimal.Decimal  `json:"premium_price"`
+	TotalPrice       decimal.Decimal  `json:"total_price"`
+	BidStatus        tables.BidStatus `json:"bid_status"` // 0-no one 1-bid pending or confirmed
+}
+
+type ReqAuctionBids struct {
+	Account string `json:"account" binding:"required"`
+	Pagination
+}
+
+type RespAuctionBids struct {
+	Total int64            `json:"total"`
+	List  []AuctionBidInfo `json:"list"`
+}
+
+type AuctionBidInfo struct {
+	ChainType    common.ChainType `json:"chain_type"`
+	Address      string           `json:"address"`
+	Hash         string           `json:"hash"`
+	BasicPrice   decimal.Decimal  `json:"basic_price"`
+	PremiumPrice decimal.Decimal  `json:"premium_price"`
+	BidTime      int64            `json:"bid_time"`
+	Status       int              `json:"status"`       // 0-pending 1-confirmed -1-rejected
+	ConfirmedAt  uint64           `json:"confirmed_at"` // block timestamp of the bid tx, 0 until committed
+}
+
+func (h *HttpHandle) GetAccountAuctionList(ctx *gin.Context) {
+	var (
+		funcName = "GetAccountAuctionList"
+		clientIp = GetClientIp(ctx)
+		req      ReqAuctionList
+		apiResp  http_api.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doGetAccountAuctionList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doGetAccountAuctionList err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doGetAccountAuctionList(ctx context.Context, req *ReqAuctionList, apiResp *http_api.ApiResp) error {
+	var resp RespAuctionList
+	resp.List = make([]AuctionListItem, 0)
+	if req.Sort == "" {
+		req.Sort = AuctionSortEnd
+	}
+	if req.Sort != AuctionSortEnd && req.Sort != AuctionSortPrice {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "sort invalid")
+		return nil
+	}
+
+	timeCell, err := h.dasCore.GetTimeCell()
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeError500, "GetTimeCell err")
+		return fmt.Errorf("GetTimeCell err: %s", err.Error())
+	}
+	nowTime := timeCell.Timestamp()
+	auctionConfig, err := h.GetAuctionConfig(h.dasCore)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeError500, "GetAuctionConfig err")
+		return fmt.Errorf("GetAuctionConfig err: %s", err.Error())
+	}
+	gracePeriodTime, auctionPeriodTime := int64(auctionConfig.GracePeriodTime), int64(auctionConfig.AuctionPeriodTime)
+
+	expiredFrom, expiredTo, ok := auctionExpiredRange(nowTime, gracePeriodTime, auctionPeriodTime, req.MinRemaining, req.MaxRemaining)
+	if !ok {
+		resp.Now = nowTime
+		apiResp.ApiRespOK(resp)
+		return nil
+	}
+
+	accounts, err := h.dbDao.WithContext(ctx).GetAuctionAccounts(uint64(expiredFrom), uint64(expiredTo), auctionListMaxAccounts+1)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "search auction accounts err")
+		return fmt.Errorf("GetAuctionAccounts err: %s", err.Error())
+	}
+	if len(accounts) > auctionListMaxAccounts {
+		accounts, resp.Truncated = accounts[:auctionListMaxAccounts], true
+		log.Warn(ctx, "GetAuctionAccounts truncated:", auctionListMaxAccounts, expiredFrom, expiredTo)
+	}
+
+	type lengthPrice struct {
+		baseAmount, accountPrice decimal.Decimal
+	}
+	prices := make(map[string]lengthPrice) // the price of an account depends on its char and byte length
+	var list []AuctionListItem
+	for _, acc := range accounts {
+		_, accLen, err := common.GetDotBitAccountLength(acc.Account)
+		if err != nil || accLen == 0 {
+			log.Warn(ctx, "GetDotBitAccountLength skip:", acc.Account)
+			continue
+		}
+		if !req.matchLength(accLen) {
+			continue
+		}
+		priceKey := fmt.Sprintf("%d-%d", accLen, len(acc.Account))
+		price, ok := prices[priceKey]
+		if !ok {
+			if price.baseAmount, price.accountPrice, err = h.getAccountPrice(ctx, uint8(accLen), "", acc.Account, false); err != nil {
+				apiResp.ApiRespErr(http_api.ApiCodeError500, "get account price err")
+				return fmt.Errorf("getAccountPrice err: %s", err.Error())
+			}
+			prices[priceKey] = price
+		}
+		item := AuctionListItem{
+			Account:          acc.Account,
+			AccountId:        acc.AccountId,
+			Length:           accLen,
+			ExpiredAt:        acc.ExpiredAt,
+			StartAuctionTime: acc.ExpiredAt + uint64(gracePeriodTime),
+			EndAuctionTime:   acc.ExpiredAt + uint64(gracePeriodTime+auctionPeriodTime),
+			BaseAmount:       price.baseAmount,
+			AccountPrice:     price.accountPrice,
+			PremiumPrice:     auctionPremium(acc.ExpiredAt, auctionConfig.GracePeriodTime, nowTime),
+		}
+		item.Remaining = int64(item.EndAuctionTime) - nowTime
+		item.TotalPrice = item.BaseAmount.Add(item.AccountPrice).Add(item.PremiumPrice)
+		if !req.matchPrice(item.TotalPrice) {
+			continue
+		}
+		list = append(list, item)
+	}
+	sortAuctionList(list, req.Sort)
+
+	resp.Now = nowTime
+	resp.Total = int64(len(list))
+	resp.List = pageAuctionList(list, req.GetOffset(), req.GetLimit())
+
+	// bids on the page, an auction account is taken by its first committed bid
+	var names []string
+	for _, v := range resp.List {
+		names = append(names, v.Account)
+	}
+	counts, err := h.dbDao.WithContext(ctx).GetAuctionBidCounts(names, time.Unix(expiredFrom, 0))
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "search auction bids err")
+		return fmt.Errorf("GetAuctionBidCounts err: %s", err.Error())
+	}
+	bids := make(map[string]int64)
+	for _, v := range counts {
+		bids[v.Account] = v.Num
+	}
+	for i := range resp.List {
+		if bids[resp.List[i].Account] > 0 {
+			resp.List[i].BidStatus = tables.BidStatusByOthers
+		}
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// auctionExpiredRange is the expired_at range of the accounts on dutch auction: now-grace-auction < expired_at < now-grace,
+// narrowed by the remaining seconds, ok is false when it is empty
+func auctionExpiredRange(nowTime, gracePeriodTime, auctionPeriodTime, minRemaining, maxRemaining int64) (from, to int64, ok bool) {
+	from, to = nowTime-gracePeriodTime-auctionPeriodTime, nowTime-gracePeriodTime
+	if minRemaining > 0 {
+		if v := nowTime + minRemaining - gracePeriodTime - auctionPeriodTime - 1; v > from {
+			from = v
+		}
+	}
+	if maxRemaining > 0 {
+		if v := nowTime + maxRemaining - gracePeriodTime - auctionPeriodTime + 1; v < to {
+			to = v
+		}
+	}
+	return from, to, from >= 0 && to > from
+}
+
+func (req *ReqAuctionList) matchLength(accLen int) bool {
+	return (req.MinLength <= 0 || accLen >= req.MinLength) && (req.MaxLength <= 0 || accLen <= req.MaxLength)
+}
+
+func (req *ReqAuctionList) matchPrice(totalPrice decimal.Decimal) bool {
+	return (!req.MinPrice.IsPositive() || !totalPrice.LessThan(req.MinPrice)) &&
+		(!req.MaxPrice.IsPositive() || !totalPrice.GreaterThan(req.MaxPrice))
+}
+
+// sortAuctionList keeps the ending soonest order of the db for AuctionSortEnd
+func sortAuctionList(list []AuctionListItem, sortBy string) {
+	if sortBy == AuctionSortPrice {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].TotalPrice.LessThan(list[j].TotalPrice) })
+	}
+}
+
+func pageAuctionList(list []AuctionListItem, offset, limit int) []AuctionListItem {
+	if offset >= len(list) {
+		return make([]AuctionListItem, 0)
+	}
+	end := offset + limit
+	if end > len(list) {
+		end = len(list)
+	}
+	return list[offset:end]
+}
+
+func (h *HttpHandle) GetAccountAuctionBids(ctx *gin.Context) {
+	var (
+		funcName = "GetAccountAuctionBids"
+		clientIp = GetClientIp(ctx)
+		req      ReqAuctionBids
+		apiResp  http_api.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doGetAccountAuctionBids(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doGetAccountAuctionBids err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doGetAccountAuctionBids(ctx context.Context, req *ReqAuctionBids, apiResp *http_api.ApiResp) error {
+	var resp RespAuctionBids
+	resp.List = make([]AuctionBidInfo, 0)
+	account := strings.ToLower(req.Account)
+	if !strings.HasSuffix(account, common.DasAccountSuffix) {
+		account += common.DasAccountSuffix
+	}
+
+	list, total, err := h.dbDao.WithContext(ctx).GetAuctionBidHistory(account, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "search auction bids err")
+		return fmt.Errorf("GetAuctionBidHistory err: %s", err.Error())
+	}
+	resp.Total = total
+	for _, v := range list {
+		hash, _ := common.String2OutPoint(v.Outpoint)
+		resp.List = append(resp.List, AuctionBidInfo{
+			ChainType:    v.ChainType,
+			Address:      v.Address,
+			Hash:         hash,
+			BasicPrice:   v.BasicPrice,
+			PremiumPrice: v.PremiumPrice,
+			BidTime:      v.BidTime,
+			Status:       v.Status,
+			ConfirmedAt:  v.BlockTimestamp,
+		})
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
@@ -0,0 +1,96 @@
+package handle
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func TestAuctionExpiredRange(t *testing.T) {
+	now, grace, auction := int64(1700000000), int64(90*86400), int64(27*86400)
+	tests := []struct {
+		name                       string
+		now                        int64
+		minRemaining, maxRemaining int64
+		from, to                   int64
+		ok                         bool
+	}{
+		{"all", now, 0, 0, now - grace - auction, now - grace, true},
+		{"min remaining", now, 86400, 0, now + 86400 - grace - auction - 1, now - grace, true},
+		{"max remaining", now, 0, 3600, now - grace - auction, now + 3600 - grace - auction + 1, true},
+		{"before any auction", grace, 0, 0, 0, 0, false},
+		{"min above max", now, 86400 * 2, 86400, 0, 0, false},
+	}
+	for _, v := range tests {
+		from, to, ok := auctionExpiredRange(v.now, grace, auction, v.minRemaining, v.maxRemaining)
+		if ok != v.ok || (ok && (from != v.from || to != v.to)) {
+			t.Fatal(v.name, from, to, ok)
+		}
+	}
+}
+
+func TestAuctionListFilter(t *testing.T) {
+	tests := []struct {
+		req    ReqAuctionList
+		accLen int
+		price  int64
+		match  bool
+	}{
+		{ReqAuctionList{}, 1, 1, true},
+		{ReqAuctionList{MinLength: 4, MaxLength: 6}, 4, 1, true},
+		{ReqAuctionList{MinLength: 4, MaxLength: 6}, 6, 1, true},
+		{ReqAuctionList{MinLength: 4, MaxLength: 6}, 3, 1, false},
+		{ReqAuctionList{MinLength: 4, MaxLength: 6}, 7, 1, false},
+		{ReqAuctionList{MinPrice: decimal.NewFromInt(10), MaxPrice: decimal.NewFromInt(20)}, 5, 10, true},
+		{ReqAuctionList{MinPrice: decimal.NewFromInt(10), MaxPrice: decimal.NewFromInt(20)}, 5, 20, true},
+		{ReqAuctionList{MinPrice: decimal.NewFromInt(10), MaxPrice: decimal.NewFromInt(20)}, 5, 9, false},
+		{ReqAuctionList{MinPrice: decimal.NewFromInt(10), MaxPrice: decimal.NewFromInt(20)}, 5, 21, false},
+	}
+	for i, v := range tests {
+		if ok := v.req.matchLength(v.accLen) && v.req.matchPrice(decimal.NewFromInt(v.price)); ok != v.match {
+			t.Fatal(i, ok)
+		}
+	}
+}
+
+func TestAuctionListSortAndPage(t *testing.T) {
+	newList := func() []AuctionListItem {
+		var list []AuctionListItem
+		for i, p := range []int64{30, 10, 20, 10} {
+			list = append(list, AuctionListItem{Account: string(rune('a'+i)) + ".bit", TotalPrice: decimal.NewFromInt(p)})
+		}
+		return list
+	}
+	accounts := func(list []AuctionListItem) (res string) {
+		for _, v := range list {
+			res += v.Account[:1]
+		}
+		return
+	}
+
+	list := newList()
+	sortAuctionList(list, AuctionSortEnd)
+	if got := accounts(list); got != "abcd" {
+		t.Fatal("end sort changed the db order:", got)
+	}
+	sortAuctionList(list, AuctionSortPrice)
+	if got := accounts(list); got != "bdca" {
+		t.Fatal("price sort not stable:", got)
+	}
+
+	tests := []struct {
+		offset, limit int
+		want          string
+	}{
+		{0, 2, "bd"},
+		{2, 2, "ca"},
+		{3, 2, "a"},
+		{4, 2, ""},
+		{10, 2, ""},
+	}
+	for _, v := range tests {
+		page := pageAuctionList(list, v.offset, v.limit)
+		if page == nil || accounts(page) != v.want {
+			t.Fatal(v.offset, v.limit, accounts(page))
+		}
+	}
+}
@@ -53,13 +53,13 @@ func (h *HttpHandle) GetAuctionOrderStatus(ctx *gin.Context) {
 func (h *HttpHandle) doGetAuctionOrderStatus(ctx context.Context, req *ReqAuctionOrderStatus, apiResp *http_api.ApiResp) (err error) {
 	var resp RepReqGetAuctionOrder
 
-	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
 	}
 	req.address, req.chainType = addrHex.AddressHex, addrHex.ChainType
-	order, err := h.dbDao.GetAuctionOrderStatus(addrHex.ChainType, addrHex.AddressHex, req.Hash)
+	order, err := h.dbDao.WithContext(ctx).GetAuctionOrderStatus(addrHex.ChainType, addrHex.AddressHex, req.Hash)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "db error")
 		return
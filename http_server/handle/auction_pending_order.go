@@ -43,13 +43,13 @@ func (h *HttpHandle) GetPendingAuctionOrder(ctx *gin.Context) {
 
 func (h *HttpHandle) doGetPendingAuctionOrder(ctx context.Context, req *ReqGetPendingAuctionOrder, apiResp *http_api.ApiResp) (err error) {
 	resp := make([]RepReqGetAuctionOrder, 0)
-	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
 	}
 	req.address, req.chainType = addrHex.AddressHex, addrHex.ChainType
-	list, err := h.dbDao.GetPendingAuctionOrder(addrHex.ChainType, addrHex.AddressHex)
+	list, err := h.dbDao.WithContext(ctx).GetPendingAuctionOrder(addrHex.ChainType, addrHex.AddressHex)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "db error")
 		return
@@ -50,7 +50,7 @@ func (h *HttpHandle) GetAccountAuctionPrice(ctx *gin.Context) {
 func (h *HttpHandle) doGetAccountAuctionPrice(ctx context.Context, req *ReqAuctionPrice, apiResp *http_api.ApiResp) (err error) {
 	var resp RespAuctionPrice
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "search account err")
 		return fmt.Errorf("SearchAccount err: %s", err.Error())
@@ -95,7 +95,30 @@ func (h *HttpHandle) doGetAccountAuctionPrice(ctx context.Context, req *ReqAucti
 	}
 	resp.BaseAmount = baseAmount
 	resp.AccountPrice = accountPrice
-	resp.PremiumPrice = decimal.NewFromFloat(common.Premium(int64(acc.ExpiredAt+uint64(auctionConfig.GracePeriodTime)), int64(nowTime)))
+	resp.PremiumPrice = auctionPremium(acc.ExpiredAt, auctionConfig.GracePeriodTime, nowTime)
 	apiResp.ApiRespOK(resp)
 	return
 }
+
+// auctionPremium is the premium in USD of a bid at nowTime, the auction starts when the grace period after expiredAt ends.
+// Every auction price shown must come from here so it matches what doAccountAuctionBid charges.
+func auctionPremium(expiredAt uint64, gracePeriodTime uint32, nowTime int64) decimal.Decimal {
+	return decimal.NewFromFloat(common.Premium(int64(expiredAt+uint64(gracePeriodTime)), nowTime))
+}
+
+// auctionTargetTime returns the first second in [from, to] at which the premium is at most targetPremium,
+// the premium only decreases so it is a binary search over auctionPremium
+func auctionTargetTime(expiredAt uint64, gracePeriodTime uint32, from, to int64, targetPremium decimal.Decimal) (int64, bool) {
+	if from > to || auctionPremium(expiredAt, gracePeriodTime, to).GreaterThan(targetPremium) {
+		return 0, false
+	}
+	for from < to {
+		mid := from + (to-from)/2
+		if auctionPremium(expiredAt, gracePeriodTime, mid).GreaterThan(targetPremium) {
+			from = mid + 1
+		} else {
+			to = mid
+		}
+	}
+	return from, true
+}
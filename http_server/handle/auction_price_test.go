@@ -0,0 +1,25 @@
+package handle
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func TestAuctionTargetTime(t *testing.T) {
+	expiredAt, grace := uint64(1700000000), uint32(90*86400)
+	start := int64(expiredAt) + int64(grace)
+	end := start + 27*86400
+
+	target := decimal.NewFromInt(100)
+	ts, ok := auctionTargetTime(expiredAt, grace, start, end, target)
+	if !ok {
+		t.Fatal("target not reached")
+	}
+	if auctionPremium(expiredAt, grace, ts).GreaterThan(target) || !auctionPremium(expiredAt, grace, ts-1).GreaterThan(target) {
+		t.Fatal("not the first second under target:", ts)
+	}
+
+	if _, ok := auctionTargetTime(expiredAt, grace, start, start+86400, target); ok {
+		t.Fatal("target reached within a day")
+	}
+}
@@ -0,0 +1,145 @@
+package handle
+
+import (
+	"context"
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// curl -X POST http://127.0.0.1:8120/v1/account/auction/projection -d'{"account":"michaeltest1.bit","timestamps":[1704153600],"target_price":"50"}'
+
+const auctionProjectionMaxPoints = 100
+
+type ReqAuctionProjection struct {
+	Account     string          `json:"account" binding:"required"`
+	Timestamps  []int64         `json:"timestamps"`   // seconds within the auction, empty means one point a day until it ends
+	TargetPrice decimal.Decimal `json:"target_price"` // USD, basic price plus premium, the time it is reached is returned
+}
+
+type RespAuctionProjection struct {
+	Now              int64               `json:"now"`
+	StartAuctionTime uint64              `json:"start_auction_time"`
+	EndAuctionTime   uint64              `json:"end_auction_time"`
+	BaseAmount       decimal.Decimal     `json:"base_amount"`
+	AccountPrice     decimal.Decimal     `json:"account_price"`
+	Points           []AuctionPricePoint `json:"points"`
+	Target           *AuctionPricePoint  `json:"target"` // null when the price stays above target_price until the auction ends
+}
+
+type AuctionPricePoint struct {
+	Timestamp    int64           `json:"timestamp"`
+	PremiumPrice decimal.Decimal `json:"premium_price"`
+	TotalPrice   decimal.Decimal `json:"total_price"`
+}
+
+func (h *HttpHandle) GetAccountAuctionProjection(ctx *gin.Context) {
+	var (
+		funcName = "GetAccountAuctionProjection"
+		clientIp = GetClientIp(ctx)
+		req      ReqAuctionProjection
+		apiResp  http_api.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doGetAccountAuctionProjection(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doGetAccountAuctionProjection err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doGetAccountAuctionProjection(ctx context.Context, req *ReqAuctionProjection, apiResp *http_api.ApiResp) (err error) {
+	var resp RespAuctionProjection
+	if len(req.Timestamps) > auctionProjectionMaxPoints {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, fmt.Sprintf("at most %d timestamps", auctionProjectionMaxPoints))
+		return nil
+	}
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "search account err")
+		return fmt.Errorf("SearchAccount err: %s", err.Error())
+	}
+	timeCell, err := h.dasCore.GetTimeCell()
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeError500, "GetTimeCell err")
+		return fmt.Errorf("GetTimeCell err: %s", err.Error())
+	}
+	nowTime := timeCell.Timestamp()
+	if status, _, err := h.checkDutchAuction(ctx, acc.ExpiredAt, uint64(nowTime)); err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeError500, "checkDutchAuction err")
+		return fmt.Errorf("checkDutchAuction err: %s", err.Error())
+	} else if status != tables.SearchStatusOnDutchAuction {
+		apiResp.ApiRespErr(http_api.ApiCodeAuctionAccountNotFound, "This account has not been in dutch auction")
+		return nil
+	}
+
+	_, accLen, err := common.GetDotBitAccountLength(req.Account)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "account invalid")
+		return fmt.Errorf("GetDotBitAccountLength err: %s", err.Error())
+	}
+	if accLen == 0 {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "account invalid")
+		return fmt.Errorf("accLen is 0")
+	}
+	baseAmount, accountPrice, err := h.getAccountPrice(ctx, uint8(accLen), "", req.Account, false)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeError500, "get account price err")
+		return fmt.Errorf("getAccountPrice err: %s", err.Error())
+	}
+	auctionConfig, err := h.GetAuctionConfig(h.dasCore)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeError500, "GetAuctionConfig err")
+		return fmt.Errorf("GetAuctionConfig err: %s", err.Error())
+	}
+	basicPrice := baseAmount.Add(accountPrice)
+	resp.Now = nowTime
+	resp.StartAuctionTime = acc.ExpiredAt + uint64(auctionConfig.GracePeriodTime)
+	resp.EndAuctionTime = acc.ExpiredAt + uint64(auctionConfig.GracePeriodTime+auctionConfig.AuctionPeriodTime)
+	resp.BaseAmount = baseAmount
+	resp.AccountPrice = accountPrice
+	// the last second a bid is accepted, checkDutchAuction requires expired_at > now-grace-auction
+	lastTime := int64(resp.EndAuctionTime) - 1
+
+	point := func(timestamp int64) AuctionPricePoint {
+		premium := auctionPremium(acc.ExpiredAt, auctionConfig.GracePeriodTime, timestamp)
+		return AuctionPricePoint{Timestamp: timestamp, PremiumPrice: premium, TotalPrice: basicPrice.Add(premium)}
+	}
+	timestamps := req.Timestamps
+	if len(timestamps) == 0 {
+		for t := nowTime; t <= lastTime; t += 86400 {
+			timestamps = append(timestamps, t)
+		}
+	}
+	for _, t := range timestamps {
+		if t < nowTime || t > lastTime {
+			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, fmt.Sprintf("timestamp %d not in [%d, %d]", t, nowTime, lastTime))
+			return nil
+		}
+		resp.Points = append(resp.Points, point(t))
+	}
+	if req.TargetPrice.IsPositive() {
+		if t, ok := auctionTargetTime(acc.ExpiredAt, auctionConfig.GracePeriodTime, nowTime, lastTime, req.TargetPrice.Sub(basicPrice)); ok {
+			p := point(t)
+			resp.Target = &p
+		}
+	}
+
+	apiResp.ApiRespOK(resp)
+	return
+}
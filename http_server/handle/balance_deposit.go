@@ -84,7 +84,7 @@ func (h *HttpHandle) doBalanceDeposit(ctx context.Context, req *ReqBalanceDeposi
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "address.Parse")
 		return fmt.Errorf("to address.Parse err: %s", err.Error())
 	}
-	if config.Cfg.Server.Net == common.DasNetTypeMainNet {
+	if config.Cfg().Server.Net == common.DasNetTypeMainNet {
 		if fromAddress.Mode != address.Mainnet || toAddress.Mode != address.Mainnet {
 			apiResp.ApiRespErr(api_code.ApiCodeError500, "testnet address")
 			return nil
@@ -70,7 +70,7 @@ func (h *HttpHandle) BalanceInfo(ctx *gin.Context) {
 }
 
 func (h *HttpHandle) doBalanceInfo(ctx context.Context, req *ReqBalanceInfo, apiResp *api_code.ApiResp) error {
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -74,7 +74,7 @@ func (h *HttpHandle) BalancePay(ctx *gin.Context) {
 }
 
 func (h *HttpHandle) doBalancePay(ctx context.Context, req *ReqBalancePay, apiResp *api_code.ApiResp) error {
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -88,7 +88,7 @@ func (h *HttpHandle) doBalancePay(ctx context.Context, req *ReqBalancePay, apiRe
 	}
 
 	// check order
-	order, err := h.dbDao.GetOrderByOrderId(req.OrderId)
+	order, err := h.dbDao.WithContext(ctx).GetOrderByOrderId(req.OrderId)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "get order fail")
 		return fmt.Errorf("GetOrderByOrderId err: %s [%s]", err.Error(), req.OrderId)
@@ -78,7 +78,7 @@ func (h *HttpHandle) BalanceWithdraw(ctx *gin.Context) {
 
 func (h *HttpHandle) doBalanceWithdraw(ctx context.Context, req *ReqBalanceWithdraw, apiResp *api_code.ApiResp) error {
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -100,10 +100,10 @@ func (h *HttpHandle) doBalanceWithdraw(ctx context.Context, req *ReqBalanceWithd
 		return fmt.Errorf("address.Parse err: %s [%s]", err.Error(), req.ReceiverAddress)
 	}
 
-	if config.Cfg.Server.Net == common.DasNetTypeMainNet && parseAddress.Mode != address.Mainnet {
+	if config.Cfg().Server.Net == common.DasNetTypeMainNet && parseAddress.Mode != address.Mainnet {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "testnet address is invalid")
 		return fmt.Errorf("testnet address: %s", req.ReceiverAddress)
-	} else if config.Cfg.Server.Net != common.DasNetTypeMainNet && parseAddress.Mode != address.Testnet {
+	} else if config.Cfg().Server.Net != common.DasNetTypeMainNet && parseAddress.Mode != address.Testnet {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "main net address is invalid")
 		return fmt.Errorf("mainnet address: %s", req.ReceiverAddress)
 	}
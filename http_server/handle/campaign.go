@@ -0,0 +1,25 @@
+package handle
+
+import (
+	"context"
+	"das_register_server/campaign"
+	"fmt"
+	"time"
+)
+
+// evaluateCampaigns returns the discount of the active coupon campaigns for the order, the single evaluator of order register and renew
+func (h *HttpHandle) evaluateCampaigns(ctx context.Context, o campaign.Order) (*campaign.Result, error) {
+	dbDao := h.dbDao.WithContext(ctx)
+	list, err := dbDao.GetActiveCouponCampaigns(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("GetActiveCouponCampaigns err: %s", err.Error())
+	}
+	res, err := campaign.Evaluate(list, dbDao, o, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Discounts) > 0 {
+		log.Info(ctx, "evaluateCampaigns:", o.Action, o.Address, o.AmountUSD, res.DiscountUSD, res.Discounts)
+	}
+	return res, nil
+}
@@ -67,9 +67,9 @@ func (h *HttpHandle) doCkbRpc(ctx *gin.Context, req *ReqCkbRpc) {
 	url := ""
 	switch req.Method {
 	case "get_cells", "get_cells_capacity":
-		url = config.Cfg.Chain.IndexUrl
+		url = config.Cfg().Chain.IndexUrl
 	case "get_blockchain_info", "get_block_by_number", "send_transaction":
-		url = config.Cfg.Chain.CkbUrl
+		url = config.Cfg().Chain.CkbUrl
 	default:
 		ctx.JSON(http.StatusOK, ApiRespErr(req.ID, api_code.ApiCodeMethodNotExist, fmt.Sprintf("method [%s] not exist", req.Method)))
 		return
@@ -60,8 +60,8 @@ func (h *HttpHandle) ConfigInfo(ctx *gin.Context) {
 
 func (h *HttpHandle) doConfigInfo(ctx context.Context, apiResp *api_code.ApiResp) error {
 	var resp RespConfigInfo
-	resp.PremiumPercentage = config.Cfg.Stripe.PremiumPercentage
-	resp.PremiumBase = config.Cfg.Stripe.PremiumBase
+	resp.PremiumPercentage = config.Cfg().Stripe.PremiumPercentage
+	resp.PremiumBase = config.Cfg().Stripe.PremiumBase
 
 	if err := h.checkSystemUpgrade(apiResp); err != nil {
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
@@ -146,8 +146,8 @@ func (h *HttpHandle) doConfigInfo(ctx context.Context, apiResp *api_code.ApiResp
 	resp.MinTtl = minTtl
 	resp.AccountExpirationGracePeriod = accountExpirationGracePeriod
 
-	resp.Premium = config.Cfg.Das.Premium
-	resp.MinAccountLen = uint32(config.Cfg.Das.AccountMinLength)
+	resp.Premium = config.Cfg().Das.Premium
+	resp.MinAccountLen = uint32(config.Cfg().Das.AccountMinLength)
 	resp.InviterDiscount = decInviteDiscount
 	resp.ProfitRateOfInviter = decProfitRateInviter
 
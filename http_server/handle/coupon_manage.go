@@ -0,0 +1,397 @@
+package handle
+
+import (
+	"context"
+	"das_register_server/config"
+	"das_register_server/dao"
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// curl -X POST http://127.0.0.1:8119/v1/coupon/list -d'{"desc":"xx","status":4,"page":1,"size":20}'
+// curl -X POST http://127.0.0.1:8119/v1/coupon/revoke -d'{"codes":["XXXXXXXX"],"batch_ids":["20261019-1a2b3c4d"]}'
+// curl -X POST http://127.0.0.1:8119/v1/coupon/extend -d'{"desc":"xx","expire_time":"2026-12-31 23:59:59"}'
+// curl -X POST http://127.0.0.1:8119/v1/coupon/lookup -d'{"code":"XXXXXXXX"}'
+// curl -X POST http://127.0.0.1:8119/v1/coupon/stats -d'{"desc":"xx"}'
+
+type ReqCouponList struct {
+	Pagination
+	BatchId    string              `json:"batch_id"`
+	Desc       string              `json:"desc"` // the desc the codes were created with
+	CouponType tables.CouponType   `json:"type"`
+	Status     tables.CouponStatus `json:"status"` // 2-used 3-expired 4-available 5-revoked, 0 means all
+}
+
+type RespCouponList struct {
+	Total int64        `json:"total"`
+	List  []CouponItem `json:"list"`
+}
+
+type CouponItem struct {
+	Id         uint64              `json:"id"`
+	Code       string              `json:"code"` // salted hash, the plain code is only in the create download
+	BatchId    string              `json:"batch_id"`
+	CouponType tables.CouponType   `json:"type"`
+	Desc       string              `json:"desc"`
+	Status     tables.CouponStatus `json:"status"`
+	OrderId    string              `json:"order_id"`
+	UseAt      int64               `json:"use_at"`
+	RevokedAt  int64               `json:"revoked_at"`
+	StartAt    int64               `json:"start_at"`
+	ExpiredAt  int64               `json:"expired_at"`
+	CreatedAt  int64               `json:"created_at"`
+}
+
+// ReqCouponSelect selects the codes to change by ids, plain codes, batches or the desc they were created with
+type ReqCouponSelect struct {
+	Ids      []uint64 `json:"ids"`
+	Codes    []string `json:"codes"` // plain codes or qrcode contents
+	BatchIds []string `json:"batch_ids"`
+	Desc     string   `json:"desc"`
+}
+
+type ReqCouponRevoke struct {
+	ReqCouponSelect
+}
+
+type RespCouponRevoke struct {
+	Rows int64 `json:"rows"`
+}
+
+type ReqCouponExtend struct {
+	ReqCouponSelect
+	ExpireTime string `json:"expire_time" binding:"required"` // 2006-01-02 15:04:05
+}
+
+type RespCouponExtend struct {
+	Rows int64 `json:"rows"`
+}
+
+type ReqCouponLookup struct {
+	Code string `json:"code" binding:"required"` // plain code or qrcode content
+}
+
+type RespCouponLookup struct {
+	Coupon CouponItem       `json:"coupon"`
+	Order  *CouponOrderInfo `json:"order"`
+}
+
+type CouponOrderInfo struct {
+	OrderId        string                `json:"order_id"`
+	Account        string                `json:"account"`
+	ChainType      common.ChainType      `json:"chain_type"`
+	Address        string                `json:"address"`
+	OrderStatus    tables.OrderStatus    `json:"order_status"`
+	RegisterStatus tables.RegisterStatus `json:"register_status"`
+	Timestamp      int64                 `json:"timestamp"`
+}
+
+type ReqCouponStats struct {
+	BatchId string `json:"batch_id"` // empty means all batches
+	Desc    string `json:"desc"`
+}
+
+type RespCouponStats struct {
+	List []dao.CouponStats `json:"list"`
+}
+
+func couponItem(coupon tables.TableCoupon, now time.Time) CouponItem {
+	return CouponItem{
+		Id:         coupon.Id,
+		Code:       coupon.Code,
+		BatchId:    coupon.BatchId,
+		CouponType: coupon.CouponType,
+		Desc:       coupon.Desc,
+		Status:     coupon.GetStatus(now),
+		OrderId:    coupon.OrderId,
+		UseAt:      coupon.UseAt,
+		RevokedAt:  coupon.RevokedAt,
+		StartAt:    coupon.StartAt.Unix(),
+		ExpiredAt:  coupon.ExpiredAt.Unix(),
+		CreatedAt:  coupon.CreatedAt.Unix(),
+	}
+}
+
+// couponCodeHash hashes a plain code the way it is stored, the qrcode prefix is dropped
+func couponCodeHash(code string) (string, error) {
+	salt := config.Cfg().Server.CouponEncrySalt
+	if salt == "" {
+		return "", fmt.Errorf("config coupon_encry_salt is empty")
+	}
+	code = strings.TrimSpace(code)
+	if prefix := config.Cfg().Server.CouponQrcodePrefix; prefix != "" {
+		code = strings.TrimPrefix(code, prefix)
+	}
+	return couponEncry(code, salt), nil
+}
+
+func (req *ReqCouponSelect) empty() bool {
+	return len(req.Ids) == 0 && len(req.Codes) == 0 && len(req.BatchIds) == 0 && req.Desc == ""
+}
+
+func (req *ReqCouponSelect) filter() (dao.CouponFilter, error) {
+	filter := dao.CouponFilter{Ids: req.Ids, BatchIds: req.BatchIds, Desc: req.Desc}
+	for _, v := range req.Codes {
+		code, err := couponCodeHash(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.Codes = append(filter.Codes, code)
+	}
+	return filter, nil
+}
+
+func (h *HttpHandle) CouponList(ctx *gin.Context) {
+	var (
+		funcName = "CouponList"
+		clientIp = GetClientIp(ctx)
+		req      ReqCouponList
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doCouponList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doCouponList err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doCouponList(ctx context.Context, req *ReqCouponList, apiResp *api_code.ApiResp) error {
+	var resp RespCouponList
+
+	filter := dao.CouponFilter{Desc: req.Desc}
+	if req.BatchId != "" {
+		filter.BatchIds = []string{req.BatchId}
+	}
+	list, total, err := h.dbDao.WithContext(ctx).GetCouponList(filter, req.CouponType, req.Status, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search coupon list err")
+		return fmt.Errorf("GetCouponList err: %s", err.Error())
+	}
+	now := time.Now()
+	resp.Total = total
+	resp.List = make([]CouponItem, 0, len(list))
+	for _, v := range list {
+		resp.List = append(resp.List, couponItem(v, now))
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+func (h *HttpHandle) CouponRevoke(ctx *gin.Context) {
+	var (
+		funcName = "CouponRevoke"
+		clientIp = GetClientIp(ctx)
+		req      ReqCouponRevoke
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doCouponRevoke(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doCouponRevoke err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doCouponRevoke(ctx context.Context, req *ReqCouponRevoke, apiResp *api_code.ApiResp) error {
+	if req.empty() {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "ids, codes, batch_ids or desc is required")
+		return nil
+	}
+	filter, err := req.filter()
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "system setting error")
+		return err
+	}
+	rows, err := h.dbDao.WithContext(ctx).RevokeCoupons(filter)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "revoke coupon err")
+		return fmt.Errorf("RevokeCoupons err: %s", err.Error())
+	}
+	log.Info("doCouponRevoke:", req.Ids, len(req.Codes), req.BatchIds, req.Desc, rows)
+
+	apiResp.ApiRespOK(RespCouponRevoke{Rows: rows})
+	return nil
+}
+
+func (h *HttpHandle) CouponExtend(ctx *gin.Context) {
+	var (
+		funcName = "CouponExtend"
+		clientIp = GetClientIp(ctx)
+		req      ReqCouponExtend
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doCouponExtend(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doCouponExtend err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doCouponExtend(ctx context.Context, req *ReqCouponExtend, apiResp *api_code.ApiResp) error {
+	if req.empty() {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "ids, codes, batch_ids or desc is required")
+		return nil
+	}
+	expireAt, err := time.ParseInLocation("2006-01-02 15:04:05", req.ExpireTime, time.Local)
+	if err != nil || expireAt.Before(time.Now()) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "expire_time invalid")
+		return nil
+	}
+	filter, err := req.filter()
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "system setting error")
+		return err
+	}
+	rows, err := h.dbDao.WithContext(ctx).ExtendCoupons(filter, expireAt)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "extend coupon err")
+		return fmt.Errorf("ExtendCoupons err: %s", err.Error())
+	}
+	log.Info("doCouponExtend:", req.Ids, len(req.Codes), req.BatchIds, req.Desc, req.ExpireTime, rows)
+
+	apiResp.ApiRespOK(RespCouponExtend{Rows: rows})
+	return nil
+}
+
+func (h *HttpHandle) CouponLookup(ctx *gin.Context) {
+	var (
+		funcName = "CouponLookup"
+		clientIp = GetClientIp(ctx)
+		req      ReqCouponLookup
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, ctx)
+
+	if err = h.doCouponLookup(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doCouponLookup err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doCouponLookup(ctx context.Context, req *ReqCouponLookup, apiResp *api_code.ApiResp) error {
+	var resp RespCouponLookup
+
+	code, err := couponCodeHash(req.Code)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "system setting error")
+		return err
+	}
+	coupon, err := h.dbDao.WithContext(ctx).GetCouponByCode(code)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search coupon err")
+		return fmt.Errorf("GetCouponByCode err: %s", err.Error())
+	} else if coupon.Id == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeCouponInvalid, "gift card not found")
+		return nil
+	}
+	resp.Coupon = couponItem(coupon, time.Now())
+	if coupon.OrderId != "" {
+		order, err := h.dbDao.WithContext(ctx).GetOrderByOrderId(coupon.OrderId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search order err")
+			return fmt.Errorf("GetOrderByOrderId err: %s", err.Error())
+		}
+		if order.Id > 0 {
+			resp.Order = &CouponOrderInfo{
+				OrderId:        order.OrderId,
+				Account:        order.Account,
+				ChainType:      order.ChainType,
+				Address:        order.Address,
+				OrderStatus:    order.OrderStatus,
+				RegisterStatus: order.RegisterStatus,
+				Timestamp:      order.Timestamp,
+			}
+		}
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+func (h *HttpHandle) CouponStats(ctx *gin.Context) {
+	var (
+		funcName = "CouponStats"
+		clientIp = GetClientIp(ctx)
+		req      ReqCouponStats
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doCouponStats(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doCouponStats err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doCouponStats(ctx context.Context, req *ReqCouponStats, apiResp *api_code.ApiResp) error {
+	filter := dao.CouponFilter{Desc: req.Desc}
+	if req.BatchId != "" {
+		filter.BatchIds = []string{req.BatchId}
+	}
+	list, err := h.dbDao.WithContext(ctx).GetCouponStats(filter)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search coupon stats err")
+		return fmt.Errorf("GetCouponStats err: %s", err.Error())
+	}
+	if list == nil {
+		list = []dao.CouponStats{}
+	}
+
+	apiResp.ApiRespOK(RespCouponStats{List: list})
+	return nil
+}
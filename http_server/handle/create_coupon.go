@@ -1,39 +1,62 @@
 package handle
 
 import (
+	"context"
+	"crypto/rand"
 	"crypto/sha256"
 	"das_register_server/config"
+	"das_register_server/dao"
 	"das_register_server/tables"
 	"encoding/csv"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	api_code "github.com/dotbitHQ/das-lib/http_api"
 	"github.com/gin-gonic/gin"
 	"github.com/scorpiotzh/toolib"
-	"math/rand"
+	"io"
+	"math/big"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 )
 
+// curl -X POST http://127.0.0.1:8119/v1/create/coupon -d'{"desc":"xx","start_time":"2026-10-19 00:00:00","expire_time":"2026-12-31 23:59:59","format":"csv","qrcode":true,"coupons":[{"type":1,"num":100}]}' -o coupon.csv
+
 var letters = []rune("ABCDEFGHJKLMNPQRSTUVWXYZ23456789")
 
+const (
+	CouponFormatCsv  = "csv"
+	CouponFormatJson = "json"
+
+	couponMaxNum     = 100000 // codes of one request, they are kept in memory until the download is written
+	couponFlushRows  = 500
+	couponMaxRetries = 10
+)
+
 type Coupon struct {
 	CouponType tables.CouponType `json:"type"`
 	Num        int               `json:"num"`
-	FileName   string            `json:"file_name"`
-	FileAddr   string            `json:"file_addr"`
 }
 type ReqCreateCoupon struct {
 	Desc         string   `json:"desc"`
 	ExpireTime   string   `json:"expire_time"`
 	StartTime    string   `json:"start_time"`
 	CouponsGroup []Coupon `json:"coupons"`
+	Format       string   `json:"format"` // csv (default) or json
+	Qrcode       bool     `json:"qrcode"` // adds the qrcode payload, coupon_qrcode_prefix followed by the code
 }
-type RespCreateCoupon struct {
-	Coupons []Coupon `json:"coupons"`
+
+// CouponExportRow is one created code of the download, the plain code is not kept on the server
+type CouponExportRow struct {
+	BatchId       string            `json:"batch_id"`
+	CouponType    tables.CouponType `json:"type"`
+	Code          string            `json:"code"`
+	QrcodeContent string            `json:"qrcode_content,omitempty"`
 }
 
+// CreateCoupon creates a batch of codes per coupon group and streams the plain codes back as a csv or json download.
+// Every code is stored before the first byte is written; if the download breaks the batches are revoked.
 func (h *HttpHandle) CreateCoupon(ctx *gin.Context) {
 	var (
 		funcName = "CreateCoupon"
@@ -51,131 +74,261 @@ func (h *HttpHandle) CreateCoupon(ctx *gin.Context) {
 	}
 	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
 
-	if err = h.doCreateCoupon(&req, &apiResp); err != nil {
+	rows, err := h.doCreateCoupon(ctx.Request.Context(), &req, &apiResp)
+	if err != nil {
 		log.Error("doCreateCoupon err:", err.Error(), funcName, clientIp, ctx)
-		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+	}
+	if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		ctx.JSON(http.StatusOK, apiResp)
+		return
 	}
 
-	ctx.JSON(http.StatusOK, apiResp)
+	fileName := fmt.Sprintf("coupon-%s.%s", rows[0].BatchId, req.Format)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	if req.Format == CouponFormatJson {
+		ctx.Header("Content-Type", "application/json; charset=utf-8")
+	} else {
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	}
+	ctx.Status(http.StatusOK)
+	if err = writeCoupons(ctx.Writer, req.Format, req.Qrcode, rows); err != nil {
+		log.Error("writeCoupons err:", err.Error(), funcName, clientIp, ctx)
+		var batchIds []string
+		for _, v := range rows {
+			if len(batchIds) == 0 || batchIds[len(batchIds)-1] != v.BatchId {
+				batchIds = append(batchIds, v.BatchId)
+			}
+		}
+		// the request context is likely canceled by the broken download, the revoke must not depend on it
+		revokeCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		num, err := h.dbDao.WithContext(revokeCtx).RevokeCoupons(dao.CouponFilter{BatchIds: batchIds})
+		if err != nil {
+			log.Error("RevokeCoupons err:", err.Error(), batchIds, funcName, clientIp, ctx)
+		} else {
+			log.Warn("CreateCoupon download broken, batches revoked:", batchIds, num)
+		}
+	}
 }
 
-func (h *HttpHandle) doCreateCoupon(req *ReqCreateCoupon, apiResp *api_code.ApiResp) error {
-	var resp RespCreateCoupon
-	salt := config.Cfg.Server.CouponEncrySalt
-	filePath := config.Cfg.Server.CouponFilePath
-	qrcodePrefix := config.Cfg.Server.CouponQrcodePrefix
-	codeLength := config.Cfg.Server.CouponCodeLength
-	if salt == "" || filePath == "" || qrcodePrefix == "" || codeLength == 0 {
+func (h *HttpHandle) doCreateCoupon(ctx context.Context, req *ReqCreateCoupon, apiResp *api_code.ApiResp) ([]CouponExportRow, error) {
+	salt := config.Cfg().Server.CouponEncrySalt
+	qrcodePrefix := config.Cfg().Server.CouponQrcodePrefix
+	codeLength := config.Cfg().Server.CouponCodeLength
+	if salt == "" || codeLength == 0 || (req.Qrcode && qrcodePrefix == "") {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "config err")
-		return fmt.Errorf("coupon config error")
+		return nil, fmt.Errorf("coupon config error")
 	}
-	if len(req.CouponsGroup) == 0 || req.Desc == "" || req.ExpireTime == "" || req.StartTime == "" {
+	if req.Format == "" {
+		req.Format = CouponFormatCsv
+	}
+	if len(req.CouponsGroup) == 0 || req.Desc == "" || req.ExpireTime == "" || req.StartTime == "" ||
+		(req.Format != CouponFormatCsv && req.Format != CouponFormatJson) {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
-		return nil
+		return nil, nil
 	}
 	startAt, err := time.ParseInLocation("2006-01-02 15:04:05", req.StartTime, time.Local)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
-		return nil
+		return nil, nil
 	}
 	expireAt, err := time.ParseInLocation("2006-01-02 15:04:05", req.ExpireTime, time.Local)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
-		return nil
+		return nil, nil
 	}
 	if startAt.After(expireAt) {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
-		return nil
+		return nil, nil
 	}
-	for i, _ := range req.CouponsGroup {
-		num := req.CouponsGroup[i].Num
-		coupon_type := req.CouponsGroup[i].CouponType
-		if num <= 0 || coupon_type <= 0 {
+	total := 0
+	for _, v := range req.CouponsGroup {
+		if v.Num <= 0 || v.CouponType <= 0 {
 			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
-			return nil
+			return nil, nil
 		}
+		total += v.Num
+	}
+	if total > couponMaxNum {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("at most %d codes per request", couponMaxNum))
+		return nil, nil
+	}
 
-		var fileData [][]string
-		var tableData []tables.TableCoupon
-		for j := 0; j < num; j++ {
-			code := randStr(codeLength)
-			hashCode := couponEncry(code, salt)
-			rowData := []string{fmt.Sprintf("%s%s", qrcodePrefix, code), fmt.Sprintf("%s", req.Desc)}
-			fileData = append(fileData, rowData)
+	var rows []CouponExportRow
+	var batchIds []string
+	for _, group := range req.CouponsGroup {
+		batchId, err := newCouponBatchId()
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "create coupon fail")
+			return nil, err
+		}
+		codes, err := h.newCouponCodes(ctx, group.Num, codeLength, salt)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "create coupon fail")
+			return nil, h.revokeCouponBatches(ctx, batchIds, err)
+		}
+		tableData := make([]tables.TableCoupon, 0, len(codes))
+		for _, code := range codes {
 			tableData = append(tableData, tables.TableCoupon{
-				Code:       hashCode,
-				CouponType: coupon_type,
+				Code:       couponEncry(code, salt),
+				BatchId:    batchId,
+				CouponType: group.CouponType,
 				Desc:       req.Desc,
 				ExpiredAt:  expireAt,
 				StartAt:    startAt,
 			})
+			row := CouponExportRow{BatchId: batchId, CouponType: group.CouponType, Code: code}
+			if req.Qrcode {
+				row.QrcodeContent = qrcodePrefix + code
+			}
+			rows = append(rows, row)
 		}
+		batchIds = append(batchIds, batchId)
+		if err := h.dbDao.WithContext(ctx).CreateCoupon(tableData); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "create coupon fail")
+			return nil, h.revokeCouponBatches(ctx, batchIds, fmt.Errorf("CreateCoupon err: %s", err.Error()))
+		}
+		log.Info("doCreateCoupon:", batchId, group.CouponType, group.Num, req.Desc)
+	}
 
-		fileName := fmt.Sprintf("/coupon-%s.csv", req.CouponsGroup[i].FileName)
-		req.CouponsGroup[i].FileAddr = filePath + fileName
+	apiResp.ApiRespOK(nil)
+	return rows, nil
+}
 
-		if err := writeCouponCsv(filePath, fileName, fileData); err != nil {
-			log.Error("Write file err:", err.Error())
-			apiResp.ApiRespErr(api_code.ApiCodeError500, "Write file err")
-			return err
-		}
+// revokeCouponBatches revokes the batches already created by a request that failed, so no code without a download stays usable
+func (h *HttpHandle) revokeCouponBatches(ctx context.Context, batchIds []string, err error) error {
+	if len(batchIds) == 0 {
+		return err
+	}
+	if _, e := h.dbDao.WithContext(ctx).RevokeCoupons(dao.CouponFilter{BatchIds: batchIds}); e != nil {
+		return fmt.Errorf("%s, RevokeCoupons %v err: %s", err.Error(), batchIds, e.Error())
+	}
+	return err
+}
 
-		if err := h.dbDao.CreateCoupon(tableData); err != nil {
-			log.Error("CreateCoupon err:", err.Error())
-			apiResp.ApiRespErr(api_code.ApiCodeError500, "create coupon fail")
-			return err
+// newCouponCodes returns num distinct plain codes whose hashes are not taken yet
+func (h *HttpHandle) newCouponCodes(ctx context.Context, num int, length uint8, salt string) ([]string, error) {
+	codes := make([]string, 0, num)
+	seen := make(map[string]struct{}, num)
+	for i := 0; len(codes) < num; i++ {
+		if i >= couponMaxRetries {
+			return nil, fmt.Errorf("not enough unique codes of length %d after %d tries", length, couponMaxRetries)
+		}
+		candidates := make(map[string]string) // hash => code
+		for len(codes)+len(candidates) < num {
+			code, err := randStr(length)
+			if err != nil {
+				return nil, fmt.Errorf("randStr err: %s", err.Error())
+			}
+			if _, ok := seen[code]; ok {
+				continue
+			}
+			seen[code] = struct{}{}
+			candidates[couponEncry(code, salt)] = code
+		}
+		hashes := make([]string, 0, len(candidates))
+		for k := range candidates {
+			hashes = append(hashes, k)
 		}
+		existing, err := h.dbDao.WithContext(ctx).GetExistingCouponCodes(hashes)
+		if err != nil {
+			return nil, fmt.Errorf("GetExistingCouponCodes err: %s", err.Error())
+		}
+		for _, v := range existing {
+			delete(candidates, v)
+		}
+		for _, v := range candidates {
+			codes = append(codes, v)
+		}
+	}
+	return codes, nil
+}
 
+func writeCoupons(w gin.ResponseWriter, format string, qrcode bool, rows []CouponExportRow) error {
+	if format == CouponFormatJson {
+		return writeCouponJson(w, rows)
 	}
-	resp.Coupons = req.CouponsGroup
-	apiResp.ApiRespOK(resp)
-	return nil
+	return writeCouponCsv(w, qrcode, rows)
 }
 
-func writeCouponCsv(filePath, fileName string, data [][]string) error {
-	if err := checkPath(filePath); err != nil {
-		return err
+func writeCouponCsv(w gin.ResponseWriter, qrcode bool, rows []CouponExportRow) error {
+	writer := csv.NewWriter(w)
+	header := []string{"batch_id", "type", "code"}
+	if qrcode {
+		header = append(header, "qrcode_content")
 	}
-	file, err := os.Create(filePath + "/" + fileName)
-	if err != nil {
+	if err := writer.Write(header); err != nil {
 		return err
 	}
-	defer file.Close()
-	writer := csv.NewWriter(file)
-	writer.Comma = ','
-	writer.Write([]string{"qrcode_content"})
-	for i, _ := range data {
-		writer.Write(data[i])
+	for i, v := range rows {
+		record := []string{v.BatchId, strconv.Itoa(int(v.CouponType)), v.Code}
+		if qrcode {
+			record = append(record, v.QrcodeContent)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		if (i+1)%couponFlushRows == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+			w.Flush()
+		}
 	}
 	writer.Flush()
-	return nil
+	return writer.Error()
 }
 
-func randStr(n uint8) string {
-	rand.Seed(time.Now().UnixNano())
+func writeCouponJson(w gin.ResponseWriter, rows []CouponExportRow) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i, v := range rows {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			b = append([]byte(","), b...)
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if (i+1)%couponFlushRows == 0 {
+			w.Flush()
+		}
+	}
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
+// newCouponBatchId is the create day followed by 8 random hex chars
+func newCouponBatchId() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("rand.Read err: %s", err.Error())
+	}
+	return fmt.Sprintf("%s-%s", time.Now().Format("20060102"), hex.EncodeToString(b)), nil
+}
+
+// randStr draws every letter uniformly from crypto/rand
+func randStr(n uint8) (string, error) {
+	max := big.NewInt(int64(len(letters)))
 	b := make([]rune, n)
 	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
+		index, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = letters[index.Int64()]
 	}
-	return string(b)
+	return string(b), nil
 }
+
 func couponEncry(str, salt string) string {
 	hash := sha256.New()
 	hash.Write([]byte(str + salt))
 	hashed := hash.Sum(nil)
 	return hex.EncodeToString(hashed)
 }
-func checkPath(path string) error {
-	_, err := os.Stat(path)
-	if err != nil {
-		if os.IsExist(err) {
-			return nil
-		}
-		err := os.MkdirAll(path, 0766)
-		if err != nil {
-			return err
-		}
-		return nil
-	}
-	return nil
-}
@@ -0,0 +1,56 @@
+package handle
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRandStr(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		code, err := randStr(8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != 8 || strings.Trim(code, string(letters)) != "" {
+			t.Fatalf("randStr: %s", code)
+		}
+		if seen[code] {
+			t.Fatalf("randStr repeated %s", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestWriteCoupons(t *testing.T) {
+	rows := []CouponExportRow{
+		{BatchId: "20261019-00000001", CouponType: 1, Code: "AAAA", QrcodeContent: "https://d.id/AAAA"},
+		{BatchId: "20261019-00000001", CouponType: 1, Code: "BBBB", QrcodeContent: "https://d.id/BBBB"},
+	}
+
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	if err := writeCoupons(ctx.Writer, CouponFormatCsv, true, rows); err != nil {
+		t.Fatal(err)
+	}
+	want := "batch_id,type,code,qrcode_content\n20261019-00000001,1,AAAA,https://d.id/AAAA\n20261019-00000001,1,BBBB,https://d.id/BBBB\n"
+	if rec.Body.String() != want {
+		t.Fatalf("csv: %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(rec)
+	if err := writeCoupons(ctx.Writer, CouponFormatJson, false, rows); err != nil {
+		t.Fatal(err)
+	}
+	var got []CouponExportRow
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err, rec.Body.String())
+	}
+	if len(got) != 2 || got[1] != rows[1] {
+		t.Fatalf("json: %+v", got)
+	}
+}
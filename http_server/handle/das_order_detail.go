@@ -1,6 +1,7 @@
 package handle
 
 import (
+	"context"
 	"das_register_server/tables"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
@@ -44,18 +45,18 @@ func (h *HttpHandle) DasOrderDetail(ctx *gin.Context) {
 	}
 	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
 
-	if err = h.doDasOrderDetail(&req, &apiResp); err != nil {
+	if err = h.doDasOrderDetail(ctx.Request.Context(), &req, &apiResp); err != nil {
 		log.Error("doDasOrderDetail err:", err.Error(), funcName, clientIp, ctx)
 	}
 
 	ctx.JSON(http.StatusOK, apiResp)
 }
 
-func (h *HttpHandle) doDasOrderDetail(req *ReqDasOrderDetail, apiResp *api_code.ApiResp) error {
+func (h *HttpHandle) doDasOrderDetail(ctx context.Context, req *ReqDasOrderDetail, apiResp *api_code.ApiResp) error {
 	var resp RespDasOrderDetail
 	resp.OrderDetailList = make([]DasOrderDetail, 0)
 
-	list, err := h.dbDao.GetOrderListByOrderIds(req.OrderIdList)
+	list, err := h.dbDao.WithContext(ctx).GetOrderListByOrderIds(req.OrderIdList)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search order list fail")
 		return fmt.Errorf("GetOrderListByOrderIds err: %s", err.Error())
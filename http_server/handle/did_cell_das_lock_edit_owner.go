@@ -5,6 +5,7 @@ import (
 	"context"
 	"das_register_server/config"
 	"das_register_server/internal"
+	"das_register_server/signer"
 	"das_register_server/tables"
 	"encoding/json"
 	"fmt"
@@ -101,7 +102,7 @@ func (h *HttpHandle) doDidCellDasLockEditOwner(ctx context.Context, req *ReqDidC
 		return nil
 	}
 
-	//addrHexFrom, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	//addrHexFrom, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	//if err != nil {
 	//	apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "address is invalid")
 	//	return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -119,7 +120,7 @@ func (h *HttpHandle) doDidCellDasLockEditOwner(ctx context.Context, req *ReqDidC
 			Key:      req.RawParam.ReceiverAddress,
 		},
 	}
-	addrHexTo, err := toCTA.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHexTo, err := toCTA.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeInvalidTargetAddress, "receiver address is invalid")
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -133,7 +134,7 @@ func (h *HttpHandle) doDidCellDasLockEditOwner(ctx context.Context, req *ReqDidC
 	if err := h.checkSystemUpgrade(apiResp); err != nil {
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(http_api.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
@@ -141,7 +142,7 @@ func (h *HttpHandle) doDidCellDasLockEditOwner(ctx context.Context, req *ReqDidC
 	var didCellOutPoint *types.OutPoint
 	var editOwnerLock, normalCellScript *types.Script
 
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get account info")
 		return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
@@ -159,7 +160,7 @@ func (h *HttpHandle) doDidCellDasLockEditOwner(ctx context.Context, req *ReqDidC
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "account is not a dob")
 		return nil
 	}
-	didAccount, err := h.dbDao.GetDidAccountByAccountIdWithoutArgs(accountId)
+	didAccount, err := h.dbDao.WithContext(ctx).GetDidAccountByAccountIdWithoutArgs(accountId)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get did cell info")
 		return fmt.Errorf("GetDidAccountByAccountId err: %s", err.Error())
@@ -179,7 +180,7 @@ func (h *HttpHandle) doDidCellDasLockEditOwner(ctx context.Context, req *ReqDidC
 	}
 	editOwnerLock = addrHexTo.ParsedAddress.Script
 
-	parseSvrAddr, err := address.Parse(config.Cfg.Server.PayServerAddress)
+	parseSvrAddr, err := address.Parse(signer.PayAddress())
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeError500, err.Error())
 		return fmt.Errorf("address.Parse err: %s", err.Error())
@@ -205,7 +206,7 @@ func (h *HttpHandle) doDidCellDasLockEditOwner(ctx context.Context, req *ReqDidC
 		ChainType:  formHex.ChainType,
 		Address:    formHex.AddressHex,
 		Account:    req.Account,
-		EvmChainId: req.GetChainId(config.Cfg.Server.Net),
+		EvmChainId: req.GetChainId(config.Cfg().Server.Net),
 	}
 	if _, si, err := h.buildTx(ctx, &reqBuild, txParams); err != nil {
 		checkBuildTxErr(err, apiResp)
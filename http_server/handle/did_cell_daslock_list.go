@@ -69,7 +69,7 @@ func (h *HttpHandle) doDidCellDasLockList(ctx context.Context, req *ReqDidCellDa
 	var resp RespDidCellDasLockList
 	resp.List = make([]DidAccount, 0)
 
-	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "address invalid")
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -85,7 +85,7 @@ func (h *HttpHandle) doDidCellDasLockList(ctx context.Context, req *ReqDidCellDa
 	}
 	args := common.Bytes2Hex(dasLock.Args)
 	codeHash := dasLock.CodeHash.String()
-	list, err := h.dbDao.GetDasLockDidCellList(args, codeHash, req.GetLimit(), req.GetOffset())
+	list, err := h.dbDao.WithContext(ctx).GetDasLockDidCellList(args, codeHash, req.GetLimit(), req.GetOffset())
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get did account list")
 		return fmt.Errorf("GetDasLockDidCellList err: %s", err.Error())
@@ -99,7 +99,7 @@ func (h *HttpHandle) doDidCellDasLockList(ctx context.Context, req *ReqDidCellDa
 		resp.List = append(resp.List, didAcc)
 	}
 
-	count, err := h.dbDao.GetDasLockDidCellListTotal(args, codeHash)
+	count, err := h.dbDao.WithContext(ctx).GetDasLockDidCellListTotal(args, codeHash)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get did account count")
 		return fmt.Errorf("GetDasLockDidCellListTotal err: %s", err.Error())
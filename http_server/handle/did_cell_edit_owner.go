@@ -5,6 +5,7 @@ import (
 	"context"
 	"das_register_server/config"
 	"das_register_server/internal"
+	"das_register_server/signer"
 	"das_register_server/tables"
 	"das_register_server/timer"
 	"das_register_server/unipay"
@@ -91,7 +92,7 @@ func (h *HttpHandle) doDidCellEditOwner(ctx context.Context, req *ReqDidCellEdit
 	var resp RespDidCellEditOwner
 
 	req.Account = strings.ToLower(req.Account)
-	addrHexFrom, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHexFrom, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "address is invalid")
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -109,7 +110,7 @@ func (h *HttpHandle) doDidCellEditOwner(ctx context.Context, req *ReqDidCellEdit
 			Key:      req.RawParam.ReceiverAddress,
 		},
 	}
-	addrHexTo, err := toCTA.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHexTo, err := toCTA.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeInvalidTargetAddress, "receiver address is invalid")
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -151,7 +152,7 @@ func (h *HttpHandle) doDidCellEditOwner(ctx context.Context, req *ReqDidCellEdit
 	if err := h.checkSystemUpgrade(apiResp); err != nil {
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(http_api.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
@@ -159,7 +160,7 @@ func (h *HttpHandle) doDidCellEditOwner(ctx context.Context, req *ReqDidCellEdit
 	var didCellOutPoint, accountCellOutPoint *types.OutPoint
 	var editOwnerLock, normalCellScript *types.Script
 
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get account info")
 		return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
@@ -180,7 +181,7 @@ func (h *HttpHandle) doDidCellEditOwner(ctx context.Context, req *ReqDidCellEdit
 			return nil
 		}
 	} else if acc.Status == tables.AccountStatusOnUpgrade {
-		didAccount, err := h.dbDao.GetDidAccountByAccountIdWithoutArgs(accountId)
+		didAccount, err := h.dbDao.WithContext(ctx).GetDidAccountByAccountIdWithoutArgs(accountId)
 		if err != nil {
 			apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get did cell info")
 			return fmt.Errorf("GetDidAccountByAccountId err: %s", err.Error())
@@ -223,7 +224,7 @@ func (h *HttpHandle) doDidCellEditOwner(ctx context.Context, req *ReqDidCellEdit
 		return nil
 	}
 
-	parseSvrAddr, err := address.Parse(config.Cfg.Server.PayServerAddress)
+	parseSvrAddr, err := address.Parse(signer.PayAddress())
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeError500, err.Error())
 		return fmt.Errorf("address.Parse err: %s", err.Error())
@@ -282,14 +283,14 @@ func (h *HttpHandle) doDidCellEditOwner(ctx context.Context, req *ReqDidCellEdit
 		premiumAmount := decimal.Zero
 
 		if req.PayTokenId == tables.TokenIdStripeUSD {
-			premiumPercentage = config.Cfg.Stripe.PremiumPercentage
-			premiumBase = config.Cfg.Stripe.PremiumBase
+			premiumPercentage = config.Cfg().Stripe.PremiumPercentage
+			premiumBase = config.Cfg().Stripe.PremiumBase
 			premiumAmount = amountTotalPayToken
 			amountTotalPayToken = amountTotalPayToken.Mul(premiumPercentage.Add(decimal.NewFromInt(1))).Add(premiumBase.Mul(decimal.NewFromInt(100)))
 			amountTotalPayToken = decimal.NewFromInt(amountTotalPayToken.Ceil().IntPart())
 			premiumAmount = amountTotalPayToken.Sub(premiumAmount)
 		}
-		res, err := unipay.CreateOrder(unipay.ReqOrderCreate{
+		res, err := unipay.CreateOrder(ctx, unipay.ReqOrderCreate{
 			ChainTypeAddress:  req.ChainTypeAddress,
 			BusinessId:        unipay.BusinessIdDasRegisterSvr,
 			Amount:            amountTotalPayToken,
@@ -350,7 +351,7 @@ func (h *HttpHandle) doDidCellEditOwner(ctx context.Context, req *ReqDidCellEdit
 		resp.ContractAddress = res.ContractAddress
 		resp.ClientSecret = res.ClientSecret
 
-		if err := h.dbDao.CreateOrderWithPayment(order, paymentInfo); err != nil {
+		if err := h.dbDao.WithContext(ctx).CreateOrderWithPayment(order, paymentInfo); err != nil {
 			log.Error(ctx, "CreateOrder err:", err.Error())
 			apiResp.ApiRespErr(http_api.ApiCodeError500, "create order fail")
 			return fmt.Errorf("CreateOrderWithPayment err: %s", err.Error())
@@ -363,7 +364,7 @@ func (h *HttpHandle) doDidCellEditOwner(ctx context.Context, req *ReqDidCellEdit
 		ChainType:  addrHexFrom.ChainType,
 		Address:    addrHexFrom.AddressHex,
 		Account:    req.Account,
-		EvmChainId: req.GetChainId(config.Cfg.Server.Net),
+		EvmChainId: req.GetChainId(config.Cfg().Server.Net),
 	}
 	if didCellTx, si, err := h.buildTx(ctx, &reqBuild, txParams); err != nil {
 		checkBuildTxErr(err, apiResp)
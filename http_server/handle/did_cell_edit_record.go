@@ -81,7 +81,7 @@ func (h *HttpHandle) doDidCellEditRecord(ctx context.Context, req *ReqDidCellEdi
 	var resp RespDidCellEditRecord
 
 	req.Account = strings.ToLower(req.Account)
-	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "address invalid")
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -93,7 +93,7 @@ func (h *HttpHandle) doDidCellEditRecord(ctx context.Context, req *ReqDidCellEdi
 	if err := h.checkSystemUpgrade(apiResp); err != nil {
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
@@ -102,7 +102,7 @@ func (h *HttpHandle) doDidCellEditRecord(ctx context.Context, req *ReqDidCellEdi
 	var accountCellOutPoint *types.OutPoint
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
 
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
 		return fmt.Errorf("SearchAccount err: %s", err.Error())
@@ -124,7 +124,7 @@ func (h *HttpHandle) doDidCellEditRecord(ctx context.Context, req *ReqDidCellEdi
 			return nil
 		}
 	} else if acc.Status == tables.AccountStatusOnUpgrade {
-		didAccount, err := h.dbDao.GetDidAccountByAccountIdWithoutArgs(accountId)
+		didAccount, err := h.dbDao.WithContext(ctx).GetDidAccountByAccountIdWithoutArgs(accountId)
 		if err != nil {
 			apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get did cell info")
 			return fmt.Errorf("GetDidAccountByAccountId err: %s", err.Error())
@@ -199,7 +199,7 @@ func (h *HttpHandle) doDidCellEditRecord(ctx context.Context, req *ReqDidCellEdi
 	reqBuild.Account = req.Account
 	reqBuild.ChainType = addrHex.ChainType
 	reqBuild.Address = addrHex.AddressHex
-	reqBuild.EvmChainId = req.GetChainId(config.Cfg.Server.Net)
+	reqBuild.EvmChainId = req.GetChainId(config.Cfg().Server.Net)
 
 	records := witness.ConvertToCellRecords(editRecords)
 	recordsBys := records.AsSlice()
@@ -77,7 +77,7 @@ func (h *HttpHandle) doDidCellList(ctx context.Context, req *ReqDidCellList, api
 	resp.List = make([]DidAccount, 0)
 
 	req.Keyword = strings.ToLower(req.Keyword)
-	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "address invalid")
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -86,7 +86,7 @@ func (h *HttpHandle) doDidCellList(ctx context.Context, req *ReqDidCellList, api
 		return nil
 	}
 	args := common.Bytes2Hex(addrHex.ParsedAddress.Script.Args)
-	list, err := h.dbDao.GetDidAccountList(args, req.Keyword, req.GetLimit(), req.GetOffset())
+	list, err := h.dbDao.WithContext(ctx).GetDidAccountList(args, req.Keyword, req.GetLimit(), req.GetOffset())
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get did account list")
 		return fmt.Errorf("GetDidAccountList err: %s", err.Error())
@@ -100,7 +100,7 @@ func (h *HttpHandle) doDidCellList(ctx context.Context, req *ReqDidCellList, api
 		resp.List = append(resp.List, didAcc)
 	}
 
-	count, err := h.dbDao.GetDidAccountListTotal(args, req.Keyword)
+	count, err := h.dbDao.WithContext(ctx).GetDidAccountListTotal(args, req.Keyword)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get did account count")
 		return fmt.Errorf("GetDidAccountListTotal err: %s", err.Error())
@@ -86,7 +86,7 @@ func (h *HttpHandle) doDidCellRecyclableList(ctx context.Context, req *ReqDidCel
 	resp.List = make([]DidCellRecyclable, 0)
 
 	req.Keyword = strings.ToLower(req.Keyword)
-	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "address invalid")
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -131,7 +131,7 @@ func (h *HttpHandle) doDidCellRecyclableList(ctx context.Context, req *ReqDidCel
 
 	//
 	args := common.Bytes2Hex(addrHex.ParsedAddress.Script.Args)
-	list, err := h.dbDao.GetDidCellRecyclableList(args, req.Keyword, req.GetLimit(), req.GetOffset(), expiredAt)
+	list, err := h.dbDao.WithContext(ctx).GetDidCellRecyclableList(args, req.Keyword, req.GetLimit(), req.GetOffset(), expiredAt)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get did account list")
 		return fmt.Errorf("GetDidCellRecyclableList err: %s", err.Error())
@@ -146,7 +146,7 @@ func (h *HttpHandle) doDidCellRecyclableList(ctx context.Context, req *ReqDidCel
 		accounts = append(accounts, v.Account)
 	}
 
-	count, err := h.dbDao.GetDidCellRecyclableListTotal(args, req.Keyword, expiredAt)
+	count, err := h.dbDao.WithContext(ctx).GetDidCellRecyclableListTotal(args, req.Keyword, expiredAt)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get did account count")
 		return fmt.Errorf("GetDidAccountListTotal err: %s", err.Error())
@@ -154,7 +154,7 @@ func (h *HttpHandle) doDidCellRecyclableList(ctx context.Context, req *ReqDidCel
 	resp.Total = count
 
 	// recycle ing
-	pendingList, err := h.dbDao.GetRecyclingByAddr(addrHex.ChainType, addrHex.AddressHex, accounts)
+	pendingList, err := h.dbDao.WithContext(ctx).GetRecyclingByAddr(addrHex.ChainType, addrHex.AddressHex, accounts)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get recycling list")
 		return fmt.Errorf("GetRecyclingByAddr err: %s", err.Error())
@@ -70,7 +70,7 @@ func (h *HttpHandle) doDidCellRecycle(ctx context.Context, req *ReqDidCellRecycl
 	var resp RespDidCellRecycle
 
 	req.Account = strings.ToLower(req.Account)
-	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "address invalid")
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -81,7 +81,7 @@ func (h *HttpHandle) doDidCellRecycle(ctx context.Context, req *ReqDidCellRecycl
 	args := common.Bytes2Hex(addrHex.ParsedAddress.Script.Args)
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
 
-	didAccount, err := h.dbDao.GetDidAccountByAccountId(accountId, args)
+	didAccount, err := h.dbDao.WithContext(ctx).GetDidAccountByAccountId(accountId, args)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get did account info")
 		return fmt.Errorf("GetDidAccountByAccountId err: %s", err.Error())
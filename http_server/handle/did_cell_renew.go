@@ -6,6 +6,7 @@ import (
 	"das_register_server/http_server/api_code"
 	"das_register_server/internal"
 	"das_register_server/notify"
+	"das_register_server/signer"
 	"das_register_server/tables"
 	"das_register_server/unipay"
 	"encoding/json"
@@ -86,7 +87,7 @@ func (h *HttpHandle) DidCellRenew(ctx *gin.Context) {
 func (h *HttpHandle) doDidCellRenew(ctx context.Context, req *ReqDidCellRenew, apiResp *http_api.ApiResp) error {
 	var resp RespDidCellRenew
 
-	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "FormatChainTypeAddress err")
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -110,18 +111,18 @@ func (h *HttpHandle) doDidCellRenew(ctx context.Context, req *ReqDidCellRenew, a
 	if err := h.checkSystemUpgrade(apiResp); err != nil {
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
 
 	// check renew info
-	if req.RenewYears < 1 || req.RenewYears > config.Cfg.Das.MaxRegisterYears {
+	if req.RenewYears < 1 || req.RenewYears > config.Cfg().Das.MaxRegisterYears {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("renew years[%d] invalid", req.RenewYears))
 		return nil
 	}
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		log.Error(ctx, "GetAccountInfoByAccountId err: ", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account fail")
@@ -153,7 +154,7 @@ func (h *HttpHandle) doDidCellRenew(ctx context.Context, req *ReqDidCellRenew, a
 	// did cell
 	var txParams *txbuilder.BuildTransactionParams
 	if acc.Status == tables.AccountStatusOnUpgrade {
-		didAcc, err := h.dbDao.GetDidAccountByAccountIdWithoutArgs(accountId)
+		didAcc, err := h.dbDao.WithContext(ctx).GetDidAccountByAccountIdWithoutArgs(accountId)
 		if err != nil {
 			apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to get did account")
 			return nil
@@ -161,7 +162,7 @@ func (h *HttpHandle) doDidCellRenew(ctx context.Context, req *ReqDidCellRenew, a
 			apiResp.ApiRespErr(api_code.ApiCodeAccountNotExist, "did account not exist")
 			return nil
 		}
-		parseSvrAddr, err := address.Parse(config.Cfg.Server.PayServerAddress)
+		parseSvrAddr, err := address.Parse(signer.PayAddress())
 		if err != nil {
 			apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
 			return fmt.Errorf("address.Parse err: %s", err.Error())
@@ -251,7 +252,7 @@ func (h *HttpHandle) doDidCellRenew(ctx context.Context, req *ReqDidCellRenew, a
 	var order tables.TableDasOrderInfo
 	var paymentInfo tables.TableDasOrderPayInfo
 
-	if config.Cfg.Server.UniPayUrl == "" {
+	if config.Cfg().Server.UniPayUrl == "" {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "UniPayUrl is nil")
 		return fmt.Errorf("UniPayUrl is nil")
 	}
@@ -259,14 +260,14 @@ func (h *HttpHandle) doDidCellRenew(ctx context.Context, req *ReqDidCellRenew, a
 	premiumBase := decimal.Zero
 	premiumAmount := decimal.Zero
 	if req.PayTokenId == tables.TokenIdStripeUSD {
-		premiumPercentage = config.Cfg.Stripe.PremiumPercentage
-		premiumBase = config.Cfg.Stripe.PremiumBase
+		premiumPercentage = config.Cfg().Stripe.PremiumPercentage
+		premiumBase = config.Cfg().Stripe.PremiumBase
 		premiumAmount = amountTotalPayToken
 		amountTotalPayToken = amountTotalPayToken.Mul(premiumPercentage.Add(decimal.NewFromInt(1))).Add(premiumBase.Mul(decimal.NewFromInt(100)))
 		amountTotalPayToken = decimal.NewFromInt(amountTotalPayToken.Ceil().IntPart())
 		premiumAmount = amountTotalPayToken.Sub(premiumAmount)
 	}
-	res, err := unipay.CreateOrder(unipay.ReqOrderCreate{
+	res, err := unipay.CreateOrder(ctx, unipay.ReqOrderCreate{
 		ChainTypeAddress:  req.ChainTypeAddress,
 		BusinessId:        unipay.BusinessIdDasRegisterSvr,
 		Amount:            amountTotalPayToken,
@@ -340,7 +341,7 @@ func (h *HttpHandle) doDidCellRenew(ctx context.Context, req *ReqDidCellRenew, a
 			ChainType:  0,
 			Address:    req.KeyInfo.Key,
 			Account:    req.Account,
-			EvmChainId: req.GetChainId(config.Cfg.Server.Net),
+			EvmChainId: req.GetChainId(config.Cfg().Server.Net),
 		}
 		if didCellTx, si, err := h.buildTx(ctx, &reqBuild, txParams); err != nil {
 			checkBuildTxErr(err, apiResp)
@@ -352,7 +353,7 @@ func (h *HttpHandle) doDidCellRenew(ctx context.Context, req *ReqDidCellRenew, a
 		order.IsDidCell = tables.IsDidCellYes
 	}
 
-	if err := h.dbDao.CreateOrderWithPayment(order, paymentInfo); err != nil {
+	if err := h.dbDao.WithContext(ctx).CreateOrderWithPayment(order, paymentInfo); err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "create order fail")
 		return fmt.Errorf("CreateOrderWithPayment err: %s", err.Error())
 	}
@@ -360,7 +361,7 @@ func (h *HttpHandle) doDidCellRenew(ctx context.Context, req *ReqDidCellRenew, a
 	// notify
 	go func() {
 		notify.SendLarkOrderNotify(&notify.SendLarkOrderNotifyParam{
-			Key:        config.Cfg.Notify.LarkRegisterKey,
+			Key:        config.Cfg().Notify.LarkRegisterKey,
 			Action:     "renew account order",
 			Account:    order.Account,
 			OrderId:    order.OrderId,
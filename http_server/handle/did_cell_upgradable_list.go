@@ -90,7 +90,7 @@ func (h *HttpHandle) doDidCellUpgradableList(ctx context.Context, req *ReqDidCel
 	resp.List = make([]UpgradableAccount, 0)
 
 	req.Keyword = strings.ToLower(req.Keyword)
-	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "address invalid")
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -99,7 +99,7 @@ func (h *HttpHandle) doDidCellUpgradableList(ctx context.Context, req *ReqDidCel
 		return nil
 	}
 
-	list, err := h.dbDao.GetAccountUpgradableList(addrHex.ChainType, addrHex.AddressHex, req.Keyword, req.GetLimit(), req.GetOffset())
+	list, err := h.dbDao.WithContext(ctx).GetAccountUpgradableList(addrHex.ChainType, addrHex.AddressHex, req.Keyword, req.GetLimit(), req.GetOffset())
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get account list")
 		return fmt.Errorf("GetAccountUpgradableList err: %s", err.Error())
@@ -117,7 +117,7 @@ func (h *HttpHandle) doDidCellUpgradableList(ctx context.Context, req *ReqDidCel
 		accountIds = append(accountIds, v.AccountId)
 	}
 
-	count, err := h.dbDao.GetAccountUpgradableListTotal(addrHex.ChainType, addrHex.AddressHex, req.Keyword)
+	count, err := h.dbDao.WithContext(ctx).GetAccountUpgradableListTotal(addrHex.ChainType, addrHex.AddressHex, req.Keyword)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get account count")
 		return fmt.Errorf("GetAccountUpgradableListTotal err: %s", err.Error())
@@ -125,7 +125,7 @@ func (h *HttpHandle) doDidCellUpgradableList(ctx context.Context, req *ReqDidCel
 	resp.Total = count
 
 	// status
-	orders, err := h.dbDao.GetUpgradeOrder(accountIds)
+	orders, err := h.dbDao.WithContext(ctx).GetUpgradeOrder(accountIds)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "Failed to get upgrade order")
 		return fmt.Errorf("GetUpgradeOrder err: %s", err.Error())
@@ -72,7 +72,7 @@ func (h *HttpHandle) doDidCellUpgradePrice(ctx context.Context, req *ReqDidCellU
 	var resp RespDidCellUpgradePrice
 
 	req.Account = strings.ToLower(req.Account)
-	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "address is invalid")
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -82,7 +82,7 @@ func (h *HttpHandle) doEditManager(ctx context.Context, req *ReqEditManager, api
 	var resp RespEditManager
 	req.Account = strings.ToLower(req.Account)
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -96,7 +96,7 @@ func (h *HttpHandle) doEditManager(ctx context.Context, req *ReqEditManager, api
 				Key:      req.RawParam.ManagerAddress,
 			},
 		}
-		managerHex, err := chainTypeAddress.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+		managerHex, err := chainTypeAddress.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 		if err != nil {
 			apiResp.ApiRespErr(api_code.ApiCodeInvalidTargetAddress, "manager address NormalToHex err")
 			return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -133,7 +133,7 @@ func (h *HttpHandle) doEditManager(ctx context.Context, req *ReqEditManager, api
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
 
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
@@ -144,7 +144,7 @@ func (h *HttpHandle) doEditManager(ctx context.Context, req *ReqEditManager, api
 	}
 
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
 		return fmt.Errorf("SearchAccount err: %s", err.Error())
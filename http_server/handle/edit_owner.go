@@ -83,7 +83,7 @@ func (h *HttpHandle) doEditOwner(ctx context.Context, req *ReqEditOwner, apiResp
 
 	req.Account = strings.ToLower(req.Account)
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -98,7 +98,7 @@ func (h *HttpHandle) doEditOwner(ctx context.Context, req *ReqEditOwner, apiResp
 				Key:      req.RawParam.ReceiverAddress,
 			},
 		}
-		ownerHex, err := chainTypeAddress.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+		ownerHex, err := chainTypeAddress.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 		if err != nil {
 			apiResp.ApiRespErr(api_code.ApiCodeInvalidTargetAddress, "owner address NormalToHex err")
 			return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
@@ -135,7 +135,7 @@ func (h *HttpHandle) doEditOwner(ctx context.Context, req *ReqEditOwner, apiResp
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
 
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
@@ -146,7 +146,7 @@ func (h *HttpHandle) doEditOwner(ctx context.Context, req *ReqEditOwner, apiResp
 	}
 
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
 		return fmt.Errorf("SearchAccount err: %s", err.Error())
@@ -90,7 +90,7 @@ func (h *HttpHandle) EditRecords(ctx *gin.Context) {
 func (h *HttpHandle) doEditRecords(ctx context.Context, req *ReqEditRecords, apiResp *api_code.ApiResp) error {
 	var resp RespEditRecords
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -110,7 +110,7 @@ func (h *HttpHandle) doEditRecords(ctx context.Context, req *ReqEditRecords, api
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
 
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
@@ -121,7 +121,7 @@ func (h *HttpHandle) doEditRecords(ctx context.Context, req *ReqEditRecords, api
 	}
 
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
 		return fmt.Errorf("SearchAccount err: %s", err.Error())
@@ -230,7 +230,7 @@ func (h *HttpHandle) doEditRecordsForDidCell(ctx context.Context, req *ReqEditRe
 	}
 	args := common.Bytes2Hex(addrParse.Script.Args)
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	didAccount, err := h.dbDao.GetDidAccountByAccountId(accountId, args)
+	didAccount, err := h.dbDao.WithContext(ctx).GetDidAccountByAccountId(accountId, args)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
 		return fmt.Errorf("SearchAccount err: %s", err.Error())
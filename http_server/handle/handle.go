@@ -6,6 +6,8 @@ import (
 	"das_register_server/config"
 	"das_register_server/dao"
 	"das_register_server/elastic"
+	"das_register_server/event"
+	"das_register_server/reserved"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
@@ -22,43 +24,42 @@ var (
 )
 
 type HttpHandle struct {
-	ctx                    context.Context
-	dbDao                  *dao.DbDao
-	rc                     *cache.RedisCache
-	es                     *elastic.Es
-	dasCore                *core.DasCore
-	dasCache               *dascache.DasCache
-	txBuilderBase          *txbuilder.DasTxBuilderBase
-	serverScript           *types.Script
-	mapReservedAccounts    map[string]struct{}
-	mapUnAvailableAccounts map[string]struct{}
+	ctx           context.Context
+	dbDao         *dao.DbDao
+	rc            *cache.RedisCache
+	es            *elastic.Es
+	dasCore       *core.DasCore
+	dasCache      *dascache.DasCache
+	txBuilderBase *txbuilder.DasTxBuilderBase
+	serverScript  *types.Script
+	reservedList  *reserved.List
+	statusHub     *event.Hub
 }
 
 type HttpHandleParams struct {
-	DbDao                  *dao.DbDao
-	Rc                     *cache.RedisCache
-	Es                     *elastic.Es
-	Ctx                    context.Context
-	DasCore                *core.DasCore
-	DasCache               *dascache.DasCache
-	TxBuilderBase          *txbuilder.DasTxBuilderBase
-	ServerScript           *types.Script
-	MapReservedAccounts    map[string]struct{}
-	MapUnAvailableAccounts map[string]struct{}
+	DbDao         *dao.DbDao
+	Rc            *cache.RedisCache
+	Es            *elastic.Es
+	Ctx           context.Context
+	DasCore       *core.DasCore
+	DasCache      *dascache.DasCache
+	TxBuilderBase *txbuilder.DasTxBuilderBase
+	ServerScript  *types.Script
+	ReservedList  *reserved.List
 }
 
 func Initialize(p HttpHandleParams) *HttpHandle {
 	hh := HttpHandle{
-		dbDao:                  p.DbDao,
-		rc:                     p.Rc,
-		es:                     p.Es,
-		ctx:                    p.Ctx,
-		dasCore:                p.DasCore,
-		dasCache:               p.DasCache,
-		txBuilderBase:          p.TxBuilderBase,
-		serverScript:           p.ServerScript,
-		mapReservedAccounts:    p.MapReservedAccounts,
-		mapUnAvailableAccounts: p.MapUnAvailableAccounts,
+		dbDao:         p.DbDao,
+		rc:            p.Rc,
+		es:            p.Es,
+		ctx:           p.Ctx,
+		dasCore:       p.DasCore,
+		dasCache:      p.DasCache,
+		txBuilderBase: p.TxBuilderBase,
+		serverScript:  p.ServerScript,
+		reservedList:  p.ReservedList,
+		statusHub:     event.NewHub(p.Ctx),
 	}
 	return &hh
 }
@@ -69,7 +70,7 @@ func GetClientIp(ctx *gin.Context) string {
 }
 
 func (h *HttpHandle) checkSystemUpgrade(apiResp *api_code.ApiResp) error {
-	if config.Cfg.Server.IsUpdate {
+	if config.Cfg().Server.IsUpdate {
 		apiResp.ApiRespErr(api_code.ApiCodeSystemUpgrade, "The service is under maintenance, please try again later.")
 		return fmt.Errorf("backend system upgrade")
 	}
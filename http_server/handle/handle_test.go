@@ -42,7 +42,7 @@ func getDasCore(t *testing.T) *core.DasCore {
 		core.WithClient(ckbClient),
 		core.WithDasContractArgs(env.ContractArgs),
 		core.WithDasContractCodeHash(env.ContractCodeHash),
-		core.WithDasNetType(config.Cfg.Server.Net),
+		core.WithDasNetType(config.Cfg().Server.Net),
 		core.WithTHQCodeHash(env.THQCodeHash),
 	}
 	var wg sync.WaitGroup
@@ -2,7 +2,9 @@ package handle
 
 import (
 	"context"
+	"das_register_server/campaign"
 	"das_register_server/config"
+	"das_register_server/dao"
 	"das_register_server/internal"
 	"das_register_server/notify"
 	"das_register_server/tables"
@@ -99,7 +101,7 @@ func (h *HttpHandle) doOrderChange(ctx context.Context, req *ReqOrderChange, api
 		return nil
 	}
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -109,7 +111,7 @@ func (h *HttpHandle) doOrderChange(ctx context.Context, req *ReqOrderChange, api
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
 
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
@@ -127,7 +129,7 @@ func (h *HttpHandle) doOrderChange(ctx context.Context, req *ReqOrderChange, api
 	//}
 
 	// order check
-	if err := h.checkOrderInfo(req.CoinType, req.CrossCoinType, &req.ReqOrderRegisterBase, apiResp); err != nil {
+	if err := h.checkOrderInfo(ctx, req.CoinType, req.CrossCoinType, &req.ReqOrderRegisterBase, apiResp); err != nil {
 		return fmt.Errorf("checkOrderInfo err: %s", err.Error())
 	}
 	if apiResp.ErrNo != api_code.ApiCodeSuccess {
@@ -176,15 +178,48 @@ func (h *HttpHandle) doNewOrder(ctx context.Context, req *ReqOrderChange, apiRes
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
 		return
 	}
+
+	// coupon campaigns
+	campaignOrder := campaign.Order{
+		Action:     tables.CampaignActionRegister,
+		AccountLen: int(accLen),
+		CharSets:   campaign.CharSetsWithoutSuffix(oldOrderContent.AccountCharStr),
+		Years:      req.RegisterYears,
+		ChainType:  req.ChainType,
+		Address:    req.Address,
+		HasInviter: req.InviterAccount != "",
+		AmountUSD:  amountTotalUSD,
+	}
+	campaignRes, err := h.evaluateCampaigns(ctx, campaignOrder)
+	if err != nil {
+		log.Error(ctx, "evaluateCampaigns err: ", err.Error())
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
+		return
+	}
+	if campaignRes.DiscountUSD.IsPositive() {
+		amountTotalUSD = campaignRes.AmountUSD
+		if amountTotalCKB, amountTotalPayToken, err = h.getAmountByUSD(ctx, amountTotalUSD, req.PayTokenId); err != nil {
+			log.Error(ctx, "getAmountByUSD err: ", err.Error())
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
+			return
+		}
+	}
+
 	if amountTotalUSD.Cmp(decimal.Zero) != 1 || amountTotalCKB.Cmp(decimal.Zero) != 1 || amountTotalPayToken.Cmp(decimal.Zero) != 1 {
 		log.Error(ctx, "order amount err:", amountTotalUSD, amountTotalCKB, amountTotalPayToken)
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
 		return
 	}
 	//
-	inviterAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.InviterAccount))
-	if _, ok := config.Cfg.InviterWhitelist[inviterAccountId]; ok {
-		req.ChannelAccount = req.InviterAccount
+	if req.InviterAccount != "" {
+		inviterAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.InviterAccount))
+		if ok, err := h.isReferralChannel(ctx, inviterAccountId); err != nil {
+			log.Error(ctx, "isReferralChannel err:", err.Error())
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search channel account fail")
+			return
+		} else if ok {
+			req.ChannelAccount = req.InviterAccount
+		}
 	}
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
 	orderContent := tables.TableOrderContent{
@@ -194,6 +229,7 @@ func (h *HttpHandle) doNewOrder(ctx context.Context, req *ReqOrderChange, apiRes
 		RegisterYears:  req.RegisterYears,
 		AmountTotalUSD: amountTotalUSD,
 		AmountTotalCKB: amountTotalCKB,
+		Campaigns:      campaignRes.Discounts,
 	}
 	contentDataStr, err := json.Marshal(&orderContent)
 	if err != nil {
@@ -205,7 +241,7 @@ func (h *HttpHandle) doNewOrder(ctx context.Context, req *ReqOrderChange, apiRes
 	var order tables.TableDasOrderInfo
 	var paymentInfo tables.TableDasOrderPayInfo
 	// unipay
-	if config.Cfg.Server.UniPayUrl != "" {
+	if config.Cfg().Server.UniPayUrl != "" {
 		addrNormal, err := h.dasCore.Daf().HexToNormal(core.DasAddressHex{
 			DasAlgorithmId: req.ChainType.ToDasAlgorithmId(true),
 			AddressHex:     req.Address,
@@ -221,14 +257,14 @@ func (h *HttpHandle) doNewOrder(ctx context.Context, req *ReqOrderChange, apiRes
 		premiumBase := decimal.Zero
 		premiumAmount := decimal.Zero
 		if req.PayTokenId == tables.TokenIdStripeUSD {
-			premiumPercentage = config.Cfg.Stripe.PremiumPercentage
-			premiumBase = config.Cfg.Stripe.PremiumBase
+			premiumPercentage = config.Cfg().Stripe.PremiumPercentage
+			premiumBase = config.Cfg().Stripe.PremiumBase
 			premiumAmount = amountTotalPayToken
 			amountTotalPayToken = amountTotalPayToken.Mul(premiumPercentage.Add(decimal.NewFromInt(1))).Add(premiumBase.Mul(decimal.NewFromInt(100)))
 			amountTotalPayToken = decimal.NewFromInt(amountTotalPayToken.Ceil().IntPart())
 			premiumAmount = amountTotalPayToken.Sub(premiumAmount)
 		}
-		res, err := unipay.CreateOrder(unipay.ReqOrderCreate{
+		res, err := unipay.CreateOrder(ctx, unipay.ReqOrderCreate{
 			ChainTypeAddress: core.ChainTypeAddress{
 				Type: "blockchain",
 				KeyInfo: core.KeyInfo{
@@ -329,7 +365,10 @@ func (h *HttpHandle) doNewOrder(ctx context.Context, req *ReqOrderChange, apiRes
 		resp.ReceiptAddress = addr
 	}
 
-	if err := h.dbDao.CreateOrderWithPayment(order, paymentInfo); err != nil {
+	if err := h.dbDao.WithContext(ctx).CreateOrderWithCampaigns(order, paymentInfo, campaignRes.Usages(campaignOrder)); err == dao.ErrCampaignCapReached {
+		apiResp.ApiRespErr(api_code.ApiCodeCouponUsed, "campaign quota reached, please retry")
+		return
+	} else if err != nil {
 		log.Error(ctx, "CreateOrder err:", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "create order fail")
 		return
@@ -337,7 +376,7 @@ func (h *HttpHandle) doNewOrder(ctx context.Context, req *ReqOrderChange, apiRes
 	// notify
 	go func() {
 		notify.SendLarkOrderNotify(&notify.SendLarkOrderNotifyParam{
-			Key:        config.Cfg.Notify.LarkRegisterKey,
+			Key:        config.Cfg().Notify.LarkRegisterKey,
 			Action:     "change register order",
 			Account:    order.Account,
 			OrderId:    order.OrderId,
@@ -351,7 +390,7 @@ func (h *HttpHandle) doNewOrder(ctx context.Context, req *ReqOrderChange, apiRes
 
 func (h *HttpHandle) oldOrderCheck(ctx context.Context, req *ReqOrderChange, apiResp *api_code.ApiResp) (oldOrderContent *tables.TableOrderContent) {
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	order, err := h.dbDao.GetLatestRegisterOrderBySelf(req.ChainType, req.Address, accountId)
+	order, err := h.dbDao.WithContext(ctx).GetLatestRegisterOrderBySelf(req.ChainType, req.Address, accountId)
 	if err != nil {
 		log.Error(ctx, "GetLatestRegisterOrderBySelf err: ", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search order fail")
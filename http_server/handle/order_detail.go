@@ -4,6 +4,7 @@ import (
 	"context"
 	"das_register_server/config"
 	"das_register_server/tables"
+	"das_register_server/tracing"
 	"das_register_server/unipay"
 	"encoding/json"
 	"fmt"
@@ -94,8 +95,9 @@ func (h *HttpHandle) doOrderDetail(ctx context.Context, req *ReqOrderDetail, api
 		return nil
 	}
 	req.Account = strings.ToLower(req.Account)
+	tracing.SetAttributes(ctx, tracing.AttrAccount.String(req.Account))
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -103,7 +105,7 @@ func (h *HttpHandle) doOrderDetail(ctx context.Context, req *ReqOrderDetail, api
 	req.ChainType, req.Address = addressHex.ChainType, addressHex.AddressHex
 
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	order, err := h.dbDao.GetLatestRegisterOrderBySelf(req.ChainType, req.Address, accountId)
+	order, err := h.dbDao.WithContext(ctx).GetLatestRegisterOrderBySelf(req.ChainType, req.Address, accountId)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search order fail")
 		return fmt.Errorf("GetLatestOrderBySelf err: %s", err.Error())
@@ -126,7 +128,7 @@ func (h *HttpHandle) doOrderDetail(ctx context.Context, req *ReqOrderDetail, api
 	switch order.PayTokenId {
 	case tables.TokenIdStripeUSD, tables.TokenIdTrc20USDT,
 		tables.TokenIdBep20USDT, tables.TokenIdErc20USDT:
-		unipayRes, err := unipay.GetOrderInfo(unipay.ReqOrderInfo{
+		unipayRes, err := unipay.GetOrderInfo(ctx, unipay.ReqOrderInfo{
 			BusinessId: unipay.BusinessIdDasRegisterSvr,
 			OrderId:    order.OrderId,
 		})
@@ -1,6 +1,7 @@
 package handle
 
 import (
+	"context"
 	"das_register_server/config"
 	"das_register_server/dao"
 	"das_register_server/notify"
@@ -33,7 +34,7 @@ func (h *HttpHandle) RpcOrderInfo(p json.RawMessage, apiResp *api_code.ApiResp)
 		return
 	}
 
-	if err = h.doOrderInfo(&req[0], apiResp); err != nil {
+	if err = h.doOrderInfo(h.ctx, &req[0], apiResp); err != nil {
 		log.Error("doOrderInfo err:", err.Error())
 	}
 }
@@ -55,17 +56,17 @@ func (h *HttpHandle) OrderInfo(ctx *gin.Context) {
 	}
 	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
 
-	if err = h.doOrderInfo(&req, &apiResp); err != nil {
+	if err = h.doOrderInfo(ctx.Request.Context(), &req, &apiResp); err != nil {
 		log.Error("doOrderInfo err:", err.Error(), funcName, clientIp, ctx)
 	}
 
 	ctx.JSON(http.StatusOK, apiResp)
 }
 
-func (h *HttpHandle) doOrderInfo(req *ReqOrderInfo, apiResp *api_code.ApiResp) error {
+func (h *HttpHandle) doOrderInfo(ctx context.Context, req *ReqOrderInfo, apiResp *api_code.ApiResp) error {
 	var resp RespOrderInfo
 	// register info
-	list, err := h.dbDao.GetAccountNumRegisterNum()
+	list, err := h.dbDao.WithContext(ctx).GetAccountNumRegisterNum()
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
 		return fmt.Errorf("GetAccountNumRegisterNum err: %s", err.Error())
@@ -73,21 +74,21 @@ func (h *HttpHandle) doOrderInfo(req *ReqOrderInfo, apiResp *api_code.ApiResp) e
 	msg := GetAccountNumRegisterNumStr(list)
 
 	// order info
-	listOrder, err := h.dbDao.GetOrderTotalAmount()
+	listOrder, err := h.dbDao.WithContext(ctx).GetOrderTotalAmount()
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
 		return fmt.Errorf("GetOrderTotalAmount err: %s", err.Error())
 	}
 
 	// refund
-	listRefund, err := h.dbDao.GetOrderRefundTotalAmount()
+	listRefund, err := h.dbDao.WithContext(ctx).GetOrderRefundTotalAmount()
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
 		return fmt.Errorf("GetOrderRefundTotalAmount err: %s", err.Error())
 	}
 	msg2 := GetOrderAmountStr(listOrder, listRefund)
 	res := fmt.Sprintf("%s\n%s", msg, msg2)
-	notify.SendLarkTextNotify(config.Cfg.Notify.LarkErrorKey, "Das Info", res)
+	notify.SendLarkTextNotify(config.Cfg().Notify.LarkErrorKey, "Das Info", res)
 	apiResp.ApiRespOK(resp)
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"das_register_server/config"
 	"das_register_server/tables"
+	"das_register_server/tracing"
 	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
@@ -77,15 +78,16 @@ func (h *HttpHandle) doOrderPayHash(ctx context.Context, req *ReqOrderPayHash, a
 		return nil
 	}
 	req.Account = strings.ToLower(req.Account)
+	tracing.SetAttributes(ctx, tracing.AttrAccount.String(req.Account), tracing.AttrOrderId.String(req.OrderId))
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
 	}
 	req.ChainType, req.Address = addressHex.ChainType, addressHex.AddressHex
 
-	order, err := h.dbDao.GetOrderByOrderId(req.OrderId)
+	order, err := h.dbDao.WithContext(ctx).GetOrderByOrderId(req.OrderId)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search order fail")
 		return fmt.Errorf("GetOrderByOrderId err: %s", err.Error())
@@ -112,7 +114,7 @@ func (h *HttpHandle) doOrderPayHash(ctx context.Context, req *ReqOrderPayHash, a
 		Timestamp:    time.Now().UnixNano() / 1e6,
 	}
 
-	if err := h.dbDao.CreateOrderPayInfo(&payInfo); err != nil {
+	if err := h.dbDao.WithContext(ctx).CreateOrderPayInfo(&payInfo); err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "update hash fail")
 		return fmt.Errorf("CreateOrderPayInfo err: %s", err.Error())
 	}
@@ -2,11 +2,14 @@ package handle
 
 import (
 	"context"
+	"das_register_server/campaign"
 	"das_register_server/config"
+	"das_register_server/dao"
 	"das_register_server/internal"
 	"das_register_server/notify"
 	"das_register_server/tables"
 	"das_register_server/timer"
+	"das_register_server/tracing"
 	"das_register_server/unipay"
 	"encoding/json"
 	"fmt"
@@ -169,12 +172,13 @@ func (h *HttpHandle) doOrderRegister(ctx context.Context, req *ReqOrderRegister,
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
 		return nil
 	}
+	tracing.SetAttributes(ctx, tracing.AttrAccount.String(req.Account))
 	if yes := req.PayTokenId.IsTokenIdCkbInternal(); yes {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("pay token id [%s] invalid", req.PayTokenId))
 		return nil
 	}
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -190,7 +194,7 @@ func (h *HttpHandle) doOrderRegister(ctx context.Context, req *ReqOrderRegister,
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
 
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
@@ -205,7 +209,7 @@ func (h *HttpHandle) doOrderRegister(ctx context.Context, req *ReqOrderRegister,
 
 	// check un pay
 	maxUnPayCount := int64(300)
-	if unPayCount, err := h.dbDao.GetUnPayOrderCount(req.ChainType, req.Address); err != nil {
+	if unPayCount, err := h.dbDao.WithContext(ctx).GetUnPayOrderCount(req.ChainType, req.Address); err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to check order count")
 		return nil
 	} else if unPayCount > maxUnPayCount {
@@ -220,7 +224,7 @@ func (h *HttpHandle) doOrderRegister(ctx context.Context, req *ReqOrderRegister,
 	//}
 
 	// order check
-	if err := h.checkOrderInfo(req.CoinType, req.CrossCoinType, &req.ReqOrderRegisterBase, apiResp); err != nil {
+	if err := h.checkOrderInfo(ctx, req.CoinType, req.CrossCoinType, &req.ReqOrderRegisterBase, apiResp); err != nil {
 		return fmt.Errorf("checkOrderInfo err: %s", err.Error())
 	}
 	if apiResp.ErrNo != api_code.ApiCodeSuccess {
@@ -283,14 +287,14 @@ func (h *HttpHandle) doOrderRegister(ctx context.Context, req *ReqOrderRegister,
 	return nil
 }
 
-func (h *HttpHandle) checkOrderInfo(coinType, crossCoinType string, req *ReqOrderRegisterBase, apiResp *api_code.ApiResp) error {
-	if req.RegisterYears <= 0 || req.RegisterYears > config.Cfg.Das.MaxRegisterYears {
+func (h *HttpHandle) checkOrderInfo(ctx context.Context, coinType, crossCoinType string, req *ReqOrderRegisterBase, apiResp *api_code.ApiResp) error {
+	if req.RegisterYears <= 0 || req.RegisterYears > config.Cfg().Das.MaxRegisterYears {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("register years[%d] invalid", req.RegisterYears))
 		return nil
 	}
 	if req.InviterAccount != "" {
 		accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.InviterAccount))
-		acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+		acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 		if err != nil {
 			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search inviter account fail")
 			return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
@@ -308,7 +312,7 @@ func (h *HttpHandle) checkOrderInfo(coinType, crossCoinType string, req *ReqOrde
 
 	if req.ChannelAccount != "" {
 		accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.ChannelAccount))
-		acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+		acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 		if err != nil {
 			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search channel account fail")
 			return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
@@ -363,15 +367,47 @@ func (h *HttpHandle) doRegisterOrder(ctx context.Context, req *ReqOrderRegister,
 		return
 	}
 
+	// coupon campaigns
+	campaignOrder := campaign.Order{
+		Action:     tables.CampaignActionRegister,
+		AccountLen: int(accLen),
+		CharSets:   campaign.CharSetsWithoutSuffix(req.AccountCharStr),
+		Years:      req.RegisterYears,
+		ChainType:  req.ChainType,
+		Address:    req.Address,
+		HasInviter: req.InviterAccount != "",
+		AmountUSD:  amountTotalUSD,
+	}
+	campaignRes, err := h.evaluateCampaigns(ctx, campaignOrder)
+	if err != nil {
+		log.Error(ctx, "evaluateCampaigns err: ", err.Error())
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
+		return
+	}
+	if campaignRes.DiscountUSD.IsPositive() {
+		amountTotalUSD = campaignRes.AmountUSD
+		if amountTotalCKB, amountTotalPayToken, err = h.getAmountByUSD(ctx, amountTotalUSD, req.PayTokenId); err != nil {
+			log.Error(ctx, "getAmountByUSD err: ", err.Error())
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
+			return
+		}
+	}
+
 	if amountTotalUSD.Cmp(decimal.Zero) != 1 || amountTotalCKB.Cmp(decimal.Zero) != 1 || amountTotalPayToken.Cmp(decimal.Zero) != 1 {
 		log.Error(ctx, "order amount err:", amountTotalUSD, amountTotalCKB, amountTotalPayToken)
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
 		return
 	}
 
-	inviterAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.InviterAccount))
-	if _, ok := config.Cfg.InviterWhitelist[inviterAccountId]; ok {
-		req.ChannelAccount = req.InviterAccount
+	if req.InviterAccount != "" {
+		inviterAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.InviterAccount))
+		if ok, err := h.isReferralChannel(ctx, inviterAccountId); err != nil {
+			log.Error(ctx, "isReferralChannel err:", err.Error())
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search channel account fail")
+			return
+		} else if ok {
+			req.ChannelAccount = req.InviterAccount
+		}
 	}
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
 	orderContent := tables.TableOrderContent{
@@ -381,6 +417,7 @@ func (h *HttpHandle) doRegisterOrder(ctx context.Context, req *ReqOrderRegister,
 		RegisterYears:  req.RegisterYears,
 		AmountTotalUSD: amountTotalUSD,
 		AmountTotalCKB: amountTotalCKB,
+		Campaigns:      campaignRes.Discounts,
 	}
 
 	contentDataStr, err := json.Marshal(&orderContent)
@@ -417,7 +454,7 @@ func (h *HttpHandle) doRegisterOrder(ctx context.Context, req *ReqOrderRegister,
 	var order tables.TableDasOrderInfo
 	var paymentInfo tables.TableDasOrderPayInfo
 	// unipay
-	if config.Cfg.Server.UniPayUrl != "" {
+	if config.Cfg().Server.UniPayUrl != "" {
 		addrNormal, err := h.dasCore.Daf().HexToNormal(core.DasAddressHex{
 			DasAlgorithmId: req.ChainType.ToDasAlgorithmId(true),
 			AddressHex:     req.Address,
@@ -433,14 +470,14 @@ func (h *HttpHandle) doRegisterOrder(ctx context.Context, req *ReqOrderRegister,
 		premiumBase := decimal.Zero
 		premiumAmount := decimal.Zero
 		if req.PayTokenId == tables.TokenIdStripeUSD {
-			premiumPercentage = config.Cfg.Stripe.PremiumPercentage
-			premiumBase = config.Cfg.Stripe.PremiumBase
+			premiumPercentage = config.Cfg().Stripe.PremiumPercentage
+			premiumBase = config.Cfg().Stripe.PremiumBase
 			premiumAmount = amountTotalPayToken
 			amountTotalPayToken = amountTotalPayToken.Mul(premiumPercentage.Add(decimal.NewFromInt(1))).Add(premiumBase.Mul(decimal.NewFromInt(100)))
 			amountTotalPayToken = decimal.NewFromInt(amountTotalPayToken.Ceil().IntPart())
 			premiumAmount = amountTotalPayToken.Sub(premiumAmount)
 		}
-		res, err := unipay.CreateOrder(unipay.ReqOrderCreate{
+		res, err := unipay.CreateOrder(ctx, unipay.ReqOrderCreate{
 			ChainTypeAddress: core.ChainTypeAddress{
 				Type: "blockchain",
 				KeyInfo: core.KeyInfo{
@@ -541,7 +578,11 @@ func (h *HttpHandle) doRegisterOrder(ctx context.Context, req *ReqOrderRegister,
 		resp.ReceiptAddress = addr
 	}
 
-	if err := h.dbDao.CreateOrderWithPayment(order, paymentInfo); err != nil {
+	tracing.SetAttributes(ctx, tracing.OrderAttrs(&order)...)
+	if err := h.dbDao.WithContext(ctx).CreateOrderWithCampaigns(order, paymentInfo, campaignRes.Usages(campaignOrder)); err == dao.ErrCampaignCapReached {
+		apiResp.ApiRespErr(api_code.ApiCodeCouponUsed, "campaign quota reached, please retry")
+		return
+	} else if err != nil {
 		log.Error(ctx, "CreateOrder err:", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "create order fail")
 		return
@@ -550,7 +591,7 @@ func (h *HttpHandle) doRegisterOrder(ctx context.Context, req *ReqOrderRegister,
 	// notify
 	go func() {
 		notify.SendLarkOrderNotify(&notify.SendLarkOrderNotifyParam{
-			Key:        config.Cfg.Notify.LarkRegisterKey,
+			Key:        config.Cfg().Notify.LarkRegisterKey,
 			Action:     "new register order",
 			Account:    order.Account,
 			OrderId:    order.OrderId,
@@ -670,7 +711,8 @@ func (h *HttpHandle) doRegisterCouponOrder(ctx context.Context, req *ReqOrderReg
 	resp.Amount = order.PayAmount
 	//resp.CodeUrl = ""
 
-	err = h.dbDao.CreateCouponOrder(&order, coupon.Code)
+	tracing.SetAttributes(ctx, tracing.OrderAttrs(&order)...)
+	err = h.dbDao.WithContext(ctx).CreateCouponOrder(&order, coupon.Code)
 	if redisErr := h.rc.DeleteCouponLockWithRedis(coupon.Code); redisErr != nil {
 		log.Error(ctx, "delete coupon redis lock error : ", redisErr.Error())
 	}
@@ -683,7 +725,7 @@ func (h *HttpHandle) doRegisterCouponOrder(ctx context.Context, req *ReqOrderReg
 	// notify
 	go func() {
 		notify.SendLarkOrderNotify(&notify.SendLarkOrderNotifyParam{
-			Key:        config.Cfg.Notify.LarkRegisterKey,
+			Key:        config.Cfg().Notify.LarkRegisterKey,
 			Action:     "new register coupon order",
 			Account:    order.Account,
 			OrderId:    order.OrderId,
@@ -705,19 +747,10 @@ func (h *HttpHandle) getOrderAmount(ctx context.Context, accLen uint8, args, acc
 		amountTotalPayToken = decimal.Zero
 		return
 	}
-	payToken := timer.GetTokenInfo(payTokenId)
-	if payToken.TokenId == "" {
+	if payToken := timer.GetTokenInfo(payTokenId); payToken.TokenId == "" {
 		e = fmt.Errorf("not supported [%s]", payTokenId)
 		return
 	}
-	//
-	quoteCell, err := h.dasCore.GetQuoteCell()
-	if err != nil {
-		e = fmt.Errorf("GetQuoteCell err: %s", err.Error())
-		return
-	}
-	quote := quoteCell.Quote()
-	decQuote := decimal.NewFromInt(int64(quote)).Div(decimal.NewFromInt(common.UsdRateBase))
 	// base price
 	baseAmount, accountPrice, err := h.getAccountPrice(ctx, accLen, args, account, isRenew)
 	if err != nil {
@@ -741,16 +774,34 @@ func (h *HttpHandle) getOrderAmount(ctx context.Context, accLen uint8, args, acc
 	amountTotalUSD = accountPrice
 
 	log.Info(ctx, "before Premium:", account, isRenew, amountTotalUSD, baseAmount, accountPrice)
-	if config.Cfg.Das.Premium.Cmp(decimal.Zero) == 1 {
-		amountTotalUSD = amountTotalUSD.Mul(config.Cfg.Das.Premium.Add(decimal.NewFromInt(1)))
+	if config.Cfg().Das.Premium.Cmp(decimal.Zero) == 1 {
+		amountTotalUSD = amountTotalUSD.Mul(config.Cfg().Das.Premium.Add(decimal.NewFromInt(1)))
 	}
-	if config.Cfg.Das.Discount.Cmp(decimal.Zero) == 1 {
-		amountTotalUSD = amountTotalUSD.Mul(config.Cfg.Das.Discount)
+	if config.Cfg().Das.Discount.Cmp(decimal.Zero) == 1 {
+		amountTotalUSD = amountTotalUSD.Mul(config.Cfg().Das.Discount)
 	}
 	amountTotalUSD = amountTotalUSD.Add(baseAmount)
 	log.Info(ctx, "after Premium:", account, isRenew, amountTotalUSD, baseAmount, accountPrice)
 
 	amountTotalUSD = amountTotalUSD.Mul(decimal.NewFromInt(100)).Ceil().DivRound(decimal.NewFromInt(100), 2)
+	amountTotalCKB, amountTotalPayToken, e = h.getAmountByUSD(ctx, amountTotalUSD, payTokenId)
+	return
+}
+
+// getAmountByUSD converts an order amount in USD to CKB by the quote cell and to the pay token by its price
+func (h *HttpHandle) getAmountByUSD(ctx context.Context, amountTotalUSD decimal.Decimal, payTokenId tables.PayTokenId) (amountTotalCKB decimal.Decimal, amountTotalPayToken decimal.Decimal, e error) {
+	payToken := timer.GetTokenInfo(payTokenId)
+	if payToken.TokenId == "" {
+		e = fmt.Errorf("not supported [%s]", payTokenId)
+		return
+	}
+	quoteCell, err := h.dasCore.GetQuoteCell()
+	if err != nil {
+		e = fmt.Errorf("GetQuoteCell err: %s", err.Error())
+		return
+	}
+	quote := quoteCell.Quote()
+	decQuote := decimal.NewFromInt(int64(quote)).Div(decimal.NewFromInt(common.UsdRateBase))
 	amountTotalCKB = amountTotalUSD.Div(decQuote).Mul(decimal.NewFromInt(int64(common.OneCkb))).Ceil()
 	amountTotalPayToken = amountTotalUSD.Div(payToken.Price).Mul(decimal.New(1, payToken.Decimals)).Ceil()
 
@@ -772,13 +823,13 @@ func (h *HttpHandle) getOrderAmount(ctx context.Context, accLen uint8, args, acc
 }
 func (h *HttpHandle) getCouponInfo(ctx context.Context, code string) (err error, info *RespCouponInfo) {
 	info = new(RespCouponInfo)
-	salt := config.Cfg.Server.CouponEncrySalt
+	salt := config.Cfg().Server.CouponEncrySalt
 	if salt == "" {
 		log.Error(ctx, "GetCoupon err: config coupon_encry_salt is empty")
 		return fmt.Errorf("system setting error"), info
 	}
 	code = couponEncry(code, salt)
-	res, err := h.dbDao.GetCouponByCode(code)
+	res, err := h.dbDao.WithContext(ctx).GetCouponByCode(code)
 	if err != nil {
 		log.Error(ctx, "GetCoupon err:", err.Error())
 		return fmt.Errorf("get gift card error"), info
@@ -792,6 +843,10 @@ func (h *HttpHandle) getCouponInfo(ctx context.Context, code string) (err error,
 		info.CouponStatus = tables.CouponStatusUsed
 		return nil, info
 	}
+	if res.RevokedAt > 0 {
+		info.CouponStatus = tables.CouponStatusRevoked
+		return nil, info
+	}
 	nowTime := time.Now().Unix()
 	if nowTime < res.StartAt.Unix() || nowTime > res.ExpiredAt.Unix() {
 		info.CouponStatus = tables.CouponStatusExpired
@@ -803,14 +858,14 @@ func (h *HttpHandle) getCouponInfo(ctx context.Context, code string) (err error,
 }
 
 func (h *HttpHandle) checkCoupon(ctx context.Context, code string, apiResp *api_code.ApiResp) (coupon *tables.TableCoupon) {
-	salt := config.Cfg.Server.CouponEncrySalt
+	salt := config.Cfg().Server.CouponEncrySalt
 	if salt == "" {
 		log.Error(ctx, "GetCoupon err: config coupon_encry_salt is empty")
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "system setting error")
 		return nil
 	}
 	code = couponEncry(code, salt)
-	res, err := h.dbDao.GetCouponByCode(code)
+	res, err := h.dbDao.WithContext(ctx).GetCouponByCode(code)
 	if err != nil {
 		log.Error(ctx, "GetCoupon err:", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "get gift card error")
@@ -824,6 +879,10 @@ func (h *HttpHandle) checkCoupon(ctx context.Context, code string, apiResp *api_
 		apiResp.ApiRespErr(api_code.ApiCodeCouponUsed, "gift card has been used")
 		return nil
 	}
+	if res.RevokedAt > 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeCouponInvalid, "gift card has been revoked")
+		return nil
+	}
 	nowTime := time.Now().Unix()
 	if nowTime < res.StartAt.Unix() || nowTime > res.ExpiredAt.Unix() {
 		apiResp.ApiRespErr(api_code.ApiCodeCouponUnopen, "gift card time has not arrived or expired")
@@ -2,10 +2,13 @@ package handle
 
 import (
 	"context"
+	"das_register_server/campaign"
 	"das_register_server/config"
+	"das_register_server/dao"
 	"das_register_server/internal"
 	"das_register_server/notify"
 	"das_register_server/tables"
+	"das_register_server/tracing"
 	"das_register_server/unipay"
 	"encoding/json"
 	"fmt"
@@ -95,12 +98,13 @@ func (h *HttpHandle) doOrderRenew(ctx context.Context, req *ReqOrderRenew, apiRe
 		return nil
 	}
 	req.Account = strings.ToLower(req.Account)
+	tracing.SetAttributes(ctx, tracing.AttrAccount.String(req.Account))
 	if yes := req.PayTokenId.IsTokenIdCkbInternal(); yes {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("pay token id [%s] invalid", req.PayTokenId))
 		return nil
 	}
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -110,7 +114,7 @@ func (h *HttpHandle) doOrderRenew(ctx context.Context, req *ReqOrderRenew, apiRe
 	if err := h.checkSystemUpgrade(apiResp); err != nil {
 		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 	}
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 		return fmt.Errorf("sync block number")
 	}
@@ -121,7 +125,7 @@ func (h *HttpHandle) doOrderRenew(ctx context.Context, req *ReqOrderRenew, apiRe
 	//	return fmt.Errorf("AccountActionLimitExist: %d %s %s", req.ChainType, req.Address, req.Account)
 	//}
 
-	acc := h.checkRenewOrder(req, apiResp)
+	acc := h.checkRenewOrder(ctx, req, apiResp)
 	if apiResp.ErrNo != api_code.ApiCodeSuccess {
 		return nil
 	}
@@ -178,6 +182,38 @@ func (h *HttpHandle) doRenewOrder(ctx context.Context, acc *tables.TableAccountI
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
 		return
 	}
+
+	// coupon campaigns
+	accountChars, err := h.dasCore.GetAccountCharSetList(req.Account)
+	if err != nil {
+		log.Error(ctx, "GetAccountCharSetList err: ", err.Error())
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
+		return
+	}
+	campaignOrder := campaign.Order{
+		Action:     tables.CampaignActionRenew,
+		AccountLen: int(accBuilder.AccountChars.Len()),
+		CharSets:   campaign.CharSetsWithoutSuffix(accountChars),
+		Years:      req.RenewYears,
+		ChainType:  req.ChainType,
+		Address:    req.Address,
+		AmountUSD:  amountTotalUSD,
+	}
+	campaignRes, err := h.evaluateCampaigns(ctx, campaignOrder)
+	if err != nil {
+		log.Error(ctx, "evaluateCampaigns err: ", err.Error())
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
+		return
+	}
+	if campaignRes.DiscountUSD.IsPositive() {
+		amountTotalUSD = campaignRes.AmountUSD
+		if amountTotalCKB, amountTotalPayToken, err = h.getAmountByUSD(ctx, amountTotalUSD, req.PayTokenId); err != nil {
+			log.Error(ctx, "getAmountByUSD err: ", err.Error())
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
+			return
+		}
+	}
+
 	if amountTotalUSD.Cmp(decimal.Zero) != 1 || amountTotalCKB.Cmp(decimal.Zero) != 1 || amountTotalPayToken.Cmp(decimal.Zero) != 1 {
 		log.Error(ctx, "order amount err:", amountTotalUSD, amountTotalCKB, amountTotalPayToken)
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "get order amount fail")
@@ -189,6 +225,7 @@ func (h *HttpHandle) doRenewOrder(ctx context.Context, acc *tables.TableAccountI
 		AmountTotalUSD: amountTotalUSD,
 		AmountTotalCKB: amountTotalCKB,
 		RenewYears:     req.RenewYears,
+		Campaigns:      campaignRes.Discounts,
 	}
 	contentDataStr, err := json.Marshal(&orderContent)
 	if err != nil {
@@ -221,7 +258,7 @@ func (h *HttpHandle) doRenewOrder(ctx context.Context, acc *tables.TableAccountI
 	// unipay
 	var order tables.TableDasOrderInfo
 	var paymentInfo tables.TableDasOrderPayInfo
-	if config.Cfg.Server.UniPayUrl != "" {
+	if config.Cfg().Server.UniPayUrl != "" {
 		addrNormal, err := h.dasCore.Daf().HexToNormal(core.DasAddressHex{
 			DasAlgorithmId: req.ChainType.ToDasAlgorithmId(true),
 			AddressHex:     req.Address,
@@ -237,14 +274,14 @@ func (h *HttpHandle) doRenewOrder(ctx context.Context, acc *tables.TableAccountI
 		premiumBase := decimal.Zero
 		premiumAmount := decimal.Zero
 		if req.PayTokenId == tables.TokenIdStripeUSD {
-			premiumPercentage = config.Cfg.Stripe.PremiumPercentage
-			premiumBase = config.Cfg.Stripe.PremiumBase
+			premiumPercentage = config.Cfg().Stripe.PremiumPercentage
+			premiumBase = config.Cfg().Stripe.PremiumBase
 			premiumAmount = amountTotalPayToken
 			amountTotalPayToken = amountTotalPayToken.Mul(premiumPercentage.Add(decimal.NewFromInt(1))).Add(premiumBase.Mul(decimal.NewFromInt(100)))
 			amountTotalPayToken = decimal.NewFromInt(amountTotalPayToken.Ceil().IntPart())
 			premiumAmount = amountTotalPayToken.Sub(premiumAmount)
 		}
-		res, err := unipay.CreateOrder(unipay.ReqOrderCreate{
+		res, err := unipay.CreateOrder(ctx, unipay.ReqOrderCreate{
 			ChainTypeAddress: core.ChainTypeAddress{
 				Type: "blockchain",
 				KeyInfo: core.KeyInfo{
@@ -340,7 +377,11 @@ func (h *HttpHandle) doRenewOrder(ctx context.Context, acc *tables.TableAccountI
 		resp.ReceiptAddress = addr
 	}
 
-	if err := h.dbDao.CreateOrderWithPayment(order, paymentInfo); err != nil {
+	tracing.SetAttributes(ctx, tracing.OrderAttrs(&order)...)
+	if err := h.dbDao.WithContext(ctx).CreateOrderWithCampaigns(order, paymentInfo, campaignRes.Usages(campaignOrder)); err == dao.ErrCampaignCapReached {
+		apiResp.ApiRespErr(api_code.ApiCodeCouponUsed, "campaign quota reached, please retry")
+		return
+	} else if err != nil {
 		log.Error(ctx, "CreateOrder err:", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "create order fail")
 		return
@@ -348,7 +389,7 @@ func (h *HttpHandle) doRenewOrder(ctx context.Context, acc *tables.TableAccountI
 	// notify
 	go func() {
 		notify.SendLarkOrderNotify(&notify.SendLarkOrderNotifyParam{
-			Key:        config.Cfg.Notify.LarkRegisterKey,
+			Key:        config.Cfg().Notify.LarkRegisterKey,
 			Action:     "renew account order",
 			Account:    order.Account,
 			OrderId:    order.OrderId,
@@ -360,13 +401,13 @@ func (h *HttpHandle) doRenewOrder(ctx context.Context, acc *tables.TableAccountI
 	}()
 }
 
-func (h *HttpHandle) checkRenewOrder(req *ReqOrderRenew, apiResp *api_code.ApiResp) *tables.TableAccountInfo {
-	if req.RenewYears < 1 || req.RenewYears > config.Cfg.Das.MaxRegisterYears {
+func (h *HttpHandle) checkRenewOrder(ctx context.Context, req *ReqOrderRenew, apiResp *api_code.ApiResp) *tables.TableAccountInfo {
+	if req.RenewYears < 1 || req.RenewYears > config.Cfg().Das.MaxRegisterYears {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("renew years[%d] invalid", req.RenewYears))
 		return nil
 	}
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		log.Error("GetAccountInfoByAccountId err: ", err.Error())
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account fail")
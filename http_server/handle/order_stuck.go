@@ -0,0 +1,67 @@
+package handle
+
+import (
+	"das_register_server/tables"
+	"das_register_server/timer"
+	"fmt"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"time"
+)
+
+// curl -X POST http://127.0.0.1:8119/v1/order/stuck -d'{"stage":"pre_register_send"}'
+
+type ReqOrderStuck struct {
+	Stage tables.OrderStage `json:"stage"` // empty means all stages
+}
+
+type RespOrderStuck struct {
+	Total int                `json:"total"`
+	List  []timer.StuckOrder `json:"list"`
+}
+
+func (h *HttpHandle) OrderStuck(ctx *gin.Context) {
+	var (
+		funcName = "OrderStuck"
+		clientIp = GetClientIp(ctx)
+		req      ReqOrderStuck
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doOrderStuck(&req, &apiResp); err != nil {
+		log.Error("doOrderStuck err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doOrderStuck(req *ReqOrderStuck, apiResp *api_code.ApiResp) error {
+	var resp RespOrderStuck
+
+	list, err := timer.FindStuckOrders(h.dbDao, time.Now())
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search stuck orders err")
+		return fmt.Errorf("FindStuckOrders err: %s", err.Error())
+	}
+	resp.List = make([]timer.StuckOrder, 0, len(list))
+	for _, v := range list {
+		if req.Stage == "" || v.Stage == req.Stage {
+			resp.List = append(resp.List, v)
+		}
+	}
+	resp.Total = len(resp.List)
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
@@ -0,0 +1,261 @@
+package handle
+
+import (
+	"context"
+	"crypto/rand"
+	"das_register_server/tables"
+	"das_register_server/webhook"
+	"encoding/hex"
+	"fmt"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// curl -X POST http://127.0.0.1:8119/v1/partner/webhook/set -d'{"partner_id":"xx","url":"https://xx/callback","events":["registered","refunded"]}'
+
+type ReqPartnerWebhookSet struct {
+	PartnerId   string               `json:"partner_id" binding:"required"`
+	Url         string               `json:"url" binding:"required"`
+	Events      []string             `json:"events"` // empty means all events
+	Status      tables.WebhookStatus `json:"status"`
+	ResetSecret bool                 `json:"reset_secret"`
+}
+
+type RespPartnerWebhookSet struct {
+	RespPartnerWebhookInfo
+	Secret string `json:"secret,omitempty"` // only returned when it is generated
+}
+
+type ReqPartnerWebhookInfo struct {
+	PartnerId string `json:"partner_id" binding:"required"`
+}
+
+type RespPartnerWebhookInfo struct {
+	PartnerId string               `json:"partner_id"`
+	Url       string               `json:"url"`
+	Events    []string             `json:"events"`
+	Status    tables.WebhookStatus `json:"status"`
+}
+
+type ReqPartnerWebhookDeliveries struct {
+	Pagination
+	PartnerId string `json:"partner_id" binding:"required"`
+	OrderId   string `json:"order_id"`
+}
+
+type RespPartnerWebhookDeliveries struct {
+	Total int64                         `json:"total"`
+	List  []tables.TableWebhookDelivery `json:"list"`
+}
+
+type ReqPartnerWebhookRedeliver struct {
+	PartnerId  string `json:"partner_id" binding:"required"`
+	DeliveryId string `json:"delivery_id" binding:"required"`
+}
+
+type RespPartnerWebhookRedeliver struct {
+}
+
+func (h *HttpHandle) PartnerWebhookSet(ctx *gin.Context) {
+	var (
+		funcName = "PartnerWebhookSet"
+		clientIp = GetClientIp(ctx)
+		req      ReqPartnerWebhookSet
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doPartnerWebhookSet(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doPartnerWebhookSet err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doPartnerWebhookSet(ctx context.Context, req *ReqPartnerWebhookSet, apiResp *api_code.ApiResp) error {
+	var resp RespPartnerWebhookSet
+
+	if u, err := url.Parse(req.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "url invalid")
+		return nil
+	}
+	for _, v := range req.Events {
+		if !webhook.IsEvent(v) {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("event [%s] invalid", v))
+			return nil
+		}
+	}
+	if req.Status != tables.WebhookStatusEnable && req.Status != tables.WebhookStatusDisable {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "status invalid")
+		return nil
+	}
+
+	info, err := h.dbDao.WithContext(ctx).GetPartnerWebhook(req.PartnerId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search partner webhook err")
+		return fmt.Errorf("GetPartnerWebhook err: %s", err.Error())
+	}
+	if info.Secret == "" || req.ResetSecret {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "generate secret err")
+			return fmt.Errorf("rand.Read err: %s", err.Error())
+		}
+		info.Secret = hex.EncodeToString(secret)
+		resp.Secret = info.Secret
+	}
+	info.PartnerId = req.PartnerId
+	info.Url = req.Url
+	info.Events = strings.Join(req.Events, ",")
+	info.Status = req.Status
+	if err = h.dbDao.WithContext(ctx).CreateOrUpdatePartnerWebhook(info); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "save partner webhook err")
+		return fmt.Errorf("CreateOrUpdatePartnerWebhook err: %s", err.Error())
+	}
+
+	resp.RespPartnerWebhookInfo = partnerWebhookInfo(info)
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+func (h *HttpHandle) PartnerWebhookInfo(ctx *gin.Context) {
+	var (
+		funcName = "PartnerWebhookInfo"
+		clientIp = GetClientIp(ctx)
+		req      ReqPartnerWebhookInfo
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doPartnerWebhookInfo(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doPartnerWebhookInfo err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doPartnerWebhookInfo(ctx context.Context, req *ReqPartnerWebhookInfo, apiResp *api_code.ApiResp) error {
+	info, err := h.dbDao.WithContext(ctx).GetPartnerWebhook(req.PartnerId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search partner webhook err")
+		return fmt.Errorf("GetPartnerWebhook err: %s", err.Error())
+	} else if info.Id == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "partner webhook not exist")
+		return nil
+	}
+	apiResp.ApiRespOK(partnerWebhookInfo(info))
+	return nil
+}
+
+func partnerWebhookInfo(info tables.TablePartnerWebhook) RespPartnerWebhookInfo {
+	resp := RespPartnerWebhookInfo{
+		PartnerId: info.PartnerId,
+		Url:       info.Url,
+		Events:    make([]string, 0),
+		Status:    info.Status,
+	}
+	if info.Events != "" {
+		resp.Events = strings.Split(info.Events, ",")
+	}
+	return resp
+}
+
+func (h *HttpHandle) PartnerWebhookDeliveries(ctx *gin.Context) {
+	var (
+		funcName = "PartnerWebhookDeliveries"
+		clientIp = GetClientIp(ctx)
+		req      ReqPartnerWebhookDeliveries
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doPartnerWebhookDeliveries(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doPartnerWebhookDeliveries err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doPartnerWebhookDeliveries(ctx context.Context, req *ReqPartnerWebhookDeliveries, apiResp *api_code.ApiResp) error {
+	var resp RespPartnerWebhookDeliveries
+
+	list, err := h.dbDao.WithContext(ctx).GetWebhookDeliveryList(req.PartnerId, req.OrderId, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search delivery list err")
+		return fmt.Errorf("GetWebhookDeliveryList err: %s", err.Error())
+	}
+	resp.Total, err = h.dbDao.WithContext(ctx).GetWebhookDeliveryCount(req.PartnerId, req.OrderId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search delivery count err")
+		return fmt.Errorf("GetWebhookDeliveryCount err: %s", err.Error())
+	}
+	resp.List = list
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+func (h *HttpHandle) PartnerWebhookRedeliver(ctx *gin.Context) {
+	var (
+		funcName = "PartnerWebhookRedeliver"
+		clientIp = GetClientIp(ctx)
+		req      ReqPartnerWebhookRedeliver
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doPartnerWebhookRedeliver(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doPartnerWebhookRedeliver err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doPartnerWebhookRedeliver(ctx context.Context, req *ReqPartnerWebhookRedeliver, apiResp *api_code.ApiResp) error {
+	rows, err := h.dbDao.WithContext(ctx).RedoWebhookDelivery(req.PartnerId, req.DeliveryId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "redo delivery err")
+		return fmt.Errorf("RedoWebhookDelivery err: %s", err.Error())
+	} else if rows == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "delivery not exist")
+		return nil
+	}
+	apiResp.ApiRespOK(RespPartnerWebhookRedeliver{})
+	return nil
+}
@@ -0,0 +1,301 @@
+package handle
+
+import (
+	"bytes"
+	"context"
+	"das_register_server/config"
+	"das_register_server/referral"
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// curl -X POST http://127.0.0.1:8119/v1/referral/dashboard -d'{"account":"inviter.bit","start":"2026-10-01","end":"2026-10-31","bucket":"week"}'
+// curl "http://127.0.0.1:8119/v1/referral/payout?start=2026-10-01&end=2026-10-31&format=csv"
+// curl -X POST http://127.0.0.1:8119/v1/referral/channel/list -d'{"page":1,"size":20}'
+// curl -X POST http://127.0.0.1:8119/v1/referral/channel/set -d'{"account":"channel.bit","status":0,"remark":"partner x"}'
+
+const referralMaxDays = 366
+
+type ReqReferralDashboard struct {
+	Account string          `json:"account" binding:"required"` // inviter or channel account
+	Start   string          `json:"start"`                      // 2006-01-02, default 30 days before end
+	End     string          `json:"end"`                        // 2006-01-02 included, default today
+	Bucket  referral.Bucket `json:"bucket"`                     // day, week or month, default day
+}
+
+type ReqReferralPayout struct {
+	Start  string `json:"start" form:"start" binding:"required"` // 2006-01-02
+	End    string `json:"end" form:"end" binding:"required"`     // 2006-01-02 included
+	Format string `json:"format" form:"format"`                  // json or csv, default json
+}
+
+type RespReferralPayout struct {
+	Start time.Time             `json:"start"`
+	End   time.Time             `json:"end"`
+	Tiers []config.ReferralTier `json:"tiers"`
+	List  []referral.Payout     `json:"list"`
+}
+
+type ReqReferralChannelList struct {
+	Pagination
+}
+
+type RespReferralChannelList struct {
+	Total int64                         `json:"total"`
+	List  []tables.TableReferralChannel `json:"list"`
+}
+
+type ReqReferralChannelSet struct {
+	Account string                       `json:"account" binding:"required"`
+	Status  tables.ReferralChannelStatus `json:"status"` // 0-enable 1-disable
+	Remark  string                       `json:"remark"`
+}
+
+// referralRange parses the days of a referral request into [start, end)
+func referralRange(startDate, endDate string) (start, end time.Time, err error) {
+	today := time.Now()
+	end = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	if endDate != "" {
+		if end, err = time.ParseInLocation("2006-01-02", endDate, time.Local); err != nil {
+			return
+		}
+	}
+	end = end.AddDate(0, 0, 1)
+	start = end.AddDate(0, 0, -30)
+	if startDate != "" {
+		if start, err = time.ParseInLocation("2006-01-02", startDate, time.Local); err != nil {
+			return
+		}
+	}
+	if !start.Before(end) || end.Sub(start) > referralMaxDays*24*time.Hour {
+		err = fmt.Errorf("start must be before end and at most %d days apart", referralMaxDays)
+	}
+	return
+}
+
+func (h *HttpHandle) ReferralDashboard(ctx *gin.Context) {
+	var (
+		funcName = "ReferralDashboard"
+		clientIp = GetClientIp(ctx)
+		req      ReqReferralDashboard
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doReferralDashboard(&req, &apiResp); err != nil {
+		log.Error("doReferralDashboard err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doReferralDashboard(req *ReqReferralDashboard, apiResp *api_code.ApiResp) error {
+	if req.Bucket == "" {
+		req.Bucket = referral.BucketDay
+	}
+	if !req.Bucket.Valid() {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "bucket invalid")
+		return nil
+	}
+	start, end, err := referralRange(req.Start, req.End)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, err.Error())
+		return nil
+	}
+	account := strings.ToLower(req.Account)
+	if !strings.HasSuffix(account, common.DasAccountSuffix) {
+		account += common.DasAccountSuffix
+	}
+
+	d, err := referral.BuildDashboard(h.dbDao, account, start, end, req.Bucket, config.Cfg().Referral.Tiers)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "build referral dashboard err")
+		return fmt.Errorf("BuildDashboard err: %s", err.Error())
+	}
+
+	apiResp.ApiRespOK(d)
+	return nil
+}
+
+func (h *HttpHandle) ReferralPayout(ctx *gin.Context) {
+	var (
+		funcName = "ReferralPayout"
+		clientIp = GetClientIp(ctx)
+		req      ReqReferralPayout
+		apiResp  api_code.ApiResp
+	)
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		log.Error("ShouldBindQuery err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if req.Format == "" {
+		req.Format = ReportFormatJson
+	}
+	start, end, err := referralRange(req.Start, req.End)
+	if err != nil || (req.Format != ReportFormatJson && req.Format != ReportFormatCsv) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "start, end or format invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
+	tiers := config.Cfg().Referral.Tiers
+	list, err := referral.BuildPayouts(h.dbDao.WithContext(ctx.Request.Context()), start, end, tiers)
+	if err != nil {
+		log.Error("BuildPayouts err:", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "build referral payouts err")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
+	if req.Format == ReportFormatCsv {
+		var buf bytes.Buffer
+		if err := referral.WritePayoutsCSV(&buf, list); err != nil {
+			log.Error("WritePayoutsCSV err:", err.Error(), funcName, clientIp, ctx)
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "render referral payouts err")
+			ctx.JSON(http.StatusOK, apiResp)
+			return
+		}
+		fileName := fmt.Sprintf("referral-payout-%s-%s", start.Format("20060102"), end.AddDate(0, 0, -1).Format("20060102"))
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, fileName))
+		ctx.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+		return
+	}
+	apiResp.ApiRespOK(RespReferralPayout{Start: start, End: end, Tiers: tiers, List: list})
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) ReferralChannelList(ctx *gin.Context) {
+	var (
+		funcName = "ReferralChannelList"
+		clientIp = GetClientIp(ctx)
+		req      ReqReferralChannelList
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doReferralChannelList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doReferralChannelList err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doReferralChannelList(ctx context.Context, req *ReqReferralChannelList, apiResp *api_code.ApiResp) error {
+	var resp RespReferralChannelList
+
+	list, total, err := h.dbDao.WithContext(ctx).GetReferralChannelList(req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search referral channel err")
+		return fmt.Errorf("GetReferralChannelList err: %s", err.Error())
+	}
+	resp.Total = total
+	resp.List = list
+	if resp.List == nil {
+		resp.List = []tables.TableReferralChannel{}
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+func (h *HttpHandle) ReferralChannelSet(ctx *gin.Context) {
+	var (
+		funcName = "ReferralChannelSet"
+		clientIp = GetClientIp(ctx)
+		req      ReqReferralChannelSet
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doReferralChannelSet(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doReferralChannelSet err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doReferralChannelSet(ctx context.Context, req *ReqReferralChannelSet, apiResp *api_code.ApiResp) error {
+	if req.Status != tables.ReferralChannelStatusEnable && req.Status != tables.ReferralChannelStatusDisable {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "status invalid")
+		return nil
+	}
+	account := strings.ToLower(req.Account)
+	if !strings.HasSuffix(account, common.DasAccountSuffix) {
+		account += common.DasAccountSuffix
+	}
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	if req.Status == tables.ReferralChannelStatusEnable {
+		acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
+			return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+		} else if acc.Id == 0 {
+			apiResp.ApiRespErr(api_code.ApiCodeAccountNotExist, "account not exist")
+			return nil
+		}
+	}
+
+	channel := tables.TableReferralChannel{
+		AccountId: accountId,
+		Account:   account,
+		Status:    req.Status,
+		Remark:    req.Remark,
+	}
+	if err := h.dbDao.WithContext(ctx).SetReferralChannel(channel); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "set referral channel err")
+		return fmt.Errorf("SetReferralChannel err: %s", err.Error())
+	}
+	log.Info("doReferralChannelSet:", account, accountId, req.Status)
+
+	apiResp.ApiRespOK(channel)
+	return nil
+}
+
+// isReferralChannel reports the inviter is a whitelisted channel, by the api managed channels or the legacy inviter_whitelist
+func (h *HttpHandle) isReferralChannel(ctx context.Context, inviterAccountId string) (bool, error) {
+	channel, err := h.dbDao.WithContext(ctx).GetReferralChannel(inviterAccountId)
+	if err != nil {
+		return false, fmt.Errorf("GetReferralChannel err: %s", err.Error())
+	} else if channel.Id > 0 {
+		// a channel set through the api overrides the inviter_whitelist config, a disabled one included
+		return channel.Status == tables.ReferralChannelStatusEnable, nil
+	}
+	_, ok := config.Cfg().InviterWhitelist[inviterAccountId]
+	return ok, nil
+}
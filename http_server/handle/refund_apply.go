@@ -1,7 +1,7 @@
 package handle
 
 import (
-	"das_register_server/config"
+	"das_register_server/signer"
 	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
@@ -73,7 +73,7 @@ func (h *HttpHandle) RefundApply(ctx *gin.Context) {
 func (h *HttpHandle) doRefundApply(req *ReqRefundApply, apiResp *api_code.ApiResp) error {
 	var resp RespRefundApply
 
-	parseAddress, err := address.Parse(config.Cfg.Server.PayServerAddress)
+	parseAddress, err := address.Parse(signer.PayAddress())
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
 		return fmt.Errorf("address.Parse err: %s", err.Error())
@@ -75,7 +75,7 @@ func (h *HttpHandle) RegisteringList(ctx *gin.Context) {
 func (h *HttpHandle) doRegisteringList(ctx context.Context, req *ReqRegisteringList, apiResp *api_code.ApiResp) error {
 	var resp RespRegisteringList
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -88,7 +88,7 @@ func (h *HttpHandle) doRegisteringList(ctx context.Context, req *ReqRegisteringL
 		return nil
 	}
 
-	list, err := h.dbDao.GetRegisteringOrders(req.ChainType, req.Address)
+	list, err := h.dbDao.WithContext(ctx).GetRegisteringOrders(req.ChainType, req.Address)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "get registering account fail")
 		return fmt.Errorf("GetRegisteringOrders err: %s", err.Error())
@@ -0,0 +1,93 @@
+package handle
+
+import (
+	"bytes"
+	"das_register_server/report"
+	"fmt"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"time"
+)
+
+// curl "http://127.0.0.1:8119/v1/report?period=weekly&date=2024-01-08&format=csv"
+
+const (
+	ReportFormatJson     = "json"
+	ReportFormatMarkdown = "markdown"
+	ReportFormatCsv      = "csv"
+)
+
+type ReqReport struct {
+	Period report.Period `json:"period" form:"period"` // daily or weekly, default daily
+	Date   string        `json:"date" form:"date"`     // 2006-01-02, any day of the period, default yesterday
+	Format string        `json:"format" form:"format"` // json, markdown or csv, default json
+}
+
+func (h *HttpHandle) Report(ctx *gin.Context) {
+	var (
+		funcName = "Report"
+		clientIp = GetClientIp(ctx)
+		req      ReqReport
+		apiResp  api_code.ApiResp
+	)
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		log.Error("ShouldBindQuery err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if req.Period == "" {
+		req.Period = report.PeriodDaily
+	}
+	if req.Format == "" {
+		req.Format = ReportFormatJson
+	}
+	day := time.Now().AddDate(0, 0, -1)
+	if req.Date != "" {
+		d, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "date invalid")
+			ctx.JSON(http.StatusOK, apiResp)
+			return
+		}
+		day = d
+	}
+	if !req.Period.Valid() || (req.Format != ReportFormatJson && req.Format != ReportFormatMarkdown && req.Format != ReportFormatCsv) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "period or format invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
+	r, err := report.Build(h.dbDao.WithContext(ctx.Request.Context()), req.Period, day)
+	if err != nil {
+		log.Error("report.Build err:", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "build report err")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
+	fileName := fmt.Sprintf("%s-%s", r.Period, r.Start.Format("20060102"))
+	switch req.Format {
+	case ReportFormatMarkdown:
+		ctx.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.md"`, fileName))
+		ctx.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(r.Markdown()))
+	case ReportFormatCsv:
+		var buf bytes.Buffer
+		if err := r.CSV(&buf); err != nil {
+			log.Error("report.CSV err:", err.Error(), funcName, clientIp, ctx)
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "render report err")
+			ctx.JSON(http.StatusOK, apiResp)
+			return
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, fileName))
+		ctx.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	default:
+		apiResp.ApiRespOK(r)
+		ctx.JSON(http.StatusOK, apiResp)
+	}
+}
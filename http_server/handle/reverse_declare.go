@@ -85,7 +85,7 @@ import (
 //		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 //	}
 //
-//	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+//	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 //		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 //		return fmt.Errorf("sync block number")
 //	}
@@ -294,7 +294,7 @@ func (h *HttpHandle) buildTx(ctx context.Context, req *reqBuildTx, txParams *txb
 		return "", nil, fmt.Errorf("txBuilder.BuildTransaction err: %s", err.Error())
 	}
 	sizeInBlock, _ := txBuilder.Transaction.SizeInBlock()
-	txFeeRate := config.Cfg.Server.TxTeeRate
+	txFeeRate := config.Cfg().Server.TxTeeRate
 	if txFeeRate == 0 {
 		txFeeRate = 1
 	}
@@ -1,6 +1,7 @@
 package handle
 
 import (
+	"context"
 	"das_register_server/tables"
 	"encoding/json"
 	"fmt"
@@ -37,7 +38,7 @@ func (h *HttpHandle) RpcReverseLatest(p json.RawMessage, apiResp *api_code.ApiRe
 		return
 	}
 
-	if err = h.doReverseLatest(&req[0], apiResp); err != nil {
+	if err = h.doReverseLatest(h.ctx, &req[0], apiResp); err != nil {
 		log.Error("doReverseLatest err:", err.Error())
 	}
 }
@@ -59,14 +60,14 @@ func (h *HttpHandle) ReverseLatest(ctx *gin.Context) {
 	}
 	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
 
-	if err = h.doReverseLatest(&req, &apiResp); err != nil {
+	if err = h.doReverseLatest(ctx.Request.Context(), &req, &apiResp); err != nil {
 		log.Error("doReverseLatest err:", err.Error(), funcName, clientIp, ctx)
 	}
 
 	ctx.JSON(http.StatusOK, apiResp)
 }
 
-func (h *HttpHandle) doReverseLatest(req *ReqReverseLatest, apiResp *api_code.ApiResp) error {
+func (h *HttpHandle) doReverseLatest(ctx context.Context, req *ReqReverseLatest, apiResp *api_code.ApiResp) error {
 	addressHex, err := h.dasCore.Daf().NormalToHex(core.DasAddressNormal{
 		ChainType:     req.ChainType,
 		AddressNormal: req.Address,
@@ -79,7 +80,7 @@ func (h *HttpHandle) doReverseLatest(req *ReqReverseLatest, apiResp *api_code.Ap
 	req.ChainType, req.Address = addressHex.ChainType, addressHex.AddressHex
 	var resp RespReverseLatest
 
-	reverse, err := h.dbDao.SearchLatestReverse(req.ChainType, req.Address)
+	reverse, err := h.dbDao.WithContext(ctx).SearchLatestReverse(req.ChainType, req.Address)
 	if err != nil {
 		if err != gorm.ErrRecordNotFound {
 			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search reverse err")
@@ -95,7 +96,7 @@ func (h *HttpHandle) doReverseLatest(req *ReqReverseLatest, apiResp *api_code.Ap
 
 	// account
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(reverse.Account))
-	acc, err := h.dbDao.GetAccountInfoByAccountId(accountId)
+	acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(accountId)
 	if err != nil {
 		if err != gorm.ErrRecordNotFound {
 			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
@@ -122,7 +123,7 @@ func (h *HttpHandle) doReverseLatest(req *ReqReverseLatest, apiResp *api_code.Ap
 	}
 
 	// records
-	record, err := h.dbDao.SearchAccountReverseRecords(acc.Account, req.Address)
+	record, err := h.dbDao.WithContext(ctx).SearchAccountReverseRecords(acc.Account, req.Address)
 	if err != nil {
 		if err != gorm.ErrRecordNotFound {
 			*apiResp = api_code.ApiRespErr(api_code.ApiCodeDbError, "search account err")
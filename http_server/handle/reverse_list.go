@@ -1,6 +1,7 @@
 package handle
 
 import (
+	"context"
 	"das_register_server/tables"
 	"encoding/json"
 	"fmt"
@@ -41,7 +42,7 @@ func (h *HttpHandle) RpcReverseList(p json.RawMessage, apiResp *api_code.ApiResp
 		return
 	}
 
-	if err = h.doReverseList(&req[0], apiResp); err != nil {
+	if err = h.doReverseList(h.ctx, &req[0], apiResp); err != nil {
 		log.Error("doReverseList err:", err.Error())
 	}
 }
@@ -63,14 +64,14 @@ func (h *HttpHandle) ReverseList(ctx *gin.Context) {
 	}
 	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
 
-	if err = h.doReverseList(&req, &apiResp); err != nil {
+	if err = h.doReverseList(ctx.Request.Context(), &req, &apiResp); err != nil {
 		log.Error("doReverseList err:", err.Error(), funcName, clientIp, ctx)
 	}
 
 	ctx.JSON(http.StatusOK, apiResp)
 }
 
-func (h *HttpHandle) doReverseList(req *ReqReverseList, apiResp *api_code.ApiResp) error {
+func (h *HttpHandle) doReverseList(ctx context.Context, req *ReqReverseList, apiResp *api_code.ApiResp) error {
 	addressHex, err := h.dasCore.Daf().NormalToHex(core.DasAddressNormal{
 		ChainType:     req.ChainType,
 		AddressNormal: req.Address,
@@ -85,14 +86,14 @@ func (h *HttpHandle) doReverseList(req *ReqReverseList, apiResp *api_code.ApiRes
 	var resp RespReverseList
 	resp.List = make([]ReverseListData, 0)
 
-	list, err := h.dbDao.SearchReverseList(req.ChainType, req.Address)
+	list, err := h.dbDao.WithContext(ctx).SearchReverseList(req.ChainType, req.Address)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search reverse list err")
 		return fmt.Errorf("SearchLatestReverse err: %s", err.Error())
 	}
 	for _, v := range list {
 		// account
-		acc, err := h.dbDao.GetAccountInfoByAccountId(v.AccountId)
+		acc, err := h.dbDao.WithContext(ctx).GetAccountInfoByAccountId(v.AccountId)
 		if err != nil {
 			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
 			return fmt.Errorf("SearchAccount err: %s", err.Error())
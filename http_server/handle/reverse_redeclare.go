@@ -91,7 +91,7 @@ package handle
 //		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
 //	}
 //
-//	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+//	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 //		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
 //		return fmt.Errorf("sync block number")
 //	}
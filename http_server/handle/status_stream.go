@@ -0,0 +1,113 @@
+package handle
+
+import (
+	"das_register_server/config"
+	"das_register_server/event"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	statusStreamHeartbeat   = time.Second * 15
+	statusStreamMaxDuration = time.Minute * 10
+)
+
+type ReqStatusStream struct {
+	OrderId  string          `json:"order_id" form:"order_id"`
+	CoinType common.CoinType `json:"coin_type" form:"coin_type"`
+	Key      string          `json:"key" form:"key"`
+}
+
+// StatusStream pushes order and tx status changes as server-sent events,
+// clients reconnect after statusStreamMaxDuration
+func (h *HttpHandle) StatusStream(ctx *gin.Context) {
+	var (
+		funcName = "StatusStream"
+		clientIp = GetClientIp(ctx)
+		req      ReqStatusStream
+		apiResp  api_code.ApiResp
+	)
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		log.Error("ShouldBindQuery err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	filter := event.Filter{OrderId: req.OrderId}
+	if req.Key != "" {
+		addr := core.ChainTypeAddress{
+			Type:    "blockchain",
+			KeyInfo: core.KeyInfo{CoinType: req.CoinType, Key: req.Key},
+		}
+		addrHex, err := addr.FormatChainTypeAddress(config.Cfg().Server.Net, true)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
+			ctx.JSON(http.StatusOK, apiResp)
+			return
+		}
+		filter.ChainType, filter.Address = addrHex.ChainType, addrHex.AddressHex
+	}
+
+	sub, err := h.statusHub.Subscribe(filter)
+	if err != nil {
+		log.Error("Subscribe err:", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	defer h.statusHub.Unsubscribe(sub)
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	// current status first, so a client never misses a transition that happened before it subscribed
+	if req.OrderId != "" {
+		order, err := h.dbDao.WithContext(ctx.Request.Context()).GetOrderByOrderId(req.OrderId)
+		if err != nil {
+			log.Error("GetOrderByOrderId err:", err.Error(), req.OrderId)
+		} else if order.Id > 0 && filter.Match(&event.StatusEvent{OrderId: order.OrderId, ChainType: order.ChainType, Address: order.Address}) {
+			ctx.SSEvent("status", event.StatusEvent{
+				Type:           event.TypeOrder,
+				OrderId:        order.OrderId,
+				Account:        order.Account,
+				ChainType:      order.ChainType,
+				Address:        order.Address,
+				PayStatus:      order.PayStatus,
+				RegisterStatus: order.RegisterStatus,
+				OrderStatus:    order.OrderStatus,
+				Timestamp:      time.Now().UnixMilli(),
+			})
+		}
+	}
+
+	heartbeat := time.NewTicker(statusStreamHeartbeat)
+	defer heartbeat.Stop()
+	deadline := time.NewTimer(statusStreamMaxDuration)
+	defer deadline.Stop()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-h.ctx.Done():
+			return false
+		case <-deadline.C:
+			return false
+		case <-heartbeat.C:
+			ctx.SSEvent("ping", time.Now().Unix())
+			return true
+		case e := <-sub.C:
+			ctx.SSEvent("status", e)
+			return true
+		}
+	})
+}
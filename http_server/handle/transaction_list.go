@@ -78,7 +78,7 @@ func (h *HttpHandle) TransactionList(ctx *gin.Context) {
 
 func (h *HttpHandle) doTransactionList(ctx context.Context, req *ReqTransactionList, apiResp *api_code.ApiResp) error {
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -87,7 +87,7 @@ func (h *HttpHandle) doTransactionList(ctx context.Context, req *ReqTransactionL
 	var resp RespTransactionList
 	resp.List = make([]DataTransaction, 0)
 
-	list, err := h.dbDao.GetTransactionList(req.ChainType, req.Address, req.GetLimit(), req.GetOffset())
+	list, err := h.dbDao.WithContext(ctx).GetTransactionList(req.ChainType, req.Address, req.GetLimit(), req.GetOffset())
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search tx list err")
 		return fmt.Errorf("GetTransactionList err: %s", err.Error())
@@ -104,7 +104,7 @@ func (h *HttpHandle) doTransactionList(ctx context.Context, req *ReqTransactionL
 		})
 	}
 	//
-	count, err := h.dbDao.GetTransactionListTotal(req.ChainType, req.Address)
+	count, err := h.dbDao.WithContext(ctx).GetTransactionListTotal(req.ChainType, req.Address)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search tx count err")
 		return fmt.Errorf("GetTransactionListTotal err: %s", err.Error())
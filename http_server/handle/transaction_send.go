@@ -262,7 +262,7 @@ func (h *HttpHandle) doTransactionSend(ctx context.Context, req *ReqTransactionS
 				Outpoint:       common.OutPoint2String(hash.Hex(), 0),
 				BlockTimestamp: uint64(time.Now().UnixNano() / 1e6),
 			}
-			if err = h.dbDao.CreatePending(&pending); err != nil {
+			if err = h.dbDao.WithContext(ctx).CreatePending(&pending); err != nil {
 				log.Error(ctx, "CreatePending err: ", err.Error(), toolib.JsonString(pending))
 			}
 
@@ -279,7 +279,7 @@ func (h *HttpHandle) doTransactionSend(ctx context.Context, req *ReqTransactionS
 					Outpoint:     pending.Outpoint,
 				}
 				auctionOrder.CreateOrderId()
-				if err = h.dbDao.CreateAuctionOrder(auctionOrder); err != nil {
+				if err = h.dbDao.WithContext(ctx).CreateAuctionOrder(auctionOrder); err != nil {
 					log.Error(ctx, "CreateAuctionOrder err: ", err.Error(), toolib.JsonString(auctionOrder))
 				}
 			}
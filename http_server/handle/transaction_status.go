@@ -73,7 +73,7 @@ func (h *HttpHandle) TransactionStatus(ctx *gin.Context) {
 
 func (h *HttpHandle) doTransactionStatus(ctx context.Context, req *ReqTransactionStatus, apiResp *api_code.ApiResp) error {
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
@@ -86,7 +86,7 @@ func (h *HttpHandle) doTransactionStatus(ctx context.Context, req *ReqTransactio
 		actionList = append(actionList, tables.FormatActionType(v))
 	}
 
-	tx, err := h.dbDao.GetPendingStatus(req.ChainType, req.Address, actionList)
+	tx, err := h.dbDao.WithContext(ctx).GetPendingStatus(req.ChainType, req.Address, actionList)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search tx status err")
 		return fmt.Errorf("GetTransactionStatus err: %s", err.Error())
@@ -1,6 +1,7 @@
 package handle
 
 import (
+	"context"
 	"das_register_server/http_server/api_code"
 	"das_register_server/notify"
 	"das_register_server/tables"
@@ -56,14 +57,14 @@ func (h *HttpHandle) UniPayNotice(ctx *gin.Context) {
 	}
 	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
 
-	if err = h.doUniPayNotice(&req, &apiResp); err != nil {
+	if err = h.doUniPayNotice(ctx.Request.Context(), &req, &apiResp); err != nil {
 		log.Error("doUniPayNotice err:", err.Error(), funcName, clientIp, ctx)
 	}
 
 	ctx.JSON(http.StatusOK, apiResp)
 }
 
-func (h *HttpHandle) doUniPayNotice(req *ReqUniPayNotice, apiResp *api_code.ApiResp) error {
+func (h *HttpHandle) doUniPayNotice(ctx context.Context, req *ReqUniPayNotice, apiResp *api_code.ApiResp) error {
 	var resp RespUniPayNotice
 
 	// check BusinessId
@@ -75,17 +76,17 @@ func (h *HttpHandle) doUniPayNotice(req *ReqUniPayNotice, apiResp *api_code.ApiR
 	for _, v := range req.EventList {
 		switch v.EventType {
 		case EventTypeOrderPay:
-			if err := unipay.DoPaymentConfirm(h.dbDao, v.OrderId, v.PayHash, v.PayAddress, v.AlgorithmId); err != nil {
+			if err := unipay.DoPaymentConfirm(h.dbDao.WithContext(ctx), v.OrderId, v.PayHash, v.PayAddress, v.AlgorithmId); err != nil {
 				log.Error("DoPaymentConfirm err: ", err.Error(), v.OrderId, v.PayHash)
 				notify.SendLarkErrNotify("DoPaymentConfirm", err.Error())
 			}
 		case EventTypeOrderRefund:
-			if err := h.dbDao.UpdateUniPayRefundStatusToRefunded(v.PayHash, v.OrderId, v.RefundHash); err != nil {
-				log.Error("UpdateUniPayRefundStatusToRefunded err: ", err.Error())
-				notify.SendLarkErrNotify("UpdateUniPayRefundStatusToRefunded", err.Error())
+			if err := unipay.DoRefundConfirm(h.dbDao.WithContext(ctx), v.PayHash, v.OrderId, v.RefundHash); err != nil {
+				log.Error("DoRefundConfirm err: ", err.Error())
+				notify.SendLarkErrNotify("DoRefundConfirm", err.Error())
 			}
 		case EventTypePaymentDispute:
-			if err := h.dbDao.UpdatePayHashStatusToFailByDispute(v.PayHash, v.OrderId); err != nil {
+			if err := h.dbDao.WithContext(ctx).UpdatePayHashStatusToFailByDispute(v.PayHash, v.OrderId); err != nil {
 				log.Error("UpdatePayHashStatusToFailByDispute err: ", err.Error())
 				notify.SendLarkErrNotify("UpdatePayHashStatusToFailByDispute", err.Error())
 			}
@@ -0,0 +1,79 @@
+package handle
+
+import (
+	"das_register_server/signer"
+	"das_register_server/wallet"
+	"fmt"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+)
+
+// curl -X POST http://127.0.0.1:8119/v1/wallet/status -d'{"role":"pay"}'
+
+type ReqWalletStatus struct {
+	Role signer.Role `json:"role"` // pay, transfer_whitelist or capacity_whitelist, empty means all
+}
+
+type RespWalletStatus struct {
+	Live bool            `json:"live"` // the monitor has not run, the status is read now without spending and runway
+	List []wallet.Status `json:"list"`
+}
+
+func (h *HttpHandle) WalletStatus(ctx *gin.Context) {
+	var (
+		funcName = "WalletStatus"
+		clientIp = GetClientIp(ctx)
+		req      ReqWalletStatus
+		apiResp  api_code.ApiResp
+		err      error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx)
+
+	if err = h.doWalletStatus(ctx, &req, &apiResp); err != nil {
+		log.Error("doWalletStatus err:", err.Error(), funcName, clientIp, ctx)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doWalletStatus(ctx *gin.Context, req *ReqWalletStatus, apiResp *api_code.ApiResp) error {
+	var resp RespWalletStatus
+
+	list, err := wallet.GetStatus(h.rc)
+	if err != nil {
+		log.Warn("wallet.GetStatus err:", err.Error())
+	}
+	if list == nil {
+		resp.Live = true
+		for _, role := range wallet.Roles {
+			addr := signer.Address(role)
+			if addr == "" {
+				continue
+			}
+			st, err := wallet.Check(ctx.Request.Context(), h.dasCore.Client(), role, addr)
+			if err != nil {
+				apiResp.ApiRespErr(api_code.ApiCodeError500, "check wallet err")
+				return fmt.Errorf("wallet.Check err: %s", err.Error())
+			}
+			list = append(list, *st)
+		}
+	}
+	resp.List = make([]wallet.Status, 0, len(list))
+	for _, v := range list {
+		if req.Role == "" || v.Role == req.Role {
+			resp.List = append(resp.List, v)
+		}
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
@@ -79,14 +79,14 @@ func (h *HttpHandle) doWithdrawList(ctx context.Context, req *ReqWithdrawList, a
 	var resp RespWithdrawList
 	resp.List = make([]WithdrawListData, 0)
 
-	addressHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	addressHex, err := req.FormatChainTypeAddress(config.Cfg().Server.Net, true)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
 		return nil
 	}
 	req.ChainType, req.Address = addressHex.ChainType, addressHex.AddressHex
 
-	list, err := h.dbDao.GetTransactionListByAction(req.ChainType, req.Address, common.DasActionWithdrawFromWallet, req.GetLimit(), req.GetOffset())
+	list, err := h.dbDao.WithContext(ctx).GetTransactionListByAction(req.ChainType, req.Address, common.DasActionWithdrawFromWallet, req.GetLimit(), req.GetOffset())
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search withdraw list err")
 		return fmt.Errorf("GetTransactionListByAction err: %s", err.Error())
@@ -102,7 +102,7 @@ func (h *HttpHandle) doWithdrawList(ctx context.Context, req *ReqWithdrawList, a
 		})
 	}
 
-	tt, err := h.dbDao.GetTransactionTotalCapacityByAction(req.ChainType, req.Address, common.DasActionWithdrawFromWallet)
+	tt, err := h.dbDao.WithContext(ctx).GetTransactionTotalCapacityByAction(req.ChainType, req.Address, common.DasActionWithdrawFromWallet)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search withdraw list err")
 		return fmt.Errorf("GetTransactionTotalCapacityByAction err: %s", err.Error())
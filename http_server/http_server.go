@@ -6,6 +6,7 @@ import (
 	"das_register_server/dao"
 	"das_register_server/elastic"
 	"das_register_server/http_server/handle"
+	"das_register_server/reserved"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/dascache"
 	"github.com/dotbitHQ/das-lib/http_api/logger"
@@ -32,18 +33,17 @@ type HttpServer struct {
 }
 
 type HttpServerParams struct {
-	Ctx                    context.Context
-	Address                string
-	InternalAddress        string
-	DbDao                  *dao.DbDao
-	Rc                     *cache.RedisCache
-	Es                     *elastic.Es
-	DasCore                *core.DasCore
-	DasCache               *dascache.DasCache
-	TxBuilderBase          *txbuilder.DasTxBuilderBase
-	ServerScript           *types.Script
-	MapReservedAccounts    map[string]struct{}
-	MapUnAvailableAccounts map[string]struct{}
+	Ctx             context.Context
+	Address         string
+	InternalAddress string
+	DbDao           *dao.DbDao
+	Rc              *cache.RedisCache
+	Es              *elastic.Es
+	DasCore         *core.DasCore
+	DasCache        *dascache.DasCache
+	TxBuilderBase   *txbuilder.DasTxBuilderBase
+	ServerScript    *types.Script
+	ReservedList    *reserved.List
 }
 
 func Initialize(p HttpServerParams) (*HttpServer, error) {
@@ -54,16 +54,15 @@ func Initialize(p HttpServerParams) (*HttpServer, error) {
 		engine:          gin.New(),
 		internalEngine:  gin.New(),
 		h: handle.Initialize(handle.HttpHandleParams{
-			DbDao:                  p.DbDao,
-			Rc:                     p.Rc,
-			Es:                     p.Es,
-			DasCore:                p.DasCore,
-			Ctx:                    p.Ctx,
-			DasCache:               p.DasCache,
-			TxBuilderBase:          p.TxBuilderBase,
-			MapReservedAccounts:    p.MapReservedAccounts,
-			MapUnAvailableAccounts: p.MapUnAvailableAccounts,
-			ServerScript:           p.ServerScript,
+			DbDao:         p.DbDao,
+			Rc:            p.Rc,
+			Es:            p.Es,
+			DasCore:       p.DasCore,
+			Ctx:           p.Ctx,
+			DasCache:      p.DasCache,
+			TxBuilderBase: p.TxBuilderBase,
+			ReservedList:  p.ReservedList,
+			ServerScript:  p.ServerScript,
 		}),
 		rc: p.Rc,
 	}
@@ -3,11 +3,14 @@ package http_server
 import (
 	"das_register_server/config"
 	"das_register_server/http_server/api_code"
+	"das_register_server/prometheus"
+	"das_register_server/tracing"
 	"encoding/json"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/http_api"
 	sentrygin "github.com/getsentry/sentry-go/gin"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/scorpiotzh/toolib"
 	"net/http"
 	"time"
@@ -21,8 +24,8 @@ func (h *HttpServer) initRouter() {
 		`https:\/\/da\.systems`,
 		`https:\/\/app\.gogodas\.com`,
 	}
-	if len(config.Cfg.Origins) > 0 {
-		toolib.AllowOriginList = append(toolib.AllowOriginList, config.Cfg.Origins...)
+	if len(config.Cfg().Origins) > 0 {
+		toolib.AllowOriginList = append(toolib.AllowOriginList, config.Cfg().Origins...)
 	} else {
 		toolib.AllowOriginList = append(toolib.AllowOriginList, originList...)
 	}
@@ -31,14 +34,21 @@ func (h *HttpServer) initRouter() {
 		Repanic: true,
 	}))
 	h.engine.Use(http_api.ReqIdMiddleware())
+	h.engine.Use(tracing.MiddlewareGin(config.Cfg().Server.Name))
 	v1 := h.engine.Group("v1")
 	{
 		// cache
 		shortExpireTime, longExpireTime, lockTime := time.Second*5, time.Second*15, time.Minute
 		shortDataTime, longDataTime := time.Minute*3, time.Minute*10
-		cacheHandleShort := toolib.MiddlewareCacheByRedis(h.rc.GetRedisClient(), false, shortDataTime, lockTime, shortExpireTime, respHandle)
-		cacheHandleLong := toolib.MiddlewareCacheByRedis(h.rc.GetRedisClient(), false, longDataTime, lockTime, longExpireTime, respHandle)
+		cacheHandleShort := h.middlewareCacheByTag(shortDataTime, lockTime, shortExpireTime, nil)
+		cacheHandleLong := h.middlewareCacheByTag(longDataTime, lockTime, longExpireTime, nil)
 		//cacheHandleShortCookies := toolib.MiddlewareCacheByRedis(h.rc.GetRedisClient(), true, shortDataTime, lockTime, shortExpireTime, respHandle)
+		// purged by the block parser when a tx touches the account or address of the request
+		cacheAccountShort := h.middlewareCacheByTag(shortDataTime, lockTime, shortExpireTime, cacheTagAccount)
+		cacheAccountLong := h.middlewareCacheByTag(longDataTime, lockTime, longExpireTime, cacheTagAccount)
+		cacheAddressShort := h.middlewareCacheByTag(shortDataTime, lockTime, shortExpireTime, cacheTagAddress)
+		cacheAddressLong := h.middlewareCacheByTag(longDataTime, lockTime, longExpireTime, cacheTagAddress)
+		cacheAccountAddressShort := h.middlewareCacheByTag(shortDataTime, lockTime, shortExpireTime, cacheTagAccountAndAddress)
 
 		//v1.POST("/query", cacheHandleShort, h.h.Query)
 		//v1.POST("/operate", h.h.Operate)
@@ -46,31 +56,36 @@ func (h *HttpServer) initRouter() {
 		v1.GET("/version", api_code.DoMonitorLog("Version"), cacheHandleShort, h.h.Version)
 		v1.POST("/token/list", api_code.DoMonitorLog(api_code.MethodTokenList), cacheHandleLong, h.h.TokenList)
 		v1.POST("/config/info", api_code.DoMonitorLog(api_code.MethodConfigInfo), cacheHandleShort, h.h.ConfigInfo)
-		v1.POST("/account/list", api_code.DoMonitorLog(api_code.MethodAccountList), cacheHandleLong, h.h.AccountList) // user's not on sale accounts
-		v1.POST("/account/mine", api_code.DoMonitorLog(api_code.MethodAccountMine), cacheHandleLong, h.h.AccountMine) // user's accounts by pagination
-		v1.POST("/account/detail", api_code.DoMonitorLog(api_code.MethodAccountDetail), cacheHandleLong, h.h.AccountDetail)
-		v1.POST("/account/records", api_code.DoMonitorLog(api_code.MethodAccountRecords), cacheHandleShort, h.h.AccountRecords)
+		v1.POST("/account/list", api_code.DoMonitorLog(api_code.MethodAccountList), cacheAddressLong, h.h.AccountList) // user's not on sale accounts
+		v1.POST("/account/mine", api_code.DoMonitorLog(api_code.MethodAccountMine), cacheAddressLong, h.h.AccountMine) // user's accounts by pagination
+		v1.POST("/account/detail", api_code.DoMonitorLog(api_code.MethodAccountDetail), cacheAccountLong, h.h.AccountDetail)
+		v1.POST("/account/records", api_code.DoMonitorLog(api_code.MethodAccountRecords), cacheAccountShort, h.h.AccountRecords)
 		//v1.POST("/reverse/latest", api_code.DoMonitorLog(api_code.MethodReverseLatest), cacheHandleShort, h.h.ReverseLatest)
 		//v1.POST("/reverse/list", api_code.DoMonitorLog(api_code.MethodReverseList), cacheHandleShort, h.h.ReverseList)
-		v1.POST("/transaction/status", api_code.DoMonitorLog(api_code.MethodTransactionStatus), cacheHandleShort, h.h.TransactionStatus)
-		v1.POST("/balance/info", api_code.DoMonitorLog(api_code.MethodBalanceInfo), cacheHandleLong, h.h.BalanceInfo) // balance（712，not 712，sort address）
-		v1.POST("/transaction/list", api_code.DoMonitorLog(api_code.MethodTransactionList), cacheHandleLong, h.h.TransactionList)
-		v1.POST("/rewards/mine", api_code.DoMonitorLog(api_code.MethodRewardsMine), cacheHandleLong, h.h.RewardsMine)
-		v1.POST("/withdraw/list", api_code.DoMonitorLog(api_code.MethodWithdrawList), cacheHandleLong, h.h.WithdrawList)
-		v1.POST("/account/search", api_code.DoMonitorLog(api_code.MethodAccountSearch), cacheHandleShort, h.h.AccountSearch)
-		v1.POST("/account/registering/list", api_code.DoMonitorLog(api_code.MethodRegisteringList), cacheHandleLong, h.h.RegisteringList)
+		v1.POST("/transaction/status", api_code.DoMonitorLog(api_code.MethodTransactionStatus), cacheAddressShort, h.h.TransactionStatus)
+		v1.POST("/balance/info", api_code.DoMonitorLog(api_code.MethodBalanceInfo), cacheAddressLong, h.h.BalanceInfo) // balance（712，not 712，sort address）
+		v1.POST("/transaction/list", api_code.DoMonitorLog(api_code.MethodTransactionList), cacheAddressLong, h.h.TransactionList)
+		v1.POST("/rewards/mine", api_code.DoMonitorLog(api_code.MethodRewardsMine), cacheAddressLong, h.h.RewardsMine)
+		v1.POST("/withdraw/list", api_code.DoMonitorLog(api_code.MethodWithdrawList), cacheAddressLong, h.h.WithdrawList)
+		v1.POST("/account/search", api_code.DoMonitorLog(api_code.MethodAccountSearch), cacheAccountAddressShort, h.h.AccountSearch)
+		v1.POST("/account/search/bulk", api_code.DoMonitorLog(api_code.MethodAccountSearchBulk), cacheAccountAddressShort, h.h.AccountSearchBulk)
+		v1.POST("/account/reserved", api_code.DoMonitorLog(api_code.MethodAccountReserved), h.h.AccountReserved)
+		v1.POST("/account/registering/list", api_code.DoMonitorLog(api_code.MethodRegisteringList), cacheAddressLong, h.h.RegisteringList)
 		v1.POST("/account/order/detail", api_code.DoMonitorLog(api_code.MethodOrderDetail), h.h.OrderDetail)
 		v1.POST("/address/deposit", api_code.DoMonitorLog(api_code.MethodAddressDeposit), cacheHandleLong, h.h.AddressDeposit)
 		v1.POST("/character/set/list", api_code.DoMonitorLog(api_code.MethodCharacterSetList), cacheHandleLong, h.h.CharacterSetList)
 		v1.POST("/account/auction/info", api_code.DoMonitorLog(api_code.MethodAuctionInfo), h.h.GetAccountAuctionInfo)
 		v1.POST("/account/auction/price", api_code.DoMonitorLog(api_code.MethodAuctionPrice), h.h.GetAccountAuctionPrice)
 		v1.POST("/account/auction/order-status", api_code.DoMonitorLog(api_code.MethodAuctionOrderStatus), h.h.GetAuctionOrderStatus)
-		v1.POST("/account/auction/pending-order", api_code.DoMonitorLog(api_code.MethodAuctionPendingOrder), cacheHandleLong, h.h.GetPendingAuctionOrder)
+		v1.POST("/account/auction/pending-order", api_code.DoMonitorLog(api_code.MethodAuctionPendingOrder), cacheAddressLong, h.h.GetPendingAuctionOrder)
+		v1.POST("/account/auction/list", api_code.DoMonitorLog(api_code.MethodAuctionList), cacheHandleShort, h.h.GetAccountAuctionList)
+		v1.POST("/account/auction/bids", api_code.DoMonitorLog(api_code.MethodAuctionBids), cacheHandleShort, h.h.GetAccountAuctionBids)
+		v1.POST("/account/auction/projection", api_code.DoMonitorLog(api_code.MethodAuctionProjection), h.h.GetAccountAuctionProjection)
 		v1.POST("/account/recommend", api_code.DoMonitorLog("account-recommend"), cacheHandleShort, h.h.AccountRecommend)
-		v1.POST("/did/cell/list", api_code.DoMonitorLog("did-cell-list"), cacheHandleShort, h.h.DidCellList)
-		v1.POST("/did/cell/upgradable/list", api_code.DoMonitorLog("did-cell-upgradable-list"), cacheHandleShort, h.h.DidCellUpgradableList)
-		v1.POST("/did/cell/upgrade/price", api_code.DoMonitorLog("did-cell-upgrade-price"), cacheHandleShort, h.h.DidCellUpgradePrice)
-		v1.POST("/did/cell/recyclable/list", api_code.DoMonitorLog("did-cell-recyclable-list"), cacheHandleShort, h.h.DidCellRecyclableList)
+		v1.POST("/did/cell/list", api_code.DoMonitorLog("did-cell-list"), cacheAddressShort, h.h.DidCellList)
+		v1.POST("/did/cell/upgradable/list", api_code.DoMonitorLog("did-cell-upgradable-list"), cacheAddressShort, h.h.DidCellUpgradableList)
+		v1.POST("/did/cell/upgrade/price", api_code.DoMonitorLog("did-cell-upgrade-price"), cacheAccountShort, h.h.DidCellUpgradePrice)
+		v1.POST("/did/cell/recyclable/list", api_code.DoMonitorLog("did-cell-recyclable-list"), cacheAddressShort, h.h.DidCellRecyclableList)
 		//v1.POST("/did/cell/daslock/list", api_code.DoMonitorLog("did-cell-daslock-list"), cacheHandleShort, h.h.DidCellDasLockList)
 		v1.POST("/did/cell/daslock/edit/owner", api_code.DoMonitorLog("did-cell-daslock-edit-owner"), h.h.DidCellDasLockEditOwner)
 
@@ -101,26 +116,50 @@ func (h *HttpServer) initRouter() {
 		//v1.POST("/did/cell/renew", api_code.DoMonitorLog("did-cell-renew"), h.h.DidCellRenew)
 		//v1.POST("/did/cell/edit/record", api_code.DoMonitorLog("did-cell-edit-record"), h.h.DidCellEditRecord)
 
+		// stream, long-lived so no cache or monitor middleware
+		v1.GET("/stream/status", h.h.StatusStream)
+
 		// node rpc
 		v1.POST("/node/ckb/rpc", api_code.DoMonitorLog(api_code.MethodCkbRpc), h.h.CkbRpc)
 		v1.GET("/test/jenkins", func(c *gin.Context) {
 			c.JSON(200, "main--v1.15.3")
 		})
+		v1.GET("/openapi.json", apiDocHandle(h.engine, apiDocRoutes))
 	}
 
+	h.internalEngine.Use(tracing.MiddlewareGin(config.Cfg().Server.Name))
 	internalV1 := h.internalEngine.Group("v1")
 	{
 		internalV1.POST("/refund/apply", h.h.RefundApply)
-		if config.Cfg.Server.Net != common.DasNetTypeMainNet {
+		if config.Cfg().Server.Net != common.DasNetTypeMainNet {
 			internalV1.POST("/sign/tx", h.h.SignTx)
 		}
 		internalV1.POST("/order/info", h.h.OrderInfo)
 		internalV1.POST("/account/register", h.h.AccountRegister)
 		internalV1.POST("/account/renew", h.h.AccountRenew)
 		internalV1.POST("/order/detail", h.h.DasOrderDetail)
+		internalV1.POST("/order/stuck", h.h.OrderStuck)
+		internalV1.POST("/wallet/status", h.h.WalletStatus)
 		internalV1.POST("/create/coupon", h.h.CreateCoupon)
+		internalV1.POST("/coupon/list", h.h.CouponList)
+		internalV1.POST("/coupon/revoke", h.h.CouponRevoke)
+		internalV1.POST("/coupon/extend", h.h.CouponExtend)
+		internalV1.POST("/coupon/lookup", h.h.CouponLookup)
+		internalV1.POST("/coupon/stats", h.h.CouponStats)
 		internalV1.POST("/unipay/notice", h.h.UniPayNotice)
+		internalV1.POST("/partner/webhook/set", h.h.PartnerWebhookSet)
+		internalV1.POST("/partner/webhook/info", h.h.PartnerWebhookInfo)
+		internalV1.POST("/partner/webhook/deliveries", h.h.PartnerWebhookDeliveries)
+		internalV1.POST("/partner/webhook/redeliver", h.h.PartnerWebhookRedeliver)
+		internalV1.GET("/report", h.h.Report)
+		internalV1.POST("/referral/dashboard", h.h.ReferralDashboard)
+		internalV1.GET("/referral/payout", h.h.ReferralPayout)
+		internalV1.POST("/referral/channel/list", h.h.ReferralChannelList)
+		internalV1.POST("/referral/channel/set", h.h.ReferralChannelSet)
+		internalV1.GET("/openapi.json", apiDocHandle(h.internalEngine, apiDocInternalRoutes))
 	}
+	// prometheus scrape, same registry as the push gateway
+	h.internalEngine.GET("/metrics", gin.WrapH(promhttp.HandlerFor(prometheus.PromRegister, promhttp.HandlerOpts{})))
 }
 
 func respHandle(c *gin.Context, res string, err error) {
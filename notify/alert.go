@@ -0,0 +1,324 @@
+package notify
+
+import (
+	"context"
+	"das_register_server/config"
+	"das_register_server/prometheus"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "info"
+}
+
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(s) {
+	case "", "info":
+		return SeverityInfo, nil
+	case "warning":
+		return SeverityWarning, nil
+	case "error":
+		return SeverityError, nil
+	case "critical":
+		return SeverityCritical, nil
+	}
+	return SeverityInfo, fmt.Errorf("unknown severity [%s]", s)
+}
+
+type Alert struct {
+	Severity Severity
+	Title    string
+	Text     string
+	Key      string // dedup key, title and text when empty
+	Time     time.Time
+
+	suppressed int
+}
+
+func (a *Alert) dedupKey() string {
+	if a.Key != "" {
+		return a.Title + "\n" + a.Key
+	}
+	return a.Title + "\n" + a.Text
+}
+
+// Message is the alert rendered as plain text for the sinks
+func (a *Alert) Message() string {
+	msg := a.Text
+	if a.suppressed > 0 {
+		msg += fmt.Sprintf("\n(%d identical alerts suppressed)", a.suppressed)
+	}
+	return msg
+}
+
+func (a *Alert) Subject() string {
+	return fmt.Sprintf("[%s] %s", strings.ToUpper(a.Severity.String()), a.Title)
+}
+
+// Notifier is a sink alerts are delivered to
+type Notifier interface {
+	Notify(a *Alert) error
+}
+
+const (
+	alertQueueSize        = 1000
+	alertDedupMaxEntries  = 10000
+	defaultAlertDedup     = time.Minute * 10
+	defaultAlertRateLimit = 20
+)
+
+type alertRoute struct {
+	title       *regexp.Regexp
+	minSeverity Severity
+	sinks       []string
+}
+
+type sinkLimit struct {
+	windowStart time.Time
+	count       int
+	dropped     int
+}
+
+type Alerter struct {
+	queue     chan *Alert
+	sinks     map[string]Notifier
+	routes    []alertRoute
+	dedup     time.Duration
+	rateLimit int
+
+	lock   sync.Mutex
+	sent   map[string]*Alert // last sent alert by dedup key
+	limits map[string]*sinkLimit
+}
+
+var alerter *Alerter
+
+// InitAlert builds the sinks and routes from config.Cfg().Alert and starts delivering,
+// without any configured sink alerts are logged and sent to notify.lark_error_key
+func InitAlert(ctx context.Context, wg *sync.WaitGroup) error {
+	a, err := NewAlerter()
+	if err != nil {
+		return err
+	}
+	a.Run(ctx, wg)
+	alerter = a
+	return nil
+}
+
+func NewAlerter() (*Alerter, error) {
+	cfg := config.Cfg().Alert
+	a := Alerter{
+		queue:     make(chan *Alert, alertQueueSize),
+		sinks:     make(map[string]Notifier),
+		dedup:     time.Duration(cfg.DedupSeconds) * time.Second,
+		rateLimit: cfg.RateLimitPerMinute,
+		sent:      make(map[string]*Alert),
+		limits:    make(map[string]*sinkLimit),
+	}
+	if a.dedup == 0 {
+		a.dedup = defaultAlertDedup
+	}
+	if a.rateLimit == 0 {
+		a.rateLimit = defaultAlertRateLimit
+	}
+	for _, v := range cfg.Sinks {
+		if _, ok := a.sinks[v.Name]; ok || v.Name == "" {
+			return nil, fmt.Errorf("alert sink name [%s] invalid or duplicated", v.Name)
+		}
+		n, err := NewNotifier(v)
+		if err != nil {
+			return nil, fmt.Errorf("alert sink [%s]: %s", v.Name, err.Error())
+		}
+		a.sinks[v.Name] = n
+	}
+	if len(a.sinks) == 0 {
+		a.sinks[SinkTypeLog] = &LogNotifier{}
+		if key := config.Cfg().Notify.LarkErrorKey; key != "" {
+			a.sinks[SinkTypeLark] = &LarkNotifier{Key: key}
+		}
+	}
+
+	for i, v := range cfg.Routes {
+		route := alertRoute{sinks: v.Sinks}
+		if v.Title != "" {
+			re, err := regexp.Compile(v.Title)
+			if err != nil {
+				return nil, fmt.Errorf("alert route [%d] title: %s", i, err.Error())
+			}
+			route.title = re
+		}
+		severity, err := ParseSeverity(v.MinSeverity)
+		if err != nil {
+			return nil, fmt.Errorf("alert route [%d]: %s", i, err.Error())
+		}
+		route.minSeverity = severity
+		for _, s := range v.Sinks {
+			if _, ok := a.sinks[s]; !ok {
+				return nil, fmt.Errorf("alert route [%d] sink [%s] not exist", i, s)
+			}
+		}
+		a.routes = append(a.routes, route)
+	}
+	if len(a.routes) == 0 {
+		var all []string
+		for name := range a.sinks {
+			all = append(all, name)
+		}
+		a.routes = append(a.routes, alertRoute{sinks: all})
+	}
+	return &a, nil
+}
+
+func (a *Alerter) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer http_api.RecoverPanic()
+		for {
+			select {
+			case v := <-a.queue:
+				a.deliver(v)
+			case <-ctx.Done():
+				log.Debug("alerter done")
+				wg.Done()
+				return
+			}
+		}
+	}()
+}
+
+// deliver sends v to the sinks of every matching route unless it is a duplicate or the sink is over its limit
+func (a *Alerter) deliver(v *Alert) {
+	if !a.checkDedup(v) {
+		return
+	}
+	for _, name := range a.match(v) {
+		if !a.checkLimit(name, v) {
+			continue
+		}
+		if err := a.sinks[name].Notify(v); err != nil {
+			log.Error("alert notify err:", name, v.Title, err.Error())
+		}
+	}
+}
+
+func (a *Alerter) checkDedup(v *Alert) bool {
+	if a.dedup <= 0 {
+		return true
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	key := v.dedupKey()
+	if last, ok := a.sent[key]; ok && v.Time.Sub(last.Time) < a.dedup {
+		last.suppressed++
+		return false
+	} else if ok {
+		v.suppressed = last.suppressed
+	}
+	if len(a.sent) >= alertDedupMaxEntries {
+		for k, last := range a.sent {
+			if v.Time.Sub(last.Time) >= a.dedup {
+				delete(a.sent, k)
+			}
+		}
+	}
+	a.sent[key] = &Alert{Time: v.Time}
+	return true
+}
+
+func (a *Alerter) match(v *Alert) []string {
+	var list []string
+	seen := make(map[string]struct{})
+	for _, r := range a.routes {
+		if v.Severity < r.minSeverity || (r.title != nil && !r.title.MatchString(v.Title)) {
+			continue
+		}
+		for _, s := range r.sinks {
+			if _, ok := seen[s]; !ok {
+				seen[s] = struct{}{}
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
+
+func (a *Alerter) checkLimit(sink string, v *Alert) bool {
+	if a.rateLimit <= 0 || v.Severity >= SeverityCritical {
+		return true
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	l, ok := a.limits[sink]
+	if !ok {
+		l = &sinkLimit{}
+		a.limits[sink] = l
+	}
+	if v.Time.Sub(l.windowStart) >= time.Minute {
+		if l.dropped > 0 {
+			log.Warn("alert rate limited:", sink, l.dropped)
+		}
+		l.windowStart, l.count, l.dropped = v.Time, 0, 0
+	}
+	if l.count >= a.rateLimit {
+		l.dropped++
+		return false
+	}
+	l.count++
+	return true
+}
+
+// SendAlert queues an alert for the configured sinks, it never blocks the caller
+func SendAlert(severity Severity, title, text string) {
+	Send(&Alert{Severity: severity, Title: title, Text: text})
+}
+
+func Send(v *Alert) {
+	if v == nil || v.Title == "" || v.Text == "" {
+		return
+	}
+	if v.Time.IsZero() {
+		v.Time = time.Now()
+	}
+	if prometheus.Tools != nil {
+		prometheus.Tools.Metrics.ErrNotify().WithLabelValues(v.Title, v.Severity.String()).Inc()
+	}
+	if alerter == nil {
+		log.Warn("alert:", v.Subject(), v.Text)
+		if key := config.Cfg().Notify.LarkErrorKey; key != "" && v.Severity >= SeverityError {
+			go func() {
+				defer http_api.RecoverPanic()
+				SendLarkTextNotify(key, v.Subject(), v.Text)
+			}()
+		}
+		return
+	}
+	select {
+	case alerter.queue <- v:
+	default:
+		log.Error("alert queue full, drop:", v.Subject(), v.Text)
+	}
+}
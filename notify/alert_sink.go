@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"das_register_server/config"
+	"fmt"
+	"github.com/parnurzeal/gorequest"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+const (
+	SinkTypeLark    = "lark"
+	SinkTypeDiscord = "discord"
+	SinkTypeSlack   = "slack"
+	SinkTypeSmtp    = "smtp"
+	SinkTypeLog     = "log"
+)
+
+func NewNotifier(sink config.AlertSink) (Notifier, error) {
+	switch sink.Type {
+	case SinkTypeLark:
+		if sink.Url == "" {
+			return nil, fmt.Errorf("lark key is empty")
+		}
+		return &LarkNotifier{Key: sink.Url}, nil
+	case SinkTypeDiscord:
+		if sink.Url == "" {
+			return nil, fmt.Errorf("discord webhook is empty")
+		}
+		return &DiscordNotifier{Webhook: sink.Url}, nil
+	case SinkTypeSlack:
+		if sink.Url == "" {
+			return nil, fmt.Errorf("slack webhook is empty")
+		}
+		return &SlackNotifier{Webhook: sink.Url}, nil
+	case SinkTypeSmtp:
+		if _, _, err := net.SplitHostPort(sink.Smtp.Addr); err != nil {
+			return nil, fmt.Errorf("smtp addr: %s", err.Error())
+		} else if sink.Smtp.From == "" || len(sink.Smtp.To) == 0 {
+			return nil, fmt.Errorf("smtp from or to is empty")
+		}
+		return &SmtpNotifier{
+			Addr:     sink.Smtp.Addr,
+			User:     sink.Smtp.User,
+			Password: sink.Smtp.Password,
+			From:     sink.Smtp.From,
+			To:       sink.Smtp.To,
+		}, nil
+	case SinkTypeLog:
+		return &LogNotifier{}, nil
+	}
+	return nil, fmt.Errorf("unknown sink type [%s]", sink.Type)
+}
+
+type LarkNotifier struct {
+	Key string
+}
+
+func (n *LarkNotifier) Notify(a *Alert) error {
+	var data MsgData
+	data.MsgType = "post"
+	data.Content.Post.ZhCn.Title = a.Subject()
+	data.Content.Post.ZhCn.Content = [][]MsgContent{{{Tag: "text", Text: a.Message()}}}
+	_, err := postLark(n.Key, &data)
+	return err
+}
+
+type DiscordNotifier struct {
+	Webhook string
+}
+
+func (n *DiscordNotifier) Notify(a *Alert) error {
+	return SendNotifyDiscord(n.Webhook, fmt.Sprintf("**%s**\n%s", a.Subject(), a.Message()))
+}
+
+// SlackNotifier posts to a slack incoming webhook or anything speaking the same {"text": ""} payload
+type SlackNotifier struct {
+	Webhook string
+}
+
+func (n *SlackNotifier) Notify(a *Alert) error {
+	data := struct {
+		Text string `json:"text"`
+	}{Text: fmt.Sprintf("*%s*\n%s", a.Subject(), a.Message())}
+	resp, body, errs := gorequest.New().Post(n.Webhook).Timeout(time.Second * 10).SendStruct(&data).End()
+	if len(errs) > 0 {
+		return fmt.Errorf("errs:%v", errs)
+	} else if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http code:%d %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+type SmtpNotifier struct {
+	Addr     string
+	User     string
+	Password string
+	From     string
+	To       []string
+}
+
+func (n *SmtpNotifier) Notify(a *Alert) error {
+	var auth smtp.Auth
+	if n.User != "" {
+		host, _, _ := net.SplitHostPort(n.Addr)
+		auth = smtp.PlainAuth("", n.User, n.Password, host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		n.From, strings.Join(n.To, ","), a.Subject(), a.Time.Format(time.RFC1123Z), a.Message())
+	return smtp.SendMail(n.Addr, auth, n.From, n.To, []byte(msg))
+}
+
+type LogNotifier struct {
+}
+
+func (n *LogNotifier) Notify(a *Alert) error {
+	log.Warn("alert:", a.Subject(), a.Message())
+	return nil
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"das_register_server/config"
+	"testing"
+	"time"
+)
+
+type recordNotifier struct {
+	list []*Alert
+}
+
+func (n *recordNotifier) Notify(a *Alert) error {
+	n.list = append(n.list, a)
+	return nil
+}
+
+func newTestAlerter(t *testing.T) (*Alerter, *recordNotifier, *recordNotifier) {
+	config.Cfg().Alert.DedupSeconds = 60
+	config.Cfg().Alert.RateLimitPerMinute = 2
+	config.Cfg().Alert.Sinks = []config.AlertSink{{Name: "ops", Type: SinkTypeLog}, {Name: "oncall", Type: SinkTypeLog}}
+	config.Cfg().Alert.Routes = []config.AlertRoute{
+		{Sinks: []string{"ops"}},
+		{Title: "^Block Parse$", MinSeverity: "critical", Sinks: []string{"oncall"}},
+	}
+	defer func() {
+		config.Cfg().Alert.Sinks, config.Cfg().Alert.Routes = nil, nil
+		config.Cfg().Alert.DedupSeconds, config.Cfg().Alert.RateLimitPerMinute = 0, 0
+	}()
+	a, err := NewAlerter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, oncall := &recordNotifier{}, &recordNotifier{}
+	a.sinks["ops"], a.sinks["oncall"] = ops, oncall
+	return a, ops, oncall
+}
+
+func TestAlerterDedup(t *testing.T) {
+	a, ops, _ := newTestAlerter(t)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		a.deliver(&Alert{Severity: SeverityError, Title: "GetTipBlockNumber", Text: "timeout", Time: now.Add(time.Second * time.Duration(i))})
+	}
+	if len(ops.list) != 1 {
+		t.Fatal("duplicates not suppressed:", len(ops.list))
+	}
+	a.deliver(&Alert{Severity: SeverityError, Title: "GetTipBlockNumber", Text: "timeout", Time: now.Add(time.Minute * 2)})
+	if len(ops.list) != 2 || ops.list[1].suppressed != 4 {
+		t.Fatal("suppressed count:", len(ops.list), ops.list[len(ops.list)-1].suppressed)
+	}
+}
+
+func TestAlerterRateLimitAndRoute(t *testing.T) {
+	a, ops, oncall := newTestAlerter(t)
+	now := time.Now()
+	for i, text := range []string{"a", "b", "c", "d"} {
+		a.deliver(&Alert{Severity: SeverityError, Title: "doOrderApplyTx", Text: text, Time: now.Add(time.Second * time.Duration(i))})
+	}
+	if len(ops.list) != 2 {
+		t.Fatal("rate limit:", len(ops.list))
+	}
+	// critical skips the limit and matches the oncall route too
+	a.deliver(&Alert{Severity: SeverityCritical, Title: "Block Parse", Text: "x", Time: now.Add(time.Second * 5)})
+	if len(ops.list) != 3 || len(oncall.list) != 1 {
+		t.Fatal("critical route:", len(ops.list), len(oncall.list))
+	}
+	a.deliver(&Alert{Severity: SeverityError, Title: "Block Parse", Text: "y", Time: now.Add(time.Minute * 2)})
+	if len(oncall.list) != 1 {
+		t.Fatal("min severity not applied:", len(oncall.list))
+	}
+}
+
+func TestAlerterLarkFallback(t *testing.T) {
+	config.Cfg().Notify.LarkErrorKey = "key"
+	defer func() {
+		config.Cfg().Notify.LarkErrorKey = ""
+	}()
+	a, err := NewAlerter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := a.sinks[SinkTypeLark].(*LarkNotifier); !ok || n.Key != "key" {
+		t.Fatal("lark fallback sink:", a.sinks)
+	}
+	if a.dedup != defaultAlertDedup || a.rateLimit != defaultAlertRateLimit {
+		t.Fatal("defaults:", a.dedup, a.rateLimit)
+	}
+	if names := a.match(&Alert{Severity: SeverityError, Title: "x"}); len(names) != 2 {
+		t.Fatal("fallback route:", names)
+	}
+
+	config.Cfg().Alert.Sinks = []config.AlertSink{{Name: "ops", Type: SinkTypeLog}}
+	defer func() {
+		config.Cfg().Alert.Sinks = nil
+	}()
+	if a, err = NewAlerter(); err != nil {
+		t.Fatal(err)
+	} else if _, ok := a.sinks[SinkTypeLark]; ok {
+		t.Fatal("lark fallback with configured sinks")
+	}
+}
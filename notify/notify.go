@@ -1,7 +1,6 @@
 package notify
 
 import (
-	"das_register_server/prometheus"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/http_api/logger"
 	"github.com/parnurzeal/gorequest"
@@ -51,20 +50,25 @@ func SendLarkTextNotify(key, title, text string) {
 			},
 		},
 	}
-	url := fmt.Sprintf(LarkNotifyUrl, key)
-	_, body, errs := gorequest.New().Post(url).Timeout(time.Second * 10).SendStruct(&data).End()
-	if len(errs) > 0 {
-		log.Error("sendLarkTextNotify req err:", errs)
+	if body, err := postLark(key, &data); err != nil {
+		log.Error("sendLarkTextNotify req err:", err.Error())
 	} else {
 		log.Info("sendLarkTextNotify req:", body)
 	}
 }
 
-func SendLarkErrNotify(title, text string) {
-	if title == "" || text == "" {
-		return
+func postLark(key string, data *MsgData) (string, error) {
+	url := fmt.Sprintf(LarkNotifyUrl, key)
+	_, body, errs := gorequest.New().Post(url).Timeout(time.Second * 10).SendStruct(data).End()
+	if len(errs) > 0 {
+		return body, fmt.Errorf("errs:%v", errs)
 	}
-	prometheus.Tools.Metrics.ErrNotify().WithLabelValues(title, text).Inc()
+	return body, nil
+}
+
+// SendLarkErrNotify raises an error alert, see Send for routing
+func SendLarkErrNotify(title, text string) {
+	SendAlert(SeverityError, title, text)
 }
 
 func GetLarkTextNotifyStr(funcName, keyInfo, errInfo string) string {
@@ -98,10 +102,8 @@ func SendLarkTextNotifyAtAll(key, title, text string) {
 			},
 		},
 	}
-	url := fmt.Sprintf(LarkNotifyUrl, key)
-	_, body, errs := gorequest.New().Post(url).Timeout(time.Second * 10).SendStruct(&data).End()
-	if len(errs) > 0 {
-		log.Error("SendLarkTextNotifyAtAll req err:", errs)
+	if body, err := postLark(key, &data); err != nil {
+		log.Error("SendLarkTextNotifyAtAll req err:", err.Error())
 	} else {
 		log.Info("SendLarkTextNotifyAtAll req:", body)
 	}
@@ -27,24 +27,7 @@ func SendLarkOrderNotify(p *SendLarkOrderNotifyParam) {
 > amount: %s
 > time: %s`
 	address := fmt.Sprintf("(%s)%s", p.ChainType.ToString(), p.Address)
-	amount := p.Amount
-	switch p.PayTokenId {
-	case tables.TokenIdBnb, tables.TokenIdEth, tables.TokenIdPol: //, tables.TokenIdMatic:
-		amount = amount.DivRound(decimal.New(1, 18), 18)
-	case tables.TokenIdCkb, tables.TokenIdDas, tables.TokenIdCkbInternal,
-		tables.TokenIdPadgeInternal, tables.TokenIdCkbCCC:
-		amount = amount.DivRound(decimal.New(1, 8), 8)
-	case tables.TokenIdTrx:
-		amount = amount.DivRound(decimal.New(1, 6), 6)
-	case tables.TokenIdWx:
-		amount = amount.DivRound(decimal.New(1, 2), 2)
-	case tables.TokenIdDoge:
-		amount = amount.DivRound(decimal.New(1, 8), 8)
-	case tables.TokenIdStripeUSD:
-		amount = amount.DivRound(decimal.New(1, 2), 2)
-	case tables.ToKenIdDidPoint:
-		amount = amount.DivRound(decimal.New(1, 6), 6)
-	}
+	amount := p.PayTokenId.ToDecimal(p.Amount)
 	msg = fmt.Sprintf(msg, p.Account, p.OrderId, address, p.PayTokenId, amount.String(), time.Now().Format("2006-01-02 15:04:05"))
 	SendLarkTextNotify(p.Key, p.Action, msg)
 }
@@ -25,5 +25,5 @@ func SendLarkRegisterNotify(p *SendLarkRegisterNotifyParam) {
 	t := time.Unix(int64(p.Time/1000), 0)
 
 	msg = fmt.Sprintf(msg, p.Account, p.OrderId, t.Format("2006-01-02 15:04:05"), p.Hash)
-	SendLarkTextNotify(config.Cfg.Notify.LarkRegisterKey, p.Action, msg)
+	SendLarkTextNotify(config.Cfg().Notify.LarkRegisterKey, p.Action, msg)
 }
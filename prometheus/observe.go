@@ -0,0 +1,152 @@
+package prometheus
+
+import (
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const (
+	ComponentMysql  = "mysql"
+	ComponentRedis  = "redis"
+	ComponentCkbRpc = "ckb_rpc"
+	ComponentSigner = "signer"
+
+	TxSendResultOk   = "ok"
+	TxSendResultFail = "fail"
+)
+
+// the helpers below are no-ops before Init, so packages can record metrics without caring about start order
+
+func ObserveLatency(component, operation string, start time.Time) {
+	if Tools == nil {
+		return
+	}
+	Tools.Metrics.Latency().WithLabelValues(component, operation).Observe(time.Since(start).Seconds())
+}
+
+func ObserveTxSend(action string, err error) {
+	if Tools == nil {
+		return
+	}
+	result := TxSendResultOk
+	if err != nil {
+		result = TxSendResultFail
+	}
+	Tools.Metrics.TxSend().WithLabelValues(action, result).Inc()
+}
+
+// ObserveSign counts the signatures of a signer role by key, current or previous
+func ObserveSign(role, key string, err error) {
+	if Tools == nil {
+		return
+	}
+	result := TxSendResultOk
+	if err != nil {
+		result = TxSendResultFail
+	}
+	Tools.Metrics.SignerSign().WithLabelValues(role, key, result).Inc()
+}
+
+// ObserveCellPool counts the reserve, sent, release and conflict events of the pay cell pool
+func ObserveCellPool(event string) {
+	if Tools == nil {
+		return
+	}
+	Tools.Metrics.CellPool().WithLabelValues(event).Inc()
+}
+
+// ObserveCellPoolFree sets the usable free cells, target and split size in CKB of the pay cell pool
+func ObserveCellPoolFree(free, target int, splitCkb uint64) {
+	if Tools == nil {
+		return
+	}
+	gauge := Tools.Metrics.CellPoolFree()
+	gauge.WithLabelValues("free_cells").Set(float64(free))
+	gauge.WithLabelValues("target").Set(float64(target))
+	gauge.WithLabelValues("split_ckb").Set(float64(splitCkb))
+}
+
+// ObservePaymentConfirm orderTimestamp is the order create time in milliseconds
+func ObservePaymentConfirm(payTokenId string, orderTimestamp int64) {
+	if Tools == nil || orderTimestamp <= 0 {
+		return
+	}
+	seconds := float64(time.Now().UnixMilli()-orderTimestamp) / 1e3
+	Tools.Metrics.PaymentConfirm().WithLabelValues(payTokenId).Observe(seconds)
+}
+
+const gormStartKey = "prometheus:start"
+
+// RegisterGormCallbacks records the latency of every statement executed by db,
+// name distinguishes the databases, e.g. register_query, parser_query
+func RegisterGormCallbacks(db *gorm.DB, name string) error {
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(gormStartKey, time.Now())
+	}
+	after := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			if v, ok := tx.InstanceGet(gormStartKey); ok {
+				if start, ok := v.(time.Time); ok {
+					ObserveLatency(ComponentMysql, name+"_"+operation, start)
+				}
+			}
+		}
+	}
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("prometheus:before_create", before); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("prometheus:after_create", after("create")); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("prometheus:before_query", before); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("prometheus:after_query", after("query")); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("prometheus:before_update", before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("prometheus:after_update", after("update")); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("prometheus:before_delete", before); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("prometheus:after_delete", after("delete")); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("prometheus:before_row", before); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("prometheus:after_row", after("row")); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("gorm:raw").Register("prometheus:before_raw", before); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("prometheus:after_raw", after("raw"))
+}
+
+// WrapRedis records the latency of every command and pipeline sent by red, labeled by command name
+func WrapRedis(red *redis.Client) {
+	red.WrapProcess(func(oldProcess func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			start := time.Now()
+			err := oldProcess(cmd)
+			ObserveLatency(ComponentRedis, strings.ToLower(cmd.Name()), start)
+			return err
+		}
+	})
+	red.WrapProcessPipeline(func(oldProcess func([]redis.Cmder) error) func([]redis.Cmder) error {
+		return func(cmds []redis.Cmder) error {
+			start := time.Now()
+			err := oldProcess(cmds)
+			ObserveLatency(ComponentRedis, "pipeline", start)
+			return err
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/dotbitHQ/das-lib/http_api/logger"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/push"
 	"net"
 	"sync"
@@ -24,9 +25,22 @@ type Prometheus struct {
 }
 
 type Metric struct {
-	l         sync.Mutex
-	api       *prometheus.SummaryVec
-	errNotify *prometheus.CounterVec
+	l              sync.Mutex
+	api            *prometheus.SummaryVec
+	errNotify      *prometheus.CounterVec
+	orderCount     *prometheus.GaugeVec
+	orderOldest    *prometheus.GaugeVec
+	paymentConfirm *prometheus.HistogramVec
+	txSend         *prometheus.CounterVec
+	parserLag      prometheus.Gauge
+	refundQueue    *prometheus.GaugeVec
+	tokenPriceAge  *prometheus.GaugeVec
+	latency        *prometheus.HistogramVec
+	stuckOrder     *prometheus.GaugeVec
+	signerSign     *prometheus.CounterVec
+	hotWallet      *prometheus.GaugeVec
+	cellPool       *prometheus.CounterVec
+	cellPoolFree   *prometheus.GaugeVec
 }
 
 func (m *Metric) Api() *prometheus.SummaryVec {
@@ -47,21 +61,193 @@ func (m *Metric) ErrNotify() *prometheus.CounterVec {
 		defer m.l.Unlock()
 		m.errNotify = prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "notify",
-		}, []string{"title", "text"})
+		}, []string{"title", "severity"})
 		PromRegister.MustRegister(m.errNotify)
 	}
 	return m.errNotify
 }
 
+// OrderCount is the number of open orders in each register stage
+func (m *Metric) OrderCount() *prometheus.GaugeVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.orderCount == nil {
+		m.orderCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "order_count",
+		}, []string{"action", "register_status"})
+		PromRegister.MustRegister(m.orderCount)
+	}
+	return m.orderCount
+}
+
+// OrderOldest is the age in seconds of the oldest open order in each register stage
+func (m *Metric) OrderOldest() *prometheus.GaugeVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.orderOldest == nil {
+		m.orderOldest = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "order_oldest_seconds",
+		}, []string{"action", "register_status"})
+		PromRegister.MustRegister(m.orderOldest)
+	}
+	return m.orderOldest
+}
+
+// StuckOrder is the number of orders over the time budget of their stage
+func (m *Metric) StuckOrder() *prometheus.GaugeVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.stuckOrder == nil {
+		m.stuckOrder = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "stuck_order_count",
+		}, []string{"stage"})
+		PromRegister.MustRegister(m.stuckOrder)
+	}
+	return m.stuckOrder
+}
+
+// SignerSign is the number of signatures by signer role and key
+func (m *Metric) SignerSign() *prometheus.CounterVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.signerSign == nil {
+		m.signerSign = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "signer_sign",
+		}, []string{"role", "key", "result"})
+		PromRegister.MustRegister(m.signerSign)
+	}
+	return m.signerSign
+}
+
+// HotWallet is the spendable capacity, cells, spending and runway of the signer addresses
+func (m *Metric) HotWallet() *prometheus.GaugeVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.hotWallet == nil {
+		m.hotWallet = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "hot_wallet",
+		}, []string{"role", "item"})
+		PromRegister.MustRegister(m.hotWallet)
+	}
+	return m.hotWallet
+}
+
+// CellPool is the number of reservations of the pay cell pool by event
+func (m *Metric) CellPool() *prometheus.CounterVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.cellPool == nil {
+		m.cellPool = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cell_pool",
+		}, []string{"event"})
+		PromRegister.MustRegister(m.cellPool)
+	}
+	return m.cellPool
+}
+
+// CellPoolFree is the free cells, target and split size of the pay cell pool
+func (m *Metric) CellPoolFree() *prometheus.GaugeVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.cellPoolFree == nil {
+		m.cellPoolFree = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cell_pool_free",
+		}, []string{"item"})
+		PromRegister.MustRegister(m.cellPoolFree)
+	}
+	return m.cellPoolFree
+}
+
+// PaymentConfirm is the time from order creation to payment confirmation
+func (m *Metric) PaymentConfirm() *prometheus.HistogramVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.paymentConfirm == nil {
+		m.paymentConfirm = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "payment_confirm_seconds",
+			Buckets: []float64{30, 60, 120, 300, 600, 1800, 3600, 7200, 21600, 86400},
+		}, []string{"pay_token_id"})
+		PromRegister.MustRegister(m.paymentConfirm)
+	}
+	return m.paymentConfirm
+}
+
+func (m *Metric) TxSend() *prometheus.CounterVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.txSend == nil {
+		m.txSend = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tx_send",
+		}, []string{"action", "result"})
+		PromRegister.MustRegister(m.txSend)
+	}
+	return m.txSend
+}
+
+// ParserLag is the number of blocks the block parser is behind the ckb tip
+func (m *Metric) ParserLag() prometheus.Gauge {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.parserLag == nil {
+		m.parserLag = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "parser_lag_blocks",
+		})
+		PromRegister.MustRegister(m.parserLag)
+	}
+	return m.parserLag
+}
+
+func (m *Metric) RefundQueue() *prometheus.GaugeVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.refundQueue == nil {
+		m.refundQueue = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "refund_queue",
+		}, []string{"status"})
+		PromRegister.MustRegister(m.refundQueue)
+	}
+	return m.refundQueue
+}
+
+func (m *Metric) TokenPriceAge() *prometheus.GaugeVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.tokenPriceAge == nil {
+		m.tokenPriceAge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "token_price_age_seconds",
+		}, []string{"token_id"})
+		PromRegister.MustRegister(m.tokenPriceAge)
+	}
+	return m.tokenPriceAge
+}
+
+// Latency is the call latency of the dependencies, component is one of mysql, redis, ckb_rpc
+func (m *Metric) Latency() *prometheus.HistogramVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.latency == nil {
+		m.latency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "dependency_latency_seconds",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		}, []string{"component", "operation"})
+		PromRegister.MustRegister(m.latency)
+	}
+	return m.latency
+}
+
 func Init() {
 	Tools = &Prometheus{}
+	PromRegister.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
 }
 
 func (t *Prometheus) Run() {
-	if config.Cfg.Server.PrometheusPushGateway != "" && config.Cfg.Server.Name != "" {
-		t.pusher = push.New(config.Cfg.Server.PrometheusPushGateway, config.Cfg.Server.Name)
+	if config.Cfg().Server.PrometheusPushGateway != "" && config.Cfg().Server.Name != "" {
+		t.pusher = push.New(config.Cfg().Server.PrometheusPushGateway, config.Cfg().Server.Name)
 		t.pusher.Gatherer(PromRegister)
-		t.pusher.Grouping("env", fmt.Sprint(config.Cfg.Server.Net))
+		t.pusher.Grouping("env", fmt.Sprint(config.Cfg().Server.Net))
 		t.pusher.Grouping("instance", GetLocalIp("eth0"))
 
 		go func() {
@@ -0,0 +1,224 @@
+package referral
+
+import (
+	"das_register_server/config"
+	"das_register_server/dao"
+	"das_register_server/tables"
+	"encoding/csv"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/shopspring/decimal"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var oneCkb = decimal.NewFromInt(int64(common.OneCkb))
+
+// Bucket is the length of the dashboard points
+type Bucket string
+
+const (
+	BucketDay   Bucket = "day"
+	BucketWeek  Bucket = "week"
+	BucketMonth Bucket = "month"
+)
+
+func (b Bucket) Valid() bool {
+	return b == BucketDay || b == BucketWeek || b == BucketMonth
+}
+
+// Start returns the start of the bucket containing t, weeks start on monday
+func (b Bucket) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch b {
+	case BucketWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case BucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// PickTier returns the reached tier with the highest bonus rate, nil when none is reached
+func PickTier(tiers []config.ReferralTier, invitees int64, volumeUSD decimal.Decimal) *config.ReferralTier {
+	var best *config.ReferralTier
+	for i, v := range tiers {
+		if invitees < v.MinInvitees || volumeUSD.LessThan(v.MinVolumeUSD) {
+			continue
+		}
+		if best == nil || v.BonusRate.GreaterThan(best.BonusRate) {
+			best = &tiers[i]
+		}
+	}
+	return best
+}
+
+// Figures are the referral results of an account over a time range, rewards are the on-chain rebates in CKB
+type Figures struct {
+	Invitees  int64           `json:"invitees"`
+	Orders    int64           `json:"orders"` // registered orders of the invitees
+	VolumeUSD decimal.Decimal `json:"volume_usd"`
+	RewardCKB decimal.Decimal `json:"reward_ckb"`
+}
+
+type Point struct {
+	Start time.Time `json:"start"`
+	Figures
+}
+
+type Dashboard struct {
+	Account   string          `json:"account"`
+	Start     time.Time       `json:"start"`
+	End       time.Time       `json:"end"`
+	Bucket    Bucket          `json:"bucket"`
+	Total     Figures         `json:"total"`
+	Tier      string          `json:"tier"`
+	BonusRate decimal.Decimal `json:"bonus_rate"`
+	BonusCKB  decimal.Decimal `json:"bonus_ckb"`
+	Points    []Point         `json:"points"`
+}
+
+// BuildDashboard computes the results of the inviter or channel account in [start, end)
+func BuildDashboard(dbDao *dao.DbDao, account string, start, end time.Time, bucket Bucket, tiers []config.ReferralTier) (*Dashboard, error) {
+	rebates, err := dbDao.GetInviterRebates(account, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("GetInviterRebates err: %s", err.Error())
+	}
+	orders, err := dbDao.GetReferralOrders(account, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("GetReferralOrders err: %s", err.Error())
+	}
+	return newDashboard(account, start, end, bucket, tiers, rebates, orders), nil
+}
+
+func newDashboard(account string, start, end time.Time, bucket Bucket, tiers []config.ReferralTier, rebates []tables.TableRebateInfo, orders []tables.TableReferralOrder) *Dashboard {
+	d := Dashboard{Account: account, Start: start, End: end, Bucket: bucket}
+	points := make(map[int64]*Point)
+	invitees := make(map[int64]map[string]bool)
+	point := func(t time.Time) *Point {
+		s := bucket.Start(t.In(start.Location()))
+		p, ok := points[s.Unix()]
+		if !ok {
+			p = &Point{Start: s, Figures: Figures{VolumeUSD: decimal.Zero, RewardCKB: decimal.Zero}}
+			points[s.Unix()] = p
+			invitees[s.Unix()] = make(map[string]bool)
+		}
+		return p
+	}
+	for s := bucket.Start(start); s.Before(end); s = next(bucket, s) {
+		point(s)
+	}
+
+	all := make(map[string]bool)
+	d.Total = Figures{VolumeUSD: decimal.Zero, RewardCKB: decimal.Zero}
+	for _, v := range rebates {
+		p := point(time.UnixMilli(int64(v.BlockTimestamp)))
+		reward := decimal.NewFromInt(int64(v.Reward)).Div(oneCkb)
+		p.RewardCKB = p.RewardCKB.Add(reward)
+		d.Total.RewardCKB = d.Total.RewardCKB.Add(reward)
+		if key := p.Start.Unix(); !invitees[key][v.InviteeAccount] {
+			invitees[key][v.InviteeAccount] = true
+			p.Invitees++
+		}
+		all[v.InviteeAccount] = true
+	}
+	d.Total.Invitees = int64(len(all))
+	for _, v := range orders {
+		p := point(v.CreatedAt)
+		p.Orders++
+		p.VolumeUSD = p.VolumeUSD.Add(v.AmountUSD)
+		d.Total.Orders++
+		d.Total.VolumeUSD = d.Total.VolumeUSD.Add(v.AmountUSD)
+	}
+
+	for _, p := range points {
+		d.Points = append(d.Points, *p)
+	}
+	sort.Slice(d.Points, func(i, j int) bool { return d.Points[i].Start.Before(d.Points[j].Start) })
+	d.BonusRate, d.BonusCKB = decimal.Zero, decimal.Zero
+	if tier := PickTier(tiers, d.Total.Invitees, d.Total.VolumeUSD); tier != nil {
+		d.Tier, d.BonusRate = tier.Name, tier.BonusRate
+		d.BonusCKB = d.Total.RewardCKB.Mul(tier.BonusRate).RoundDown(8)
+	}
+	return &d
+}
+
+func next(bucket Bucket, t time.Time) time.Time {
+	switch bucket {
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// Payout is the bonus owed to an inviter or channel for a period, the on-chain rebates are already paid
+type Payout struct {
+	InviterAccount   string           `json:"inviter_account"`
+	InviterChainType common.ChainType `json:"inviter_chain_type"`
+	InviterAddress   string           `json:"inviter_address"`
+	Figures
+	Tier      string          `json:"tier"`
+	BonusRate decimal.Decimal `json:"bonus_rate"`
+	BonusCKB  decimal.Decimal `json:"bonus_ckb"`
+}
+
+// BuildPayouts returns the payout of every inviter and channel rewarded in [start, end), largest bonus first
+func BuildPayouts(dbDao *dao.DbDao, start, end time.Time, tiers []config.ReferralTier) ([]Payout, error) {
+	sums, err := dbDao.GetRebateSums(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("GetRebateSums err: %s", err.Error())
+	}
+	volumes, err := dbDao.GetReferralVolumes(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("GetReferralVolumes err: %s", err.Error())
+	}
+	return payouts(sums, volumes, tiers), nil
+}
+
+func payouts(sums []dao.RebateSum, volumes []dao.ReferralVolume, tiers []config.ReferralTier) []Payout {
+	byAccount := make(map[string]dao.ReferralVolume)
+	for _, v := range volumes {
+		byAccount[v.Account] = v
+	}
+	list := make([]Payout, 0, len(sums))
+	for _, v := range sums {
+		volume := byAccount[v.InviterAccount]
+		p := Payout{
+			InviterAccount:   v.InviterAccount,
+			InviterChainType: v.InviterChainType,
+			InviterAddress:   v.InviterAddress,
+			Figures: Figures{
+				Invitees:  v.Invitees,
+				Orders:    volume.Orders,
+				VolumeUSD: volume.VolumeUSD,
+				RewardCKB: v.Reward.Div(oneCkb),
+			},
+			BonusRate: decimal.Zero,
+			BonusCKB:  decimal.Zero,
+		}
+		if tier := PickTier(tiers, p.Invitees, p.VolumeUSD); tier != nil {
+			p.Tier, p.BonusRate = tier.Name, tier.BonusRate
+			p.BonusCKB = p.RewardCKB.Mul(tier.BonusRate).RoundDown(8)
+		}
+		list = append(list, p)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].BonusCKB.GreaterThan(list[j].BonusCKB) })
+	return list
+}
+
+// WritePayoutsCSV writes one row per payout, the bonus_ckb column is what to send
+func WritePayoutsCSV(out io.Writer, list []Payout) error {
+	w := csv.NewWriter(out)
+	_ = w.Write([]string{"inviter_account", "inviter_chain_type", "inviter_address", "invitees", "orders", "volume_usd", "reward_ckb", "tier", "bonus_rate", "bonus_ckb"})
+	for _, v := range list {
+		_ = w.Write([]string{v.InviterAccount, strconv.Itoa(int(v.InviterChainType)), v.InviterAddress,
+			strconv.FormatInt(v.Invitees, 10), strconv.FormatInt(v.Orders, 10), v.VolumeUSD.String(),
+			v.RewardCKB.String(), v.Tier, v.BonusRate.String(), v.BonusCKB.String()})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,88 @@
+package referral
+
+import (
+	"bytes"
+	"das_register_server/config"
+	"das_register_server/dao"
+	"das_register_server/tables"
+	"github.com/shopspring/decimal"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testTiers = []config.ReferralTier{
+	{Name: "silver", MinInvitees: 2, BonusRate: decimal.NewFromFloat(0.05)},
+	{Name: "gold", MinInvitees: 3, MinVolumeUSD: decimal.NewFromInt(100), BonusRate: decimal.NewFromFloat(0.1)},
+}
+
+func TestBucketStart(t *testing.T) {
+	day := time.Date(2024, 1, 10, 15, 4, 5, 0, time.UTC) // wednesday
+	for bucket, want := range map[Bucket]time.Time{
+		BucketDay:   time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		BucketWeek:  time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+		BucketMonth: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if got := bucket.Start(day); !got.Equal(want) {
+			t.Errorf("%s: %s, want %s", bucket, got, want)
+		}
+	}
+}
+
+func TestPickTier(t *testing.T) {
+	if tier := PickTier(testTiers, 1, decimal.NewFromInt(1000)); tier != nil {
+		t.Fatal("no tier expected:", tier.Name)
+	}
+	if tier := PickTier(testTiers, 5, decimal.NewFromInt(50)); tier == nil || tier.Name != "silver" {
+		t.Fatal("silver expected:", tier)
+	}
+	if tier := PickTier(testTiers, 3, decimal.NewFromInt(100)); tier == nil || tier.Name != "gold" {
+		t.Fatal("gold expected:", tier)
+	}
+}
+
+func TestNewDashboard(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 3)
+	rebates := []tables.TableRebateInfo{
+		{InviteeAccount: "a.bit", Reward: 10 * 1e8, BlockTimestamp: uint64(start.Add(time.Hour).UnixMilli())},
+		{InviteeAccount: "a.bit", Reward: 5 * 1e8, BlockTimestamp: uint64(start.Add(2 * time.Hour).UnixMilli())},
+		{InviteeAccount: "b.bit", Reward: 20 * 1e8, BlockTimestamp: uint64(start.AddDate(0, 0, 2).UnixMilli())},
+	}
+	orders := []tables.TableReferralOrder{
+		{Account: "a.bit", AmountUSD: decimal.NewFromInt(30), CreatedAt: start},
+		{Account: "b.bit", AmountUSD: decimal.NewFromInt(70), CreatedAt: start.AddDate(0, 0, 2)},
+	}
+	d := newDashboard("inviter.bit", start, end, BucketDay, testTiers, rebates, orders)
+	if len(d.Points) != 3 || d.Points[1].Invitees != 0 || d.Points[0].Invitees != 1 || !d.Points[0].RewardCKB.Equal(decimal.NewFromInt(15)) {
+		t.Fatalf("points: %+v", d.Points)
+	}
+	if d.Total.Invitees != 2 || d.Total.Orders != 2 || !d.Total.VolumeUSD.Equal(decimal.NewFromInt(100)) || !d.Total.RewardCKB.Equal(decimal.NewFromInt(35)) {
+		t.Fatalf("total: %+v", d.Total)
+	}
+	if d.Tier != "silver" || !d.BonusCKB.Equal(decimal.NewFromFloat(1.75)) {
+		t.Fatal("bonus:", d.Tier, d.BonusCKB)
+	}
+}
+
+func TestPayouts(t *testing.T) {
+	list := payouts([]dao.RebateSum{
+		{InviterAccount: "small.bit", InviterChainType: 1, InviterAddress: "0x1", Invitees: 1, Reward: decimal.NewFromInt(100 * 1e8)},
+		{InviterAccount: "big.bit", InviterChainType: 1, InviterAddress: "0x2", Invitees: 3, Reward: decimal.NewFromInt(200 * 1e8)},
+	}, []dao.ReferralVolume{{Account: "big.bit", Orders: 3, VolumeUSD: decimal.NewFromInt(150)}}, testTiers)
+	if len(list) != 2 || list[0].InviterAccount != "big.bit" || list[0].Tier != "gold" || !list[0].BonusCKB.Equal(decimal.NewFromInt(20)) {
+		t.Fatalf("payouts: %+v", list)
+	}
+	if list[1].Tier != "" || !list[1].BonusCKB.IsZero() {
+		t.Fatalf("payouts: %+v", list[1])
+	}
+
+	var buf bytes.Buffer
+	if err := WritePayoutsCSV(&buf, list); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || lines[1] != "big.bit,1,0x2,3,3,150,200,gold,0.1,20" {
+		t.Fatalf("csv: %q", lines)
+	}
+}
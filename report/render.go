@@ -0,0 +1,160 @@
+package report
+
+import (
+	"das_register_server/tables"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const dateLayout = "2006-01-02"
+
+func (r *Report) Title() string {
+	if r.Period == PeriodWeekly {
+		return fmt.Sprintf("weekly report %s ~ %s", r.Start.Format(dateLayout), r.End.AddDate(0, 0, -1).Format(dateLayout))
+	}
+	return fmt.Sprintf("daily report %s", r.Start.Format(dateLayout))
+}
+
+func couponTypeName(t tables.CouponType) string {
+	switch t {
+	case tables.CouponType4byte:
+		return "4 chars"
+	case tables.CouponType5byte:
+		return "5 chars"
+	}
+	return strconv.Itoa(int(t))
+}
+
+func sumNum(list []LengthAmount) (num int) {
+	for _, v := range list {
+		num += v.Num
+	}
+	return
+}
+
+func writeTokenTable(b *strings.Builder, list []TokenAmount) {
+	if len(list) == 0 {
+		b.WriteString("none\n\n")
+		return
+	}
+	b.WriteString("| token | orders | amount |\n| --- | ---: | ---: |\n")
+	for _, v := range list {
+		fmt.Fprintf(b, "| %s | %d | %s |\n", v.PayTokenId, v.Num, v.Amount.String())
+	}
+	b.WriteString("\n")
+}
+
+func writeLengthTable(b *strings.Builder, list []LengthAmount) {
+	if len(list) == 0 {
+		b.WriteString("none\n\n")
+		return
+	}
+	b.WriteString("| length | token | orders | amount |\n| ---: | --- | ---: | ---: |\n")
+	for _, v := range list {
+		fmt.Fprintf(b, "| %d | %s | %d | %s |\n", v.Length, v.PayTokenId, v.Num, v.Amount.String())
+	}
+	b.WriteString("\n")
+}
+
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title())
+
+	b.WriteString("## Overview\n\n")
+	fmt.Fprintf(&b, "- accounts: %d\n- owners: %d\n", r.Accounts, r.Owners)
+	fmt.Fprintf(&b, "- new registrations: %d\n- renewals: %d\n", sumNum(r.Registrations), sumNum(r.Renewals))
+	fmt.Fprintf(&b, "- pipeline: apply_register %d, pre_register %d, propose %d, confirm_proposal %d\n\n",
+		r.Pipeline.ApplyRegister, r.Pipeline.PreRegister, r.Pipeline.Propose, r.Pipeline.ConfirmProposal)
+
+	b.WriteString("## New registrations\n\n")
+	writeLengthTable(&b, r.Registrations)
+	b.WriteString("## Renewals\n\n")
+	writeLengthTable(&b, r.Renewals)
+	b.WriteString("## Revenue\n\n")
+	writeTokenTable(&b, r.Revenue)
+	b.WriteString("## Refunds\n\n")
+	writeTokenTable(&b, r.Refunds)
+
+	b.WriteString("## Coupons used\n\n")
+	if len(r.Coupons) == 0 {
+		b.WriteString("none\n\n")
+	} else {
+		b.WriteString("| type | used |\n| --- | ---: |\n")
+		for _, v := range r.Coupons {
+			fmt.Fprintf(&b, "| %s | %d |\n", couponTypeName(v.CouponType), v.Num)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Auction bids\n\n")
+	fmt.Fprintf(&b, "- bids: %d\n- accounts: %d\n- amount (USD): %s\n\n", r.AuctionBids.Num, r.AuctionBids.Accounts, r.AuctionBids.Amount.String())
+
+	b.WriteString("## Since launch\n\n### Revenue\n\n")
+	writeTokenTable(&b, r.TotalRevenue)
+	b.WriteString("### Refunds\n\n")
+	writeTokenTable(&b, r.TotalRefunds)
+	return b.String()
+}
+
+// CSV writes one row per figure: section,item,pay_token_id,num,amount
+func (r *Report) CSV(out io.Writer) error {
+	w := csv.NewWriter(out)
+	_ = w.Write([]string{"section", "item", "pay_token_id", "num", "amount"})
+	row := func(section, item string, token tables.PayTokenId, num int64, amount string) {
+		_ = w.Write([]string{section, item, string(token), strconv.FormatInt(num, 10), amount})
+	}
+	row("period", r.Title(), "", 0, "")
+	row("overview", "accounts", "", r.Accounts, "")
+	row("overview", "owners", "", r.Owners, "")
+	row("pipeline", "apply_register", "", r.Pipeline.ApplyRegister, "")
+	row("pipeline", "pre_register", "", r.Pipeline.PreRegister, "")
+	row("pipeline", "propose", "", r.Pipeline.Propose, "")
+	row("pipeline", "confirm_proposal", "", r.Pipeline.ConfirmProposal, "")
+	for _, v := range r.Registrations {
+		row("registration", strconv.Itoa(v.Length), v.PayTokenId, int64(v.Num), v.Amount.String())
+	}
+	for _, v := range r.Renewals {
+		row("renewal", strconv.Itoa(v.Length), v.PayTokenId, int64(v.Num), v.Amount.String())
+	}
+	tokens := func(section string, list []TokenAmount) {
+		for _, v := range list {
+			row(section, "", v.PayTokenId, int64(v.Num), v.Amount.String())
+		}
+	}
+	tokens("revenue", r.Revenue)
+	tokens("refund", r.Refunds)
+	tokens("total_revenue", r.TotalRevenue)
+	tokens("total_refund", r.TotalRefunds)
+	for _, v := range r.Coupons {
+		row("coupon", couponTypeName(v.CouponType), "", int64(v.Num), "")
+	}
+	row("auction_bid", "bids", "", int64(r.AuctionBids.Num), r.AuctionBids.Amount.String())
+	row("auction_bid", "accounts", "", int64(r.AuctionBids.Accounts), "")
+	w.Flush()
+	return w.Error()
+}
+
+// Summary is the short plain text version sent as a notification
+func (r *Report) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "- accounts: %d\n- owners: %d\n", r.Accounts, r.Owners)
+	fmt.Fprintf(&b, "- new registrations: %d\n- renewals: %d\n", sumNum(r.Registrations), sumNum(r.Renewals))
+	for _, v := range r.Revenue {
+		fmt.Fprintf(&b, "- revenue %s: %s\n", v.PayTokenId, v.Amount.String())
+	}
+	for _, v := range r.Refunds {
+		fmt.Fprintf(&b, "- refund %s: %s (%d)\n", v.PayTokenId, v.Amount.String(), v.Num)
+	}
+	var coupons int
+	for _, v := range r.Coupons {
+		coupons += v.Num
+	}
+	fmt.Fprintf(&b, "- coupons used: %d\n", coupons)
+	fmt.Fprintf(&b, "- auction bids: %d\n", r.AuctionBids.Num)
+	fmt.Fprintf(&b, "- pipeline: apply_register %d, pre_register %d, propose %d, confirm_proposal %d",
+		r.Pipeline.ApplyRegister, r.Pipeline.PreRegister, r.Pipeline.Propose, r.Pipeline.ConfirmProposal)
+	return b.String()
+}
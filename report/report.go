@@ -0,0 +1,183 @@
+package report
+
+import (
+	"das_register_server/dao"
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api/logger"
+	"github.com/shopspring/decimal"
+	"sort"
+	"time"
+)
+
+var log = logger.NewLogger("report", logger.LevelDebug)
+
+type Period string
+
+const (
+	PeriodDaily  Period = "daily"
+	PeriodWeekly Period = "weekly"
+)
+
+func (p Period) Valid() bool {
+	return p == PeriodDaily || p == PeriodWeekly
+}
+
+// PeriodRange returns the [start, end) of the day or the monday based week containing day, in day's location
+func PeriodRange(period Period, day time.Time) (start, end time.Time) {
+	start = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	if period == PeriodWeekly {
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7)
+	}
+	return start, start.AddDate(0, 0, 1)
+}
+
+type Pipeline struct {
+	ApplyRegister   int64 `json:"apply_register"`
+	PreRegister     int64 `json:"pre_register"`
+	Propose         int64 `json:"propose"`
+	ConfirmProposal int64 `json:"confirm_proposal"`
+}
+
+// TokenAmount is an amount of one pay token, Amount is already divided by the token decimals
+type TokenAmount struct {
+	PayTokenId tables.PayTokenId `json:"pay_token_id"`
+	Num        int               `json:"num"`
+	Amount     decimal.Decimal   `json:"amount"`
+}
+
+type LengthAmount struct {
+	Length int `json:"length"`
+	TokenAmount
+}
+
+type Report struct {
+	Period        Period                `json:"period"`
+	Start         time.Time             `json:"start"`
+	End           time.Time             `json:"end"`
+	Accounts      int64                 `json:"accounts"`
+	Owners        int64                 `json:"owners"`
+	Pipeline      Pipeline              `json:"pipeline"` // orders still on their way to registered, not limited to the period
+	Registrations []LengthAmount        `json:"registrations"`
+	Renewals      []LengthAmount        `json:"renewals"`
+	Revenue       []TokenAmount         `json:"revenue"` // registrations and renewals
+	Refunds       []TokenAmount         `json:"refunds"`
+	TotalRevenue  []TokenAmount         `json:"total_revenue"` // since launch
+	TotalRefunds  []TokenAmount         `json:"total_refunds"`
+	Coupons       []dao.CouponUsedCount `json:"coupons"`
+	AuctionBids   dao.AuctionBidCount   `json:"auction_bids"` // amount in USD
+}
+
+// Build computes the report of the period containing day
+func Build(dbDao *dao.DbDao, period Period, day time.Time) (*Report, error) {
+	r := Report{Period: period}
+	r.Start, r.End = PeriodRange(period, day)
+
+	var err error
+	if r.Accounts, err = dbDao.GetAccountCount(); err != nil {
+		return nil, fmt.Errorf("GetAccountCount err: %s", err.Error())
+	}
+	if r.Owners, err = dbDao.GetOwnerCount(); err != nil {
+		return nil, fmt.Errorf("GetOwnerCount err: %s", err.Error())
+	}
+	statusList, err := dbDao.GetRegisterStatusCount()
+	if err != nil {
+		return nil, fmt.Errorf("GetRegisterStatusCount err: %s", err.Error())
+	}
+	for _, v := range statusList {
+		switch v.RegisterStatus {
+		case tables.RegisterStatusApplyRegister:
+			r.Pipeline.ApplyRegister = v.CountNum
+		case tables.RegisterStatusPreRegister:
+			r.Pipeline.PreRegister = v.CountNum
+		case tables.RegisterStatusProposal:
+			r.Pipeline.Propose = v.CountNum
+		case tables.RegisterStatusConfirmProposal:
+			r.Pipeline.ConfirmProposal = v.CountNum
+		}
+	}
+
+	registrations, err := dbDao.GetPaidOrderLengthAmount(common.DasActionApplyRegister, r.Start, r.End)
+	if err != nil {
+		return nil, fmt.Errorf("GetPaidOrderLengthAmount register err: %s", err.Error())
+	}
+	renewals, err := dbDao.GetPaidOrderLengthAmount(common.DasActionRenewAccount, r.Start, r.End)
+	if err != nil {
+		return nil, fmt.Errorf("GetPaidOrderLengthAmount renew err: %s", err.Error())
+	}
+	r.Registrations, r.Renewals = lengthAmounts(registrations), lengthAmounts(renewals)
+	r.Revenue = revenue(r.Registrations, r.Renewals)
+
+	refunds, err := dbDao.GetOrderRefundAmountByTime(r.Start, r.End)
+	if err != nil {
+		return nil, fmt.Errorf("GetOrderRefundAmountByTime err: %s", err.Error())
+	}
+	r.Refunds = tokenAmounts(refunds)
+	totalRevenue, err := dbDao.GetOrderTotalAmount()
+	if err != nil {
+		return nil, fmt.Errorf("GetOrderTotalAmount err: %s", err.Error())
+	}
+	r.TotalRevenue = tokenAmounts(totalRevenue)
+	totalRefunds, err := dbDao.GetOrderRefundTotalAmount()
+	if err != nil {
+		return nil, fmt.Errorf("GetOrderRefundTotalAmount err: %s", err.Error())
+	}
+	r.TotalRefunds = tokenAmounts(totalRefunds)
+
+	if r.Coupons, err = dbDao.GetCouponUsedCount(r.Start, r.End); err != nil {
+		return nil, fmt.Errorf("GetCouponUsedCount err: %s", err.Error())
+	}
+	if r.AuctionBids, err = dbDao.GetAuctionBidCount(r.Start, r.End); err != nil {
+		return nil, fmt.Errorf("GetAuctionBidCount err: %s", err.Error())
+	}
+	return &r, nil
+}
+
+func lengthAmounts(list []dao.OrderLengthAmount) []LengthAmount {
+	res := make([]LengthAmount, 0, len(list))
+	for _, v := range list {
+		res = append(res, LengthAmount{
+			Length:      v.Length,
+			TokenAmount: TokenAmount{PayTokenId: v.PayTokenId, Num: v.Num, Amount: v.PayTokenId.ToDecimal(v.Amount)},
+		})
+	}
+	return res
+}
+
+func tokenAmounts(list []dao.OrderTotalAmount) []TokenAmount {
+	res := make([]TokenAmount, 0, len(list))
+	for _, v := range list {
+		res = append(res, TokenAmount{PayTokenId: v.PayTokenId, Num: v.Num, Amount: v.PayTokenId.ToDecimal(v.Amount)})
+	}
+	sortTokenAmounts(res)
+	return res
+}
+
+func revenue(lists ...[]LengthAmount) []TokenAmount {
+	byToken := make(map[tables.PayTokenId]*TokenAmount)
+	for _, list := range lists {
+		for _, v := range list {
+			t, ok := byToken[v.PayTokenId]
+			if !ok {
+				t = &TokenAmount{PayTokenId: v.PayTokenId}
+				byToken[v.PayTokenId] = t
+			}
+			t.Num += v.Num
+			t.Amount = t.Amount.Add(v.Amount)
+		}
+	}
+	res := make([]TokenAmount, 0, len(byToken))
+	for _, v := range byToken {
+		res = append(res, *v)
+	}
+	sortTokenAmounts(res)
+	return res
+}
+
+func sortTokenAmounts(list []TokenAmount) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].PayTokenId < list[j].PayTokenId
+	})
+}
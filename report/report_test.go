@@ -0,0 +1,60 @@
+package report
+
+import (
+	"bytes"
+	"das_register_server/dao"
+	"das_register_server/tables"
+	"github.com/shopspring/decimal"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPeriodRange(t *testing.T) {
+	day := time.Date(2024, 1, 10, 15, 4, 5, 0, time.UTC) // wednesday
+	start, end := PeriodRange(PeriodDaily, day)
+	if !start.Equal(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("daily:", start, end)
+	}
+	for _, d := range []time.Time{day, time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 14, 23, 0, 0, 0, time.UTC)} {
+		start, end = PeriodRange(PeriodWeekly, d)
+		if !start.Equal(time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+			t.Fatal("weekly:", d, start, end)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	registrations := lengthAmounts([]dao.OrderLengthAmount{
+		{Length: 4, PayTokenId: tables.TokenIdCkb, Num: 2, Amount: decimal.New(1500, 8)},
+		{Length: 5, PayTokenId: tables.TokenIdEth, Num: 1, Amount: decimal.New(1, 16)},
+	})
+	renewals := lengthAmounts([]dao.OrderLengthAmount{
+		{Length: 5, PayTokenId: tables.TokenIdCkb, Num: 1, Amount: decimal.New(500, 8)},
+	})
+	r := Report{
+		Period:        PeriodWeekly,
+		Registrations: registrations,
+		Renewals:      renewals,
+		Revenue:       revenue(registrations, renewals),
+		Coupons:       []dao.CouponUsedCount{{CouponType: tables.CouponType4byte, Num: 3}},
+	}
+	r.Start, r.End = PeriodRange(PeriodWeekly, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC))
+
+	if len(r.Revenue) != 2 || r.Revenue[0].PayTokenId != tables.TokenIdCkb || r.Revenue[0].Num != 3 || r.Revenue[0].Amount.String() != "2000" {
+		t.Fatal("revenue:", r.Revenue)
+	}
+	md := r.Markdown()
+	for _, v := range []string{"# weekly report 2024-01-08 ~ 2024-01-14", "- new registrations: 3", "| 5 | eth_eth | 1 | 0.01 |", "| 4 chars | 3 |"} {
+		if !strings.Contains(md, v) {
+			t.Fatal("markdown missing:", v, "\n", md)
+		}
+	}
+	var buf bytes.Buffer
+	if err := r.CSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "revenue,,ckb_ckb,3,2000\n") || !strings.Contains(buf.String(), "registration,4,ckb_ckb,2,1500\n") {
+		t.Fatal("csv:", buf.String())
+	}
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"context"
+	"das_register_server/config"
+	"das_register_server/dao"
+	"das_register_server/notify"
+	"github.com/robfig/cron/v3"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDailyCronSpec  = "0 0 1 * * ?"
+	defaultWeeklyCronSpec = "0 10 1 * * 1"
+)
+
+type ToolReport struct {
+	Ctx   context.Context
+	Wg    *sync.WaitGroup
+	DbDao *dao.DbDao
+	cron  *cron.Cron
+}
+
+// RunReport sends the daily and weekly report to config.Cfg().Notify.LarkDasInfoKey
+func (t *ToolReport) RunReport() error {
+	if config.Cfg().Notify.LarkDasInfoKey == "" {
+		return nil
+	}
+	dailySpec, weeklySpec := config.Cfg().Report.DailyCronSpec, config.Cfg().Report.WeeklyCronSpec
+	if dailySpec == "" {
+		dailySpec = defaultDailyCronSpec
+	}
+	if weeklySpec == "" {
+		weeklySpec = defaultWeeklyCronSpec
+	}
+
+	t.cron = cron.New(cron.WithSeconds())
+	if _, err := t.cron.AddFunc(dailySpec, func() { t.doReport(PeriodDaily) }); err != nil {
+		return err
+	}
+	if _, err := t.cron.AddFunc(weeklySpec, func() { t.doReport(PeriodWeekly) }); err != nil {
+		return err
+	}
+	t.cron.Start()
+
+	t.Wg.Add(1)
+	go func() {
+		<-t.Ctx.Done()
+		<-t.cron.Stop().Done()
+		log.Debug("RunReport done")
+		t.Wg.Done()
+	}()
+	return nil
+}
+
+// doReport reports the last finished period
+func (t *ToolReport) doReport(period Period) {
+	day := time.Now().AddDate(0, 0, -1)
+	if period == PeriodWeekly {
+		day = time.Now().AddDate(0, 0, -7)
+	}
+	r, err := Build(t.DbDao, period, day)
+	if err != nil {
+		log.Error("Build err:", period, err.Error())
+		notify.SendLarkErrNotify("report", err.Error())
+		return
+	}
+	notify.SendLarkTextNotify(config.Cfg().Notify.LarkDasInfoKey, r.Title(), r.Summary())
+}
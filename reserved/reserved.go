@@ -0,0 +1,253 @@
+package reserved
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/http_api/logger"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	log = logger.NewLogger("reserved", logger.LevelDebug)
+)
+
+type Status string
+
+const (
+	StatusNone        Status = ""
+	StatusReserved    Status = "reserved"    // in a PreservedAccount config cell
+	StatusUnavailable Status = "unavailable" // in the Unavailable config cell
+)
+
+// Source is where a list was loaded from
+type Source string
+
+const (
+	SourceConfigCell Source = "config_cell"
+	SourceCache      Source = "cache" // the redis copy of the config cells, it does not say which cell
+)
+
+// preservedCells are the 20 cells the reserved names are split over
+var preservedCells = []common.ConfigCellTypeArgs{
+	common.ConfigCellTypeArgsPreservedAccount00,
+	common.ConfigCellTypeArgsPreservedAccount01,
+	common.ConfigCellTypeArgsPreservedAccount02,
+	common.ConfigCellTypeArgsPreservedAccount03,
+	common.ConfigCellTypeArgsPreservedAccount04,
+	common.ConfigCellTypeArgsPreservedAccount05,
+	common.ConfigCellTypeArgsPreservedAccount06,
+	common.ConfigCellTypeArgsPreservedAccount07,
+	common.ConfigCellTypeArgsPreservedAccount08,
+	common.ConfigCellTypeArgsPreservedAccount09,
+	common.ConfigCellTypeArgsPreservedAccount10,
+	common.ConfigCellTypeArgsPreservedAccount11,
+	common.ConfigCellTypeArgsPreservedAccount12,
+	common.ConfigCellTypeArgsPreservedAccount13,
+	common.ConfigCellTypeArgsPreservedAccount14,
+	common.ConfigCellTypeArgsPreservedAccount15,
+	common.ConfigCellTypeArgsPreservedAccount16,
+	common.ConfigCellTypeArgsPreservedAccount17,
+	common.ConfigCellTypeArgsPreservedAccount18,
+	common.ConfigCellTypeArgsPreservedAccount19,
+}
+
+// Entry is why an account is in a list
+type Entry struct {
+	Status Status
+	Cell   string // the name of the config cell, empty when loaded from the cache
+}
+
+type snapshot struct {
+	reserved    map[string]string // account hash => config cell
+	unavailable map[string]string
+	source      Source
+	updatedAt   time.Time
+}
+
+// List holds the reserved and unavailable accounts of the config cells,
+// a refresh builds new maps and swaps them in, the lookups never see a half loaded list
+type List struct {
+	dasCore *core.DasCore
+	lock    sync.Mutex // one refresh at a time
+	value   atomic.Value
+}
+
+func NewList(dasCore *core.DasCore) *List {
+	return &List{dasCore: dasCore}
+}
+
+// NewListFromMaps is a fixed list, for tests and tools without a das core
+func NewListFromMaps(reserved, unavailable map[string]struct{}) *List {
+	l := &List{}
+	l.value.Store(newSnapshot(SourceCache, reserved, unavailable))
+	return l
+}
+
+func newSnapshot(source Source, reserved, unavailable map[string]struct{}) *snapshot {
+	s := &snapshot{
+		reserved:    make(map[string]string, len(reserved)),
+		unavailable: make(map[string]string, len(unavailable)),
+		source:      source,
+		updatedAt:   time.Now(),
+	}
+	for k := range reserved {
+		s.reserved[k] = ""
+	}
+	for k := range unavailable {
+		s.unavailable[k] = ""
+	}
+	return s
+}
+
+// AccountHash is the key of the config cells, the first 20 bytes of the blake2b of the account without .bit
+func AccountHash(account string) string {
+	account = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(account), common.DasAccountSuffix))
+	return common.Bytes2Hex(common.Blake2b([]byte(account))[:20])
+}
+
+// Refresh reloads the lists from the config cells, or from the redis cache when the cells can't be read.
+// Lists loaded from the config cells are not replaced by the cache, the current lists are kept if the load fails.
+func (l *List) Refresh() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.refresh(l.loadConfigCells, l.loadCache)
+}
+
+func (l *List) refresh(loadConfigCells, loadCache func() (*snapshot, error)) error {
+	s, err := loadConfigCells()
+	if err != nil {
+		if l.load().source == SourceConfigCell {
+			return fmt.Errorf("loadConfigCells err: %s, keep the config cell lists", err.Error())
+		}
+		var errCache error
+		if s, errCache = loadCache(); errCache != nil {
+			return fmt.Errorf("loadConfigCells err: %s, loadCache err: %s", err.Error(), errCache.Error())
+		}
+		log.Warn("loadConfigCells err:", err.Error(), "use the cache")
+	}
+	l.value.Store(s)
+	log.Info("Refresh:", s.source, "reserved:", len(s.reserved), "unavailable:", len(s.unavailable))
+	return nil
+}
+
+func (l *List) loadConfigCells() (*snapshot, error) {
+	s := &snapshot{
+		reserved:    make(map[string]string),
+		unavailable: make(map[string]string),
+		source:      SourceConfigCell,
+	}
+	for i, v := range preservedCells {
+		builder, err := l.dasCore.ConfigCellDataBuilderByTypeArgs(v)
+		if err != nil {
+			return nil, fmt.Errorf("ConfigCellDataBuilderByTypeArgs [%s] err: %s", v, err.Error())
+		}
+		cell := fmt.Sprintf("PreservedAccount%02d", i)
+		for k := range builder.ConfigCellPreservedAccountMap {
+			s.reserved[k] = cell
+		}
+	}
+	builder, err := l.dasCore.ConfigCellDataBuilderByTypeArgs(common.ConfigCellTypeArgsUnavailable)
+	if err != nil {
+		return nil, fmt.Errorf("ConfigCellDataBuilderByTypeArgs [%s] err: %s", common.ConfigCellTypeArgsUnavailable, err.Error())
+	}
+	for k := range builder.ConfigCellUnavailableAccountMap {
+		s.unavailable[k] = "Unavailable"
+	}
+	s.updatedAt = time.Now()
+	return s, nil
+}
+
+func (l *List) loadCache() (*snapshot, error) {
+	var cacheBuilder core.CacheConfigCellReservedAccounts
+	strCache, err := l.dasCore.GetConfigCellByCache(core.CacheConfigCellKeyReservedAccounts)
+	if err != nil {
+		return nil, fmt.Errorf("GetConfigCellByCache err: %s", err.Error())
+	} else if strCache == "" {
+		return nil, fmt.Errorf("cache is empty")
+	} else if err = json.Unmarshal([]byte(strCache), &cacheBuilder); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	return newSnapshot(SourceCache, cacheBuilder.MapReservedAccounts, cacheBuilder.MapUnAvailableAccounts), nil
+}
+
+func (l *List) load() *snapshot {
+	s, _ := l.value.Load().(*snapshot)
+	if s == nil {
+		return &snapshot{}
+	}
+	return s
+}
+
+// Lookup is the entry of an account with or without .bit, unavailable first as account/search does
+func (l *List) Lookup(account string) Entry {
+	return l.LookupHash(AccountHash(account))
+}
+
+func (l *List) LookupHash(hash string) Entry {
+	s := l.load()
+	if cell, ok := s.unavailable[hash]; ok {
+		return Entry{Status: StatusUnavailable, Cell: cell}
+	}
+	if cell, ok := s.reserved[hash]; ok {
+		return Entry{Status: StatusReserved, Cell: cell}
+	}
+	return Entry{Status: StatusNone}
+}
+
+// LookupReservedFirst is the entry of an account with or without .bit, reserved first as account/detail does
+func (l *List) LookupReservedFirst(account string) Entry {
+	s := l.load()
+	hash := AccountHash(account)
+	if cell, ok := s.reserved[hash]; ok {
+		return Entry{Status: StatusReserved, Cell: cell}
+	}
+	if cell, ok := s.unavailable[hash]; ok {
+		return Entry{Status: StatusUnavailable, Cell: cell}
+	}
+	return Entry{Status: StatusNone}
+}
+
+// Info is the source and the time of the current lists
+func (l *List) Info() (Source, time.Time) {
+	s := l.load()
+	return s.source, s.updatedAt
+}
+
+// Run refreshes the lists every interval and whenever notify gets a config cell update,
+// the config cell outpoints are synced first so the update is seen before the next das core sync
+func (l *List) Run(ctx context.Context, wg *sync.WaitGroup, every time.Duration, notify <-chan string) {
+	ticker := time.NewTicker(every)
+	wg.Add(1)
+	go func() {
+		defer http_api.RecoverPanic()
+		defer wg.Done()
+		for {
+			select {
+			case <-ticker.C:
+				if err := l.Refresh(); err != nil {
+					log.Error("Refresh err:", err.Error())
+				}
+			case hash := <-notify:
+				log.Info("config cell updated:", hash)
+				if err := l.dasCore.AsyncDasConfigCell(); err != nil {
+					log.Error("AsyncDasConfigCell err:", err.Error())
+				}
+				if err := l.Refresh(); err != nil {
+					log.Error("Refresh err:", err.Error())
+				}
+			case <-ctx.Done():
+				ticker.Stop()
+				log.Info("reserved list refresh done")
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,78 @@
+package reserved
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	if AccountHash("Google.bit") != AccountHash("google") {
+		t.Fatal("hash of google")
+	}
+	both, reserved := AccountHash("both"), AccountHash("google")
+	l := NewListFromMaps(
+		map[string]struct{}{reserved: {}, both: {}},
+		map[string]struct{}{both: {}},
+	)
+	for account, status := range map[string]Status{"google.bit": StatusReserved, "both": StatusUnavailable, "free.bit": StatusNone} {
+		if e := l.Lookup(account); e.Status != status || e.Cell != "" {
+			t.Fatal(account, e.Status, e.Cell)
+		}
+	}
+	if e := l.LookupReservedFirst("both.bit"); e.Status != StatusReserved {
+		t.Fatal("both", e.Status)
+	}
+	if e := l.LookupReservedFirst("free"); e.Status != StatusNone {
+		t.Fatal("free", e.Status)
+	}
+	if source, updatedAt := l.Info(); source != SourceCache || updatedAt.IsZero() {
+		t.Fatal(source, updatedAt)
+	}
+
+	// a new snapshot replaces the lists at once
+	l.value.Store(&snapshot{reserved: map[string]string{AccountHash("free"): "PreservedAccount03"}, source: SourceConfigCell})
+	if e := l.Lookup("free.bit"); e.Status != StatusReserved || e.Cell != "PreservedAccount03" {
+		t.Fatal(e)
+	}
+	if e := l.Lookup("google.bit"); e.Status != StatusNone {
+		t.Fatal(e)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	cells := &snapshot{reserved: map[string]string{AccountHash("google"): "PreservedAccount00"}, source: SourceConfigCell}
+	cache := newSnapshot(SourceCache, map[string]struct{}{AccountHash("cache"): {}}, nil)
+	failed := func() (*snapshot, error) { return nil, fmt.Errorf("failed") }
+	fromCells := func() (*snapshot, error) { return cells, nil }
+	fromCache := func() (*snapshot, error) { return cache, nil }
+
+	// nothing loaded yet, the cache is used when the cells fail
+	l := &List{}
+	if err := l.refresh(failed, fromCache); err != nil {
+		t.Fatal(err)
+	} else if source, _ := l.Info(); source != SourceCache {
+		t.Fatal(source)
+	}
+	if err := l.refresh(fromCells, fromCache); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed load keeps the config cell lists
+	if err := l.refresh(failed, fromCache); err == nil {
+		t.Fatal("refresh without error")
+	}
+	if source, _ := l.Info(); source != SourceConfigCell {
+		t.Fatal(source)
+	}
+	if e := l.Lookup("google"); e.Status != StatusReserved || e.Cell != "PreservedAccount00" {
+		t.Fatal(e)
+	}
+
+	// both failing keeps the current lists
+	l = NewListFromMaps(map[string]struct{}{AccountHash("old"): {}}, nil)
+	if err := l.refresh(failed, failed); err == nil {
+		t.Fatal("refresh without error")
+	} else if e := l.Lookup("old"); e.Status != StatusReserved {
+		t.Fatal(e)
+	}
+}
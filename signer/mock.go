@@ -0,0 +1,97 @@
+package signer
+
+import (
+	"context"
+	"das_register_server/config"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/remote_sign"
+	"github.com/dotbitHQ/das-lib/sign"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const mockPath = "/v1/remote/sign"
+
+// mockKey returns the private key of the mock signer of addr, current or in its rotation window
+func mockKey(c *config.CfgServer, addr string, now time.Time) string {
+	for _, v := range c.SignerRoles() {
+		if v.Current.Type == config.SignerTypeMock && v.Current.Address == addr {
+			return v.Current.Private
+		}
+		if v.Previous.Type == config.SignerTypeMock && v.Previous.Address == addr {
+			until, err := time.ParseInLocation("2006-01-02 15:04:05", v.PreviousUntil, time.Local)
+			if err == nil && now.Before(until) {
+				return v.Previous.Private
+			}
+		}
+	}
+	return ""
+}
+
+// MockHandler serves the ckb tx signing of the remote sign api with the mock keys of the config
+func MockHandler(w http.ResponseWriter, r *http.Request) {
+	serveMock(config.Cfg(), w, r)
+}
+
+func serveMock(c *config.CfgServer, w http.ResponseWriter, r *http.Request) {
+	var req remote_sign.ReqRemoteSign
+	var apiResp http_api.ApiResp
+	defer func() {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(apiResp)
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
+		return
+	}
+	if req.SignType != remote_sign.SignTypeTx {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, fmt.Sprintf("sign type %d not supported", req.SignType))
+		return
+	}
+	private := mockKey(c, req.Address, time.Now())
+	if private == "" {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "no mock key for "+req.Address)
+		return
+	}
+	sig, err := sign.LocalSign(private)(req.Data)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeError500, err.Error())
+		return
+	}
+	apiResp.ApiRespOK(remote_sign.RespRemoteSign{Data: hex.EncodeToString(sig)})
+}
+
+// RunMockServer serves the mock keys on config.Cfg().Signer.MockAddr for development and test nets
+func RunMockServer(ctx context.Context, wg *sync.WaitGroup) error {
+	addr := config.Cfg().Signer.MockAddr
+	if addr == "" {
+		return nil
+	}
+	if config.Cfg().Server.Net == common.DasNetTypeMainNet {
+		return fmt.Errorf("mock signer is not allowed on mainnet")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(mockPath, MockHandler)
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error("mock signer ListenAndServe err:", err.Error())
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+		log.Debug("mock signer done")
+	}()
+	log.Info("mock signer ok:", addr)
+	return nil
+}
@@ -0,0 +1,135 @@
+package signer
+
+import (
+	"das_register_server/config"
+	"das_register_server/prometheus"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/sign"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	KeyCurrent  = "current"
+	KeyPrevious = "previous"
+)
+
+type roleSigner struct {
+	current       *Signer
+	previous      *Signer
+	previousUntil time.Time
+}
+
+// keys returns the keys allowed to sign for addr at now, current first
+func (r *roleSigner) keys(addr string, now time.Time) (list []*Signer, names []string) {
+	if r.current != nil && r.current.Address == addr {
+		list, names = append(list, r.current), append(names, KeyCurrent)
+	}
+	if r.previous != nil && r.previous.Address == addr && now.Before(r.previousUntil) {
+		list, names = append(list, r.previous), append(names, KeyPrevious)
+	}
+	return
+}
+
+var (
+	roles       atomic.Value // map[Role]*roleSigner
+	payAddress  atomic.Value // string
+	payAddrOnce sync.Once
+)
+
+func init() {
+	roles.Store(map[Role]*roleSigner{})
+	payAddress.Store("")
+}
+
+// Load builds the signers of every role from config.Cfg(), it is called again on config reload
+// so a rotated key is used without a restart
+func Load() error {
+	if err := load(config.Cfg()); err != nil {
+		return err
+	}
+	payAddrOnce.Do(func() {
+		payAddress.Store(Address(RolePay))
+	})
+	return nil
+}
+
+func load(c *config.CfgServer) error {
+	mockUrl := ""
+	if c.Signer.MockAddr != "" {
+		mockUrl = "http://" + c.Signer.MockAddr + mockPath
+	}
+	m := make(map[Role]*roleSigner)
+	for role, v := range c.SignerRoles() {
+		var r roleSigner
+		var err error
+		if v.Current.Address != "" {
+			if r.current, err = newSigner(v.Current, mockUrl); err != nil {
+				return fmt.Errorf("signer %s current: %s", role, err.Error())
+			}
+		}
+		if v.Previous.Address != "" && v.PreviousUntil != "" {
+			if r.previousUntil, err = time.ParseInLocation("2006-01-02 15:04:05", v.PreviousUntil, time.Local); err != nil {
+				return fmt.Errorf("signer %s previous_until: %s", role, err.Error())
+			}
+			if r.previous, err = newSigner(v.Previous, mockUrl); err != nil {
+				return fmt.Errorf("signer %s previous: %s", role, err.Error())
+			}
+		}
+		m[role] = &r
+	}
+	roles.Store(m)
+	log.Info("signer loaded")
+	return nil
+}
+
+func getRole(role Role) *roleSigner {
+	if r, ok := roles.Load().(map[Role]*roleSigner)[role]; ok {
+		return r
+	}
+	return &roleSigner{}
+}
+
+// PayAddress is the pay address of the first Load, the tx builder signs and pays the fees with it
+// and every server cell lookup uses it for the life of the process
+func PayAddress() string {
+	return payAddress.Load().(string)
+}
+
+// Address is the current address of the role
+func Address(role Role) string {
+	if r := getRole(role); r.current != nil {
+		return r.current.Address
+	}
+	return ""
+}
+
+// Handle signs for addr with the key of the role at the time of signing, falling back to
+// the previous key of the rotation window when the current one fails or is for another address
+func Handle(role Role, addr string) sign.HandleSignCkbMessage {
+	return func(message string) ([]byte, error) {
+		start := time.Now()
+		defer prometheus.ObserveLatency(prometheus.ComponentSigner, role, start)
+
+		list, names := getRole(role).keys(addr, start)
+		if len(list) == 0 {
+			prometheus.ObserveSign(role, "", fmt.Errorf("no key"))
+			return nil, fmt.Errorf("no %s signer for %s", role, addr)
+		}
+		var err error
+		for i, s := range list {
+			var sig []byte
+			sig, err = s.Sign(message)
+			prometheus.ObserveSign(role, names[i], err)
+			if err == nil {
+				if names[i] == KeyPrevious {
+					log.Warn("signed with the previous key:", role, addr)
+				}
+				return sig, nil
+			}
+			log.Error("Sign err:", role, names[i], s.Type, err.Error())
+		}
+		return nil, fmt.Errorf("%s signer: %s", role, err.Error())
+	}
+}
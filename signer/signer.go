@@ -0,0 +1,84 @@
+package signer
+
+import (
+	"bytes"
+	"das_register_server/config"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api/logger"
+	"github.com/dotbitHQ/das-lib/remote_sign"
+	"github.com/dotbitHQ/das-lib/sign"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+)
+
+var log = logger.NewLogger("signer", logger.LevelDebug)
+
+type Role = string
+
+const (
+	RolePay               Role = config.SignerRolePay
+	RoleTransferWhitelist Role = config.SignerRoleTransferWhitelist
+	RoleCapacityWhitelist Role = config.SignerRoleCapacityWhitelist
+)
+
+// Signer signs ckb sign messages for the secp256k1 lock of one address
+type Signer struct {
+	Type    string
+	Address string
+	args    []byte
+	handle  sign.HandleSignCkbMessage
+}
+
+func newSigner(k config.SignerKey, mockUrl string) (*Signer, error) {
+	addr, err := address.Parse(k.Address)
+	if err != nil {
+		return nil, fmt.Errorf("address.Parse err: %s", err.Error())
+	}
+	s := Signer{Type: k.Type, Address: k.Address, args: addr.Script.Args}
+	switch k.Type {
+	case config.SignerTypeLocal:
+		if k.Private != "" {
+			s.handle = sign.LocalSign(k.Private)
+		}
+	case config.SignerTypeRemote:
+		if k.RemoteUrl != "" {
+			s.handle = remote_sign.SignTxForCKBHandle(k.RemoteUrl, k.Address)
+		}
+	case config.SignerTypeMock:
+		s.handle = remote_sign.SignTxForCKBHandle(mockUrl, k.Address)
+	default:
+		return nil, fmt.Errorf("unknown signer type: %s", k.Type)
+	}
+	return &s, nil
+}
+
+// Sign signs message and checks the signature recovers to the lock args of the address,
+// so a key that does not belong to the address is never used in a tx
+func (s *Signer) Sign(message string) ([]byte, error) {
+	if s.handle == nil {
+		return nil, fmt.Errorf("%s signer of %s has no key", s.Type, s.Address)
+	}
+	sig, err := s.handle(message)
+	if err != nil {
+		return nil, err
+	}
+	args, err := recoverArgs(common.Hex2Bytes(message), sig)
+	if err != nil {
+		return nil, fmt.Errorf("recoverArgs err: %s", err.Error())
+	}
+	if !bytes.Equal(args, s.args) {
+		return nil, fmt.Errorf("signature of %s signer is not from the key of %s", s.Type, s.Address)
+	}
+	return sig, nil
+}
+
+// recoverArgs returns the secp256k1 blake160 lock args of the key that signed digest
+func recoverArgs(digest, sig []byte) ([]byte, error) {
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return nil, fmt.Errorf("crypto.SigToPub err: %s", err.Error())
+	}
+	return blake2b.Blake160(crypto.CompressPubkey(pub))
+}
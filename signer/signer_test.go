@@ -0,0 +1,89 @@
+package signer
+
+import (
+	"das_register_server/config"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMessage = "0x4d1e8ba2a53bd6e2b0e3aec8de6cf5ae5a4dcb13e6f7d8a25d38c8b4d59a0e16"
+
+func testKey(t *testing.T) *address.AddressGenerateResult {
+	key, err := address.GenerateAddress(address.Testnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func testLoad(t *testing.T, c *config.CfgServer, pay config.SignerRole) {
+	c.Server.Net = common.DasNetTypeTestnet2
+	c.Signer.Pay = pay
+	if err := load(c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandleRotation(t *testing.T) {
+	oldKey, newKey := testKey(t), testKey(t)
+	var c config.CfgServer
+	testLoad(t, &c, config.SignerRole{
+		Current: config.SignerKey{Type: config.SignerTypeLocal, Address: newKey.Address, Private: newKey.PrivateKey},
+	})
+	if Address(RolePay) != newKey.Address {
+		t.Fatal(Address(RolePay))
+	}
+	if _, err := Handle(RolePay, newKey.Address)(testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Handle(RolePay, oldKey.Address)(testMessage); err == nil {
+		t.Fatal("signed for an address without key")
+	}
+
+	// the previous key signs for its address until the end of the window
+	handle := Handle(RolePay, oldKey.Address)
+	rotation := config.SignerRole{
+		Current:       config.SignerKey{Type: config.SignerTypeLocal, Address: newKey.Address, Private: newKey.PrivateKey},
+		Previous:      config.SignerKey{Type: config.SignerTypeLocal, Address: oldKey.Address, Private: oldKey.PrivateKey},
+		PreviousUntil: time.Now().Add(time.Hour).Format("2006-01-02 15:04:05"),
+	}
+	testLoad(t, &c, rotation)
+	if _, err := handle(testMessage); err != nil {
+		t.Fatal(err)
+	}
+	rotation.PreviousUntil = time.Now().Add(-time.Hour).Format("2006-01-02 15:04:05")
+	testLoad(t, &c, rotation)
+	if _, err := handle(testMessage); err == nil {
+		t.Fatal("previous key signed after the window")
+	}
+
+	// a key that is not the key of the address is refused
+	testLoad(t, &c, config.SignerRole{
+		Current: config.SignerKey{Type: config.SignerTypeLocal, Address: newKey.Address, Private: oldKey.PrivateKey},
+	})
+	if _, err := Handle(RolePay, newKey.Address)(testMessage); err == nil || !strings.Contains(err.Error(), "is not from the key") {
+		t.Fatalf("wrong key: %v", err)
+	}
+}
+
+func TestMockSigner(t *testing.T) {
+	key := testKey(t)
+	var c config.CfgServer
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveMock(&c, w, r)
+	}))
+	defer srv.Close()
+
+	c.Signer.MockAddr = strings.TrimPrefix(srv.URL, "http://")
+	testLoad(t, &c, config.SignerRole{
+		Current: config.SignerKey{Type: config.SignerTypeMock, Address: key.Address, Private: key.PrivateKey},
+	})
+	if _, err := Handle(RolePay, key.Address)(testMessage); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,200 @@
+package suggest
+
+import (
+	"github.com/dotbitHQ/das-lib/common"
+	"sort"
+	"strings"
+)
+
+// Source is how a candidate was made from the searched name
+type Source string
+
+const (
+	SourceEdit   Source = "edit"   // one char replaced, added, removed or two swapped
+	SourceAffix  Source = "affix"  // a prefix or suffix added
+	SourceNumber Source = "number" // letters written as digits or a number added
+	SourceEmoji  Source = "emoji"  // a word replaced by its emoji
+	SourceLength Source = "length" // made long enough for a cheaper length tier
+	SourceEs     Source = "es"     // the recommend-acc index
+)
+
+var (
+	DefaultPrefixes = []string{"my", "the", "get", "go", "hey", "i"}
+	DefaultSuffixes = []string{"dao", "hq", "app", "labs", "club", "pro", "xyz", "web3", "official"}
+
+	numberSuffixes = []string{"1", "7", "8", "88", "99", "666", "888"}
+	leet           = map[string]string{"o": "0", "i": "1", "l": "1", "e": "3", "a": "4", "s": "5", "t": "7", "b": "8", "g": "9"}
+	wordEmoji      = map[string]string{
+		"fire": "🔥", "love": "❤", "heart": "❤", "rocket": "🚀", "moon": "🌙", "sun": "☀", "star": "⭐",
+		"cat": "🐱", "dog": "🐶", "money": "💰", "diamond": "💎", "king": "👑", "crown": "👑", "ghost": "👻",
+		"pizza": "🍕", "beer": "🍺", "coffee": "☕", "music": "🎵", "game": "🎮", "apple": "🍎", "earth": "🌍",
+	}
+	alphabet = strings.Split("abcdefghijklmnopqrstuvwxyz0123456789", "")
+)
+
+// Candidate is a name to suggest, without .bit
+type Candidate struct {
+	Name     string `json:"name"`
+	Source   Source `json:"source"`
+	Distance int    `json:"distance"` // chars changed from the searched name, ranks the candidates of the same price
+}
+
+type Options struct {
+	Prefixes   []string // DefaultPrefixes if empty
+	Suffixes   []string // DefaultSuffixes if empty
+	MinLength  int      // in chars, the candidates out of [MinLength, MaxLength] are dropped, 0 means no limit
+	MaxLength  int
+	TierLength int // shorter names are priced by their length, at least this many chars is the basic price
+	Max        int // candidates returned, closest first, 0 means all
+}
+
+// Chars splits a name without .bit into its chars, an emoji is one char
+func Chars(name string) []string {
+	list, _, _ := common.GetDotBitAccountLength(name + common.DasAccountSuffix)
+	return list
+}
+
+// Variants are the candidates for a name without .bit, closest first and without the name itself.
+// Whether a candidate is valid, free and its price are left to the caller.
+func Variants(name string, opts Options) []Candidate {
+	name = strings.ToLower(strings.TrimSuffix(name, common.DasAccountSuffix))
+	chars := Chars(name)
+	if len(chars) == 0 {
+		return nil
+	}
+	prefixes, suffixes := opts.Prefixes, opts.Suffixes
+	if len(prefixes) == 0 {
+		prefixes = DefaultPrefixes
+	}
+	if len(suffixes) == 0 {
+		suffixes = DefaultSuffixes
+	}
+
+	seen := map[string]bool{name: true}
+	var list []Candidate
+	add := func(c Candidate) {
+		if seen[c.Name] || strings.Contains(c.Name, ".") {
+			return
+		}
+		n := len(Chars(c.Name))
+		if n == 0 || (opts.MinLength > 0 && n < opts.MinLength) || (opts.MaxLength > 0 && n > opts.MaxLength) {
+			return
+		}
+		seen[c.Name] = true
+		list = append(list, c)
+	}
+
+	// numbers
+	all, changed := make([]string, len(chars)), 0
+	for i, v := range chars {
+		all[i] = v
+		if d, ok := leet[v]; ok {
+			all[i] = d
+			changed++
+			add(Candidate{Name: join(chars[:i], []string{d}, chars[i+1:]), Source: SourceNumber, Distance: 1})
+		}
+	}
+	if changed > 1 {
+		add(Candidate{Name: strings.Join(all, ""), Source: SourceNumber, Distance: changed})
+	}
+	// a short name padded up to the basic price is a length tier alternative
+	padded := func(n string, source Source) Candidate {
+		c := Candidate{Name: n, Source: source, Distance: len(Chars(n)) - len(chars)}
+		if opts.TierLength > len(chars) && len(Chars(n)) >= opts.TierLength {
+			c.Source = SourceLength
+		}
+		return c
+	}
+	for _, v := range numberSuffixes {
+		add(padded(name+v, SourceNumber))
+	}
+	// emoji
+	var words []string
+	for word := range wordEmoji {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	for _, word := range words {
+		if strings.Contains(name, word) {
+			add(Candidate{Name: strings.Replace(name, word, wordEmoji[word], 1), Source: SourceEmoji, Distance: len(word)})
+		}
+	}
+	// affixes
+	for _, v := range prefixes {
+		add(padded(v+name, SourceAffix))
+	}
+	for _, v := range suffixes {
+		add(padded(name+v, SourceAffix))
+	}
+
+	// edits last, the variants above are the same names made on purpose
+	latin := isLatin(chars)
+	for i := range chars {
+		add(Candidate{Name: join(chars[:i], chars[i+1:]), Source: SourceEdit, Distance: 1})
+		if i+1 < len(chars) && chars[i] != chars[i+1] {
+			swapped := append([]string{}, chars...)
+			swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+			add(Candidate{Name: strings.Join(swapped, ""), Source: SourceEdit, Distance: 2})
+		}
+		if !latin {
+			continue
+		}
+		for _, v := range alphabet {
+			if v != chars[i] {
+				add(Candidate{Name: join(chars[:i], []string{v}, chars[i+1:]), Source: SourceEdit, Distance: 1})
+			}
+		}
+	}
+	if latin {
+		for i := 0; i <= len(chars); i++ {
+			for _, v := range alphabet {
+				add(Candidate{Name: join(chars[:i], []string{v}, chars[i:]), Source: SourceEdit, Distance: 1})
+			}
+		}
+	}
+
+	list = interleave(list)
+	if opts.Max > 0 && len(list) > opts.Max {
+		list = list[:opts.Max]
+	}
+	return list
+}
+
+// interleave takes the closest candidate of each source in turn, so the many edits don't crowd out the rest
+func interleave(list []Candidate) []Candidate {
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Distance < list[j].Distance })
+	order := []Source{SourceAffix, SourceNumber, SourceEmoji, SourceEdit, SourceLength}
+	bySource := make(map[Source][]Candidate)
+	for _, v := range list {
+		bySource[v.Source] = append(bySource[v.Source], v)
+	}
+	res := make([]Candidate, 0, len(list))
+	for len(res) < len(list) {
+		for _, s := range order {
+			if len(bySource[s]) > 0 {
+				res = append(res, bySource[s][0])
+				bySource[s] = bySource[s][1:]
+			}
+		}
+	}
+	return res
+}
+
+func isLatin(chars []string) bool {
+	for _, v := range chars {
+		if len(v) != 1 || !strings.Contains("abcdefghijklmnopqrstuvwxyz0123456789-", v) {
+			return false
+		}
+	}
+	return true
+}
+
+func join(parts ...[]string) string {
+	var b strings.Builder
+	for _, p := range parts {
+		for _, v := range p {
+			b.WriteString(v)
+		}
+	}
+	return b.String()
+}
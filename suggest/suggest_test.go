@@ -0,0 +1,29 @@
+package suggest
+
+import "testing"
+
+func TestVariants(t *testing.T) {
+	list := Variants("fire.bit", Options{MinLength: 1, TierLength: 5})
+	sources := make(map[string]Source)
+	for _, v := range list {
+		sources[v.Name] = v.Source
+	}
+	for name, source := range map[string]Source{"🔥": SourceEmoji, "f1re": SourceNumber, "fre": SourceEdit, "fier": SourceEdit, "firedao": SourceLength, "fire88": SourceLength} {
+		if sources[name] != source {
+			t.Fatal(name, sources[name], source)
+		}
+	}
+	if _, ok := sources["fire"]; ok {
+		t.Fatal("the name itself")
+	}
+
+	list = Variants("fire", Options{MinLength: 4, MaxLength: 5, Max: 10})
+	if len(list) != 10 || list[0].Source != SourceAffix || list[1].Source != SourceNumber {
+		t.Fatal(list)
+	}
+	for _, v := range list {
+		if n := len(Chars(v.Name)); n < 4 || n > 5 {
+			t.Fatal(v)
+		}
+	}
+}
@@ -64,7 +64,7 @@ CREATE TABLE `t_das_order_info`
     `pay_amount`          DECIMAL(60)  NOT NULL DEFAULT '0' COMMENT '',
     `content`             TEXT         NOT NULL COMMENT 'order detail',
     `pay_status`          SMALLINT     NOT NULL DEFAULT '0' COMMENT '1-ing 2-ok',
-    `hedge_status`        SMALLINT     NOT NULL DEFAULT '0' COMMENT '1-ing 2-ok',
+    `hedge_status`        SMALLINT     NOT NULL DEFAULT '0' COMMENT '1-ing 2-ok 3-fail',
     `pre_register_status` SMALLINT     NOT NULL DEFAULT '0' COMMENT '1-ing 2-ok',
     `register_status`     SMALLINT     NOT NULL DEFAULT '0' COMMENT '1-6',
     `order_status`        SMALLINT     NOT NULL DEFAULT '0' COMMENT '1-closed',
@@ -122,3 +122,137 @@ CREATE TABLE `t_das_order_pay_info`
 ) ENGINE = InnoDB
   DEFAULT CHARSET = utf8mb4
   COLLATE = utf8mb4_0900_ai_ci COMMENT ='order pay info';
+
+-- t_partner_webhook
+CREATE TABLE `t_partner_webhook`
+(
+    `id`         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '',
+    `partner_id` VARCHAR(255)  NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `url`        VARCHAR(1024) NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT 'webhook url',
+    `secret`     VARCHAR(255)  NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT 'hmac secret',
+    `events`     VARCHAR(255)  NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT 'comma separated, empty means all',
+    `status`     SMALLINT      NOT NULL DEFAULT '0' COMMENT '0-enable 1-disable',
+    `created_at` TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `updated_at` TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_partner_id` (`partner_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='partner webhook';
+
+-- t_webhook_delivery
+CREATE TABLE `t_webhook_delivery`
+(
+    `id`            BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '',
+    `delivery_id`   VARCHAR(255)  NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `partner_id`    VARCHAR(255)  NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `order_id`      VARCHAR(255)  NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `event`         VARCHAR(255)  NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `payload`       TEXT          NOT NULL COMMENT 'json body',
+    `status`        SMALLINT      NOT NULL DEFAULT '0' COMMENT '0-pending 1-ok 2-fail',
+    `attempts`      INT           NOT NULL DEFAULT '0' COMMENT '',
+    `next_at`       BIGINT        NOT NULL DEFAULT '0' COMMENT 'next attempt time',
+    `response_code` INT           NOT NULL DEFAULT '0' COMMENT 'last http status',
+    `last_err`      VARCHAR(1024) NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `created_at`    TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `updated_at`    TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_delivery_id` (`delivery_id`),
+    KEY `k_partner_id` (`partner_id`),
+    KEY `k_order_id` (`order_id`),
+    KEY `k_status_next_at` (`status`, `next_at`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='partner webhook delivery log';
+
+-- t_coupon_campaign
+CREATE TABLE `t_coupon_campaign`
+(
+    `id`          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '',
+    `campaign_id` VARCHAR(255) NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `name`        VARCHAR(255) NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `rules`       TEXT         NOT NULL COMMENT 'json CampaignRules',
+    `status`      SMALLINT     NOT NULL DEFAULT '0' COMMENT '0-enable 1-disable',
+    `start_at`    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `expired_at`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `created_at`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `updated_at`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_campaign_id` (`campaign_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='coupon campaign';
+
+-- t_coupon_campaign_usage
+CREATE TABLE `t_coupon_campaign_usage`
+(
+    `id`           BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '',
+    `campaign_id`  VARCHAR(255)   NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `order_id`     VARCHAR(255)   NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `chain_type`   SMALLINT       NOT NULL DEFAULT '0' COMMENT '',
+    `address`      VARCHAR(255)   NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `action`       VARCHAR(255)   NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT 'register,renew',
+    `discount_usd` DECIMAL(60, 2) NOT NULL DEFAULT '0' COMMENT '',
+    `status`       SMALLINT       NOT NULL DEFAULT '0' COMMENT '0-held 1-released',
+    `created_at`   TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_order_campaign` (`order_id`, `campaign_id`),
+    KEY `k_campaign_address` (`campaign_id`, `chain_type`, `address`),
+    KEY `k_status` (`status`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='orders discounted by a coupon campaign';
+
+-- t_referral_channel
+CREATE TABLE `t_referral_channel`
+(
+    `id`         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '',
+    `account_id` VARCHAR(255) NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `account`    VARCHAR(255) NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `status`     SMALLINT     NOT NULL DEFAULT '0' COMMENT '0-enable 1-disable',
+    `remark`     VARCHAR(255) NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `created_at` TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `updated_at` TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_account_id` (`account_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='whitelisted inviter channels';
+
+-- t_referral_order
+CREATE TABLE `t_referral_order`
+(
+    `id`              BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '',
+    `order_id`        VARCHAR(255)   NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `inviter_account` VARCHAR(255)   NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `channel_account` VARCHAR(255)   NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `account`         VARCHAR(255)   NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT 'invitee account',
+    `chain_type`      SMALLINT       NOT NULL DEFAULT '0' COMMENT '',
+    `address`         VARCHAR(255)   NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `amount_usd`      DECIMAL(60, 2) NOT NULL DEFAULT '0' COMMENT '',
+    `created_at`      TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_order_id` (`order_id`),
+    KEY `k_inviter_account` (`inviter_account`),
+    KEY `k_channel_account` (`channel_account`),
+    KEY `k_created_at` (`created_at`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='register orders with an inviter';
+
+-- t_admin_audit
+CREATE TABLE `t_admin_audit`
+(
+    `id`         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '',
+    `operator`   VARCHAR(255)  NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `action`     VARCHAR(255)  NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `order_id`   VARCHAR(255)  NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT '',
+    `before`     VARCHAR(1024) NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT 'order statuses',
+    `result`     VARCHAR(1024) NOT NULL DEFAULT '' COLLATE utf8mb4_0900_ai_ci COMMENT 'empty-running ok or the error',
+    `created_at` TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `updated_at` TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (`id`),
+    KEY `k_order_id` (`order_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='admin commands run against orders';
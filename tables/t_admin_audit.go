@@ -0,0 +1,23 @@
+package tables
+
+import "time"
+
+const (
+	TableNameAdminAudit = "t_admin_audit"
+)
+
+// TableAdminAudit is one admin command run against an order, Before is the order statuses when it started
+type TableAdminAudit struct {
+	Id        uint64    `json:"id" gorm:"column:id;primaryKey;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	Operator  string    `json:"operator" gorm:"column:operator;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Action    string    `json:"action" gorm:"column:action;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	OrderId   string    `json:"order_id" gorm:"column:order_id;index:k_order_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Before    string    `json:"before" gorm:"column:before;type:varchar(1024) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'order statuses'"`
+	Result    string    `json:"result" gorm:"column:result;type:varchar(1024) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'empty-running ok or the error'"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+func (t *TableAdminAudit) TableName() string {
+	return TableNameAdminAudit
+}
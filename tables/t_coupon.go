@@ -15,17 +15,20 @@ const (
 	CouponStatusUsed      CouponStatus = 2
 	CouponStatusExpired   CouponStatus = 3
 	CouponStatusAvailable CouponStatus = 4
+	CouponStatusRevoked   CouponStatus = 5
 )
 
 type TableCoupon struct {
 	Id         uint64     `json:"id" gorm:"column:id;primary_key;AUTO_INCREMENT"`
 	Code       string     `json:"code" gorm:"column:code;uniqueIndex:code_unique;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT ''"`
+	BatchId    string     `json:"batch_id" gorm:"column:batch_id;index:k_batch_id;type:varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'the create request'"`
 	CouponType CouponType `json:"type" gorm:"column:type;type:tinyint NOT NULL DEFAULT '0' COMMENT '1:4 2:5'"`
 	OrderId    string     `json:"order_id" gorm:"column:order_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''""`
 	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
 	UseAt      int64      `json:"use_at" gorm:"column:use_at;type:bigint(20) NOT NULL DEFAULT '0' COMMENT 'used time'"`
 	ExpiredAt  time.Time  `json:"expired_at" gorm:"column:expired_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
 	StartAt    time.Time  `json:"start_at" gorm:"column:start_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	RevokedAt  int64      `json:"revoked_at" gorm:"column:revoked_at;type:bigint(20) NOT NULL DEFAULT '0' COMMENT 'revoked time'"`
 
 	IsCheck uint8  `json:"is_check" gorm:"column:is_check;type:tinyint NOT NULL DEFAULT '0'"`
 	Desc    string `json:"desc" gorm:"column:desc;type:text NOT NULL COMMENT 'coupon desc'"`
@@ -34,3 +37,17 @@ type TableCoupon struct {
 func (t *TableCoupon) TableName() string {
 	return TableNameCoupon
 }
+
+// GetStatus a used code stays used when it is revoked, only unused codes are revoked
+func (t *TableCoupon) GetStatus(now time.Time) CouponStatus {
+	if t.Id == 0 {
+		return CouponStatusNotfound
+	} else if t.OrderId != "" {
+		return CouponStatusUsed
+	} else if t.RevokedAt > 0 {
+		return CouponStatusRevoked
+	} else if now.Before(t.StartAt) || now.After(t.ExpiredAt) {
+		return CouponStatusExpired
+	}
+	return CouponStatusAvailable
+}
@@ -0,0 +1,135 @@
+package tables
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+const (
+	TableNameCouponCampaign      = "t_coupon_campaign"
+	TableNameCouponCampaignUsage = "t_coupon_campaign_usage"
+)
+
+type CampaignStatus int
+
+const (
+	CampaignStatusEnable  CampaignStatus = 0
+	CampaignStatusDisable CampaignStatus = 1
+)
+
+type CampaignDiscountType string
+
+const (
+	CampaignDiscountPercent  CampaignDiscountType = "percent"   // discount_value is the percentage off, (0, 100]
+	CampaignDiscountFixedUSD CampaignDiscountType = "fixed_usd" // discount_value is the USD off
+)
+
+type CampaignUsageStatus int
+
+const (
+	CampaignUsageStatusHeld     CampaignUsageStatus = 0
+	CampaignUsageStatusReleased CampaignUsageStatus = 1 // the order was closed without registering or renewing
+)
+
+type CampaignAction string
+
+const (
+	CampaignActionRegister CampaignAction = "register"
+	CampaignActionRenew    CampaignAction = "renew"
+)
+
+// CampaignRules are the eligibility and discount of a campaign, stored as json in t_coupon_campaign.rules
+type CampaignRules struct {
+	AccountLengths     []int                    `json:"account_lengths"` // without .bit, empty means all lengths
+	CharSets           []common.AccountCharType `json:"char_sets"`       // every char must be in one of them, empty means all
+	DiscountType       CampaignDiscountType     `json:"discount_type"`
+	DiscountValue      decimal.Decimal          `json:"discount_value"`
+	MaxPerAddress      int64                    `json:"max_per_address"` // orders per pay address, 0 means unlimited
+	MaxTotal           int64                    `json:"max_total"`       // orders in total, 0 means unlimited
+	MinYears           int                      `json:"min_years"`
+	Actions            []CampaignAction         `json:"actions"`              // empty means register and renew
+	StackWithCampaigns bool                     `json:"stack_with_campaigns"` // applies together with the other stacking campaigns
+	StackWithInviter   bool                     `json:"stack_with_inviter"`   // applies to orders with the inviter discount
+}
+
+func (r *CampaignRules) Check() error {
+	switch r.DiscountType {
+	case CampaignDiscountPercent:
+		if !r.DiscountValue.IsPositive() || r.DiscountValue.GreaterThan(decimal.NewFromInt(100)) {
+			return fmt.Errorf("discount_value %s not in (0, 100]", r.DiscountValue)
+		}
+	case CampaignDiscountFixedUSD:
+		if !r.DiscountValue.IsPositive() {
+			return fmt.Errorf("discount_value %s not positive", r.DiscountValue)
+		}
+	default:
+		return fmt.Errorf("unknown discount_type %s", r.DiscountType)
+	}
+	for _, v := range r.Actions {
+		if v != CampaignActionRegister && v != CampaignActionRenew {
+			return fmt.Errorf("unknown action %s", v)
+		}
+	}
+	if r.MaxPerAddress < 0 || r.MaxTotal < 0 || r.MinYears < 0 {
+		return fmt.Errorf("negative cap or min_years")
+	}
+	return nil
+}
+
+// CapReached reports the usage caps leave no room for another order of the address
+func (r *CampaignRules) CapReached(total, byAddress int64) bool {
+	return (r.MaxTotal > 0 && total >= r.MaxTotal) || (r.MaxPerAddress > 0 && byAddress >= r.MaxPerAddress)
+}
+
+type TableCouponCampaign struct {
+	Id         uint64         `json:"id" gorm:"column:id;primaryKey;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	CampaignId string         `json:"campaign_id" gorm:"column:campaign_id;uniqueIndex:uk_campaign_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Name       string         `json:"name" gorm:"column:name;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Rules      string         `json:"rules" gorm:"column:rules;type:text NOT NULL COMMENT 'json CampaignRules'"`
+	Status     CampaignStatus `json:"status" gorm:"column:status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-enable 1-disable'"`
+	StartAt    time.Time      `json:"start_at" gorm:"column:start_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	ExpiredAt  time.Time      `json:"expired_at" gorm:"column:expired_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	UpdatedAt  time.Time      `json:"updated_at" gorm:"column:updated_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+func (t *TableCouponCampaign) TableName() string {
+	return TableNameCouponCampaign
+}
+
+func (t *TableCouponCampaign) GetRules() (rules CampaignRules, err error) {
+	if err = json.Unmarshal([]byte(t.Rules), &rules); err != nil {
+		err = fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	return
+}
+
+func (t *TableCouponCampaign) IsActive(now time.Time) bool {
+	return t.Status == CampaignStatusEnable && !now.Before(t.StartAt) && now.Before(t.ExpiredAt)
+}
+
+// TableCouponCampaignUsage is one order discounted by a campaign, the caps count the held usages
+type TableCouponCampaignUsage struct {
+	Id          uint64              `json:"id" gorm:"column:id;primaryKey;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	CampaignId  string              `json:"campaign_id" gorm:"column:campaign_id;uniqueIndex:uk_order_campaign,priority:2;index:k_campaign_address,priority:1;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	OrderId     string              `json:"order_id" gorm:"column:order_id;uniqueIndex:uk_order_campaign,priority:1;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	ChainType   common.ChainType    `json:"chain_type" gorm:"column:chain_type;index:k_campaign_address,priority:2;type:smallint(6) NOT NULL DEFAULT '0' COMMENT ''"`
+	Address     string              `json:"address" gorm:"column:address;index:k_campaign_address,priority:3;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Action      CampaignAction      `json:"action" gorm:"column:action;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'register,renew'"`
+	DiscountUSD decimal.Decimal     `json:"discount_usd" gorm:"column:discount_usd;type:decimal(60,2) NOT NULL DEFAULT '0' COMMENT ''"`
+	Status      CampaignUsageStatus `json:"status" gorm:"column:status;index:k_status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-held 1-released'"`
+	CreatedAt   time.Time           `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+func (t *TableCouponCampaignUsage) TableName() string {
+	return TableNameCouponCampaignUsage
+}
+
+// CampaignDiscount is a campaign applied to an order, kept in the order content
+type CampaignDiscount struct {
+	CampaignId  string          `json:"campaign_id"`
+	DiscountUSD decimal.Decimal `json:"discount_usd"`
+}
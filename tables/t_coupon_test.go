@@ -0,0 +1,36 @@
+package tables
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTableCoupon_GetStatus(t *testing.T) {
+	now := time.Now()
+	valid := TableCoupon{Id: 1, StartAt: now.Add(-time.Hour), ExpiredAt: now.Add(time.Hour)}
+
+	used := valid
+	used.OrderId, used.RevokedAt = "xx", now.Unix()
+	revoked := valid
+	revoked.RevokedAt = now.Unix()
+	expired := valid
+	expired.ExpiredAt = now.Add(-time.Minute)
+	notStarted := valid
+	notStarted.StartAt = now.Add(time.Minute)
+
+	for _, v := range []struct {
+		coupon TableCoupon
+		status CouponStatus
+	}{
+		{TableCoupon{}, CouponStatusNotfound},
+		{valid, CouponStatusAvailable},
+		{used, CouponStatusUsed},
+		{revoked, CouponStatusRevoked},
+		{expired, CouponStatusExpired},
+		{notStarted, CouponStatusExpired},
+	} {
+		if got := v.coupon.GetStatus(now); got != v.status {
+			t.Errorf("GetStatus %+v: %d, want %d", v.coupon, got, v.status)
+		}
+	}
+}
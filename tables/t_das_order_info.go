@@ -25,7 +25,7 @@ type TableDasOrderInfo struct {
 	PayAmount         decimal.Decimal  `json:"pay_amount" gorm:"column:pay_amount;type:decimal(60,0) NOT NULL DEFAULT '0' COMMENT ''"`
 	Content           string           `json:"content" gorm:"column:content;type:text NOT NULL COMMENT 'order detail'"`
 	PayStatus         TxStatus         `json:"pay_status" gorm:"column:pay_status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '1-ing 2-ok'"`
-	HedgeStatus       TxStatus         `json:"hedge_status" gorm:"column:hedge_status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '1-ing 2-ok'"`
+	HedgeStatus       TxStatus         `json:"hedge_status" gorm:"column:hedge_status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '1-ing 2-ok 3-fail'"`
 	PreRegisterStatus TxStatus         `json:"pre_register_status" gorm:"column:pre_register_status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '1-ing 2-ok'"`
 	RegisterStatus    RegisterStatus   `json:"register_status" gorm:"column:register_status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '1-6'"`
 	OrderStatus       OrderStatus      `json:"order_status" gorm:"column:order_status;index:k_order_status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '1-closed'"`
@@ -129,6 +129,29 @@ func (p PayTokenId) ToChainString() string {
 	return ""
 }
 
+// Decimals of the pay amount, 0 when the amount is not scaled
+func (p PayTokenId) Decimals() int32 {
+	switch p {
+	case TokenIdBnb, TokenIdEth, TokenIdPol: //, TokenIdMatic:
+		return 18
+	case TokenIdCkb, TokenIdDas, TokenIdCkbInternal, TokenIdPadgeInternal, TokenIdCkbCCC, TokenIdDoge:
+		return 8
+	case TokenIdTrx, ToKenIdDidPoint:
+		return 6
+	case TokenIdWx, TokenIdStripeUSD:
+		return 2
+	}
+	return 0
+}
+
+// ToDecimal converts a pay amount in the smallest unit of the token to its decimal value
+func (p PayTokenId) ToDecimal(amount decimal.Decimal) decimal.Decimal {
+	if d := p.Decimals(); d > 0 {
+		return amount.DivRound(decimal.New(1, d), d)
+	}
+	return amount
+}
+
 type PayType string
 
 const (
@@ -143,6 +166,7 @@ const (
 	TxStatusDefault TxStatus = 0
 	TxStatusSending TxStatus = 1
 	TxStatusOk      TxStatus = 2
+	TxStatusFail    TxStatus = 3 // hedge only, the hedge request failed and admin rehedge may send it again
 )
 
 type TableOrderContent struct {
@@ -153,6 +177,8 @@ type TableOrderContent struct {
 	AmountTotalUSD decimal.Decimal         `json:"amount_total_usd"`
 	AmountTotalCKB decimal.Decimal         `json:"amount_total_ckb"`
 	RenewYears     int                     `json:"renew_years"`
+	PartnerId      string                  `json:"partner_id,omitempty"`
+	Campaigns      []CampaignDiscount      `json:"campaigns,omitempty"` // amount_total_usd is after these discounts
 }
 
 func EndWithDotBitChar(list []common.AccountCharSet) bool {
@@ -199,6 +225,40 @@ const (
 	RegisterStatusRegistered      RegisterStatus = 6
 )
 
+// OrderStage is the step a paid open order is waiting on
+type OrderStage string
+
+const (
+	OrderStageTxSend             OrderStage = "tx_send"              // paid, the apply_register/renew/did cell tx is to be sent
+	OrderStageTxConfirm          OrderStage = "tx_confirm"           // tx sent, waiting for the block parser
+	OrderStagePreRegisterSend    OrderStage = "pre_register_send"    // apply_register confirmed, pre_register tx to be sent
+	OrderStagePreRegisterConfirm OrderStage = "pre_register_confirm" // pre_register tx sent
+	OrderStagePropose            OrderStage = "propose"              // pre account cell on chain, waiting to be proposed
+	OrderStageConfirmProposal    OrderStage = "confirm_proposal"     // proposed, waiting for confirm proposal
+	OrderStageNone               OrderStage = ""                     // not paid, closed or registered
+)
+
+func (t *TableDasOrderInfo) Stage() OrderStage {
+	if t.OrderStatus != OrderStatusDefault || t.RegisterStatus >= RegisterStatusRegistered {
+		return OrderStageNone
+	}
+	switch {
+	case t.PayStatus == TxStatusSending:
+		return OrderStageTxSend
+	case t.PayStatus != TxStatusOk:
+		return OrderStageNone
+	case t.PreRegisterStatus == TxStatusSending:
+		return OrderStagePreRegisterSend
+	case t.RegisterStatus == RegisterStatusProposal:
+		return OrderStagePropose
+	case t.RegisterStatus == RegisterStatusConfirmProposal:
+		return OrderStageConfirmProposal
+	case t.PreRegisterStatus == TxStatusOk:
+		return OrderStagePreRegisterConfirm
+	}
+	return OrderStageTxConfirm
+}
+
 func FormatRegisterStatusToSearchStatus(status RegisterStatus) SearchStatus {
 	switch status {
 	case RegisterStatusRegistered:
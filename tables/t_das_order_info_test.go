@@ -0,0 +1,26 @@
+package tables
+
+import "testing"
+
+func TestOrderStage(t *testing.T) {
+	list := []struct {
+		order TableDasOrderInfo
+		stage OrderStage
+	}{
+		{TableDasOrderInfo{RegisterStatus: RegisterStatusConfirmPayment}, OrderStageNone},
+		{TableDasOrderInfo{PayStatus: TxStatusSending, RegisterStatus: RegisterStatusConfirmPayment}, OrderStageTxSend},
+		{TableDasOrderInfo{PayStatus: TxStatusOk, RegisterStatus: RegisterStatusConfirmPayment}, OrderStageTxConfirm},
+		{TableDasOrderInfo{PayStatus: TxStatusOk}, OrderStageTxConfirm}, // renew
+		{TableDasOrderInfo{PayStatus: TxStatusOk, PreRegisterStatus: TxStatusSending, RegisterStatus: RegisterStatusPreRegister}, OrderStagePreRegisterSend},
+		{TableDasOrderInfo{PayStatus: TxStatusOk, PreRegisterStatus: TxStatusOk, RegisterStatus: RegisterStatusPreRegister}, OrderStagePreRegisterConfirm},
+		{TableDasOrderInfo{PayStatus: TxStatusOk, PreRegisterStatus: TxStatusOk, RegisterStatus: RegisterStatusProposal}, OrderStagePropose},
+		{TableDasOrderInfo{PayStatus: TxStatusOk, PreRegisterStatus: TxStatusOk, RegisterStatus: RegisterStatusConfirmProposal}, OrderStageConfirmProposal},
+		{TableDasOrderInfo{PayStatus: TxStatusOk, PreRegisterStatus: TxStatusOk, RegisterStatus: RegisterStatusRegistered}, OrderStageNone},
+		{TableDasOrderInfo{PayStatus: TxStatusSending, OrderStatus: OrderStatusClosed}, OrderStageNone},
+	}
+	for i, v := range list {
+		if stage := v.order.Stage(); stage != v.stage {
+			t.Fatalf("%d: %s != %s", i, stage, v.stage)
+		}
+	}
+}
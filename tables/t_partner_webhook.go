@@ -0,0 +1,47 @@
+package tables
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	TableNamePartnerWebhook = "t_partner_webhook"
+)
+
+type WebhookStatus int
+
+const (
+	WebhookStatusEnable  WebhookStatus = 0
+	WebhookStatusDisable WebhookStatus = 1
+)
+
+type TablePartnerWebhook struct {
+	Id        uint64        `json:"id" gorm:"column:id;primaryKey;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	PartnerId string        `json:"partner_id" gorm:"column:partner_id;uniqueIndex:uk_partner_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Url       string        `json:"url" gorm:"column:url;type:varchar(1024) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'webhook url'"`
+	Secret    string        `json:"-" gorm:"column:secret;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'hmac secret'"`
+	Events    string        `json:"events" gorm:"column:events;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'comma separated, empty means all'"`
+	Status    WebhookStatus `json:"status" gorm:"column:status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-enable 1-disable'"`
+	CreatedAt time.Time     `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	UpdatedAt time.Time     `json:"updated_at" gorm:"column:updated_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+func (t *TablePartnerWebhook) TableName() string {
+	return TableNamePartnerWebhook
+}
+
+func (t *TablePartnerWebhook) IsSubscribed(event string) bool {
+	if t.Id == 0 || t.Status != WebhookStatusEnable || t.Url == "" {
+		return false
+	}
+	if t.Events == "" {
+		return true
+	}
+	for _, v := range strings.Split(t.Events, ",") {
+		if strings.TrimSpace(v) == event {
+			return true
+		}
+	}
+	return false
+}
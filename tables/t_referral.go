@@ -0,0 +1,71 @@
+package tables
+
+import (
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+const (
+	TableNameReferralChannel = "t_referral_channel"
+	TableNameReferralOrder   = "t_referral_order"
+)
+
+type ReferralChannelStatus int
+
+const (
+	ReferralChannelStatusEnable  ReferralChannelStatus = 0
+	ReferralChannelStatusDisable ReferralChannelStatus = 1
+)
+
+// TableReferralChannel is a whitelisted inviter, its invitees' orders pay the channel reward to it as well
+type TableReferralChannel struct {
+	Id        uint64                `json:"id" gorm:"column:id;primaryKey;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	AccountId string                `json:"account_id" gorm:"column:account_id;uniqueIndex:uk_account_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Account   string                `json:"account" gorm:"column:account;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Status    ReferralChannelStatus `json:"status" gorm:"column:status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-enable 1-disable'"`
+	Remark    string                `json:"remark" gorm:"column:remark;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	CreatedAt time.Time             `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	UpdatedAt time.Time             `json:"updated_at" gorm:"column:updated_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+func (t *TableReferralChannel) TableName() string {
+	return TableNameReferralChannel
+}
+
+// TableReferralOrder is a register order with an inviter, the volume of the referral dashboards
+type TableReferralOrder struct {
+	Id             uint64           `json:"id" gorm:"column:id;primaryKey;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	OrderId        string           `json:"order_id" gorm:"column:order_id;uniqueIndex:uk_order_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	InviterAccount string           `json:"inviter_account" gorm:"column:inviter_account;index:k_inviter_account;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	ChannelAccount string           `json:"channel_account" gorm:"column:channel_account;index:k_channel_account;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Account        string           `json:"account" gorm:"column:account;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'invitee account'"`
+	ChainType      common.ChainType `json:"chain_type" gorm:"column:chain_type;type:smallint(6) NOT NULL DEFAULT '0' COMMENT ''"`
+	Address        string           `json:"address" gorm:"column:address;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	AmountUSD      decimal.Decimal  `json:"amount_usd" gorm:"column:amount_usd;type:decimal(60,2) NOT NULL DEFAULT '0' COMMENT ''"`
+	CreatedAt      time.Time        `json:"created_at" gorm:"column:created_at;index:k_created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+func (t *TableReferralOrder) TableName() string {
+	return TableNameReferralOrder
+}
+
+// NewReferralOrder returns the referral row of a register order with an inviter, nil for other orders
+func NewReferralOrder(order *TableDasOrderInfo) *TableReferralOrder {
+	if order.Action != common.DasActionApplyRegister {
+		return nil
+	}
+	content, err := order.GetContent()
+	if err != nil || content.InviterAccount == "" {
+		return nil
+	}
+	return &TableReferralOrder{
+		OrderId:        order.OrderId,
+		InviterAccount: content.InviterAccount,
+		ChannelAccount: content.ChannelAccount,
+		Account:        order.Account,
+		ChainType:      order.ChainType,
+		Address:        order.Address,
+		AmountUSD:      content.AmountTotalUSD,
+	}
+}
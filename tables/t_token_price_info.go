@@ -2,6 +2,7 @@ package tables
 
 import (
 	"github.com/shopspring/decimal"
+	"time"
 )
 
 type TableTokenPriceInfo struct {
@@ -16,6 +17,7 @@ type TableTokenPriceInfo struct {
 	Price     decimal.Decimal `json:"price" gorm:"column:price"`
 	Logo      string          `json:"logo" gorm:"column:logo"`
 	Status    int             `json:"status" gorm:"column:status"`
+	UpdatedAt time.Time       `json:"updated_at" gorm:"column:updated_at"`
 }
 
 const (
@@ -0,0 +1,45 @@
+package tables
+
+import (
+	"crypto/md5"
+	"fmt"
+	"time"
+)
+
+const (
+	TableNameWebhookDelivery = "t_webhook_delivery"
+)
+
+type DeliveryStatus int
+
+const (
+	DeliveryStatusPending DeliveryStatus = 0
+	DeliveryStatusOk      DeliveryStatus = 1
+	DeliveryStatusFail    DeliveryStatus = 2
+	DeliveryStatusSkip    DeliveryStatus = 3 // the partner was disabled or unsubscribed before it was sent
+)
+
+type TableWebhookDelivery struct {
+	Id           uint64         `json:"id" gorm:"column:id;primaryKey;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	DeliveryId   string         `json:"delivery_id" gorm:"column:delivery_id;uniqueIndex:uk_delivery_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	PartnerId    string         `json:"partner_id" gorm:"column:partner_id;index:k_partner_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	OrderId      string         `json:"order_id" gorm:"column:order_id;index:k_order_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Event        string         `json:"event" gorm:"column:event;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Payload      string         `json:"payload" gorm:"column:payload;type:text NOT NULL COMMENT 'json body'"`
+	Status       DeliveryStatus `json:"status" gorm:"column:status;index:k_status_next_at;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-pending 1-ok 2-fail 3-skip'"`
+	Attempts     int            `json:"attempts" gorm:"column:attempts;type:int(11) NOT NULL DEFAULT '0' COMMENT ''"`
+	NextAt       int64          `json:"next_at" gorm:"column:next_at;index:k_status_next_at;type:bigint(20) NOT NULL DEFAULT '0' COMMENT 'next attempt time'"`
+	ResponseCode int            `json:"response_code" gorm:"column:response_code;type:int(11) NOT NULL DEFAULT '0' COMMENT 'last http status'"`
+	LastErr      string         `json:"last_err" gorm:"column:last_err;type:varchar(1024) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	CreatedAt    time.Time      `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	UpdatedAt    time.Time      `json:"updated_at" gorm:"column:updated_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+func (t *TableWebhookDelivery) TableName() string {
+	return TableNameWebhookDelivery
+}
+
+// CreateDeliveryId is stable per partner, order and event, so an event observed twice is delivered once
+func CreateDeliveryId(partnerId, orderId, event string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s%s%s", partnerId, orderId, event))))
+}
@@ -0,0 +1,56 @@
+package timer
+
+import (
+	"das_register_server/config"
+	"das_register_server/prometheus"
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/txbuilder"
+	"github.com/dotbitHQ/das-lib/witness"
+)
+
+// doCellPool splits large free cells of the pay address when the pool has fewer free cells than its target
+func (t *TxTimer) doCellPool() (err error) {
+	if t.cellPool == nil || !config.Cfg().Server.TxToolSwitch {
+		return nil
+	}
+	reservation, outputs, err := t.cellPool.PlanSplit(t.ctx)
+	if err != nil {
+		return fmt.Errorf("PlanSplit err: %s", err.Error())
+	} else if reservation == nil {
+		return nil
+	}
+	defer func() { reservation.Release(err) }()
+
+	var txParams txbuilder.BuildTransactionParams
+	txParams.Inputs = reservation.Inputs()
+	for _, v := range outputs {
+		txParams.Outputs = append(txParams.Outputs, v)
+		txParams.OutputsData = append(txParams.OutputsData, []byte{})
+	}
+	actionWitness, err := witness.GenActionDataWitness(tables.DasActionRefundPay, nil)
+	if err != nil {
+		return fmt.Errorf("GenActionDataWitness err: %s", err.Error())
+	}
+	txParams.Witnesses = append(txParams.Witnesses, actionWitness)
+
+	txBuilder := txbuilder.NewDasTxBuilderFromBase(t.txBuilderBase, nil)
+	if err := txBuilder.BuildTransaction(&txParams); err != nil {
+		return fmt.Errorf("BuildTransaction err: %s", err.Error())
+	}
+	sizeInBlock, _ := txBuilder.Transaction.SizeInBlock()
+	txFeeRate := config.Cfg().Server.TxTeeRate
+	if txFeeRate == 0 {
+		txFeeRate = 1
+	}
+	txBuilder.Transaction.Outputs[len(txBuilder.Transaction.Outputs)-1].Capacity -= txFeeRate*sizeInBlock + 5000
+
+	hash, err := txBuilder.SendTransaction()
+	if err != nil {
+		return fmt.Errorf("SendTransaction err: %s", err.Error())
+	}
+	reservation.Sent()
+	prometheus.ObserveCellPool("split")
+	log.Info("doCellPool:", hash.String(), len(txParams.Inputs), len(outputs))
+	return nil
+}
@@ -0,0 +1,57 @@
+package timer
+
+import (
+	"das_register_server/prometheus"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// doMetrics refreshes the gauges that are derived from db state,
+// the counters and histograms are observed where the events happen
+func (t *TxTimer) doMetrics() error {
+	if prometheus.Tools == nil {
+		return nil
+	}
+	metrics := &prometheus.Tools.Metrics
+
+	// ckb rpc
+	start := time.Now()
+	if _, err := t.dasCore.Client().GetTipBlockNumber(t.ctx); err != nil {
+		log.Error("GetTipBlockNumber err: ", err.Error())
+	}
+	prometheus.ObserveLatency(prometheus.ComponentCkbRpc, "get_tip_block_number", start)
+
+	// open orders, reset first so stages that drained report nothing instead of a stale value
+	orderStats, err := t.dbDao.GetOpenOrderStatusStats()
+	if err != nil {
+		return fmt.Errorf("GetOpenOrderStatusStats err: %s", err.Error())
+	}
+	metrics.OrderCount().Reset()
+	metrics.OrderOldest().Reset()
+	nowMilli := time.Now().UnixMilli()
+	for _, v := range orderStats {
+		status := strconv.Itoa(int(v.RegisterStatus))
+		metrics.OrderCount().WithLabelValues(string(v.Action), status).Set(float64(v.Num))
+		metrics.OrderOldest().WithLabelValues(string(v.Action), status).Set(float64(nowMilli-v.MinTimestamp) / 1e3)
+	}
+
+	// refund queue
+	refundStats, err := t.dbDao.GetRefundQueueStats()
+	if err != nil {
+		return fmt.Errorf("GetRefundQueueStats err: %s", err.Error())
+	}
+	metrics.RefundQueue().Reset()
+	for _, v := range refundStats {
+		metrics.RefundQueue().WithLabelValues(strconv.Itoa(int(v.UniPayRefundStatus))).Set(float64(v.Num))
+	}
+
+	// token price
+	for tokenId, v := range GetTokenList() {
+		if v.UpdatedAt.IsZero() {
+			continue
+		}
+		metrics.TokenPriceAge().WithLabelValues(string(tokenId)).Set(time.Since(v.UpdatedAt).Seconds())
+	}
+	return nil
+}
@@ -69,19 +69,19 @@ func (t *NameDaoTimer) CheckNameDaoMember(account string, charset int) error {
 			log.Info("CheckNameDaoMember:", v.Account, v.AccountId)
 			msg += fmt.Sprintf("%s %s\n", v.Account, v.AccountId)
 		}
-		notify.SendLarkTextNotifyAtAll(config.Cfg.Notify.LarkErrorKey, "NameDao UnMint SubAccount List", msg)
+		notify.SendLarkTextNotifyAtAll(config.Cfg().Notify.LarkErrorKey, "NameDao UnMint SubAccount List", msg)
 	} else {
-		notify.SendLarkTextNotify(config.Cfg.Notify.LarkErrorKey, "NameDao Mint Check", "OK")
+		notify.SendLarkTextNotify(config.Cfg().Notify.LarkErrorKey, "NameDao Mint Check", "OK")
 	}
 
 	return nil
 }
 
 func (t *NameDaoTimer) RunCheckNameDaoMember() {
-	if config.Cfg.Server.Net != common.DasNetTypeMainNet {
+	if config.Cfg().Server.Net != common.DasNetTypeMainNet {
 		return
 	}
-	if config.Cfg.Notify.LarkErrorKey == "" {
+	if config.Cfg().Notify.LarkErrorKey == "" {
 		return
 	}
 	t.cron = cron.New(cron.WithSeconds())
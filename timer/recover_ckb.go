@@ -2,6 +2,7 @@ package timer
 
 import (
 	"das_register_server/config"
+	"das_register_server/signer"
 	"das_register_server/tables"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
@@ -13,7 +14,7 @@ import (
 )
 
 func (t *TxTimer) doRecoverCkb() error {
-	addrParse, err := address.Parse(config.Cfg.Server.PayServerAddress)
+	addrParse, err := address.Parse(signer.PayAddress())
 	if err != nil {
 		return fmt.Errorf("address.Parse err: %s", err.Error())
 	}
@@ -47,11 +48,11 @@ func (t *TxTimer) doRecoverCkb() error {
 		})
 		total += v.Output.Capacity
 	}
-	log.Info("doRecoverCkb:", total, len(liveCells.Objects), config.Cfg.Server.RecoverCkb)
+	log.Info("doRecoverCkb:", total, len(liveCells.Objects), config.Cfg().Server.RecoverCkb)
 	// outputs
 	capacity := 2000 * common.OneCkb
-	if config.Cfg.Server.RecoverCkb > 0 {
-		capacity = config.Cfg.Server.RecoverCkb * common.OneCkb
+	if config.Cfg().Server.RecoverCkb > 0 {
+		capacity = config.Cfg().Server.RecoverCkb * common.OneCkb
 	}
 	if total < capacity*2 {
 		return nil
@@ -1,7 +1,7 @@
 package timer
 
 import (
-	"das_register_server/config"
+	"das_register_server/signer"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
@@ -15,7 +15,7 @@ import (
 )
 
 func (t *TxTimer) doRecycleApply() error {
-	addrParse, err := address.Parse(config.Cfg.Server.PayServerAddress)
+	addrParse, err := address.Parse(signer.PayAddress())
 	if err != nil {
 		return fmt.Errorf("address.Parse err: %s", err.Error())
 	}
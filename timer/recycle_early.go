@@ -3,6 +3,7 @@ package timer
 import (
 	"bytes"
 	"das_register_server/config"
+	"das_register_server/signer"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
@@ -47,7 +48,7 @@ func (t *TxTimer) getPreCellRecycleParams() (*preCellRecycleParams, error) {
 	if err != nil {
 		return nil, fmt.Errorf("GetDasContractInfo err: %s", err.Error())
 	}
-	addrParse, err := address.Parse(config.Cfg.Server.PayServerAddress)
+	addrParse, err := address.Parse(signer.PayAddress())
 	if err != nil {
 		return nil, fmt.Errorf("address.Parse err: %s", err.Error())
 	}
@@ -82,7 +83,7 @@ func (t *TxTimer) getPreCellByMedianTime(p *preCellRecycleParams, blockRange, ti
 		},
 	}
 	if blockRange == 0 {
-		if config.Cfg.Server.Net != common.DasNetTypeMainNet {
+		if config.Cfg().Server.Net != common.DasNetTypeMainNet {
 			blockRange = 7268680 //1927285
 		} else {
 			blockRange = 8424701 //4872287
@@ -145,10 +146,10 @@ var recyclePreBlockNumberEarly uint64
 var recycleTimestampEarly = uint64(60 * 60)
 
 func (t *TxTimer) doRecyclePreEarly() error {
-	if config.Cfg.Server.Net != common.DasNetTypeMainNet {
+	if config.Cfg().Server.Net != common.DasNetTypeMainNet {
 		recycleTimestampEarly = uint64(2 * 60)
 	}
-	if !config.Cfg.Server.RecyclePreEarly {
+	if !config.Cfg().Server.RecyclePreEarly {
 		return nil
 	}
 	p, err := t.getPreCellRecycleParams()
@@ -254,12 +255,12 @@ func (t *TxTimer) doRecyclePreEarly() error {
 }
 
 func (t *TxTimer) DoRecyclePreEarly() {
-	if config.Cfg.Server.RecyclePreEarlyCronSpec == "" {
+	if config.Cfg().Server.RecyclePreEarlyCronSpec == "" {
 		return
 	}
-	log.Info("DoRecyclePreEarly:", config.Cfg.Server.RecyclePreEarlyCronSpec)
+	log.Info("DoRecyclePreEarly:", config.Cfg().Server.RecyclePreEarlyCronSpec)
 	t.cron = cron.New(cron.WithSeconds())
-	_, err := t.cron.AddFunc(config.Cfg.Server.RecyclePreEarlyCronSpec, func() {
+	_, err := t.cron.AddFunc(config.Cfg().Server.RecyclePreEarlyCronSpec, func() {
 		log.Info("doRecyclePreEarly start ...")
 		if err := t.doRecyclePreEarly(); err != nil {
 			log.Error("doRecyclePreEarly err: ", err.Error())
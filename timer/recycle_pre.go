@@ -14,7 +14,7 @@ var recyclePreBlockNumber uint64
 var recycleTimestamp = uint64(24 * 60 * 60)
 
 func (t *TxTimer) doRecyclePre() error {
-	//if config.Cfg.Server.Net != common.DasNetTypeMainNet {
+	//if config.Cfg().Server.Net != common.DasNetTypeMainNet {
 	//	recycleTimestamp = uint64(5 * 60)
 	//}
 	p, err := t.getPreCellRecycleParams()
@@ -24,9 +24,9 @@ func TestSince(t *testing.T) {
 }
 
 func TestRecycleApply(t *testing.T) {
-	config.Cfg.Server.Net = common.DasNetTypeMainNet
-	config.Cfg.Server.PayServerAddress = ""
-	config.Cfg.Server.PayPrivate = ""
+	config.Cfg().Server.Net = common.DasNetTypeMainNet
+	config.Cfg().Server.PayServerAddress = ""
+	config.Cfg().Server.PayPrivate = ""
 	dc, err := getNewDasCoreMainNet() //getNewDasCoreTestnet2()
 	if err != nil {
 		t.Fatal(err)
@@ -48,10 +48,10 @@ func TestRecycleApply(t *testing.T) {
 }
 
 func TestRecyclePre(t *testing.T) {
-	config.Cfg.Server.Net = common.DasNetTypeTestnet2
-	config.Cfg.Server.PayPrivate = ""
-	config.Cfg.Server.PayServerAddress = ""
-	config.Cfg.Server.RecyclePreEarly = true
+	config.Cfg().Server.Net = common.DasNetTypeTestnet2
+	config.Cfg().Server.PayPrivate = ""
+	config.Cfg().Server.PayServerAddress = ""
+	config.Cfg().Server.RecyclePreEarly = true
 	dc, err := getNewDasCoreTestnet2() //getNewDasCoreTestnet2()
 	if err != nil {
 		t.Fatal(err)
@@ -170,12 +170,12 @@ func getNewDasCoreMainNet() (*core.DasCore, error) {
 
 func initTxBuilder(dasCore *core.DasCore) (*txbuilder.DasTxBuilderBase, error) {
 	var handleSign sign.HandleSignCkbMessage
-	if config.Cfg.Server.PayPrivate != "" {
-		handleSign = sign.LocalSign(config.Cfg.Server.PayPrivate)
+	if config.Cfg().Server.PayPrivate != "" {
+		handleSign = sign.LocalSign(config.Cfg().Server.PayPrivate)
 	}
 	payServerAddressArgs := ""
-	if config.Cfg.Server.PayServerAddress != "" {
-		parseAddress, err := address.Parse(config.Cfg.Server.PayServerAddress)
+	if config.Cfg().Server.PayServerAddress != "" {
+		parseAddress, err := address.Parse(config.Cfg().Server.PayServerAddress)
 		if err != nil {
 			log.Error("pay server address.Parse err: ", err.Error())
 		} else {
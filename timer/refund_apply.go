@@ -1,7 +1,7 @@
 package timer
 
 import (
-	"das_register_server/config"
+	"das_register_server/signer"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
@@ -16,7 +16,7 @@ import (
 )
 
 func (t *TxTimer) doRefundApply() error {
-	addrParse, err := address.Parse(config.Cfg.Server.PayServerAddress)
+	addrParse, err := address.Parse(signer.PayAddress())
 	if err != nil {
 		return fmt.Errorf("address.Parse err: %s", err.Error())
 	}
@@ -129,7 +129,7 @@ func (t *TxTimer) doRefundApply() error {
 var preBlockNumber uint64
 
 func (t *TxTimer) doRefundPre() error {
-	//addrParse, err := address.Parse(config.Cfg.Server.PayServerAddress)
+	//addrParse, err := address.Parse(config.Cfg().Server.PayServerAddress)
 	//if err != nil {
 	//	return fmt.Errorf("address.Parse err: %s", err.Error())
 	//}
@@ -9,7 +9,7 @@ import (
 )
 
 func (t *TxTimer) checkRejected() error {
-	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+	if ok := internal.IsLatestBlockNumber(config.Cfg().Server.ParserUrl); !ok {
 		return fmt.Errorf("sync block number")
 	}
 	list, err := t.dbDao.SearchMaybeRejectedPending()
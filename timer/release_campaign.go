@@ -0,0 +1,18 @@
+package timer
+
+// doReleaseCampaignUsage gives the cap slots of the campaign discounted orders closed without
+// registering or renewing back, an expired or refunded order no longer counts against max_total
+func (t *TxTimer) doReleaseCampaignUsage() error {
+	list, err := t.dbDao.GetCampaignUsageOrdersToRelease(500)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	if err := t.dbDao.ReleaseCampaignUsages(list); err != nil {
+		return err
+	}
+	log.Info("doReleaseCampaignUsage:", list)
+	return nil
+}
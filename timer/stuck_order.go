@@ -0,0 +1,129 @@
+package timer
+
+import (
+	"das_register_server/config"
+	"das_register_server/dao"
+	"das_register_server/notify"
+	"das_register_server/prometheus"
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"time"
+)
+
+// defaultStuckOrderSla is how long a paid order may stay in a stage, config.Cfg().StuckOrder.SlaMinutes overrides it
+var defaultStuckOrderSla = map[tables.OrderStage]time.Duration{
+	tables.OrderStageTxSend:             time.Minute * 10,
+	tables.OrderStageTxConfirm:          time.Minute * 30,
+	tables.OrderStagePreRegisterSend:    time.Minute * 30,
+	tables.OrderStagePreRegisterConfirm: time.Minute * 30,
+	tables.OrderStagePropose:            time.Hour * 2,
+	tables.OrderStageConfirmProposal:    time.Hour,
+}
+
+const stuckOrderMaxNum = 500
+
+func StuckOrderSla(stage tables.OrderStage) time.Duration {
+	if v, ok := config.Cfg().StuckOrder.SlaMinutes[string(stage)]; ok && v > 0 {
+		return time.Minute * time.Duration(v)
+	}
+	return defaultStuckOrderSla[stage]
+}
+
+type StuckOrder struct {
+	OrderId        string                `json:"order_id"`
+	Account        string                `json:"account"`
+	Action         common.DasAction      `json:"action"`
+	Stage          tables.OrderStage     `json:"stage"`
+	RegisterStatus tables.RegisterStatus `json:"register_status"`
+	StageSeconds   int64                 `json:"stage_seconds"` // since the last update of the order
+	SlaSeconds     int64                 `json:"sla_seconds"`
+	LastTxAction   tables.OrderTxAction  `json:"last_tx_action"`
+	LastTxHash     string                `json:"last_tx_hash"`
+}
+
+// FindStuckOrders returns the paid open orders that have stayed in their stage longer than its sla, oldest first.
+// An order enters a stage when it is updated, so updated_at is taken as the stage start.
+func FindStuckOrders(dbDao *dao.DbDao, now time.Time) ([]StuckOrder, error) {
+	minSla := time.Duration(0)
+	for stage := range defaultStuckOrderSla {
+		if sla := StuckOrderSla(stage); minSla == 0 || sla < minSla {
+			minSla = sla
+		}
+	}
+	orders, err := dbDao.GetStuckOrders(now.Add(-minSla), stuckOrderMaxNum)
+	if err != nil {
+		return nil, fmt.Errorf("GetStuckOrders err: %s", err.Error())
+	}
+
+	var list []StuckOrder
+	var orderIds []string
+	for _, v := range orders {
+		stage := v.Stage()
+		sla := StuckOrderSla(stage)
+		age := now.Sub(v.UpdatedAt)
+		if stage == tables.OrderStageNone || sla == 0 || age < sla {
+			continue
+		}
+		list = append(list, StuckOrder{
+			OrderId:        v.OrderId,
+			Account:        v.Account,
+			Action:         v.Action,
+			Stage:          stage,
+			RegisterStatus: v.RegisterStatus,
+			StageSeconds:   int64(age.Seconds()),
+			SlaSeconds:     int64(sla.Seconds()),
+		})
+		orderIds = append(orderIds, v.OrderId)
+	}
+
+	txs, err := dbDao.GetOrderTxListByOrderIds(orderIds)
+	if err != nil {
+		return nil, fmt.Errorf("GetOrderTxListByOrderIds err: %s", err.Error())
+	}
+	lastTx := make(map[string]tables.TableDasOrderTxInfo)
+	for _, v := range txs {
+		lastTx[v.OrderId] = v
+	}
+	for i, v := range list {
+		if tx, ok := lastTx[v.OrderId]; ok {
+			list[i].LastTxAction, list[i].LastTxHash = tx.Action, tx.Hash
+		}
+	}
+	return list, nil
+}
+
+// doStuckOrders refreshes the stuck order gauge and alerts every order once per stage it gets stuck in
+func (t *TxTimer) doStuckOrders() error {
+	list, err := FindStuckOrders(t.dbDao, time.Now())
+	if err != nil {
+		return err
+	}
+
+	alerted := make(map[string]tables.OrderStage, len(list))
+	count := make(map[tables.OrderStage]int)
+	for _, v := range list {
+		count[v.Stage]++
+		alerted[v.OrderId] = v.Stage
+		if stage, ok := t.stuckAlerted[v.OrderId]; ok && stage == v.Stage {
+			continue
+		}
+		notify.Send(&notify.Alert{
+			Severity: notify.SeverityWarning,
+			Title:    "stuck order",
+			Text: fmt.Sprintf("order id: %s\naccount: %s\naction: %s\nstage: %s for %s (sla %s)\nlast tx: %s %s",
+				v.OrderId, v.Account, v.Action, v.Stage, time.Duration(v.StageSeconds)*time.Second,
+				time.Duration(v.SlaSeconds)*time.Second, v.LastTxAction, v.LastTxHash),
+			Key: v.OrderId + string(v.Stage),
+		})
+	}
+	t.stuckAlerted = alerted
+
+	if prometheus.Tools != nil {
+		gauge := prometheus.Tools.Metrics.StuckOrder()
+		for stage := range defaultStuckOrderSla {
+			gauge.WithLabelValues(string(stage)).Set(float64(count[stage]))
+		}
+	}
+	return nil
+}
@@ -2,8 +2,10 @@ package timer
 
 import (
 	"context"
+	"das_register_server/cellpool"
 	"das_register_server/config"
 	"das_register_server/dao"
+	"das_register_server/tables"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/dascache"
@@ -25,6 +27,8 @@ type TxTimer struct {
 	dasCache      *dascache.DasCache
 	txBuilderBase *txbuilder.DasTxBuilderBase
 	cron          *cron.Cron
+	stuckAlerted  map[string]tables.OrderStage // order id to the stage it was last alerted in
+	cellPool      *cellpool.Pool
 }
 
 type TxTimerParam struct {
@@ -34,6 +38,7 @@ type TxTimerParam struct {
 	DasCore       *core.DasCore
 	DasCache      *dascache.DasCache
 	TxBuilderBase *txbuilder.DasTxBuilderBase
+	CellPool      *cellpool.Pool
 }
 
 func NewTxTimer(p TxTimerParam) *TxTimer {
@@ -44,6 +49,7 @@ func NewTxTimer(p TxTimerParam) *TxTimer {
 	t.dasCore = p.DasCore
 	t.dasCache = p.DasCache
 	t.txBuilderBase = p.TxBuilderBase
+	t.cellPool = p.CellPool
 	return &t
 }
 
@@ -54,12 +60,15 @@ func (t *TxTimer) Run() error {
 	tickerToken := time.NewTicker(time.Second * 50)
 	tickerRejected := time.NewTicker(time.Second * 35)
 	tickerTxRejected := time.NewTicker(time.Minute * 5)
+	tickerMetrics := time.NewTicker(time.Second * 30)
+	tickerStuckOrders := time.NewTicker(time.Minute)
 
 	tickerExpired := time.NewTicker(time.Minute * 30)
 	tickerRecover := time.NewTicker(time.Minute * 3)
-	if config.Cfg.Server.RecoverTime > 0 {
-		tickerRecover = time.NewTicker(time.Minute * config.Cfg.Server.RecoverTime)
+	if config.Cfg().Server.RecoverTime > 0 {
+		tickerRecover = time.NewTicker(time.Minute * config.Cfg().Server.RecoverTime)
 	}
+	tickerCellPool := time.NewTicker(time.Minute)
 	tickerRefundApply := time.NewTicker(time.Minute * 10)
 	tickerClosedAndUnRefund := time.NewTicker(time.Minute * 20)
 	tickerResetCoupon := time.NewTicker(time.Minute * 1)
@@ -86,6 +95,14 @@ func (t *TxTimer) Run() error {
 					log.Error("doTxRejected err: ", err.Error())
 				}
 				log.Debug("doTxRejected end ...")
+			case <-tickerMetrics.C:
+				if err := t.doMetrics(); err != nil {
+					log.Error("doMetrics err: ", err.Error())
+				}
+			case <-tickerStuckOrders.C:
+				if err := t.doStuckOrders(); err != nil {
+					log.Error("doStuckOrders err: ", err.Error())
+				}
 			case <-t.ctx.Done():
 				log.Debug("timer done")
 				t.wg.Done()
@@ -124,7 +141,7 @@ func (t *TxTimer) Run() error {
 				if err := t.doRecycleApply(); err != nil {
 					log.Errorf("doRecycleApply err: %s", err.Error())
 				}
-				if config.Cfg.Server.RecycleAllPre {
+				if config.Cfg().Server.RecycleAllPre {
 					//if err := t.doRefundPre(); err != nil {
 					//	log.Error("doRefundPre err: %s", err.Error())
 					//}
@@ -151,6 +168,12 @@ func (t *TxTimer) Run() error {
 					log.Error("doRecoverCkb err: ", err.Error())
 				}
 				log.Debug("doRecoverCkb end ...")
+			case <-tickerCellPool.C:
+				log.Debug("doCellPool start ...")
+				if err := t.doCellPool(); err != nil {
+					log.Error("doCellPool err: ", err.Error())
+				}
+				log.Debug("doCellPool end ...")
 			case <-t.ctx.Done():
 				log.Debug("timer done")
 				t.wg.Done()
@@ -175,6 +198,9 @@ func (t *TxTimer) Run() error {
 					log.Error("doResetCoupon err: ", err.Error())
 				}
 				log.Debug("doResetCoupon end ...")
+				if err := t.doReleaseCampaignUsage(); err != nil {
+					log.Error("doReleaseCampaignUsage err: ", err.Error())
+				}
 			case <-t.ctx.Done():
 				log.Debug("timer done")
 				t.wg.Done()
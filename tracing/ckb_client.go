@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"context"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ckbClient traces the rpc methods the server calls on its hot paths,
+// the rest fall through to the embedded client untraced
+type ckbClient struct {
+	rpc.Client
+}
+
+func WrapCkbClient(client rpc.Client) rpc.Client {
+	return &ckbClient{Client: client}
+}
+
+func startCkb(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, semconv.RPCSystemKey.String("jsonrpc"), semconv.RPCServiceKey.String("ckb"), semconv.RPCMethodKey.String(method))
+	return Start(ctx, "ckb "+method, attrs...)
+}
+
+func (c *ckbClient) GetTipBlockNumber(ctx context.Context) (n uint64, err error) {
+	ctx, span := startCkb(ctx, "get_tip_block_number")
+	defer func() { End(span, err) }()
+	return c.Client.GetTipBlockNumber(ctx)
+}
+
+func (c *ckbClient) GetTipHeader(ctx context.Context) (h *types.Header, err error) {
+	ctx, span := startCkb(ctx, "get_tip_header")
+	defer func() { End(span, err) }()
+	return c.Client.GetTipHeader(ctx)
+}
+
+func (c *ckbClient) GetBlock(ctx context.Context, hash types.Hash) (b *types.Block, err error) {
+	ctx, span := startCkb(ctx, "get_block", attribute.String("ckb.block_hash", hash.Hex()))
+	defer func() { End(span, err) }()
+	return c.Client.GetBlock(ctx, hash)
+}
+
+func (c *ckbClient) GetBlockByNumber(ctx context.Context, number uint64) (b *types.Block, err error) {
+	ctx, span := startCkb(ctx, "get_block_by_number", attribute.Int64("ckb.block_number", int64(number)))
+	defer func() { End(span, err) }()
+	return c.Client.GetBlockByNumber(ctx, number)
+}
+
+func (c *ckbClient) GetLiveCell(ctx context.Context, outPoint *types.OutPoint, withData bool) (cell *types.CellWithStatus, err error) {
+	ctx, span := startCkb(ctx, "get_live_cell")
+	defer func() { End(span, err) }()
+	return c.Client.GetLiveCell(ctx, outPoint, withData)
+}
+
+func (c *ckbClient) GetTransaction(ctx context.Context, hash types.Hash) (tx *types.TransactionWithStatus, err error) {
+	ctx, span := startCkb(ctx, "get_transaction", AttrTxHash.String(hash.Hex()))
+	defer func() { End(span, err) }()
+	return c.Client.GetTransaction(ctx, hash)
+}
+
+func (c *ckbClient) DryRunTransaction(ctx context.Context, transaction *types.Transaction) (res *types.DryRunTransactionResult, err error) {
+	ctx, span := startCkb(ctx, "dry_run_transaction")
+	defer func() { End(span, err) }()
+	return c.Client.DryRunTransaction(ctx, transaction)
+}
+
+func (c *ckbClient) SendTransaction(ctx context.Context, tx *types.Transaction) (hash *types.Hash, err error) {
+	ctx, span := startCkb(ctx, "send_transaction")
+	defer func() {
+		if hash != nil {
+			span.SetAttributes(AttrTxHash.String(hash.Hex()))
+		}
+		End(span, err)
+	}()
+	return c.Client.SendTransaction(ctx, tx)
+}
+
+func (c *ckbClient) SendTransactionNoneValidation(ctx context.Context, tx *types.Transaction) (hash *types.Hash, err error) {
+	ctx, span := startCkb(ctx, "send_transaction")
+	defer func() {
+		if hash != nil {
+			span.SetAttributes(AttrTxHash.String(hash.Hex()))
+		}
+		End(span, err)
+	}()
+	return c.Client.SendTransactionNoneValidation(ctx, tx)
+}
+
+func (c *ckbClient) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (cells *indexer.LiveCells, err error) {
+	ctx, span := startCkb(ctx, "get_cells")
+	defer func() { End(span, err) }()
+	return c.Client.GetCells(ctx, searchKey, order, limit, afterCursor)
+}
+
+func (c *ckbClient) GetCellsCapacity(ctx context.Context, searchKey *indexer.SearchKey) (capacity *indexer.Capacity, err error) {
+	ctx, span := startCkb(ctx, "get_cells_capacity")
+	defer func() { End(span, err) }()
+	return c.Client.GetCellsCapacity(ctx, searchKey)
+}
+
+func (c *ckbClient) GetTransactions(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (txs *indexer.Transactions, err error) {
+	ctx, span := startCkb(ctx, "get_transactions")
+	defer func() { End(span, err) }()
+	return c.Client.GetTransactions(ctx, searchKey, order, limit, afterCursor)
+}
+
+func (c *ckbClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) (err error) {
+	ctx, span := startCkb(ctx, method)
+	defer func() { End(span, err) }()
+	return c.Client.CallContext(ctx, result, method, args...)
+}
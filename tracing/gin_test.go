@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddlewareGin(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MiddlewareGin("test"))
+	var handlerSpan trace.SpanContext
+	engine.POST("/v1/order/:id", func(ctx *gin.Context) {
+		SetAttributes(ctx.Request.Context(), AttrOrderId.String(ctx.Param("id")))
+		handlerSpan = trace.SpanContextFromContext(ctx.Request.Context())
+		ctx.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/order/abc", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("spans: %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "POST /v1/order/:id" {
+		t.Fatal("name:", span.Name())
+	}
+	if span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatal("trace id not continued:", span.SpanContext().TraceID())
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Fatal("handler does not see the request span")
+	}
+	var found bool
+	for _, v := range span.Attributes() {
+		if v.Key == AttrOrderId && v.Value.AsString() == "abc" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("order id attribute missing")
+	}
+}
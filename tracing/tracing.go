@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"das_register_server/config"
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var log = logger.NewLogger("tracing", logger.LevelDebug)
+
+const (
+	ExporterStdout = "stdout"
+	ExporterOtlp   = "otlp"
+
+	instrumentationName = "das_register_server"
+)
+
+const (
+	AttrOrderId  = attribute.Key("das.order_id")
+	AttrAccount  = attribute.Key("das.account")
+	AttrAction   = attribute.Key("das.action")
+	AttrTxHash   = attribute.Key("das.tx_hash")
+	AttrOrderIds = attribute.Key("das.order_ids")
+)
+
+// Init installs the global tracer provider from config.Cfg().Trace,
+// the returned func flushes the pending spans and must be called before exit.
+// With an empty exporter the otel no-op provider stays in place and every span below is free.
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	shutdown := func(context.Context) error { return nil }
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.Cfg().Trace.Exporter {
+	case "":
+		return shutdown, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New()
+	case ExporterOtlp:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Cfg().Trace.Endpoint)}
+		if config.Cfg().Trace.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return shutdown, fmt.Errorf("unknown trace exporter [%s]", config.Cfg().Trace.Exporter)
+	}
+	if err != nil {
+		return shutdown, fmt.Errorf("new exporter err: %s", err.Error())
+	}
+
+	ratio := config.Cfg().Trace.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(config.Cfg().Server.Name),
+			attribute.Int("das.net", int(config.Cfg().Server.Net)),
+		)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	log.Info("tracing ok:", config.Cfg().Trace.Exporter, ratio)
+	return tp.Shutdown, nil
+}
+
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on the span before ending it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// SetAttributes adds attributes to the span in ctx, e.g. the order id once a handler knows it
+func SetAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+func OrderAttrs(order *tables.TableDasOrderInfo) []attribute.KeyValue {
+	if order == nil {
+		return nil
+	}
+	return []attribute.KeyValue{
+		AttrOrderId.String(order.OrderId),
+		AttrAccount.String(order.Account),
+		AttrAction.String(string(order.Action)),
+	}
+}
+
+// InjectHeader returns the propagation headers of the span in ctx for outgoing http calls
+func InjectHeader(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
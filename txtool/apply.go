@@ -1,16 +1,19 @@
 package txtool
 
 import (
+	"das_register_server/cellpool"
 	"das_register_server/config"
 	"das_register_server/notify"
+	"das_register_server/prometheus"
 	"das_register_server/tables"
+	"das_register_server/tracing"
+	"das_register_server/webhook"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/txbuilder"
 	"github.com/dotbitHQ/das-lib/witness"
 	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
-	"github.com/nervosnetwork/ckb-sdk-go/indexer"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
 	"time"
 )
@@ -24,20 +27,31 @@ func (t *TxTool) doOrderApplyTx() error {
 		return fmt.Errorf("GetNeedSendPayOrderList err: %s", err.Error())
 	}
 	for i, _ := range list {
-		if err = t.DoOrderApplyTx(&list[i]); err != nil {
+		if err = t.DoOrderApplyTx(&list[i]); isOrderTxSkipped(err) {
+			log.Info("DoOrderApplyTx skip:", err.Error(), list[i].OrderId)
+			continue
+		} else if err != nil {
 			return fmt.Errorf("DoOrderApplyTx err: %s", err.Error())
 		}
 	}
 	return nil
 }
 
-func (t *TxTool) DoOrderApplyTx(order *tables.TableDasOrderInfo) error {
+func (t *TxTool) DoOrderApplyTx(order *tables.TableDasOrderInfo) (err error) {
 	if order == nil || order.Id == 0 {
 		return fmt.Errorf("order is nil")
 	}
+	unlock, err := t.lockOrderTx(order, isPayPending)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	ctx, span := tracing.Start(t.Ctx, "txtool apply_register", tracing.OrderAttrs(order)...)
+	defer func() { tracing.End(span, err) }()
 	p := applyTxParams{
 		order: order,
 	}
+	defer func() { p.reservation.Release(err) }()
 	txParams, err := t.buildOrderApplyTx(&p)
 	if err != nil {
 		return fmt.Errorf("buildOrderApplyTx err: %s", err.Error())
@@ -49,7 +63,7 @@ func (t *TxTool) DoOrderApplyTx(order *tables.TableDasOrderInfo) error {
 	}
 
 	sizeInBlock, _ := txBuilder.Transaction.SizeInBlock()
-	txFeeRate := config.Cfg.Server.TxTeeRate
+	txFeeRate := config.Cfg().Server.TxTeeRate
 	if txFeeRate == 0 {
 		txFeeRate = 1
 	}
@@ -62,32 +76,37 @@ func (t *TxTool) DoOrderApplyTx(order *tables.TableDasOrderInfo) error {
 	log.Info("changeCapacity:", txFee, changeCapacity)
 
 	// check has pre tx
-	preOrder, err := t.DbDao.GetPreRegisteredOrderByAccountId(order.AccountId)
+	preOrder, err := t.DbDao.WithContext(ctx).GetPreRegisteredOrderByAccountId(order.AccountId)
 	if err != nil {
 		return fmt.Errorf("GetPreRegisteredOrderByAccountId err: %s", err.Error())
 	} else if preOrder.Id > 0 && time.Now().Unix() < (preOrder.Timestamp/1e3)+2592000 { // refund
 		log.Info("UpdateOrderToRefund:", order.OrderId)
-		if err := t.DbDao.UpdateOrderToRefund(order.OrderId); err != nil {
+		if err := t.DbDao.WithContext(ctx).UpdateOrderToRefund(order.OrderId); err != nil {
 			return fmt.Errorf("UpdateOrderToRefund err: %s [%s]", err.Error(), order.OrderId)
 		}
+		webhook.DoEnqueue(t.DbDao, *order, webhook.EventFailed, "")
 		return nil
 	}
 
 	// update order
-	if err := t.DbDao.UpdatePayStatus(order.OrderId, tables.TxStatusSending, tables.TxStatusOk); err != nil {
+	if err := t.DbDao.WithContext(ctx).UpdatePayStatus(order.OrderId, tables.TxStatusSending, tables.TxStatusOk); err != nil {
 		return fmt.Errorf("UpdatePayStatus err: %s", err.Error())
 	}
 
 	log.Info(txBuilder.TxString())
 	if hash, err := txBuilder.SendTransaction(); err != nil {
+		prometheus.ObserveTxSend(string(tables.TxActionApplyRegister), err)
 		// update order
-		if err := t.DbDao.UpdatePayStatus(order.OrderId, tables.TxStatusOk, tables.TxStatusSending); err != nil {
+		if err := t.DbDao.WithContext(ctx).UpdatePayStatus(order.OrderId, tables.TxStatusOk, tables.TxStatusSending); err != nil {
 			log.Error("UpdatePayStatus err:", err.Error(), order.OrderId)
 			notify.SendLarkErrNotify(common.DasActionApplyRegister, notify.GetLarkTextNotifyStr("UpdatePayStatus", order.OrderId, err.Error()))
 		}
 		return fmt.Errorf("SendTransaction err: %s", err.Error())
 	} else {
+		prometheus.ObserveTxSend(string(tables.TxActionApplyRegister), nil)
+		span.SetAttributes(tracing.AttrTxHash.String(hash.Hex()))
 		log.Info("SendTransaction ok:", tables.TxActionApplyRegister, hash)
+		p.reservation.Sent()
 		t.DasCache.AddCellInputByAction("", txBuilder.Transaction.Inputs)
 		// update tx hash
 		orderTx := tables.TableDasOrderTxInfo{
@@ -97,7 +116,7 @@ func (t *TxTool) DoOrderApplyTx(order *tables.TableDasOrderInfo) error {
 			Status:    tables.OrderTxStatusDefault,
 			Timestamp: time.Now().UnixNano() / 1e6,
 		}
-		if err := t.DbDao.CreateOrderTx(&orderTx); err != nil {
+		if err := t.DbDao.WithContext(ctx).CreateOrderTx(&orderTx); err != nil {
 			log.Error("CreateOrderTx err:", err.Error(), order.OrderId, hash.Hex())
 			notify.SendLarkErrNotify(common.DasActionApplyRegister, notify.GetLarkTextNotifyStr("CreateOrderTx", order.OrderId, err.Error()))
 		}
@@ -106,7 +125,8 @@ func (t *TxTool) DoOrderApplyTx(order *tables.TableDasOrderInfo) error {
 }
 
 type applyTxParams struct {
-	order *tables.TableDasOrderInfo
+	order       *tables.TableDasOrderInfo
+	reservation *cellpool.Reservation
 }
 
 func (t *TxTool) buildOrderApplyTx(p *applyTxParams) (*txbuilder.BuildTransactionParams, error) {
@@ -145,46 +165,23 @@ func (t *TxTool) buildOrderApplyTx(p *applyTxParams) (*txbuilder.BuildTransactio
 	feeCapacity := uint64(1e4)
 	needCapacity := feeCapacity + applyOutputs.Capacity
 
-	change, liveCell, err := t.DasCore.GetBalanceCellWithLock(&core.ParamGetBalanceCells{
-		LockScript:   t.ServerScript,
-		CapacityNeed: needCapacity,
-		DasCache:     t.DasCache,
-		SearchOrder:  indexer.SearchOrderDesc,
-	})
+	reservation, err := t.CellPool.Reserve(p.order.OrderId, needCapacity, 0)
 	if err != nil {
-		return nil, fmt.Errorf("GetBalanceCellWithLock err %s", err.Error())
+		return nil, fmt.Errorf("Reserve err: %s", err.Error())
 	}
+	p.reservation = reservation
 
-	if change > 0 {
-		splitCkb := 2000 * common.OneCkb
-		if config.Cfg.Server.SplitCkb > 0 {
-			splitCkb = config.Cfg.Server.SplitCkb * common.OneCkb
-		}
-		changeList, err := core.SplitOutputCell2(change, splitCkb, 200, t.ServerScript, nil, indexer.SearchOrderDesc)
-		if err != nil {
-			return nil, fmt.Errorf("SplitOutputCell2 err: %s", err.Error())
-		}
-		for i := 0; i < len(changeList); i++ {
-			txParams.Outputs = append(txParams.Outputs, changeList[i])
-			txParams.OutputsData = append(txParams.OutputsData, []byte{})
-		}
-
-		//changeList, err := core.SplitOutputCell(change, 1000*common.OneCkb, 3, t.ServerScript, nil)
-		//if err != nil {
-		//	return nil, fmt.Errorf("SplitOutputCell err: %s", err.Error())
-		//}
-		//for i := len(changeList); i > 0; i-- {
-		//	txParams.Outputs = append(txParams.Outputs, changeList[i-1])
-		//	txParams.OutputsData = append(txParams.OutputsData, []byte{})
-		//}
+	changeList, err := reservation.ChangeOutputs()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(changeList); i++ {
+		txParams.Outputs = append(txParams.Outputs, changeList[i])
+		txParams.OutputsData = append(txParams.OutputsData, []byte{})
 	}
 
 	// inputs
-	for _, v := range liveCell {
-		txParams.Inputs = append(txParams.Inputs, &types.CellInput{
-			PreviousOutput: v.OutPoint,
-		})
-	}
+	txParams.Inputs = append(txParams.Inputs, reservation.Inputs()...)
 
 	// witness
 	actionWitness, err := witness.GenActionDataWitness(common.DasActionApplyRegister, nil)
@@ -2,7 +2,10 @@ package txtool
 
 import (
 	"das_register_server/notify"
+	"das_register_server/prometheus"
 	"das_register_server/tables"
+	"das_register_server/tracing"
+	"das_register_server/webhook"
 	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
@@ -44,7 +47,7 @@ func (t *TxTool) doDidCellTx() error {
 	if err != nil {
 		return fmt.Errorf("GetNeedSendPayOrderList err: %s", err.Error())
 	}
-	for _, v := range list {
+	for i, v := range list {
 		didCellTxStr, err := t.RC.GetCache(v.OrderId)
 		if err != nil {
 			return fmt.Errorf("GetCache err: %s", err.Error())
@@ -58,7 +61,13 @@ func (t *TxTool) doDidCellTx() error {
 			return fmt.Errorf("UpdatePayStatus err: %s", err.Error())
 		}
 		log.Info("doDidCellTx:", v.Action, txBuilder.TxString())
+		ctx, span := tracing.Start(t.Ctx, "txtool "+string(v.Action), tracing.OrderAttrs(&list[i])...)
 		hash, err := txBuilder.SendTransaction()
+		prometheus.ObserveTxSend(string(v.Action), err)
+		if hash != nil {
+			span.SetAttributes(tracing.AttrTxHash.String(hash.Hex()))
+		}
+		tracing.End(span, err)
 		if err != nil {
 			// clear cache
 			var outpoints []string
@@ -67,9 +76,11 @@ func (t *TxTool) doDidCellTx() error {
 			}
 			t.DasCache.ClearOutPoint(outpoints)
 			// refund
-			if err := t.DbDao.UpdateDidCellOrderToRefund(v.OrderId); err != nil {
+			if err := t.DbDao.WithContext(ctx).UpdateDidCellOrderToRefund(v.OrderId); err != nil {
 				log.Error("UpdateDidCellOrderToRefund err:", err.Error(), v.OrderId)
 				notify.SendLarkErrNotify("doDidCellTx", notify.GetLarkTextNotifyStr("UpdateDidCellOrderToRefund", v.OrderId, err.Error()))
+			} else {
+				webhook.DoEnqueue(t.DbDao, v, webhook.EventFailed, "")
 			}
 
 			//if err := t.DbDao.UpdatePayStatus(v.OrderId, tables.TxStatusOk, tables.TxStatusSending); err != nil {
@@ -88,7 +99,7 @@ func (t *TxTool) doDidCellTx() error {
 			Status:    tables.OrderTxStatusDefault,
 			Timestamp: time.Now().UnixMilli(),
 		}
-		if err := t.DbDao.CreateOrderTx(&orderTx); err != nil {
+		if err := t.DbDao.WithContext(ctx).CreateOrderTx(&orderTx); err != nil {
 			log.Error("CreateOrderTx err:", err.Error(), v.OrderId, hash.Hex())
 			notify.SendLarkErrNotify(common.DasActionTransferAccount, notify.GetLarkTextNotifyStr("CreateOrderTx", v.OrderId, err.Error()))
 		}
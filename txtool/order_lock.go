@@ -0,0 +1,54 @@
+package txtool
+
+import (
+	"das_register_server/cache"
+	"das_register_server/tables"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const orderTxLockTime = time.Minute * 3
+
+var (
+	ErrOrderTxLocked     = errors.New("the order tx is being sent by another process")
+	ErrOrderTxNotPending = errors.New("the order is no longer waiting for the tx")
+)
+
+func isPayPending(order *tables.TableDasOrderInfo) bool {
+	return order.PayStatus == tables.TxStatusSending
+}
+
+func isPreRegisterPending(order *tables.TableDasOrderInfo) bool {
+	return order.PreRegisterStatus == tables.TxStatusSending
+}
+
+// lockOrderTx takes the order tx lock and reads the order again, so a tx another instance
+// or an admin command sent meanwhile is not sent twice. order is updated to the latest row
+func (t *TxTool) lockOrderTx(order *tables.TableDasOrderInfo, pending func(*tables.TableDasOrderInfo) bool) (func(), error) {
+	if err := t.RC.LockOrderTx(order.OrderId, orderTxLockTime); err == cache.ErrDistributedLockPreemption {
+		return nil, ErrOrderTxLocked
+	} else if err != nil {
+		return nil, fmt.Errorf("LockOrderTx err: %s", err.Error())
+	}
+	unlock := func() {
+		if err := t.RC.UnlockOrderTx(order.OrderId); err != nil {
+			log.Error("UnlockOrderTx err:", err.Error(), order.OrderId)
+		}
+	}
+	latest, err := t.DbDao.GetOrderByOrderId(order.OrderId)
+	if err != nil {
+		unlock()
+		return nil, fmt.Errorf("GetOrderByOrderId err: %s", err.Error())
+	} else if latest.Id == 0 || latest.OrderStatus != tables.OrderStatusDefault || !pending(&latest) {
+		unlock()
+		return nil, ErrOrderTxNotPending
+	}
+	*order = latest
+	return unlock, nil
+}
+
+// isOrderTxSkipped is true for the errors the timer skips the order on, it is picked up again or already done
+func isOrderTxSkipped(err error) bool {
+	return err == ErrOrderTxLocked || err == ErrOrderTxNotPending
+}
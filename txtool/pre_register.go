@@ -1,18 +1,19 @@
 package txtool
 
 import (
-	"das_register_server/config"
+	"das_register_server/cellpool"
 	"das_register_server/notify"
+	"das_register_server/prometheus"
 	"das_register_server/tables"
+	"das_register_server/tracing"
+	"das_register_server/webhook"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/molecule"
 	"github.com/dotbitHQ/das-lib/txbuilder"
 	"github.com/dotbitHQ/das-lib/witness"
-	"github.com/nervosnetwork/ckb-sdk-go/address"
 	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
-	"github.com/nervosnetwork/ckb-sdk-go/indexer"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
 	"github.com/nervosnetwork/ckb-sdk-go/utils"
 	"github.com/sjatsh/uint128"
@@ -29,8 +30,11 @@ func (t *TxTool) doOrderPreRegisterTx() error {
 		return fmt.Errorf("GetNeedSendPreRegisterTxOrderList err: %s", err.Error())
 	}
 	for i, _ := range list {
-		if err = t.DoOrderPreRegisterTx(&list[i]); err != nil {
-			if strings.Contains(err.Error(), "Unknown(OutPoint") || strings.Contains(err.Error(), "ValidationFailure: see the error code") {
+		if err = t.DoOrderPreRegisterTx(&list[i]); isOrderTxSkipped(err) {
+			log.Info("DoOrderPreRegisterTx skip:", err.Error(), list[i].OrderId)
+			continue
+		} else if err != nil {
+			if cellpool.IsOutPointConflict(err) || strings.Contains(err.Error(), "ValidationFailure: see the error code") {
 				log.Error("DoOrderPreRegisterTx err:", err.Error(), list[i].AccountId)
 				notify.SendLarkErrNotify(common.DasActionPreRegister, notify.GetLarkTextNotifyStr("DoOrderPreRegisterTx", "", err.Error()))
 				continue
@@ -42,15 +46,22 @@ func (t *TxTool) doOrderPreRegisterTx() error {
 	return nil
 }
 
-func (t *TxTool) DoOrderPreRegisterTx(order *tables.TableDasOrderInfo) error {
+func (t *TxTool) DoOrderPreRegisterTx(order *tables.TableDasOrderInfo) (err error) {
 	if order == nil || order.Id == 0 {
 		return fmt.Errorf("order is nil")
 	}
+	unlock, err := t.lockOrderTx(order, isPreRegisterPending)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	ctx, span := tracing.Start(t.Ctx, "txtool pre_register", tracing.OrderAttrs(order)...)
+	defer func() { tracing.End(span, err) }()
 	orderContent, err := order.GetContent()
 	if err != nil {
 		return fmt.Errorf("GetContent err: %s", err.Error())
 	}
-	orderTxApply, err := t.DbDao.GetOrderTxByAction(order.OrderId, tables.TxActionApplyRegister)
+	orderTxApply, err := t.DbDao.WithContext(ctx).GetOrderTxByAction(order.OrderId, tables.TxActionApplyRegister)
 	if err != nil {
 		return fmt.Errorf("GetOrderTxByAction err: %s", err.Error())
 	} else if orderTxApply.Id == 0 {
@@ -58,13 +69,13 @@ func (t *TxTool) DoOrderPreRegisterTx(order *tables.TableDasOrderInfo) error {
 	}
 	// check apply
 	applyOutpoint := common.String2OutPointStruct(fmt.Sprintf("%s-0", orderTxApply.Hash))
-	applyRes, err := t.DasCore.Client().GetLiveCell(t.Ctx, applyOutpoint, false) //unknown live
+	applyRes, err := t.DasCore.Client().GetLiveCell(ctx, applyOutpoint, false) //unknown live
 	if err != nil {
 		return fmt.Errorf("check apply GetLiveCell err: %s", err.Error())
 	}
 	log.Info("DoOrderPreRegisterTx:", applyRes.Status, order.OrderId)
 	if applyRes.Status != "live" {
-		if err := t.DbDao.UpdateOrderRedoApply(order.OrderId); err != nil {
+		if err := t.DbDao.WithContext(ctx).UpdateOrderRedoApply(order.OrderId); err != nil {
 			return fmt.Errorf("UpdateOrderRedoApply err: %s", err.Error())
 		}
 		return nil
@@ -82,11 +93,11 @@ func (t *TxTool) DoOrderPreRegisterTx(order *tables.TableDasOrderInfo) error {
 	if err != nil {
 		return fmt.Errorf("GetTipBlockNumber err: %s", err.Error())
 	}
-	applyTx, err := t.DasCore.Client().GetTransaction(t.Ctx, types.HexToHash(orderTxApply.Hash))
+	applyTx, err := t.DasCore.Client().GetTransaction(ctx, types.HexToHash(orderTxApply.Hash))
 	if err != nil {
 		return fmt.Errorf("txTool DoOrderPreRegisterTx GetTransaction err: %s", err)
 	}
-	applyTxBlock, err := t.DasCore.Client().GetBlock(t.Ctx, *applyTx.TxStatus.BlockHash)
+	applyTxBlock, err := t.DasCore.Client().GetBlock(ctx, *applyTx.TxStatus.BlockHash)
 	if err != nil {
 		return fmt.Errorf("txTool DoOrderPreRegisterTx GetBlock err: %s", err)
 	}
@@ -124,6 +135,7 @@ func (t *TxTool) DoOrderPreRegisterTx(order *tables.TableDasOrderInfo) error {
 		registerYears:              orderContent.RegisterYears,
 		applyMinWaitingBlockNumber: uint64(applyMinWaitingBlockNumber),
 	}
+	defer func() { p.reservation.Release(err) }()
 	txParams, err := t.buildOrderPreRegisterTx(&p)
 	if err != nil {
 		return fmt.Errorf("buildOrderPreRegisterTx err: %s", err.Error())
@@ -135,40 +147,47 @@ func (t *TxTool) DoOrderPreRegisterTx(order *tables.TableDasOrderInfo) error {
 	}
 
 	// check has pre tx
-	preOrder, err := t.DbDao.GetPreRegisteredOrderByAccountId(order.AccountId)
+	preOrder, err := t.DbDao.WithContext(ctx).GetPreRegisteredOrderByAccountId(order.AccountId)
 	if err != nil {
 		return fmt.Errorf("GetPreRegisteredOrderByAccountId err: %s", err.Error())
 	} else if preOrder.Id > 0 && time.Now().Unix() < (preOrder.Timestamp/1e3)+2592000 { // refund
 		log.Info("UpdateOrderToRefund:", order.OrderId)
-		if err := t.DbDao.UpdateOrderToRefund(order.OrderId); err != nil {
+		if err := t.DbDao.WithContext(ctx).UpdateOrderToRefund(order.OrderId); err != nil {
 			return fmt.Errorf("UpdateOrderToRefund err: %s [%s]", err.Error(), order.OrderId)
 		}
+		webhook.DoEnqueue(t.DbDao, *order, webhook.EventFailed, "")
 		return nil
 	}
 
 	// update order
-	if err := t.DbDao.UpdatePreRegisterStatus(order.OrderId, tables.TxStatusSending, tables.TxStatusOk); err != nil {
+	if err := t.DbDao.WithContext(ctx).UpdatePreRegisterStatus(order.OrderId, tables.TxStatusSending, tables.TxStatusOk); err != nil {
 		return fmt.Errorf("UpdatePreRegisterStatus err: %s", err.Error())
 	}
 	//
 	if hash, err := txBuilder.SendTransaction(); err != nil {
+		prometheus.ObserveTxSend(string(tables.TxActionPreRegister), err)
 		if strings.Contains(err.Error(), "error code 35") || strings.Contains(err.Error(), "error code 53") {
 			log.Error("err see the error code 35 || 53:", order.OrderId, err.Error())
 			notify.SendLarkErrNotify(common.DasActionPreRegister, notify.GetLarkTextNotifyStr("UpdateOrderToClosedAndRefund", order.OrderId, order.Account))
-			if err := t.DbDao.UpdateOrderToClosedAndRefund(order.OrderId); err != nil {
+			if err := t.DbDao.WithContext(ctx).UpdateOrderToClosedAndRefund(order.OrderId); err != nil {
 				log.Error("UpdateOrderToClosed err:", err.Error())
 				notify.SendLarkErrNotify(common.DasActionPreRegister, notify.GetLarkTextNotifyStr("UpdateOrderToClosedAndRefund", order.OrderId, err.Error()))
+			} else {
+				webhook.DoEnqueue(t.DbDao, *order, webhook.EventFailed, "")
 			}
 		} else {
 			// update order
-			if err := t.DbDao.UpdatePreRegisterStatus(order.OrderId, tables.TxStatusOk, tables.TxStatusSending); err != nil {
+			if err := t.DbDao.WithContext(ctx).UpdatePreRegisterStatus(order.OrderId, tables.TxStatusOk, tables.TxStatusSending); err != nil {
 				log.Error("UpdatePayStatus err:", err.Error(), order.OrderId)
 				notify.SendLarkErrNotify(common.DasActionPreRegister, notify.GetLarkTextNotifyStr("UpdatePayStatus", order.OrderId, err.Error()))
 			}
 			return fmt.Errorf("SendTransaction err: %s", err.Error())
 		}
 	} else {
+		prometheus.ObserveTxSend(string(tables.TxActionPreRegister), nil)
+		span.SetAttributes(tracing.AttrTxHash.String(hash.Hex()))
 		log.Info("SendTransaction ok:", tables.TxActionPreRegister, hash)
+		p.reservation.Sent()
 		t.DasCache.AddCellInputByAction("", txBuilder.Transaction.Inputs)
 		// update tx hash
 		orderTx := tables.TableDasOrderTxInfo{
@@ -178,7 +197,7 @@ func (t *TxTool) DoOrderPreRegisterTx(order *tables.TableDasOrderInfo) error {
 			Status:    tables.OrderTxStatusDefault,
 			Timestamp: time.Now().UnixNano() / 1e6,
 		}
-		if err := t.DbDao.CreateOrderTx(&orderTx); err != nil {
+		if err := t.DbDao.WithContext(ctx).CreateOrderTx(&orderTx); err != nil {
 			log.Error("CreateOrderTx err:", err.Error(), order.OrderId, hash.Hex())
 			notify.SendLarkErrNotify(common.DasActionPreRegister, notify.GetLarkTextNotifyStr("CreateOrderTx", order.OrderId, err.Error()))
 		}
@@ -242,12 +261,8 @@ func (t *TxTool) getOrderInviterChannelScript(orderContent *tables.TableOrderCon
 		} else {
 			channelScript = t.ServerScript
 		}
-	} else if config.Cfg.Server.PayServerAddress != "" {
-		if parseAddr, err := address.Parse(config.Cfg.Server.PayServerAddress); err != nil {
-			log.Error("address.Parse err: ", err.Error(), config.Cfg.Server.PayServerAddress)
-		} else {
-			channelScript = parseAddr.Script
-		}
+	} else if t.ServerScript != nil {
+		channelScript = t.ServerScript
 	}
 	return inviterScript, channelScript, inviterId, nil
 }
@@ -263,6 +278,7 @@ type preRegisterTxParams struct {
 	accountChars               []common.AccountCharSet
 	registerYears              int
 	applyMinWaitingBlockNumber uint64
+	reservation                *cellpool.Reservation
 }
 
 func (t *TxTool) buildOrderPreRegisterTx(p *preRegisterTxParams) (*txbuilder.BuildTransactionParams, error) {
@@ -417,45 +433,23 @@ func (t *TxTool) buildOrderPreRegisterTx(p *preRegisterTxParams) (*txbuilder.Bui
 	feeCapacity := uint64(1112663)
 	needCapacity := feeCapacity + preOutputs.Capacity - applyCapacity
 
-	change, liveCell, err := t.DasCore.GetBalanceCellWithLock(&core.ParamGetBalanceCells{
-		LockScript:        t.ServerScript,
-		CapacityNeed:      needCapacity,
-		DasCache:          t.DasCache,
-		CapacityForChange: common.MinCellOccupiedCkb,
-		SearchOrder:       indexer.SearchOrderDesc,
-	})
+	reservation, err := t.CellPool.Reserve(p.order.OrderId, needCapacity, common.MinCellOccupiedCkb)
 	if err != nil {
-		return nil, fmt.Errorf("GetBalanceCellWithLock err %s", err.Error())
+		return nil, fmt.Errorf("Reserve err: %s", err.Error())
 	}
+	p.reservation = reservation
 
-	if change > 0 {
-		splitCkb := 2000 * common.OneCkb
-		if config.Cfg.Server.SplitCkb > 0 {
-			splitCkb = config.Cfg.Server.SplitCkb * common.OneCkb
-		}
-		changeList, err := core.SplitOutputCell2(change, splitCkb, 200, t.ServerScript, nil, indexer.SearchOrderDesc)
-		if err != nil {
-			return nil, fmt.Errorf("SplitOutputCell2 err: %s", err.Error())
-		}
-		for i := 0; i < len(changeList); i++ {
-			txParams.Outputs = append(txParams.Outputs, changeList[i])
-			txParams.OutputsData = append(txParams.OutputsData, []byte{})
-		}
-
-		//txParams.Outputs = append(txParams.Outputs, &types.CellOutput{
-		//	Capacity: change,
-		//	Lock:     t.ServerScript,
-		//	Type:     nil,
-		//})
-		//txParams.OutputsData = append(txParams.OutputsData, []byte{})
+	changeList, err := reservation.ChangeOutputs()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(changeList); i++ {
+		txParams.Outputs = append(txParams.Outputs, changeList[i])
+		txParams.OutputsData = append(txParams.OutputsData, []byte{})
 	}
 
 	// inputs
-	for _, v := range liveCell {
-		txParams.Inputs = append(txParams.Inputs, &types.CellInput{
-			PreviousOutput: v.OutPoint,
-		})
-	}
+	txParams.Inputs = append(txParams.Inputs, reservation.Inputs()...)
 
 	// cell deps
 	applyContract, err := core.GetDasContractInfo(common.DasContractNameApplyRegisterCellType)
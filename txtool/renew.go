@@ -1,16 +1,18 @@
 package txtool
 
 import (
+	"das_register_server/cellpool"
 	"das_register_server/config"
 	"das_register_server/notify"
+	"das_register_server/prometheus"
 	"das_register_server/tables"
+	"das_register_server/tracing"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/molecule"
 	"github.com/dotbitHQ/das-lib/txbuilder"
 	"github.com/dotbitHQ/das-lib/witness"
-	"github.com/nervosnetwork/ckb-sdk-go/indexer"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
 	"github.com/sjatsh/uint128"
 	"time"
@@ -25,31 +27,41 @@ func (t *TxTool) doOrderRenewTx() error {
 		return fmt.Errorf("GetNeedSendPayOrderList err: %s", err.Error())
 	}
 	for i, _ := range list {
-		if err = t.DoOrderRenewTx(&list[i]); err != nil {
+		if err = t.DoOrderRenewTx(&list[i]); isOrderTxSkipped(err) {
+			log.Info("DoOrderRenewTx skip:", err.Error(), list[i].OrderId)
+			continue
+		} else if err != nil {
 			return fmt.Errorf("DoOrderRenewTx err: %s", err.Error())
 		}
 	}
 	return nil
 }
 
-func (t *TxTool) DoOrderRenewTx(order *tables.TableDasOrderInfo) error {
+func (t *TxTool) DoOrderRenewTx(order *tables.TableDasOrderInfo) (err error) {
 	if order == nil || order.Id == 0 {
 		return fmt.Errorf("order is nil")
 	}
+	unlock, err := t.lockOrderTx(order, isPayPending)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	ctx, span := tracing.Start(t.Ctx, "txtool renew_account", tracing.OrderAttrs(order)...)
+	defer func() { tracing.End(span, err) }()
 	orderContent, err := order.GetContent()
 	if err != nil {
 		return fmt.Errorf("GetContent err: %s", err.Error())
 	}
 
 	accountId := common.GetAccountIdByAccount(order.Account)
-	acc, err := t.DbDao.GetAccountInfoByAccountId(common.Bytes2Hex(accountId))
+	acc, err := t.DbDao.WithContext(ctx).GetAccountInfoByAccountId(common.Bytes2Hex(accountId))
 	if err != nil {
 		return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
 	} else if acc.Status == tables.AccountStatusOnCross {
 		log.Error("DoOrderRenewTx:", order.OrderId, acc.Status)
 		msg := fmt.Sprintf(`order id: %s, account on cross`, order.OrderId)
 		notify.SendLarkErrNotify(common.DasActionRenewAccount, msg)
-		if err := t.DbDao.UpdateOrderStatusClosed(order.OrderId); err != nil {
+		if err := t.DbDao.WithContext(ctx).UpdateOrderStatusClosed(order.OrderId); err != nil {
 			return fmt.Errorf("UpdateOrderStatusClosed err: %s", err.Error())
 		}
 		return nil
@@ -61,6 +73,7 @@ func (t *TxTool) DoOrderRenewTx(order *tables.TableDasOrderInfo) error {
 		account:    &acc,
 		renewYears: orderContent.RenewYears,
 	}
+	defer func() { p.reservation.Release(err) }()
 	txParams, err := t.buildOrderRenewTx(&p)
 	if err != nil {
 		return fmt.Errorf("buildOrderPreRegisterTx err: %s", err.Error())
@@ -73,7 +86,7 @@ func (t *TxTool) DoOrderRenewTx(order *tables.TableDasOrderInfo) error {
 
 	sizeInBlock, _ := txBuilder.Transaction.SizeInBlock()
 
-	txFeeRate := config.Cfg.Server.TxTeeRate
+	txFeeRate := config.Cfg().Server.TxTeeRate
 	if txFeeRate == 0 {
 		txFeeRate = 1
 	}
@@ -86,18 +99,22 @@ func (t *TxTool) DoOrderRenewTx(order *tables.TableDasOrderInfo) error {
 	log.Info("changeCapacity:", txFee, changeCapacity)
 
 	// update order
-	if err := t.DbDao.UpdatePayStatus(order.OrderId, tables.TxStatusSending, tables.TxStatusOk); err != nil {
+	if err := t.DbDao.WithContext(ctx).UpdatePayStatus(order.OrderId, tables.TxStatusSending, tables.TxStatusOk); err != nil {
 		return fmt.Errorf("UpdatePayStatus err: %s", err.Error())
 	}
 	if hash, err := txBuilder.SendTransaction(); err != nil {
+		prometheus.ObserveTxSend(string(tables.TxActionRenewAccount), err)
 		// update order
-		if err := t.DbDao.UpdatePayStatus(order.OrderId, tables.TxStatusOk, tables.TxStatusSending); err != nil {
+		if err := t.DbDao.WithContext(ctx).UpdatePayStatus(order.OrderId, tables.TxStatusOk, tables.TxStatusSending); err != nil {
 			log.Error("UpdatePayStatus err:", err.Error(), order.OrderId)
 			notify.SendLarkErrNotify(common.DasActionRenewAccount, notify.GetLarkTextNotifyStr("UpdatePayStatus", order.OrderId, err.Error()))
 		}
 		return fmt.Errorf("SendTransaction err: %s", err.Error())
 	} else {
+		prometheus.ObserveTxSend(string(tables.TxActionRenewAccount), nil)
+		span.SetAttributes(tracing.AttrTxHash.String(hash.Hex()))
 		log.Info("SendTransaction ok:", tables.TxActionRenewAccount, hash)
+		p.reservation.Sent()
 		t.DasCache.AddCellInputByAction("", txBuilder.Transaction.Inputs)
 		// update tx hash
 		orderTx := tables.TableDasOrderTxInfo{
@@ -107,7 +124,7 @@ func (t *TxTool) DoOrderRenewTx(order *tables.TableDasOrderInfo) error {
 			Status:    tables.OrderTxStatusDefault,
 			Timestamp: time.Now().UnixNano() / 1e6,
 		}
-		if err := t.DbDao.CreateOrderTx(&orderTx); err != nil {
+		if err := t.DbDao.WithContext(ctx).CreateOrderTx(&orderTx); err != nil {
 			log.Error("CreateOrderTx err:", err.Error(), order.OrderId, hash.Hex())
 			notify.SendLarkErrNotify(common.DasActionRenewAccount, notify.GetLarkTextNotifyStr("CreateOrderTx", order.OrderId, err.Error()))
 		}
@@ -117,9 +134,10 @@ func (t *TxTool) DoOrderRenewTx(order *tables.TableDasOrderInfo) error {
 }
 
 type renewTxParams struct {
-	order      *tables.TableDasOrderInfo
-	account    *tables.TableAccountInfo
-	renewYears int
+	order       *tables.TableDasOrderInfo
+	account     *tables.TableAccountInfo
+	renewYears  int
+	reservation *cellpool.Reservation
 }
 
 func (t *TxTool) buildOrderRenewTx(p *renewTxParams) (*txbuilder.BuildTransactionParams, error) {
@@ -205,51 +223,23 @@ func (t *TxTool) buildOrderRenewTx(p *renewTxParams) (*txbuilder.BuildTransactio
 	feeCapacity := uint64(1e4)
 	needCapacity := feeCapacity + incomeCell.incomeCell.Capacity
 
-	change, liveCell, err := t.DasCore.GetBalanceCellWithLock(&core.ParamGetBalanceCells{
-		LockScript:        t.ServerScript,
-		CapacityNeed:      needCapacity,
-		DasCache:          t.DasCache,
-		CapacityForChange: common.MinCellOccupiedCkb,
-		SearchOrder:       indexer.SearchOrderDesc,
-	})
+	reservation, err := t.CellPool.Reserve(p.order.OrderId, needCapacity, common.MinCellOccupiedCkb)
 	if err != nil {
-		return nil, fmt.Errorf("GetBalanceCellWithLock err %s", err.Error())
+		return nil, fmt.Errorf("Reserve err: %s", err.Error())
 	}
+	p.reservation = reservation
 
 	// inputs
-	for _, v := range liveCell {
-		txParams.Inputs = append(txParams.Inputs, &types.CellInput{
-			PreviousOutput: v.OutPoint,
-		})
-	}
+	txParams.Inputs = append(txParams.Inputs, reservation.Inputs()...)
 
 	// change
-	if change > 0 {
-		splitCkb := 2000 * common.OneCkb
-		if config.Cfg.Server.SplitCkb > 0 {
-			splitCkb = config.Cfg.Server.SplitCkb * common.OneCkb
-		}
-		changeList, err := core.SplitOutputCell2(change, splitCkb, 200, t.ServerScript, nil, indexer.SearchOrderAsc)
-		if err != nil {
-			return nil, fmt.Errorf("SplitOutputCell2 err: %s", err.Error())
-		}
-		for i := 0; i < len(changeList); i++ {
-			txParams.Outputs = append(txParams.Outputs, changeList[i])
-			txParams.OutputsData = append(txParams.OutputsData, []byte{})
-		}
-
-		//changeList, err := core.SplitOutputCell(change, 1000*common.OneCkb, 3, t.ServerScript, nil)
-		//if err != nil {
-		//	return nil, fmt.Errorf("SplitOutputCell err: %s", err.Error())
-		//}
-		//for i := len(changeList); i > 0; i-- {
-		//	txParams.Outputs = append(txParams.Outputs, changeList[i-1])
-		//	txParams.OutputsData = append(txParams.OutputsData, []byte{})
-		//}
-		//for _, cell := range changeList {
-		//	txParams.Outputs = append(txParams.Outputs, cell)
-		//	txParams.OutputsData = append(txParams.OutputsData, []byte{})
-		//}
+	changeList, err := reservation.ChangeOutputs()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(changeList); i++ {
+		txParams.Outputs = append(txParams.Outputs, changeList[i])
+		txParams.OutputsData = append(txParams.OutputsData, []byte{})
 	}
 
 	// cell deps
@@ -3,6 +3,7 @@ package txtool
 import (
 	"context"
 	"das_register_server/cache"
+	"das_register_server/cellpool"
 	"das_register_server/config"
 	"das_register_server/dao"
 	"das_register_server/notify"
@@ -31,6 +32,7 @@ type TxTool struct {
 	ServerScript  *types.Script
 	RebootTime    time.Time
 	RC            *cache.RedisCache
+	CellPool      *cellpool.Pool
 }
 
 func (t *TxTool) Run() {
@@ -44,7 +46,7 @@ func (t *TxTool) Run() {
 			select {
 			case <-tickerApply.C:
 				log.Debug("doOrderApplyTx start ...")
-				if config.Cfg.Server.TxToolSwitch {
+				if config.Cfg().Server.TxToolSwitch {
 					if err := t.doOrderApplyTx(); err != nil {
 						log.Error("doOrderApplyTx err: ", err.Error())
 						notify.SendLarkErrNotify(common.DasActionApplyRegister, notify.GetLarkTextNotifyStr("doOrderApplyTx", "", err.Error()))
@@ -53,7 +55,7 @@ func (t *TxTool) Run() {
 				log.Debug("doOrderApplyTx end ...")
 			case <-tickerPreRegister.C:
 				log.Debug("doOrderPreRegisterTx start ...")
-				if config.Cfg.Server.TxToolSwitch {
+				if config.Cfg().Server.TxToolSwitch {
 					if err := t.doOrderPreRegisterTx(); err != nil {
 						log.Error("doOrderPreRegisterTx err: ", err.Error())
 						notify.SendLarkErrNotify(common.DasActionPreRegister, notify.GetLarkTextNotifyStr("doOrderPreRegisterTx", "", err.Error()))
@@ -62,7 +64,7 @@ func (t *TxTool) Run() {
 				log.Debug("doOrderPreRegisterTx end ...")
 			case <-tickerRenew.C:
 				log.Debug("doOrderRenewTx start ...")
-				if config.Cfg.Server.TxToolSwitch {
+				if config.Cfg().Server.TxToolSwitch {
 					if err := t.doOrderRenewTx(); err != nil {
 						log.Error("doOrderRenewTx err: ", err.Error())
 						notify.SendLarkErrNotify(common.DasActionRenewAccount, notify.GetLarkTextNotifyStr("doOrderRenewTx", "", err.Error()))
@@ -2,14 +2,19 @@ package unipay
 
 import (
 	"das_register_server/dao"
+	"das_register_server/event"
 	"das_register_server/notify"
+	"das_register_server/prometheus"
 	"das_register_server/tables"
+	"das_register_server/webhook"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/http_api"
 	"time"
 )
 
+const EventActionRefunded = "refunded"
+
 func (t *ToolUniPay) RunConfirmStatus() {
 	tickerSearchStatus := time.NewTicker(time.Minute * 3)
 
@@ -62,7 +67,7 @@ func (t *ToolUniPay) doConfirmStatus() error {
 	log.Info("doConfirmStatus:", len(orderIdList), len(payHashList))
 
 	// call unipay
-	resp, err := GetPaymentInfo(ReqPaymentInfo{
+	resp, err := GetPaymentInfo(t.Ctx, ReqPaymentInfo{
 		BusinessId:  BusinessIdDasRegisterSvr,
 		OrderIdList: orderIdList,
 		PayHashList: payHashList,
@@ -85,7 +90,7 @@ func (t *ToolUniPay) doConfirmStatus() error {
 			min := pending.PayHashUnconfirmedMin()
 			log.Info("PayHashUnconfirmedMin:", pending.OrderId, min)
 			//if min > 60 {
-			//	notify.SendLarkTextNotify(config.Cfg.Notify.LarkErrorKey, "Payment not completed", pending.OrderId)
+			//	notify.SendLarkTextNotify(config.Cfg().Notify.LarkErrorKey, "Payment not completed", pending.OrderId)
 			//	if err := t.DbDao.UpdateUniPayUnconfirmedToRejected(pending.OrderId, pending.Hash); err != nil {
 			//		return fmt.Errorf("UpdateUniPayUnconfirmedToRejected err: %s", err.Error())
 			//	}
@@ -112,9 +117,9 @@ func (t *ToolUniPay) doConfirmStatus() error {
 		if paymentInfo.RefundStatus != tables.UniPayRefundStatusRefunded {
 			continue
 		}
-		if err := t.DbDao.UpdateUniPayRefundStatusToRefunded(paymentInfo.PayHash, paymentInfo.OrderId, paymentInfo.RefundHash); err != nil {
-			log.Error("UpdateUniPayRefundStatusToRefunded err: ", err.Error())
-			notify.SendLarkErrNotify("UpdateUniPayRefundStatusToRefunded", err.Error())
+		if err := DoRefundConfirm(t.DbDao, paymentInfo.PayHash, paymentInfo.OrderId, paymentInfo.RefundHash); err != nil {
+			log.Error("DoRefundConfirm err: ", err.Error())
+			notify.SendLarkErrNotify("DoRefundConfirm", err.Error())
 		}
 	}
 
@@ -142,5 +147,27 @@ func DoPaymentConfirm(dbDao *dao.DbDao, orderId, payHash, payAddress string, alg
 	if err = dbDao.UpdatePayment(paymentInfo); err != nil {
 		return fmt.Errorf("UpdatePayment err: %s", err.Error())
 	}
+	if orderInfo.PayStatus == tables.TxStatusDefault {
+		prometheus.ObservePaymentConfirm(string(orderInfo.PayTokenId), orderInfo.Timestamp)
+	}
+	if orderInfo, err = dbDao.GetOrderByOrderId(orderId); err != nil {
+		log.Error("GetOrderByOrderId err: ", err.Error(), orderId)
+	} else {
+		event.PublishOrder(orderInfo, string(tables.TxActionConfirmPayment), payHash)
+		webhook.DoEnqueue(dbDao, orderInfo, webhook.EventPaymentConfirmed, payHash)
+	}
+	return nil
+}
+
+func DoRefundConfirm(dbDao *dao.DbDao, payHash, orderId, refundHash string) error {
+	if err := dbDao.UpdateUniPayRefundStatusToRefunded(payHash, orderId, refundHash); err != nil {
+		return fmt.Errorf("UpdateUniPayRefundStatusToRefunded err: %s", err.Error())
+	}
+	if orderInfo, err := dbDao.GetOrderByOrderId(orderId); err != nil {
+		log.Error("GetOrderByOrderId err: ", err.Error(), orderId)
+	} else {
+		event.PublishOrder(orderInfo, EventActionRefunded, refundHash)
+		webhook.DoEnqueue(dbDao, orderInfo, webhook.EventRefunded, refundHash)
+	}
 	return nil
 }
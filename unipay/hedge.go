@@ -5,6 +5,7 @@ import (
 	"das_register_server/notify"
 	"das_register_server/tables"
 	"das_register_server/timer"
+	"das_register_server/tracing"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/http_api"
 	"github.com/parnurzeal/gorequest"
@@ -71,6 +72,9 @@ func (t *ToolUniPay) doOrderHedge() error {
 		if err := t.doHedge(req); err != nil {
 			log.Error("doHedge err: ", err.Error(), req.OrderId)
 			notify.SendLarkErrNotify("do hedge", notify.GetLarkTextNotifyStr("doHedge", req.OrderId, err.Error()))
+			if err := t.DbDao.UpdateHedgeStatus(v.OrderId, tables.TxStatusOk, tables.TxStatusFail); err != nil {
+				log.Error("UpdateHedgeStatus err: ", err.Error(), req.OrderId)
+			}
 			continue
 		}
 	}
@@ -88,16 +92,22 @@ type RespDeposit struct {
 	Message string `json:"message"`
 }
 
-func (t *ToolUniPay) doHedge(req ReqHedge) error {
+func (t *ToolUniPay) doHedge(req ReqHedge) (err error) {
 	if req.PayTokenId == tables.TokenIdCkb || req.PayTokenId == tables.TokenIdDas || req.PayTokenId == tables.TokenIdCkbInternal {
 		return nil
 	}
 	var res RespDeposit
-	url := config.Cfg.Server.HedgeUrl
+	url := config.Cfg().Server.HedgeUrl
 	if url == "" {
 		return nil
 	}
-	resp, body, errs := gorequest.New().Post(url).SendStruct(&req).EndStruct(&res)
+	ctx, span := tracing.Start(t.Ctx, "hedge deposit", tracing.AttrOrderId.String(req.OrderId))
+	defer func() { tracing.End(span, err) }()
+	request := gorequest.New().Post(url)
+	for k, v := range tracing.InjectHeader(ctx) {
+		request.Set(k, v)
+	}
+	resp, body, errs := request.SendStruct(&req).EndStruct(&res)
 	if len(errs) > 0 {
 		return fmt.Errorf("doHedge errs:%+v %s", errs, string(body))
 	}
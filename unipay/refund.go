@@ -34,7 +34,7 @@ func (t *ToolUniPay) RunOrderRefund() {
 }
 
 func (t *ToolUniPay) doRefund() error {
-	if !config.Cfg.Server.UniPayRefundSwitch {
+	if !config.Cfg().Server.UniPayRefundSwitch {
 		return nil
 	}
 	//get payment list
@@ -79,7 +79,7 @@ func (t *ToolUniPay) doRefund() error {
 		return nil
 	}
 
-	_, err = RefundOrder(req)
+	_, err = RefundOrder(t.Ctx, req)
 	if err != nil {
 		return fmt.Errorf("RefundOrder err: %s", err.Error())
 	}
@@ -5,13 +5,18 @@ import (
 	"das_register_server/config"
 	"das_register_server/dao"
 	"das_register_server/tables"
+	"das_register_server/tracing"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
 	"github.com/dotbitHQ/das-lib/http_api/logger"
+	"github.com/parnurzeal/gorequest"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
 	"sync"
+	"time"
 )
 
 var (
@@ -42,9 +47,33 @@ type RespOrderCreate struct {
 	ClientSecret          string `json:"client_secret"`
 }
 
-func CreateOrder(req ReqOrderCreate) (resp RespOrderCreate, err error) {
-	url := fmt.Sprintf("%s/v1/order/create", config.Cfg.Server.UniPayUrl)
-	err = http_api.SendReq(url, &req, &resp)
+// sendReq calls unipay inside a client span, so a slow payment backend shows up in the caller's trace,
+// the trace headers are sent along for unipay to continue it. Same retries and checks as http_api.SendReq.
+func sendReq(ctx context.Context, path string, req, resp interface{}, attrs ...attribute.KeyValue) (err error) {
+	ctx, span := tracing.Start(ctx, "unipay "+path, attrs...)
+	defer func() { tracing.End(span, err) }()
+	url := fmt.Sprintf("%s%s", config.Cfg().Server.UniPayUrl, path)
+
+	var apiResp http_api.ApiResp
+	apiResp.Data = &resp
+	request := gorequest.New().Post(url).Retry(3, time.Second*5).Timeout(time.Second * 10)
+	for k, v := range tracing.InjectHeader(ctx) {
+		request.Set(k, v)
+	}
+	res, _, errs := request.SendStruct(req).EndStruct(&apiResp)
+	if len(errs) > 0 {
+		return fmt.Errorf("SendReq errs: %v", errs)
+	} else if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("SendReq StatusCode: %d", res.StatusCode)
+	}
+	if apiResp.ErrNo != http_api.ApiCodeSuccess {
+		return fmt.Errorf("%d - %s", apiResp.ErrNo, apiResp.ErrMsg)
+	}
+	return nil
+}
+
+func CreateOrder(ctx context.Context, req ReqOrderCreate) (resp RespOrderCreate, err error) {
+	err = sendReq(ctx, "/v1/order/create", &req, &resp, attribute.String("das.pay_token_id", string(req.PayTokenId)))
 	return
 }
 
@@ -61,9 +90,12 @@ type ReqOrderRefund struct {
 type RespOrderRefund struct {
 }
 
-func RefundOrder(req ReqOrderRefund) (resp RespOrderRefund, err error) {
-	url := fmt.Sprintf("%s/v1/order/refund", config.Cfg.Server.UniPayUrl)
-	err = http_api.SendReq(url, &req, &resp)
+func RefundOrder(ctx context.Context, req ReqOrderRefund) (resp RespOrderRefund, err error) {
+	orderIds := make([]string, 0, len(req.RefundList))
+	for _, v := range req.RefundList {
+		orderIds = append(orderIds, v.OrderId)
+	}
+	err = sendReq(ctx, "/v1/order/refund", &req, &resp, tracing.AttrOrderIds.StringSlice(orderIds))
 	return
 }
 
@@ -87,9 +119,8 @@ type PaymentInfo struct {
 	RefundHash    string                    `json:"refund_hash"`
 }
 
-func GetPaymentInfo(req ReqPaymentInfo) (resp RespPaymentInfo, err error) {
-	url := fmt.Sprintf("%s/v1/payment/info", config.Cfg.Server.UniPayUrl)
-	err = http_api.SendReq(url, &req, &resp)
+func GetPaymentInfo(ctx context.Context, req ReqPaymentInfo) (resp RespPaymentInfo, err error) {
+	err = sendReq(ctx, "/v1/payment/info", &req, &resp, tracing.AttrOrderIds.StringSlice(req.OrderIdList))
 	return
 }
 
@@ -105,9 +136,8 @@ type RespOrderInfo struct {
 	ClientSecret    string `json:"client_secret"`
 }
 
-func GetOrderInfo(req ReqOrderInfo) (resp RespOrderInfo, err error) {
-	url := fmt.Sprintf("%s/v1/order/info", config.Cfg.Server.UniPayUrl)
-	err = http_api.SendReq(url, &req, &resp)
+func GetOrderInfo(ctx context.Context, req ReqOrderInfo) (resp RespOrderInfo, err error) {
+	err = sendReq(ctx, "/v1/order/info", &req, &resp, tracing.AttrOrderId.String(req.OrderId))
 	return
 }
 
@@ -0,0 +1,141 @@
+package wallet
+
+import (
+	"context"
+	"das_register_server/cache"
+	"das_register_server/notify"
+	"das_register_server/prometheus"
+	"das_register_server/signer"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/go-redis/redis"
+	"sync"
+	"time"
+)
+
+const statusCacheKey = "wallet:status"
+
+type Monitor struct {
+	Ctx     context.Context
+	Wg      *sync.WaitGroup
+	DasCore *core.DasCore
+	Rc      *cache.RedisCache
+	samples map[signer.Role][]sample
+	low     map[string]bool // role and threshold alerted
+}
+
+// RunMonitor checks the signer addresses every minute, alerts when one gets low and
+// caches the status for the internal /v1/wallet/status
+func (m *Monitor) RunMonitor() {
+	m.samples = make(map[signer.Role][]sample)
+	m.low = make(map[string]bool)
+	ticker := time.NewTicker(time.Minute)
+
+	m.Wg.Add(1)
+	go func() {
+		defer http_api.RecoverPanic()
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.doMonitor(); err != nil {
+					log.Error("doMonitor err:", err.Error())
+				}
+			case <-m.Ctx.Done():
+				ticker.Stop()
+				log.Debug("RunMonitor done")
+				m.Wg.Done()
+				return
+			}
+		}
+	}()
+}
+
+func (m *Monitor) doMonitor() error {
+	now := time.Now()
+	var list []Status
+	for _, role := range Roles {
+		addr := signer.Address(role)
+		if addr == "" {
+			continue
+		}
+		st, err := Check(m.Ctx, m.DasCore.Client(), role, addr)
+		if err != nil {
+			log.Error("Check err:", role, err.Error())
+			continue
+		}
+
+		samples := append(m.samples[role], sample{at: now, spendable: st.SpendableCkb})
+		for len(samples) > 0 && now.Sub(samples[0].at) > spendingWindow() {
+			samples = samples[1:]
+		}
+		m.samples[role] = samples
+		evaluate(st, samples, Threshold(role))
+
+		m.alert(st)
+		if prometheus.Tools != nil {
+			gauge := prometheus.Tools.Metrics.HotWallet()
+			gauge.WithLabelValues(role, "spendable_ckb").Set(float64(st.SpendableCkb))
+			gauge.WithLabelValues(role, "spendable_cells").Set(float64(st.SpendableCells))
+			gauge.WithLabelValues(role, "spending_ckb_per_hour").Set(st.SpendingCkbPerHour)
+			gauge.WithLabelValues(role, "runway_hours").Set(st.RunwayHours)
+		}
+		list = append(list, *st)
+	}
+
+	if m.Rc != nil {
+		bys, _ := json.Marshal(list)
+		if err := m.Rc.SetCache(statusCacheKey, string(bys), time.Minute*5); err != nil {
+			return fmt.Errorf("SetCache err: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// alert sends every threshold once when it is crossed and again after it recovered
+func (m *Monitor) alert(st *Status) {
+	low := make(map[string]bool)
+	for _, v := range st.Low {
+		low[v] = true
+	}
+	for _, v := range []string{LowMinCkb, LowMinCells, LowMinRunwayHours} {
+		key := st.Role + v
+		if !low[v] {
+			delete(m.low, key)
+			continue
+		}
+		if m.low[key] {
+			continue
+		}
+		m.low[key] = true
+		severity := notify.SeverityCritical
+		if v == LowMinRunwayHours {
+			severity = notify.SeverityWarning
+		}
+		th := Threshold(st.Role)
+		notify.Send(&notify.Alert{
+			Severity: severity,
+			Title:    "hot wallet low",
+			Text: fmt.Sprintf("role: %s\naddress: %s\nbelow: %s\nspendable: %d CKB in %d cells (min %d CKB, %d cells)\nspending: %.2f CKB/h, runway: %.1f h (min %.1f h)",
+				st.Role, st.Address, v, st.SpendableCkb, st.SpendableCells, th.MinCkb, th.MinCells,
+				st.SpendingCkbPerHour, st.RunwayHours, th.MinRunwayHours),
+			Key: key,
+		})
+	}
+}
+
+// GetStatus returns the status cached by the monitor, nil when the monitor has not run
+func GetStatus(rc *cache.RedisCache) ([]Status, error) {
+	str, err := rc.GetCache(statusCacheKey)
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var list []Status
+	if err := json.Unmarshal([]byte(str), &list); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	return list, nil
+}
@@ -0,0 +1,144 @@
+package wallet
+
+import (
+	"context"
+	"das_register_server/config"
+	"das_register_server/signer"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api/logger"
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"time"
+)
+
+var log = logger.NewLogger("wallet", logger.LevelDebug)
+
+const (
+	LowMinCkb         = "min_ckb"
+	LowMinCells       = "min_cells"
+	LowMinRunwayHours = "min_runway_hours"
+
+	maxCells              = 2000 // spendable cells counted per address
+	cellsPageSize         = 500
+	defaultSpendingWindow = time.Hour * 6
+	minSpendingWindow     = time.Minute * 10 // runway is unknown until spending has been sampled this long
+)
+
+var Roles = []signer.Role{signer.RolePay, signer.RoleTransferWhitelist, signer.RoleCapacityWhitelist}
+
+// defaultPayThreshold leaves room for a burst of pre-register txs, which fail with ErrInsufficientFunds when the pay address is empty
+var defaultPayThreshold = config.WalletThreshold{MinCkb: 10000, MinCells: 5, MinRunwayHours: 24}
+
+func Threshold(role signer.Role) config.WalletThreshold {
+	switch role {
+	case signer.RolePay:
+		if th := config.Cfg().WalletMonitor.Pay; th != (config.WalletThreshold{}) {
+			return th
+		}
+		return defaultPayThreshold
+	case signer.RoleTransferWhitelist:
+		return config.Cfg().WalletMonitor.TransferWhitelist
+	case signer.RoleCapacityWhitelist:
+		return config.Cfg().WalletMonitor.CapacityWhitelist
+	}
+	return config.WalletThreshold{}
+}
+
+func spendingWindow() time.Duration {
+	if h := config.Cfg().WalletMonitor.SpendingWindowHours; h > 0 {
+		return time.Hour * time.Duration(h)
+	}
+	return defaultSpendingWindow
+}
+
+type Status struct {
+	Role               signer.Role `json:"role"`
+	Address            string      `json:"address"`
+	CapacityCkb        uint64      `json:"capacity_ckb"`  // all live cells of the lock
+	SpendableCkb       uint64      `json:"spendable_ckb"` // cells without type and data, the txs are paid with them
+	SpendableCells     int         `json:"spendable_cells"`
+	SpendingCkbPerHour float64     `json:"spending_ckb_per_hour"` // capacity drops over the spending window, top-ups excluded
+	RunwayHours        float64     `json:"runway_hours"`          // spendable / spending, -1 when unknown or nothing is spent
+	Low                []string    `json:"low"`                   // thresholds crossed: min_ckb, min_cells, min_runway_hours
+	CheckedAt          int64       `json:"checked_at"`
+}
+
+// Check reads the live capacity and spendable cells of addr, without spending and runway
+func Check(ctx context.Context, client rpc.Client, role signer.Role, addr string) (*Status, error) {
+	addrParse, err := address.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("address.Parse err: %s", err.Error())
+	}
+	st := Status{Role: role, Address: addr, RunwayHours: -1, CheckedAt: time.Now().Unix()}
+
+	capacity, err := client.GetCellsCapacity(ctx, &indexer.SearchKey{
+		Script:     addrParse.Script,
+		ScriptType: indexer.ScriptTypeLock,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetCellsCapacity err: %s", err.Error())
+	}
+	st.CapacityCkb = capacity.Capacity / common.OneCkb
+
+	searchKey := indexer.SearchKey{
+		Script:     addrParse.Script,
+		ScriptType: indexer.ScriptTypeLock,
+		Filter:     &indexer.CellsFilter{OutputDataLenRange: &[2]uint64{0, 1}},
+	}
+	spendable, cursor := uint64(0), ""
+	for st.SpendableCells < maxCells {
+		liveCells, err := client.GetCells(ctx, &searchKey, indexer.SearchOrderAsc, cellsPageSize, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("GetCells err: %s", err.Error())
+		}
+		for _, v := range liveCells.Objects {
+			if v.Output.Type == nil {
+				st.SpendableCells++
+				spendable += v.Output.Capacity
+			}
+		}
+		if len(liveCells.Objects) < cellsPageSize {
+			break
+		}
+		cursor = liveCells.LastCursor
+	}
+	st.SpendableCkb = spendable / common.OneCkb
+	return &st, nil
+}
+
+type sample struct {
+	at        time.Time
+	spendable uint64
+}
+
+// evaluate sets the spending, runway and crossed thresholds of st from the samples of the spending window, oldest first
+func evaluate(st *Status, samples []sample, th config.WalletThreshold) {
+	st.SpendingCkbPerHour, st.RunwayHours, st.Low = 0, -1, nil
+	if len(samples) > 1 {
+		elapsed := samples[len(samples)-1].at.Sub(samples[0].at)
+		spent := uint64(0)
+		for i := 1; i < len(samples); i++ {
+			if samples[i].spendable < samples[i-1].spendable {
+				spent += samples[i-1].spendable - samples[i].spendable
+			}
+		}
+		if elapsed >= minSpendingWindow {
+			st.SpendingCkbPerHour = float64(spent) / elapsed.Hours()
+			if st.SpendingCkbPerHour > 0 {
+				st.RunwayHours = float64(st.SpendableCkb) / st.SpendingCkbPerHour
+			}
+		}
+	}
+
+	if th.MinCkb > 0 && st.SpendableCkb < th.MinCkb {
+		st.Low = append(st.Low, LowMinCkb)
+	}
+	if th.MinCells > 0 && st.SpendableCells < th.MinCells {
+		st.Low = append(st.Low, LowMinCells)
+	}
+	if th.MinRunwayHours > 0 && st.RunwayHours >= 0 && st.RunwayHours < th.MinRunwayHours {
+		st.Low = append(st.Low, LowMinRunwayHours)
+	}
+}
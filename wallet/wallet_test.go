@@ -0,0 +1,35 @@
+package wallet
+
+import (
+	"das_register_server/config"
+	"testing"
+	"time"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Now()
+	th := config.WalletThreshold{MinCkb: 10000, MinCells: 5, MinRunwayHours: 24}
+
+	// 2000 CKB spent in an hour, the 5000 CKB top-up is not spending
+	samples := []sample{
+		{at: now.Add(-time.Hour), spendable: 20000},
+		{at: now.Add(-time.Minute * 40), spendable: 19000},
+		{at: now.Add(-time.Minute * 20), spendable: 24000},
+		{at: now, spendable: 23000},
+	}
+	st := Status{SpendableCkb: 23000, SpendableCells: 10}
+	evaluate(&st, samples, th)
+	if st.SpendingCkbPerHour != 2000 || st.RunwayHours != 11.5 {
+		t.Fatalf("spending %v runway %v", st.SpendingCkbPerHour, st.RunwayHours)
+	}
+	if len(st.Low) != 1 || st.Low[0] != LowMinRunwayHours {
+		t.Fatalf("low: %v", st.Low)
+	}
+
+	// runway is unknown until the window is long enough, the capacity thresholds still apply
+	st = Status{SpendableCkb: 100, SpendableCells: 1}
+	evaluate(&st, samples[2:3], th)
+	if st.RunwayHours != -1 || len(st.Low) != 2 || st.Low[0] != LowMinCkb || st.Low[1] != LowMinCells {
+		t.Fatalf("runway %v low %v", st.RunwayHours, st.Low)
+	}
+}
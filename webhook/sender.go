@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"context"
+	"das_register_server/cache"
+	"das_register_server/dao"
+	"das_register_server/notify"
+	"das_register_server/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/parnurzeal/gorequest"
+	"sync"
+	"time"
+)
+
+// deliveryLockTime covers the 10s send timeout and the db updates around it
+const deliveryLockTime = time.Minute
+
+type ToolWebhook struct {
+	Ctx   context.Context
+	Wg    *sync.WaitGroup
+	DbDao *dao.DbDao
+	RC    *cache.RedisCache
+}
+
+func (t *ToolWebhook) RunDelivery() {
+	tickerDelivery := time.NewTicker(time.Second * 10)
+
+	t.Wg.Add(1)
+	go func() {
+		defer http_api.RecoverPanic()
+		for {
+			select {
+			case <-tickerDelivery.C:
+				if err := t.doDelivery(); err != nil {
+					log.Errorf("doDelivery err: %s", err.Error())
+					notify.SendLarkErrNotify("webhook doDelivery", err.Error())
+				}
+			case <-t.Ctx.Done():
+				log.Debug("RunDelivery done")
+				t.Wg.Done()
+				return
+			}
+		}
+	}()
+}
+
+func (t *ToolWebhook) doDelivery() error {
+	list, err := t.DbDao.GetNeedSendWebhookDeliveries(defaultBatchSize)
+	if err != nil {
+		return fmt.Errorf("GetNeedSendWebhookDeliveries err: %s", err.Error())
+	}
+	for _, v := range list {
+		if err := t.RC.LockWebhookDelivery(v.DeliveryId, deliveryLockTime); err == cache.ErrDistributedLockPreemption {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("LockWebhookDelivery err: %s", err.Error())
+		}
+		err := t.deliver(v)
+		if errUnlock := t.RC.UnlockWebhookDelivery(v.DeliveryId); errUnlock != nil {
+			log.Warn("UnlockWebhookDelivery err:", errUnlock.Error(), v.DeliveryId)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver sends one delivery under its lock, the delivery and the partner are read again
+// since another instance may have sent it, or the partner changed, after the list was read
+func (t *ToolWebhook) deliver(v tables.TableWebhookDelivery) error {
+	current, err := t.DbDao.GetWebhookDeliveryById(v.Id)
+	if err != nil {
+		return fmt.Errorf("GetWebhookDeliveryById err: %s", err.Error())
+	} else if current.Status != tables.DeliveryStatusPending || current.Attempts != v.Attempts {
+		return nil
+	}
+	partner, err := t.DbDao.GetPartnerWebhook(v.PartnerId)
+	if err != nil {
+		return fmt.Errorf("GetPartnerWebhook err: %s", err.Error())
+	}
+	if !partner.IsSubscribed(v.Event) {
+		log.Info("deliver skip:", v.PartnerId, v.DeliveryId, "partner webhook disabled or unsubscribed")
+		if err = t.DbDao.UpdateWebhookDelivery(v.Id, v.Attempts, tables.DeliveryStatusSkip, 0, 0, "partner webhook disabled or unsubscribed"); err != nil {
+			return fmt.Errorf("UpdateWebhookDelivery err: %s", err.Error())
+		}
+		return nil
+	}
+
+	attempts := v.Attempts + 1
+	status, nextAt := tables.DeliveryStatusOk, int64(0)
+	code, err := send(partner, v)
+	lastErr := ""
+	if err != nil {
+		lastErr = err.Error()
+		if len(lastErr) > 1000 {
+			lastErr = lastErr[:1000]
+		}
+		status, nextAt = tables.DeliveryStatusPending, time.Now().Add(backoff(attempts)).UnixMilli()
+		if attempts >= defaultMaxRetry {
+			status = tables.DeliveryStatusFail
+			notify.SendLarkErrNotify("webhook delivery fail", notify.GetLarkTextNotifyStr(v.Event, v.PartnerId+" "+v.OrderId, lastErr))
+		}
+		log.Warn("send err:", v.PartnerId, v.DeliveryId, attempts, lastErr)
+	}
+	if err = t.DbDao.UpdateWebhookDelivery(v.Id, attempts, status, nextAt, code, lastErr); err != nil {
+		return fmt.Errorf("UpdateWebhookDelivery err: %s", err.Error())
+	}
+	return nil
+}
+
+// backoff 30s, 1m, 2m ... capped at 6h
+func backoff(attempts int) time.Duration {
+	d := time.Second * 30
+	for i := 1; i < attempts && d < time.Hour*6; i++ {
+		d *= 2
+	}
+	if d > time.Hour*6 {
+		d = time.Hour * 6
+	}
+	return d
+}
+
+func send(partner tables.TablePartnerWebhook, delivery tables.TableWebhookDelivery) (int, error) {
+	// ms, the same unit as the timestamp of the payload
+	timestamp := time.Now().UnixMilli()
+	resp, body, errs := gorequest.New().Post(partner.Url).Timeout(time.Second*10).
+		Set(HeaderEvent, delivery.Event).
+		Set(HeaderDelivery, delivery.DeliveryId).
+		Set(HeaderTimestamp, fmt.Sprintf("%d", timestamp)).
+		Set(HeaderSignature, Sign(partner.Secret, timestamp, []byte(delivery.Payload))).
+		Type(gorequest.TypeJSON).
+		Send(delivery.Payload).End()
+	if len(errs) > 0 {
+		return 0, fmt.Errorf("gorequest err: %v", errs)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(body) > 200 {
+			body = body[:200]
+		}
+		return resp.StatusCode, fmt.Errorf("http status: %d, body: %s", resp.StatusCode, body)
+	}
+	return resp.StatusCode, nil
+}
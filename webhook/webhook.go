@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"das_register_server/dao"
+	"das_register_server/tables"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api/logger"
+	"strconv"
+	"time"
+)
+
+var (
+	log = logger.NewLogger("webhook", logger.LevelDebug)
+)
+
+const (
+	EventPaymentConfirmed = "payment_confirmed"
+	EventRegistered       = "registered"
+	EventRenewed          = "renewed"
+	EventRefunded         = "refunded"
+	EventFailed           = "failed"
+
+	HeaderEvent      = "X-Das-Event"
+	HeaderDelivery   = "X-Das-Delivery"
+	HeaderTimestamp  = "X-Das-Timestamp"
+	HeaderSignature  = "X-Das-Signature"
+	SignaturePrefix  = "sha256="
+	defaultMaxRetry  = 10
+	defaultBatchSize = 50
+)
+
+var Events = []string{EventPaymentConfirmed, EventRegistered, EventRenewed, EventRefunded, EventFailed}
+
+func IsEvent(event string) bool {
+	for _, v := range Events {
+		if v == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload is the json body posted to the partner url
+type Payload struct {
+	DeliveryId     string                `json:"delivery_id"`
+	Event          string                `json:"event"`
+	PartnerId      string                `json:"partner_id"`
+	OrderId        string                `json:"order_id"`
+	Account        string                `json:"account"`
+	Action         string                `json:"action"`
+	Hash           string                `json:"hash"`
+	PayStatus      tables.TxStatus       `json:"pay_status"`
+	RegisterStatus tables.RegisterStatus `json:"register_status"`
+	OrderStatus    tables.OrderStatus    `json:"order_status"`
+	Timestamp      int64                 `json:"timestamp"`
+}
+
+// Enqueue stores a delivery for the partner of the order, orders without a partner or
+// partners not subscribed to the event are ignored. Delivery ids are stable, so callers
+// may enqueue the same event more than once.
+func Enqueue(dbDao *dao.DbDao, order tables.TableDasOrderInfo, event, hash string) error {
+	if order.OrderId == "" {
+		return nil
+	}
+	content, err := order.GetContent()
+	if err != nil {
+		return fmt.Errorf("GetContent err: %s", err.Error())
+	} else if content.PartnerId == "" {
+		return nil
+	}
+	if event == EventFailed {
+		// callers pass the order read before closing it
+		order.OrderStatus = tables.OrderStatusClosed
+	}
+	partner, err := dbDao.GetPartnerWebhook(content.PartnerId)
+	if err != nil {
+		return fmt.Errorf("GetPartnerWebhook err: %s", err.Error())
+	} else if !partner.IsSubscribed(event) {
+		return nil
+	}
+
+	payload := Payload{
+		DeliveryId:     tables.CreateDeliveryId(content.PartnerId, order.OrderId, event),
+		Event:          event,
+		PartnerId:      content.PartnerId,
+		OrderId:        order.OrderId,
+		Account:        order.Account,
+		Action:         string(order.Action),
+		Hash:           hash,
+		PayStatus:      order.PayStatus,
+		RegisterStatus: order.RegisterStatus,
+		OrderStatus:    order.OrderStatus,
+		Timestamp:      time.Now().UnixMilli(),
+	}
+	bys, err := json.Marshal(&payload)
+	if err != nil {
+		return fmt.Errorf("json.Marshal err: %s", err.Error())
+	}
+	if err = dbDao.CreateWebhookDelivery(tables.TableWebhookDelivery{
+		DeliveryId: payload.DeliveryId,
+		PartnerId:  payload.PartnerId,
+		OrderId:    payload.OrderId,
+		Event:      event,
+		Payload:    string(bys),
+		Status:     tables.DeliveryStatusPending,
+		NextAt:     payload.Timestamp,
+	}); err != nil {
+		return fmt.Errorf("CreateWebhookDelivery err: %s", err.Error())
+	}
+	log.Info("Enqueue:", payload.PartnerId, order.OrderId, event)
+	return nil
+}
+
+// DoEnqueue is Enqueue for call sites where a webhook failure must not break the main flow
+func DoEnqueue(dbDao *dao.DbDao, order tables.TableDasOrderInfo, event, hash string) {
+	if err := Enqueue(dbDao, order, event, hash); err != nil {
+		log.Error("Enqueue err:", err.Error(), order.OrderId, event)
+	}
+}
+
+// Sign returns the hex hmac-sha256 of "timestamp.body", the timestamp in ms as in the payload,
+// partners should recompute it and reject requests whose timestamp is too old
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return SignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"das_register_server/tables"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"registered"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000000." + string(body)))
+	if want := SignaturePrefix + hex.EncodeToString(mac.Sum(nil)); Sign("secret", 1700000000000, body) != want {
+		t.Fatal("signature mismatch")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	if backoff(1) != time.Second*30 || backoff(2) != time.Minute || backoff(3) != time.Minute*2 {
+		t.Fatal(backoff(1), backoff(2), backoff(3))
+	}
+	if backoff(defaultMaxRetry*2) != time.Hour*6 {
+		t.Fatal(backoff(defaultMaxRetry * 2))
+	}
+}
+
+func TestIsSubscribed(t *testing.T) {
+	partner := tables.TablePartnerWebhook{Id: 1, Url: "https://example.com", Events: "registered, refunded"}
+	if !partner.IsSubscribed(EventRefunded) || partner.IsSubscribed(EventRenewed) {
+		t.Fatal("events filter")
+	}
+	partner.Events = ""
+	if !partner.IsSubscribed(EventRenewed) {
+		t.Fatal("empty events means all")
+	}
+	partner.Status = tables.WebhookStatusDisable
+	if partner.IsSubscribed(EventRenewed) {
+		t.Fatal("disabled")
+	}
+}